@@ -0,0 +1,1402 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcutil"
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/bchain/coins/part"
+	"github.com/trezor/blockbook/common"
+	"github.com/trezor/blockbook/db"
+)
+
+// coldStakingOwnerAddress returns the address of the owner (spend part) of the cold staking output
+func (w *Worker) coldStakingOwnerAddress(spendDesc bchain.AddressDescriptor) string {
+	a, _, err := w.chainParser.GetAddressesFromAddrDesc(spendDesc)
+	if err != nil || len(a) == 0 {
+		glog.V(2).Infof("GetAddressesFromAddrDesc error %v, %v", err, spendDesc)
+		return spendDesc.String()
+	}
+	return a[0]
+}
+
+// coldStakingChanges returns the delegation changes of the transaction, the inputs and outputs of the cold staking scripts of the staking address
+func (w *Worker) coldStakingChanges(addrDesc bchain.AddressDescriptor, ta *db.TxAddresses, txid string, height uint32, indexes []int32) []ColdStakingChange {
+	var changes []ColdStakingChange
+	blocktime := int64(w.is.GetBlockTime(height))
+	for _, index := range indexes {
+		var desc bchain.AddressDescriptor
+		change := ColdStakingChange{
+			Txid:      txid,
+			Height:    int(height),
+			Blocktime: blocktime,
+		}
+		if index >= 0 {
+			if int(index) >= len(ta.Outputs) {
+				continue
+			}
+			desc = ta.Outputs[index].AddrDesc
+			change.AmountSat = (*Amount)(&ta.Outputs[index].ValueSat)
+			change.N = int(index)
+			change.Type = ColdStakingDelegate
+		} else {
+			index = ^index
+			if int(index) >= len(ta.Inputs) {
+				continue
+			}
+			desc = ta.Inputs[index].AddrDesc
+			change.AmountSat = (*Amount)(&ta.Inputs[index].ValueSat)
+			change.N = int(index)
+			change.Type = ColdStakingUndelegate
+		}
+		spendDesc, stakingDesc := part.ColdStakingAddrDescs(desc)
+		if spendDesc == nil || !bytes.Equal(stakingDesc, addrDesc) {
+			continue
+		}
+		change.Owner = w.coldStakingOwnerAddress(spendDesc)
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// isCoinstakeTx checks in the index of coinstakes if the transaction is the coinstake of the block, the coinstakes are cached by height
+func (w *Worker) isCoinstakeTx(txid string, height uint32, coinstakes map[uint32]string) (bool, error) {
+	csTxid, found := coinstakes[height]
+	if !found {
+		cs, err := w.db.GetCoinstake(height)
+		if err != nil {
+			return false, err
+		}
+		if cs != nil {
+			csTxid = cs.Txid
+		}
+		coinstakes[height] = csTxid
+	}
+	return csTxid == txid, nil
+}
+
+// GetColdStaking returns the outputs delegated to a Particl cold staking address, their owners and paged history of delegation changes.
+// The coinstake transactions, which only restake the delegated outputs, are not part of the history of changes.
+func (w *Worker) GetColdStaking(address string, page int, itemsOnPage int) (*ColdStaking, error) {
+	if _, ok := w.chainParser.(*part.ParticlParser); !ok {
+		return nil, NewAPIError("Not supported", true)
+	}
+	start := time.Now()
+	page--
+	if page < 0 {
+		page = 0
+	}
+	addrDesc, address, err := w.getAddrDescAndNormalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	var total big.Int
+	delegations := make([]ColdStakingDelegation, 0)
+	owners := make(map[string]*ColdStakingOwner)
+	// the delegated outputs are kept in the cold staking part of the balance of the staking address
+	ba, err := w.db.GetAddrDescBalance(addrDesc, db.AddressBalanceDetailUTXO)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetAddrDescBalance %v", addrDesc)
+	}
+	if ba != nil {
+		for i := range ba.StakingUtxos {
+			u := &ba.StakingUtxos[i]
+			txid, err := w.chainParser.UnpackTxid(u.BtxID)
+			if err != nil {
+				return nil, err
+			}
+			ta, err := w.db.GetTxAddresses(txid)
+			if err != nil {
+				return nil, err
+			}
+			if ta == nil || int(u.Vout) >= len(ta.Outputs) {
+				glog.Warning("DB inconsistency:  tx ", txid, ": not found in txAddresses")
+				continue
+			}
+			spendDesc, _ := part.ColdStakingAddrDescs(ta.Outputs[u.Vout].AddrDesc)
+			if spendDesc == nil {
+				continue
+			}
+			owner := w.coldStakingOwnerAddress(spendDesc)
+			delegations = append(delegations, ColdStakingDelegation{
+				Txid:          txid,
+				Vout:          u.Vout,
+				Owner:         owner,
+				AmountSat:     (*Amount)(&u.ValueSat),
+				Height:        int(u.Height),
+				Confirmations: int(bestheight - u.Height + 1),
+			})
+			total.Add(&total, &u.ValueSat)
+			o, found := owners[owner]
+			if !found {
+				o = &ColdStakingOwner{Address: owner, AmountSat: &Amount{}}
+				owners[owner] = o
+			}
+			(*big.Int)(o.AmountSat).Add((*big.Int)(o.AmountSat), &u.ValueSat)
+			o.Outputs++
+		}
+	}
+	// the newest delegations first, as in the history
+	sort.SliceStable(delegations, func(i, j int) bool {
+		return delegations[i].Height > delegations[j].Height
+	})
+	// the page of the history, the iteration stops after the first change of the next page
+	from := page * itemsOnPage
+	to := from + itemsOnPage
+	history := make([]ColdStakingChange, 0)
+	coinstakes := make(map[uint32]string)
+	changes := 0
+	err = w.db.GetAddrDescTransactions(addrDesc, 0, maxUint32, func(txid string, height uint32, indexes []int32) error {
+		coinstake, err := w.isCoinstakeTx(txid, height, coinstakes)
+		if err != nil {
+			return err
+		}
+		if coinstake {
+			return nil
+		}
+		ta, err := w.db.GetTxAddresses(txid)
+		if err != nil {
+			return err
+		}
+		if ta == nil {
+			glog.Warning("DB inconsistency:  tx ", txid, ": not found in txAddresses")
+			return nil
+		}
+		for _, change := range w.coldStakingChanges(addrDesc, ta, txid, height, indexes) {
+			if changes >= to {
+				return &db.StopIteration{}
+			}
+			if changes >= from {
+				history = append(history, change)
+			}
+			changes++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetAddrDescTransactions %v", addrDesc)
+	}
+	r := &ColdStaking{
+		AddrStr:        address,
+		TotalWeightSat: (*Amount)(&total),
+		Owners:         make([]ColdStakingOwner, 0, len(owners)),
+		Delegations:    delegations,
+		History:        history,
+	}
+	for _, o := range owners {
+		r.Owners = append(r.Owners, *o)
+	}
+	sort.Slice(r.Owners, func(i, j int) bool {
+		c := (*big.Int)(r.Owners[i].AmountSat).Cmp((*big.Int)(r.Owners[j].AmountSat))
+		if c == 0 {
+			return r.Owners[i].Address < r.Owners[j].Address
+		}
+		return c > 0
+	})
+	r.Paging = Paging{ItemsOnPage: itemsOnPage, Page: page + 1}
+	if changes >= to {
+		// the total number of changes is not known, there is at least one more page
+		r.Paging.TotalPages = -1
+	} else {
+		r.Paging.TotalPages = 1
+		if changes > 0 {
+			r.Paging.TotalPages = (changes-1)/itemsOnPage + 1
+		}
+	}
+	glog.Info("GetColdStaking ", address, ", delegations ", len(delegations), ", changes ", len(history), ", ", time.Since(start))
+	return r, nil
+}
+
+type stakingReward struct {
+	time      uint32
+	rewardSat *big.Int
+}
+
+// stakingRewardsFiatValues returns the fiat values of the rewards, using the rates of the time of each reward
+func (w *Worker) stakingRewardsFiatValues(rewards []stakingReward, currencies []string) []map[string]float64 {
+	timestamps := make([]int64, len(rewards))
+	for i := range rewards {
+		timestamps[i] = int64(rewards[i].time)
+	}
+	tickers, err := w.fiatRates.GetTickersForTimestamps(timestamps, "", "")
+	if err != nil || tickers == nil || len(*tickers) != len(rewards) {
+		if err != nil {
+			glog.Errorf("Error finding tickers for staking rewards. Error: %v", err)
+		}
+		return nil
+	}
+	values := make([]map[string]float64, len(rewards))
+	for i := range rewards {
+		values[i] = w.amountFiatValues(rewards[i].rewardSat, (*tickers)[i], currencies)
+	}
+	return values
+}
+
+// amountFiatValues returns the value of the amount in the fiat currencies at the rates of the ticker
+func (w *Worker) amountFiatValues(amountSat *big.Int, ticker *common.CurrencyRatesTicker, currencies []string) map[string]float64 {
+	if ticker == nil {
+		return nil
+	}
+	amount, err := strconv.ParseFloat((*Amount)(amountSat).DecimalString(w.chainParser.AmountDecimals()), 64)
+	if err != nil {
+		return nil
+	}
+	v := make(map[string]float64)
+	if len(currencies) == 0 {
+		for currency, rate := range ticker.Rates {
+			v[currency] = amount * float64(rate)
+		}
+	} else {
+		for _, currency := range currencies {
+			currency = strings.ToLower(currency)
+			if rate, found := ticker.Rates[currency]; found {
+				v[currency] = amount * float64(rate)
+			}
+		}
+	}
+	return v
+}
+
+func addFiatValues(sum map[string]float64, values map[string]float64) map[string]float64 {
+	if len(values) == 0 {
+		return sum
+	}
+	if sum == nil {
+		sum = make(map[string]float64, len(values))
+	}
+	for currency, v := range values {
+		sum[currency] += v
+	}
+	return sum
+}
+
+// GetStakingRewards returns the Particl staking rewards of the address in the time range, grouped by time intervals of groupBy seconds
+func (w *Worker) GetStakingRewards(address string, fromTimestamp, toTimestamp int64, currencies []string, groupBy uint32) (*StakingRewards, error) {
+	if _, ok := w.chainParser.(*part.ParticlParser); !ok {
+		return nil, NewAPIError("Not supported", true)
+	}
+	start := time.Now()
+	currencies = removeEmpty(currencies)
+	addrDesc, address, err := w.getAddrDescAndNormalizeAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	r := &StakingRewards{
+		AddrStr:        address,
+		TotalRewardSat: &Amount{},
+		Rewards:        make([]StakingReward, 0),
+	}
+	fromUnix, fromHeight, toUnix, toHeight := w.balanceHistoryHeightsFromTo(fromTimestamp, toTimestamp)
+	if fromHeight >= toHeight {
+		return r, nil
+	}
+	var rewards []stakingReward
+	err = w.db.GetStakingRewards(addrDesc, fromHeight, toHeight, func(height uint32, rewardSat *big.Int) error {
+		t := w.is.GetBlockTime(height)
+		if t >= fromUnix && t < toUnix {
+			rewards = append(rewards, stakingReward{time: t, rewardSat: new(big.Int).Set(rewardSat)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetStakingRewards %v", addrDesc)
+	}
+	// the rewards are returned from the newest block, aggregate them from the oldest
+	for i, j := 0, len(rewards)-1; i < j; i, j = i+1, j-1 {
+		rewards[i], rewards[j] = rewards[j], rewards[i]
+	}
+	values := w.stakingRewardsFiatValues(rewards, currencies)
+	var sr *StakingReward
+	for i := range rewards {
+		t := rewards[i].time - rewards[i].time%groupBy
+		if sr == nil || sr.Time != t {
+			r.Rewards = append(r.Rewards, StakingReward{Time: t, RewardSat: &Amount{}})
+			sr = &r.Rewards[len(r.Rewards)-1]
+		}
+		sr.Blocks++
+		(*big.Int)(sr.RewardSat).Add((*big.Int)(sr.RewardSat), rewards[i].rewardSat)
+		(*big.Int)(r.TotalRewardSat).Add((*big.Int)(r.TotalRewardSat), rewards[i].rewardSat)
+		r.Blocks++
+		if values != nil {
+			sr.FiatValues = addFiatValues(sr.FiatValues, values[i])
+			r.FiatValues = addFiatValues(r.FiatValues, values[i])
+		}
+	}
+	glog.Info("GetStakingRewards ", address, ", blocks ", fromHeight, "-", toHeight, ", rewards ", len(rewards), ", ", time.Since(start))
+	return r, nil
+}
+
+// stealthAddressNote explains why the stealth address has no balance and history
+const stealthAddressNote = "Payments to a stealth address are sent to one-time addresses derived from an ephemeral key " +
+	"and cannot be linked to the stealth address, its balance and transactions therefore cannot be shown. " +
+	"The receiver finds the payments by scanning the ephemeral public keys in the data outputs of transactions with the scan key."
+
+// getStealthAddress returns the stealth address details, the address never has any indexed transactions
+func (w *Worker) getStealthAddress(address string, sa *part.StealthAddress) *Address {
+	s := &StealthAddress{
+		ScanPubKey:    hex.EncodeToString(sa.ScanPubKey),
+		SpendPubKeys:  make([]string, len(sa.SpendPubKeys)),
+		NumSignatures: int(sa.NumSignatures),
+		PrefixBits:    int(sa.PrefixBits),
+		Note:          stealthAddressNote,
+	}
+	for i, pk := range sa.SpendPubKeys {
+		s.SpendPubKeys[i] = hex.EncodeToString(pk)
+	}
+	if sa.PrefixBits > 0 {
+		s.Prefix = strconv.FormatUint(uint64(sa.Prefix), 16)
+	}
+	glog.Info("GetAddress stealth ", address)
+	return &Address{
+		AddrStr:               address,
+		BalanceSat:            &Amount{},
+		UnconfirmedBalanceSat: &Amount{},
+		Stealth:               s,
+	}
+}
+
+// maxKeyImages is the maximum number of key images checked in one request
+const maxKeyImages = 1000
+
+// GetKeyImages returns the spent state of the Particl RingCT key images
+func (w *Worker) GetKeyImages(keyImages []string) ([]KeyImage, error) {
+	if _, ok := w.chainParser.(*part.ParticlParser); !ok {
+		return nil, NewAPIError("Not supported", true)
+	}
+	if len(keyImages) == 0 {
+		return nil, NewAPIError("Missing key image", true)
+	}
+	if len(keyImages) > maxKeyImages {
+		return nil, NewAPIError(fmt.Sprintf("Too many key images, maximum is %d", maxKeyImages), true)
+	}
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	r := make([]KeyImage, len(keyImages))
+	for i, s := range keyImages {
+		s = strings.ToLower(strings.TrimSpace(s))
+		keyImage, err := hex.DecodeString(s)
+		if err != nil || len(keyImage) != part.KeyImageSize {
+			return nil, NewAPIError(fmt.Sprintf("Invalid key image %s", s), true)
+		}
+		r[i].KeyImage = s
+		spend, err := w.db.GetKeyImageSpend(keyImage)
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetKeyImageSpend %v", s)
+		}
+		if spend == nil {
+			continue
+		}
+		r[i].Spent = true
+		r[i].Spend = &KeyImageSpend{
+			Txid:          spend.Txid,
+			Vin:           int(spend.Vin),
+			Height:        int(spend.Height),
+			Confirmations: int(bestheight - spend.Height + 1),
+			Blocktime:     int64(w.is.GetBlockTime(spend.Height)),
+		}
+	}
+	return r, nil
+}
+
+// GetKeyImage returns the spent state of the Particl RingCT key image
+func (w *Worker) GetKeyImage(keyImage string) (*KeyImage, error) {
+	r, err := w.GetKeyImages([]string{keyImage})
+	if err != nil {
+		return nil, err
+	}
+	return &r[0], nil
+}
+
+// maxAnonOutputs is the maximum number of anon outputs returned in one request
+const maxAnonOutputs = 1000
+
+// GetAnonOutputs returns at most count Particl RingCT anon outputs starting from the global index from
+func (w *Worker) GetAnonOutputs(from uint64, count int) (*AnonOutputs, error) {
+	if _, ok := w.chainParser.(*part.ParticlParser); !ok {
+		return nil, NewAPIError("Not supported", true)
+	}
+	if count <= 0 || count > maxAnonOutputs {
+		return nil, NewAPIError(fmt.Sprintf("Parameter 'count' must be between 1 and %d", maxAnonOutputs), true)
+	}
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	lastIndex, err := w.db.GetLastAnonIndex()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetLastAnonIndex")
+	}
+	outputs, err := w.db.GetAnonOutputs(from, count)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetAnonOutputs %v", from)
+	}
+	r := &AnonOutputs{
+		LastIndex: lastIndex,
+		Outputs:   make([]AnonOutput, len(outputs)),
+	}
+	for i := range outputs {
+		o := &outputs[i]
+		r.Outputs[i] = AnonOutput{
+			Index:           o.Index,
+			Txid:            o.Txid,
+			Vout:            int(o.Vout),
+			PubKey:          hex.EncodeToString(o.PubKey),
+			ValueCommitment: hex.EncodeToString(o.Commitment),
+			Height:          int(o.Height),
+			Confirmations:   int(bestheight - o.Height + 1),
+		}
+	}
+	return r, nil
+}
+
+// appendBlindUtxos appends the unspent blind (CT) outputs of the address to utxos, the value of blind output is hidden and reported as 0
+func (w *Worker) appendBlindUtxos(utxos Utxos, ba *db.AddrBalance, spentInMempool, inMempool map[string]struct{}) (Utxos, error) {
+	b, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+	bestheight := int(b)
+	for i := len(ba.BlindUtxos) - 1; i >= 0; i-- {
+		u := &ba.BlindUtxos[i]
+		txid, err := w.chainParser.UnpackTxid(u.BtxID)
+		if err != nil {
+			return nil, err
+		}
+		if _, e := spentInMempool[txid+strconv.Itoa(int(u.Vout))]; e {
+			continue
+		}
+		if _, e := inMempool[txid]; e {
+			continue
+		}
+		utxos = append(utxos, Utxo{
+			Txid:            txid,
+			Vout:            u.Vout,
+			AmountSat:       &Amount{},
+			Height:          int(u.Height),
+			Confirmations:   bestheight - int(u.Height) + 1,
+			Blind:           true,
+			ValueCommitment: hex.EncodeToString(u.Commitment),
+		})
+	}
+	sort.Stable(utxos)
+	return utxos, nil
+}
+
+// maxStealthScanBlocks is the maximum number of blocks scanned in one request
+const maxStealthScanBlocks = 10000
+
+// StealthScanKey is the scan key of a stealth address submitted by the client
+type StealthScanKey struct {
+	Address string
+	key     *part.StealthScanKey
+}
+
+// NewStealthScanKey checks the stealth address and its scan secret and returns the key for scanning
+func (w *Worker) NewStealthScanKey(address string, scanSecret string) (*StealthScanKey, error) {
+	if !w.is.EnableStealthScan {
+		return nil, NewAPIError("Stealth scan not enabled, use -enablestealthscan flag to enable", true)
+	}
+	addrDesc, err := w.chainParser.GetAddrDescFromAddress(address)
+	if err != nil {
+		return nil, NewAPIError(fmt.Sprintf("Invalid stealth address, %v", err), true)
+	}
+	sa := part.StealthAddressFromAddrDesc(addrDesc)
+	if sa == nil {
+		return nil, NewAPIError("Invalid stealth address", true)
+	}
+	secret, err := hex.DecodeString(strings.TrimSpace(scanSecret))
+	if err != nil {
+		return nil, NewAPIError("Invalid scan secret", true)
+	}
+	key, err := part.NewStealthScanKey(sa, secret)
+	if err != nil {
+		return nil, NewAPIError(err.Error(), true)
+	}
+	return &StealthScanKey{Address: address, key: key}, nil
+}
+
+// stealthDestination is the one-time key derived from an ephemeral key
+type stealthDestination struct {
+	pubKey  []byte
+	hash160 []byte
+	shared  []byte
+}
+
+// matchStealthOutput returns the output if it is paid to the stealth address of the key, otherwise nil
+func (w *Worker) matchStealthOutput(k *StealthScanKey, o *db.StealthOutput, derived map[string]*stealthDestination) *StealthOutput {
+	for _, ephem := range o.EphemPubKeys {
+		d, found := derived[string(ephem)]
+		if !found {
+			d = &stealthDestination{}
+			if pubKey, shared, err := k.key.DestinationPubKey(ephem); err != nil {
+				glog.V(1).Info("DestinationPubKey ", o.Txid, ":", o.Vout, ": ", err)
+			} else {
+				d.pubKey, d.hash160, d.shared = pubKey, btcutil.Hash160(pubKey), shared
+			}
+			derived[string(ephem)] = d
+		}
+		if d.pubKey == nil {
+			continue
+		}
+		dest := d.hash160
+		if o.Type == part.OutputRingCT {
+			dest = d.pubKey
+		}
+		if !bytes.Equal(dest, o.Destination) {
+			continue
+		}
+		so := &StealthOutput{
+			Txid:            o.Txid,
+			Vout:            int(o.Vout),
+			Height:          int(o.Height),
+			Type:            part.OutputTypeName(o.Type),
+			EphemeralPubKey: hex.EncodeToString(ephem),
+			PubKey:          hex.EncodeToString(d.pubKey),
+			SharedSecret:    hex.EncodeToString(d.shared),
+		}
+		if o.Type == part.OutputStandard {
+			v := o.ValueSat
+			so.AmountSat = (*Amount)(&v)
+		} else {
+			so.ValueCommitment = hex.EncodeToString(o.Commitment)
+			so.AmountSat = w.rewindStealthOutput(o, d.shared)
+		}
+		return so
+	}
+	return nil
+}
+
+// rewindStealthOutput returns the value of blind or anon output paid to a stealth address, recovered from its range proof
+// with the nonce derived from the shared secret, nil if the value cannot be recovered
+func (w *Worker) rewindStealthOutput(o *db.StealthOutput, shared []byte) *Amount {
+	_, rangeProof, err := w.db.GetTxOutputBlob(o.Txid, o.Vout)
+	if err != nil {
+		glog.Error("GetTxOutputBlob ", o.Txid, ":", o.Vout, ": ", err)
+		return nil
+	}
+	if rangeProof == nil {
+		// without the extended index the range proof is not stored, it is taken from the backend
+		tx, err := w.chain.GetTransaction(o.Txid)
+		if err != nil || int(o.Vout) >= len(tx.Vout) {
+			glog.Error("GetTransaction ", o.Txid, ":", o.Vout, ": ", err)
+			return nil
+		}
+		if rangeProof, err = hex.DecodeString(tx.Vout[o.Vout].RangeProof); err != nil {
+			return nil
+		}
+	}
+	value, _, err := part.RewindRangeProof(rangeProof, o.Commitment, part.RangeProofNonce(shared))
+	if err != nil {
+		glog.V(1).Info("RewindRangeProof ", o.Txid, ":", o.Vout, ": ", err)
+		return nil
+	}
+	return (*Amount)(new(big.Int).SetUint64(value))
+}
+
+// ScanStealthOutputs returns for each key the outputs paid to its stealth address in the blocks from lower to higher height
+func (w *Worker) ScanStealthOutputs(keys []*StealthScanKey, lower, higher uint32) ([][]StealthOutput, error) {
+	start := time.Now()
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	r := make([][]StealthOutput, len(keys))
+	for i := range r {
+		r[i] = make([]StealthOutput, 0)
+	}
+	var lastTxid string
+	derived := make([]map[string]*stealthDestination, len(keys))
+	scanned := 0
+	err = w.db.GetStealthOutputs(lower, higher, func(o *db.StealthOutput) error {
+		// the one-time keys derived from the ephemeral keys are shared by the outputs of the same transaction
+		if o.Txid != lastTxid {
+			for i := range derived {
+				derived[i] = make(map[string]*stealthDestination)
+			}
+			lastTxid = o.Txid
+		}
+		scanned++
+		for i, k := range keys {
+			if so := w.matchStealthOutput(k, o, derived[i]); so != nil {
+				so.Confirmations = int(bestheight - o.Height + 1)
+				so.Blocktime = int64(w.is.GetBlockTime(o.Height))
+				r[i] = append(r[i], *so)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetStealthOutputs %d-%d", lower, higher)
+	}
+	glog.Info("ScanStealthOutputs ", len(keys), " keys, blocks ", lower, "-", higher, ", scanned ", scanned, " outputs, ", time.Since(start))
+	return r, nil
+}
+
+// GetStealthScan returns the outputs paid to the stealth address in the blocks from lower to higher height,
+// found using the scan secret of the address. If higher is negative, the scan ends at the best block.
+func (w *Worker) GetStealthScan(address string, scanSecret string, lower, higher int) (*StealthScan, error) {
+	k, err := w.NewStealthScanKey(address, scanSecret)
+	if err != nil {
+		return nil, err
+	}
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	if higher < 0 || higher > int(bestheight) {
+		higher = int(bestheight)
+	}
+	if lower < 0 || lower > higher {
+		return nil, NewAPIError("Invalid block range", true)
+	}
+	if higher-lower >= maxStealthScanBlocks {
+		return nil, NewAPIError(fmt.Sprintf("Block range too large, maximum is %d blocks", maxStealthScanBlocks), true)
+	}
+	outputs, err := w.ScanStealthOutputs([]*StealthScanKey{k}, uint32(lower), uint32(higher))
+	if err != nil {
+		return nil, err
+	}
+	return &StealthScan{
+		Address:    k.Address,
+		FromHeight: lower,
+		ToHeight:   higher,
+		Outputs:    outputs[0],
+	}, nil
+}
+
+// coinstakeVote returns the governance vote stored in the data output of coinstake transaction
+func coinstakeVote(tx *bchain.Tx, vout *bchain.Vout) *TxVote {
+	if vout.OutputType != part.OutputTypeName(part.OutputData) || !part.IsCoinStakeTx(tx) {
+		return nil
+	}
+	data, err := hex.DecodeString(vout.Data)
+	if err != nil {
+		return nil
+	}
+	cd, ok := part.ParseCoinstakeData(data)
+	if !ok || !cd.HasVote {
+		return nil
+	}
+	return &TxVote{
+		Proposal: int(cd.Proposal),
+		Option:   int(cd.Option),
+	}
+}
+
+// GetVotes returns the stake-weighted tally of the votes for the Particl proposal in the blocks from lower to higher height.
+// If higher is negative, the tally ends at the best block.
+func (w *Worker) GetVotes(proposal string, lower, higher int) (*Votes, error) {
+	if _, ok := w.chainParser.(*part.ParticlParser); !ok {
+		return nil, NewAPIError("Not supported", true)
+	}
+	p, err := strconv.ParseUint(proposal, 10, 16)
+	if err != nil || p == 0 {
+		return nil, NewAPIError(fmt.Sprintf("Invalid proposal %s", proposal), true)
+	}
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	if higher < 0 || higher > int(bestheight) {
+		higher = int(bestheight)
+	}
+	if lower < 0 || lower > higher {
+		return nil, NewAPIError("Invalid height range", true)
+	}
+	r := &Votes{
+		Proposal:      int(p),
+		FromHeight:    lower,
+		ToHeight:      higher,
+		Blocks:        higher - lower + 1,
+		VotedStakeSat: &Amount{},
+		Options:       make([]VoteOption, 0),
+	}
+	options := make(map[uint16]*VoteOption)
+	err = w.db.GetVotes(uint16(p), uint32(lower), uint32(higher), func(v *db.Vote) error {
+		o, found := options[v.Option]
+		if !found {
+			o = &VoteOption{Option: int(v.Option), StakeSat: &Amount{}}
+			options[v.Option] = o
+		}
+		o.Blocks++
+		(*big.Int)(o.StakeSat).Add((*big.Int)(o.StakeSat), &v.StakeSat)
+		r.VotedBlocks++
+		(*big.Int)(r.VotedStakeSat).Add((*big.Int)(r.VotedStakeSat), &v.StakeSat)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetVotes %v", p)
+	}
+	total := new(big.Float).SetInt((*big.Int)(r.VotedStakeSat))
+	for _, o := range options {
+		if total.Sign() > 0 {
+			share := new(big.Float).SetInt((*big.Int)(o.StakeSat))
+			o.StakePercent, _ = share.Quo(share, total).Float64()
+			o.StakePercent *= 100
+		}
+		r.Options = append(r.Options, *o)
+	}
+	sort.Slice(r.Options, func(i, j int) bool { return r.Options[i].Option < r.Options[j].Option })
+	return r, nil
+}
+
+// GetTreasury returns the current balance of the Particl treasury fund and a page of its payouts in the time range,
+// the sum of the payouts and the carried forward amount cover the whole time range
+func (w *Worker) GetTreasury(fromTimestamp, toTimestamp int64, page int, payoutsOnPage int, currencies []string) (*Treasury, error) {
+	p, ok := w.chainParser.(*part.ParticlParser)
+	if w.chainType != bchain.ChainBitcoinType || !ok {
+		return nil, NewAPIError("Not supported", true)
+	}
+	start := time.Now()
+	page--
+	if page < 0 {
+		page = 0
+	}
+	currencies = removeEmpty(currencies)
+	r := &Treasury{
+		Addresses:         p.TreasuryAddresses(),
+		BalanceSat:        &Amount{},
+		TotalPaidSat:      &Amount{},
+		CarriedForwardSat: &Amount{},
+		Payouts:           make([]TreasuryPayout, 0),
+	}
+	for _, a := range r.Addresses {
+		addrDesc, err := p.GetAddrDescFromAddress(a)
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetAddrDescFromAddress %v", a)
+		}
+		ba, err := w.db.GetAddrDescBalance(addrDesc, db.AddressBalanceDetailNoUTXO)
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetAddrDescBalance %v", addrDesc)
+		}
+		if ba != nil {
+			(*big.Int)(r.BalanceSat).Add((*big.Int)(r.BalanceSat), &ba.BalanceSat)
+		}
+	}
+	r.FiatValues = w.amountFiatValues((*big.Int)(r.BalanceSat), w.fiatRates.GetCurrentTicker("", ""), currencies)
+	fromUnix, fromHeight, toUnix, toHeight := w.balanceHistoryHeightsFromTo(fromTimestamp, toTimestamp)
+	if fromHeight >= toHeight {
+		r.Paging, _, _, _ = computePaging(0, page, payoutsOnPage)
+		return r, nil
+	}
+	err := w.db.GetTreasury(fromHeight, toHeight, func(t *db.TreasuryBlock) error {
+		bt := w.is.GetBlockTime(t.Height)
+		if bt < fromUnix || bt >= toUnix {
+			return nil
+		}
+		r.CarriedForwardSat = (*Amount)(new(big.Int).Set(&t.CarriedForwardSat))
+		if t.PaidSat.Sign() > 0 {
+			r.Payouts = append(r.Payouts, TreasuryPayout{
+				Txid:      t.Txid,
+				Height:    int(t.Height),
+				Blocktime: int64(bt),
+				AmountSat: (*Amount)(new(big.Int).Set(&t.PaidSat)),
+			})
+			(*big.Int)(r.TotalPaidSat).Add((*big.Int)(r.TotalPaidSat), &t.PaidSat)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetTreasury")
+	}
+	// the totals are computed over the whole time range, only the payouts of the page are returned
+	count := len(r.Payouts)
+	pg, from, to, page := computePaging(count, page, payoutsOnPage)
+	r.Paging = pg
+	r.Payouts = r.Payouts[from:to]
+	payouts := make([]stakingReward, len(r.Payouts))
+	for i := range r.Payouts {
+		payouts[i] = stakingReward{time: uint32(r.Payouts[i].Blocktime), rewardSat: (*big.Int)(r.Payouts[i].AmountSat)}
+	}
+	if values := w.stakingRewardsFiatValues(payouts, currencies); values != nil {
+		for i := range r.Payouts {
+			r.Payouts[i].FiatValues = values[i]
+		}
+	}
+	glog.Info("GetTreasury blocks ", fromHeight, "-", toHeight, ", payouts ", count, ", page ", page, ", ", time.Since(start))
+	return r, nil
+}
+
+// GetSupply returns the Particl money supply at the block given by height or hash, at the best block if bid is empty,
+// the value of the blind and anon outputs is returned only as their total
+func (w *Worker) GetSupply(bid string) (*Supply, error) {
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Not supported", true)
+	}
+	if _, ok := w.chainParser.(*part.ParticlParser); !ok {
+		return nil, NewAPIError("Not supported", true)
+	}
+	var height uint32
+	if bid == "" {
+		bestheight, _, err := w.db.GetBestBlock()
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetBestBlock")
+		}
+		height = bestheight
+	} else if h, err := strconv.ParseUint(bid, 10, 32); err == nil {
+		height = uint32(h)
+	} else {
+		bh, err := w.chain.GetBlockHeader(bid)
+		if err != nil {
+			return nil, NewAPIError("Block not found", true)
+		}
+		height = bh.Height
+	}
+	s, err := w.db.GetSupply(height)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetSupply %v", height)
+	}
+	if s == nil {
+		return nil, NewAPIError(fmt.Sprintf("Supply of block %d not found", height), true)
+	}
+	hash, err := w.db.GetBlockHash(height)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBlockHash %v", height)
+	}
+	return &Supply{
+		Height:    int(height),
+		Hash:      hash,
+		TotalSat:  (*Amount)(new(big.Int).Add(&s.PlainSat, &s.HiddenSat)),
+		PlainSat:  (*Amount)(&s.PlainSat),
+		HiddenSat: (*Amount)(&s.HiddenSat),
+		Conversions: SupplyConversions{
+			PlainToHiddenSat: (*Amount)(&s.PlainToHiddenSat),
+			HiddenToPlainSat: (*Amount)(&s.HiddenToPlainSat),
+		},
+		Inexact: s.Inexact,
+	}, nil
+}
+
+// blockStake returns the proof-of-stake data of Particl block, nil for other blocks
+func (w *Worker) blockStake(height uint32) *BlockStake {
+	if _, ok := w.chainParser.(*part.ParticlParser); !ok {
+		return nil
+	}
+	cs, err := w.db.GetCoinstake(height)
+	if err != nil {
+		glog.Error("GetCoinstake ", height, ": ", err)
+		return nil
+	}
+	if cs == nil {
+		return nil
+	}
+	return &BlockStake{
+		CoinstakeTxid: cs.Txid,
+		KernelTxid:    cs.KernelTxid,
+		KernelVout:    int(cs.KernelVout),
+		Staker:        w.coldStakingOwnerAddress(cs.StakerAddrDesc),
+		ColdStaked:    cs.ColdStaked,
+		StakeSat:      (*Amount)(&cs.StakeSat),
+		RewardSat:     (*Amount)(&cs.RewardSat),
+	}
+}
+
+// stakingInfoBlocks is the number of the last blocks the staking statistics are computed from, one day of Particl blocks
+const stakingInfoBlocks = 720
+
+// GetStakingInfo returns the staking state reported by the backend combined with the statistics of the stored coinstakes.
+// If the weight (in satoshi) is given, the expected time to stake a block with the weight is returned.
+func (w *Worker) GetStakingInfo(weight string) (*StakingInfo, error) {
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Not supported", true)
+	}
+	if _, ok := w.chainParser.(*part.ParticlParser); !ok {
+		return nil, NewAPIError("Not supported", true)
+	}
+	var weightSat *big.Int
+	if weight != "" {
+		var ok bool
+		weightSat, ok = new(big.Int).SetString(weight, 10)
+		if !ok || weightSat.Sign() <= 0 {
+			return nil, NewAPIError(fmt.Sprintf("Invalid weight %s", weight), true)
+		}
+	}
+	bestheight, _, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	var lower uint32
+	if bestheight >= stakingInfoBlocks {
+		lower = bestheight - stakingInfoBlocks + 1
+	}
+	r := &StakingInfo{
+		FromHeight:       int(lower),
+		ToHeight:         int(bestheight),
+		AverageStakeSat:  &Amount{},
+		AverageRewardSat: &Amount{},
+		WeightSat:        (*Amount)(weightSat),
+	}
+	var stakeSum, rewardSum big.Int
+	var first, last uint32
+	err = w.db.GetCoinstakes(lower, bestheight, func(cs *db.Coinstake) error {
+		if r.Blocks == 0 {
+			first = cs.Height
+		}
+		last = cs.Height
+		r.Blocks++
+		if cs.ColdStaked {
+			r.ColdStakedBlocks++
+		}
+		stakeSum.Add(&stakeSum, &cs.StakeSat)
+		rewardSum.Add(&rewardSum, &cs.RewardSat)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetCoinstakes")
+	}
+	if r.Blocks > 0 {
+		n := big.NewInt(int64(r.Blocks))
+		(*big.Int)(r.AverageStakeSat).Div(&stakeSum, n)
+		(*big.Int)(r.AverageRewardSat).Div(&rewardSum, n)
+		r.ColdStakedPercent = float64(r.ColdStakedBlocks) * 100 / float64(r.Blocks)
+	}
+	if last > first {
+		r.AverageBlockTime = float64(int64(w.is.GetBlockTime(last))-int64(w.is.GetBlockTime(first))) / float64(last-first)
+	}
+	si, err := w.chain.GetStakingInfo()
+	if err != nil {
+		glog.V(1).Info("GetStakingInfo error ", err)
+		r.BackendError = err.Error()
+		return r, nil
+	}
+	r.PercentYearReward, _ = si.PercentYearReward.Float64()
+	r.Difficulty = string(si.Difficulty)
+	if ms, err := w.chainParser.AmountToBigInt(si.MoneySupply); err == nil && ms.Sign() > 0 {
+		r.MoneySupplySat = (*Amount)(&ms)
+	}
+	if nw, ok := new(big.Int).SetString(string(si.NetStakeWeight), 10); ok && nw.Sign() > 0 {
+		r.NetStakeWeightSat = (*Amount)(nw)
+		if weightSat != nil && r.AverageBlockTime > 0 {
+			ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(nw), new(big.Float).SetInt(weightSat)).Float64()
+			r.ExpectedTime = int64(r.AverageBlockTime * ratio)
+		}
+	}
+	return r, nil
+}
+
+func (item *PrivacyStatsItem) add(ps *db.PrivacyStats) {
+	item.Blocks++
+	item.PrivacyTxs += int(ps.PrivacyTxs)
+	item.PlainToBlindTxs += int(ps.PlainToBlindTxs)
+	item.PlainToAnonTxs += int(ps.PlainToAnonTxs)
+	item.BlindToAnonTxs += int(ps.BlindToAnonTxs)
+	item.BlindToPlainTxs += int(ps.BlindToPlainTxs)
+	item.AnonToPlainTxs += int(ps.AnonToPlainTxs)
+	item.AnonToBlindTxs += int(ps.AnonToBlindTxs)
+	item.AnonInputs += int(ps.AnonInputs)
+	item.ringSizeSum += uint64(ps.RingSizeSum)
+	(*big.Int)(item.CTFeeSat).Add((*big.Int)(item.CTFeeSat), &ps.CTFeeSat)
+	if item.AnonInputs > 0 {
+		item.AverageRingSize = float64(item.ringSizeSum) / float64(item.AnonInputs)
+	}
+}
+
+// GetPrivacyStats returns the statistics of Particl privacy transactions in the time range, grouped by time intervals of groupBy seconds
+func (w *Worker) GetPrivacyStats(fromTimestamp, toTimestamp int64, groupBy uint32) (*PrivacyStats, error) {
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Not supported", true)
+	}
+	if _, ok := w.chainParser.(*part.ParticlParser); !ok {
+		return nil, NewAPIError("Not supported", true)
+	}
+	start := time.Now()
+	fromUnix, fromHeight, toUnix, toHeight := w.balanceHistoryHeightsFromTo(fromTimestamp, toTimestamp)
+	r := &PrivacyStats{
+		FromHeight: int(fromHeight),
+		ToHeight:   int(toHeight),
+		Total:      PrivacyStatsItem{CTFeeSat: &Amount{}},
+		Stats:      make([]PrivacyStatsItem, 0),
+	}
+	if bestHeight, _, err := w.db.GetBestBlock(); err == nil && toHeight > bestHeight {
+		r.ToHeight = int(bestHeight)
+	}
+	if fromHeight >= toHeight {
+		return r, nil
+	}
+	var item *PrivacyStatsItem
+	err := w.db.GetPrivacyStats(fromHeight, toHeight, func(ps *db.PrivacyStats) error {
+		bt := w.is.GetBlockTime(ps.Height)
+		if bt < fromUnix || bt >= toUnix {
+			return nil
+		}
+		t := int64(bt - bt%groupBy)
+		if item == nil || item.Time != t {
+			r.Stats = append(r.Stats, PrivacyStatsItem{Time: t, CTFeeSat: &Amount{}})
+			item = &r.Stats[len(r.Stats)-1]
+		}
+		item.add(ps)
+		r.Total.add(ps)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetPrivacyStats")
+	}
+	glog.Info("GetPrivacyStats blocks ", fromHeight, "-", toHeight, ", privacy txs ", r.Total.PrivacyTxs, ", ", time.Since(start))
+	return r, nil
+}
+
+// rangeProofInfo decodes the public header of the range proof of blind or anon output,
+// nil if there is no proof or it is not the Borromean range proof, the Bulletproofs do not have the header
+func rangeProofInfo(rangeProof string) *RangeProofInfo {
+	if rangeProof == "" {
+		return nil
+	}
+	b, err := hex.DecodeString(rangeProof)
+	if err != nil {
+		return nil
+	}
+	ri, err := part.ParseRangeProofInfo(b)
+	if err != nil {
+		return nil
+	}
+	return &RangeProofInfo{
+		Exponent:    ri.Exponent,
+		Mantissa:    ri.Mantissa,
+		MinValueSat: (*Amount)(new(big.Int).SetUint64(ri.MinValue)),
+		MaxValueSat: (*Amount)(new(big.Int).SetUint64(ri.MaxValue)),
+		ExactValue:  ri.ExactValue(),
+	}
+}
+
+// Particl transaction types of the fee estimation
+const (
+	TxTypePlain = "plain"
+	TxTypeBlind = "blind"
+	TxTypeAnon  = "anon"
+)
+
+// rough sizes of the parts of Particl transaction in vbytes, the sizes of the blind and anon outputs are dominated
+// by the range proofs, the anon input grows with the ring size by the ring member offsets and the MLSAG signature,
+// the model is scaled by the ratio of the real and modelled size of the recently indexed transactions,
+// the size of the ring member is fitted to the recently indexed anon transactions, anonRingMemberSize is used
+// only if it cannot be fitted
+const (
+	txOverheadSize     = 10
+	plainInputSize     = 68
+	anonInputSize      = 60
+	anonRingMemberSize = 17
+)
+
+var txOutputSizes = map[string]float64{
+	"standard": 35,
+	"data":     12,
+	"blind":    720,
+	"anon":     760,
+}
+
+var txTypeOutputs = map[string]string{
+	TxTypePlain: "standard",
+	TxTypeBlind: "blind",
+	TxTypeAnon:  "anon",
+}
+
+// default parameters and limits of the fee estimation, the ring size limits are the ones of particld wallet
+const (
+	defaultFeeEstimateInputs  = 1
+	defaultFeeEstimateOutputs = 2
+	defaultRingSize           = 5
+	minRingSize               = 3
+	maxRingSize               = 32
+	maxFeeEstimateTxParts     = 500
+	// calibrationBlocks is the number of the last blocks the size model is calibrated from,
+	// limited also by the number of the blocks with the list of transactions kept in the index
+	calibrationBlocks = 720
+	minCalibration    = 0.5
+	maxCalibration    = 2
+	// limits of the fitted size of the ring member
+	minRingMemberSize = 1
+	maxRingMemberSize = 200
+)
+
+func txOutputSize(outputType string) float64 {
+	if s, ok := txOutputSizes[outputType]; ok {
+		return s
+	}
+	return txOutputSizes["standard"]
+}
+
+// modelTxSize returns the modelled vsize of the transaction of given type without the ring members of the anon inputs,
+// blind and anon transactions carry the fee in an additional data output
+func modelTxSize(txType string, inputs, outputs int) float64 {
+	size := txOverheadSize + float64(outputs)*txOutputSize(txTypeOutputs[txType])
+	if txType == TxTypeAnon {
+		size += float64(inputs) * anonInputSize
+	} else {
+		size += float64(inputs) * plainInputSize
+	}
+	if txType != TxTypePlain {
+		size += txOutputSize("data")
+	}
+	return size
+}
+
+// txAddressesType returns the type of the indexed transaction, anon if it has any anon input or output,
+// blind if it has any blind output, otherwise plain
+func txAddressesType(ta *db.TxAddresses) string {
+	t := TxTypePlain
+	for i := range ta.Inputs {
+		if ta.Inputs[i].InputType == "anon" {
+			return TxTypeAnon
+		}
+	}
+	for i := range ta.Outputs {
+		switch ta.Outputs[i].OutputType {
+		case "anon":
+			return TxTypeAnon
+		case "blind":
+			t = TxTypeBlind
+		}
+	}
+	return t
+}
+
+// modelTxAddressesSize returns the modelled vsize of the indexed transaction from its real inputs and outputs
+// without the ring members of the anon inputs and the number of the ring members
+func modelTxAddressesSize(ta *db.TxAddresses) (float64, float64) {
+	size := float64(txOverheadSize)
+	var members float64
+	for i := range ta.Inputs {
+		ti := &ta.Inputs[i]
+		if ti.InputType == "anon" {
+			n := ti.AnonInputs
+			if n == 0 {
+				n = 1
+			}
+			size += float64(n) * anonInputSize
+			members += float64(n) * float64(ti.RingSize)
+		} else {
+			size += plainInputSize
+		}
+	}
+	for i := range ta.Outputs {
+		size += txOutputSize(ta.Outputs[i].OutputType)
+	}
+	return size, members
+}
+
+// txSizeStats are the sums of the sizes of the transactions of the last blocks, the real vsize v, the modelled size b
+// without the ring members and the number of the ring members m, and the sums of the least squares fit of
+// v = calibration*b + ringMemberSize*m
+type txSizeStats struct {
+	samples int
+	v       float64
+	b       float64
+	m       float64
+	bb      float64
+	bm      float64
+	mm      float64
+	bv      float64
+	mv      float64
+}
+
+func (s *txSizeStats) add(vsize, modelled, members float64) {
+	s.samples++
+	s.v += vsize
+	s.b += modelled
+	s.m += members
+	s.bb += modelled * modelled
+	s.bm += modelled * members
+	s.mm += members * members
+	s.bv += modelled * vsize
+	s.mv += members * vsize
+}
+
+// fit returns the calibration of the model and the size of the ring member. The size of the ring member can be fitted
+// only if the transactions differ in the ratio of the ring members and the rest of the transaction, otherwise
+// the default size is used and the calibration is the ratio of the real and modelled size.
+func (s *txSizeStats) fit() (float64, float64) {
+	if s == nil || s.samples == 0 {
+		return 1, anonRingMemberSize
+	}
+	if det := s.bb*s.mm - s.bm*s.bm; s.mm > 0 && det > 1e-9*s.bb*s.mm {
+		c := (s.bv*s.mm - s.bm*s.mv) / det
+		m := (s.bb*s.mv - s.bm*s.bv) / det
+		if c >= minCalibration && c <= maxCalibration && m >= minRingMemberSize && m <= maxRingMemberSize {
+			return c, m
+		}
+	}
+	modelled := s.b + anonRingMemberSize*s.m
+	if modelled == 0 {
+		return 1, anonRingMemberSize
+	}
+	return math.Max(minCalibration, math.Min(maxCalibration, s.v/modelled)), anonRingMemberSize
+}
+
+// txSizeStatsCache holds the size statistics of the last blocks, recomputed when the best block changes,
+// the concurrent requests wait for the running computation instead of starting their own
+type txSizeStatsCache struct {
+	lock       sync.Mutex
+	bestHash   string
+	fromHeight uint32
+	stats      map[string]*txSizeStats
+	computing  chan struct{}
+}
+
+// getTxSizeStats returns the size statistics of the transactions of the last blocks by the transaction type.
+// The statistics require the extended index, which stores the vsize of the transactions.
+func (w *Worker) getTxSizeStats(bestHeight uint32, bestHash string) (map[string]*txSizeStats, uint32, error) {
+	c := &w.txSizeStats
+	for {
+		c.lock.Lock()
+		if c.bestHash == bestHash && c.stats != nil {
+			stats, fromHeight := c.stats, c.fromHeight
+			c.lock.Unlock()
+			return stats, fromHeight, nil
+		}
+		if c.computing == nil {
+			computing := make(chan struct{})
+			c.computing = computing
+			c.lock.Unlock()
+			stats, fromHeight, err := w.computeTxSizeStats(bestHeight)
+			c.lock.Lock()
+			if err == nil {
+				c.bestHash = bestHash
+				c.fromHeight = fromHeight
+				c.stats = stats
+			}
+			c.computing = nil
+			close(computing)
+			c.lock.Unlock()
+			return stats, fromHeight, err
+		}
+		computing := c.computing
+		c.lock.Unlock()
+		<-computing
+	}
+}
+
+// computeTxSizeStats computes the size statistics of the transactions of the last blocks up to the best height
+func (w *Worker) computeTxSizeStats(bestHeight uint32) (map[string]*txSizeStats, uint32, error) {
+	stats := make(map[string]*txSizeStats)
+	blocks := uint32(calibrationBlocks)
+	if keep := uint32(w.chainParser.KeepBlockAddresses()); keep < blocks {
+		blocks = keep
+	}
+	var lower uint32
+	if bestHeight >= blocks {
+		lower = bestHeight - blocks + 1
+	}
+	if !w.db.HasExtendedIndex() {
+		return stats, lower, nil
+	}
+	for h := lower; h <= bestHeight; h++ {
+		first := true
+		err := w.db.GetBlockTxAddresses(h, func(ta *db.TxAddresses) error {
+			// skip the coinstake, its size does not follow the model
+			if first {
+				first = false
+				return nil
+			}
+			if ta.VSize == 0 {
+				return nil
+			}
+			t := txAddressesType(ta)
+			s := stats[t]
+			if s == nil {
+				s = &txSizeStats{}
+				stats[t] = s
+			}
+			modelled, members := modelTxAddressesSize(ta)
+			s.add(float64(ta.VSize), modelled, members)
+			return nil
+		})
+		if err != nil {
+			return nil, 0, errors.Annotatef(err, "GetBlockTxAddresses %d", h)
+		}
+	}
+	return stats, lower, nil
+}
+
+// EstimateTxFees returns the estimated absolute fees of Particl plain, blind and anon transactions with given number
+// of inputs and outputs and the ring size of the anon inputs. The sizes of the transactions are modelled
+// and calibrated by the sizes of the transactions of the same type in the last blocks.
+// Zero inputs, outputs or ringSize are replaced by the defaults.
+func (w *Worker) EstimateTxFees(blocks int, conservative bool, inputs, outputs, ringSize int) (*TxFeeEstimate, error) {
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Not supported", true)
+	}
+	if _, ok := w.chainParser.(*part.ParticlParser); !ok {
+		return nil, NewAPIError("Not supported", true)
+	}
+	if blocks < 1 {
+		return nil, NewAPIError("Parameter 'number of blocks' must be positive", true)
+	}
+	if inputs == 0 {
+		inputs = defaultFeeEstimateInputs
+	}
+	if outputs == 0 {
+		outputs = defaultFeeEstimateOutputs
+	}
+	if ringSize == 0 {
+		ringSize = defaultRingSize
+	}
+	if inputs < 0 || inputs > maxFeeEstimateTxParts || outputs < 0 || outputs > maxFeeEstimateTxParts {
+		return nil, NewAPIError(fmt.Sprintf("Number of inputs and outputs must be between 1 and %d", maxFeeEstimateTxParts), true)
+	}
+	if ringSize < minRingSize || ringSize > maxRingSize {
+		return nil, NewAPIError(fmt.Sprintf("Ring size must be between %d and %d", minRingSize, maxRingSize), true)
+	}
+	feePerKB, err := w.EstimateFee(blocks, conservative)
+	if err != nil {
+		return nil, err
+	}
+	bestHeight, bestHash, err := w.db.GetBestBlock()
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBestBlock")
+	}
+	stats, fromHeight, err := w.getTxSizeStats(bestHeight, bestHash)
+	if err != nil {
+		return nil, err
+	}
+	r := &TxFeeEstimate{
+		Blocks:     blocks,
+		FeePerUnit: (*Amount)(&feePerKB),
+		Inputs:     inputs,
+		Outputs:    outputs,
+		RingSize:   ringSize,
+		FromHeight: int(fromHeight),
+		ToHeight:   int(bestHeight),
+		Fees:       make([]TxTypeFee, 0, 3),
+	}
+	for _, t := range []string{TxTypePlain, TxTypeBlind, TxTypeAnon} {
+		s := stats[t]
+		calibration, memberSize := s.fit()
+		f := TxTypeFee{
+			Type:        t,
+			Calibration: calibration,
+		}
+		if s != nil {
+			f.Samples = s.samples
+		}
+		size := modelTxSize(t, inputs, outputs) * calibration
+		if t == TxTypeAnon {
+			f.RingMemberSize = memberSize
+			size += memberSize * float64(inputs*ringSize)
+		}
+		f.VSize = int(math.Ceil(size))
+		// fee rate is per kB, round up
+		fee := new(big.Int).Mul(&feePerKB, big.NewInt(int64(f.VSize)))
+		fee.Add(fee, big.NewInt(999))
+		fee.Div(fee, big.NewInt(1000))
+		f.FeeSat = (*Amount)(fee)
+		r.Fees = append(r.Fees, f)
+	}
+	return r, nil
+}
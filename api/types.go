@@ -150,9 +150,10 @@ type Vin struct {
 	Coinbase  string                   `json:"coinbase,omitempty" ts_doc:"Data for coinbase inputs (when mining)."`
 
 	// Particl privacy transaction fields for anon (RingCT) inputs
-	InputType   string `json:"inputType,omitempty" ts_doc:"Input type for Particl: 'anon' for RingCT inputs."`
-	AnonInputs  uint32 `json:"anonInputs,omitempty" ts_doc:"Number of real inputs in RingCT transaction."`
-	RingSize    uint32 `json:"ringSize,omitempty" ts_doc:"Size of the ring (decoy set) for RingCT inputs."`
+	InputType  string   `json:"inputType,omitempty" ts_doc:"Input type for Particl: 'anon' for RingCT inputs."`
+	AnonInputs uint32   `json:"anonInputs,omitempty" ts_doc:"Number of real inputs in RingCT transaction."`
+	RingSize   uint32   `json:"ringSize,omitempty" ts_doc:"Size of the ring (decoy set) for RingCT inputs."`
+	KeyImages  []string `json:"keyImages,omitempty" ts_doc:"Key images (hex) of the real inputs of RingCT input."`
 }
 
 // Vout contains information about single transaction output
@@ -171,9 +172,13 @@ type Vout struct {
 	IsOwn       bool                     `json:"isOwn,omitempty" ts_doc:"Indicates if this output belongs to the wallet in context."`
 	Type        string                   `json:"type,omitempty" ts_doc:"Output script type (e.g., 'P2PKH', 'P2SH')."`
 	// Particl privacy transaction fields
-	ValueCommitment string `json:"valueCommitment,omitempty" ts_doc:"Pedersen commitment for blind/anon outputs (hex)."`
-	Data            string `json:"data,omitempty" ts_doc:"Ephemeral public key or data for CT outputs (hex)."`
-	RangeProof      string `json:"rangeproof,omitempty" ts_doc:"Bulletproof range proof for CT outputs (hex)."`
+	ValueCommitment string          `json:"valueCommitment,omitempty" ts_doc:"Pedersen commitment for blind/anon outputs (hex)."`
+	Data            string          `json:"data,omitempty" ts_doc:"Ephemeral public key or data for CT outputs (hex)."`
+	RangeProof      string          `json:"rangeproof,omitempty" ts_doc:"Bulletproof range proof for CT outputs (hex)."`
+	RangeProofInfo  *RangeProofInfo `json:"rangeProofInfo,omitempty" ts_doc:"Decoded public parameters of the Borromean range proof of CT outputs, missing for Bulletproofs."`
+	PubKey          string          `json:"pubkey,omitempty" ts_doc:"Public key of anon output (hex)."`
+	AnonIndex       int64           `json:"anonIndex,omitempty" ts_doc:"Global index of anon output, if known."`
+	Vote            *TxVote         `json:"vote,omitempty" ts_doc:"Governance vote decoded from the data output of coinstake transaction."`
 }
 
 // MultiTokenValue contains values for contracts with multiple token IDs
@@ -378,6 +383,10 @@ type Address struct {
 	BalanceSat            *Amount              `json:"balance" ts_doc:"Current confirmed balance (in satoshi or base units)."`
 	TotalReceivedSat      *Amount              `json:"totalReceived,omitempty" ts_doc:"Total amount ever received by this address."`
 	TotalSentSat          *Amount              `json:"totalSent,omitempty" ts_doc:"Total amount ever sent by this address."`
+	DelegatedBalanceSat   *Amount              `json:"delegatedBalance,omitempty" ts_doc:"Part of the balance owned by this address and delegated for cold staking (Particl)."`
+	StakingForOthersSat   *Amount              `json:"stakingForOthersBalance,omitempty" ts_doc:"Value delegated to this address for cold staking by other owners, not included in the balance (Particl)."`
+	BlindReceived         int                  `json:"blindReceived,omitempty" ts_doc:"Number of received blind (CT) outputs, their values are hidden and not included in the balance (Particl)."`
+	BlindSpent            int                  `json:"blindSpent,omitempty" ts_doc:"Number of spent blind (CT) outputs (Particl)."`
 	UnconfirmedBalanceSat *Amount              `json:"unconfirmedBalance" ts_doc:"Unconfirmed balance for this address."`
 	UnconfirmedTxs        int                  `json:"unconfirmedTxs" ts_doc:"Number of unconfirmed transactions for this address."`
 	UnconfirmedSending    *Amount              `json:"unconfirmedSending,omitempty" ts_doc:"Unconfirmed outgoing balance for this address."`
@@ -401,6 +410,7 @@ type Address struct {
 	Erc20Contract  *bchain.ContractInfo `json:"erc20Contract,omitempty" ts_doc:"@deprecated: replaced by contractInfo"`
 	AddressAliases AddressAliasesMap    `json:"addressAliases,omitempty" ts_doc:"Aliases assigned to this address."`
 	StakingPools   []StakingPool        `json:"stakingPools,omitempty" ts_doc:"List of staking pool data if address interacts with staking."`
+	Stealth        *StealthAddress      `json:"stealth,omitempty" ts_doc:"Decoded stealth address, present only for Particl stealth addresses."`
 	// helpers for explorer
 	Filter        string              `json:"-" ts_doc:"Filter used internally for data retrieval."`
 	XPubAddresses map[string]struct{} `json:"-" ts_doc:"Set of derived XPUB addresses (internal usage)."`
@@ -417,6 +427,9 @@ type Utxo struct {
 	Path          string  `json:"path,omitempty" ts_doc:"Derivation path for XPUB-based wallets, if applicable."`
 	Locktime      uint32  `json:"lockTime,omitempty" ts_doc:"If non-zero, locktime required before spending this UTXO."`
 	Coinbase      bool    `json:"coinbase,omitempty" ts_doc:"Indicates if this UTXO originated from a coinbase transaction."`
+	// Particl blind (CT) output
+	Blind           bool   `json:"blind,omitempty" ts_doc:"Indicates a blind (CT) output, its value is hidden and reported as 0 (Particl)."`
+	ValueCommitment string `json:"valueCommitment,omitempty" ts_doc:"Pedersen commitment of the blind output (hex), the rangeproof is in the output of the transaction (Particl)."`
 }
 
 // Utxos is array of Utxo
@@ -504,12 +517,306 @@ func (a BalanceHistories) SortAndAggregate(groupByTime uint32) BalanceHistories
 	return bhs
 }
 
+// ColdStakingDelegation is one unspent output delegated to a cold staking address
+type ColdStakingDelegation struct {
+	Txid          string  `json:"txid" ts_doc:"Transaction ID of the delegated output."`
+	Vout          int32   `json:"vout" ts_doc:"Index of the delegated output in the transaction."`
+	Owner         string  `json:"owner" ts_doc:"Spend address owning the delegated output."`
+	AmountSat     *Amount `json:"value" ts_doc:"Value of the delegated output (in satoshi)."`
+	Height        int     `json:"height" ts_doc:"Block height in which the output was confirmed."`
+	Confirmations int     `json:"confirmations" ts_doc:"Number of confirmations of the output."`
+}
+
+// ColdStakingOwner aggregates the outputs delegated by one spend address
+type ColdStakingOwner struct {
+	Address   string  `json:"address" ts_doc:"Spend address owning the delegated outputs."`
+	AmountSat *Amount `json:"value" ts_doc:"Total value currently delegated by the owner (in satoshi)."`
+	Outputs   int     `json:"outputs" ts_doc:"Number of outputs currently delegated by the owner."`
+}
+
+// Cold staking delegation change types
+const (
+	ColdStakingDelegate   = "delegate"
+	ColdStakingUndelegate = "undelegate"
+)
+
+// ColdStakingChange is one change of the delegation, an output delegated to the staking address or a delegated output spent
+type ColdStakingChange struct {
+	Txid      string  `json:"txid" ts_doc:"Transaction ID of the change."`
+	N         int     `json:"n" ts_doc:"Index of the output (delegate) or the input (undelegate) in the transaction."`
+	Type      string  `json:"type" ts_type:"'delegate' | 'undelegate'" ts_doc:"Type of the change."`
+	Owner     string  `json:"owner" ts_doc:"Spend address owning the output."`
+	AmountSat *Amount `json:"value" ts_doc:"Value of the output (in satoshi)."`
+	Height    int     `json:"height" ts_doc:"Block height of the change."`
+	Blocktime int64   `json:"blockTime" ts_doc:"Unix timestamp of the block of the change."`
+}
+
+// ColdStaking contains the outputs delegated to a cold staking address, their owners and the history of delegation changes
+type ColdStaking struct {
+	Paging
+	AddrStr        string                  `json:"address" ts_doc:"The cold staking address."`
+	TotalWeightSat *Amount                 `json:"totalDelegated" ts_doc:"Total value currently delegated to the address (in satoshi)."`
+	Owners         []ColdStakingOwner      `json:"owners" ts_doc:"Owners of the delegated outputs, ordered by delegated value."`
+	Delegations    []ColdStakingDelegation `json:"delegations" ts_doc:"Outputs currently delegated to the address."`
+	History        []ColdStakingChange     `json:"history" ts_doc:"Page of delegation changes, newest first."`
+}
+
+// StakingReward contains the staking rewards of an address aggregated over a time interval
+type StakingReward struct {
+	Time       uint32             `json:"time" ts_doc:"Unix timestamp of the start of the interval."`
+	Blocks     uint32             `json:"blocks" ts_doc:"Number of blocks staked in this interval."`
+	RewardSat  *Amount            `json:"reward" ts_doc:"Sum of the staking rewards in this interval (in satoshi)."`
+	FiatValues map[string]float64 `json:"values,omitempty" ts_doc:"Value of the rewards in fiat currencies, each reward valued at the rate of the time of its block."`
+}
+
+// StakingRewards contains the staking rewards of an address in the requested time range
+type StakingRewards struct {
+	AddrStr        string             `json:"address" ts_doc:"The rewarded address."`
+	TotalRewardSat *Amount            `json:"totalReward" ts_doc:"Sum of all staking rewards in the time range (in satoshi)."`
+	Blocks         uint32             `json:"blocks" ts_doc:"Number of blocks staked in the time range."`
+	FiatValues     map[string]float64 `json:"values,omitempty" ts_doc:"Value of all rewards in fiat currencies, each reward valued at the rate of the time of its block."`
+	Rewards        []StakingReward    `json:"rewards" ts_doc:"Rewards grouped by time intervals, oldest first."`
+}
+
+// KeyImageSpend contains the transaction spending RingCT key image
+type KeyImageSpend struct {
+	Txid          string `json:"txid" ts_doc:"Transaction ID of the spending transaction."`
+	Vin           int    `json:"vin" ts_doc:"Index of the anon input containing the key image."`
+	Height        int    `json:"height" ts_doc:"Block height of the spending transaction."`
+	Confirmations int    `json:"confirmations" ts_doc:"Number of confirmations of the spending transaction."`
+	Blocktime     int64  `json:"blockTime" ts_doc:"Unix timestamp of the block of the spending transaction."`
+}
+
+// KeyImage contains the spent state of RingCT key image
+type KeyImage struct {
+	KeyImage string         `json:"keyImage" ts_doc:"The key image (hex)."`
+	Spent    bool           `json:"spent" ts_doc:"True if the key image was used by a confirmed transaction."`
+	Spend    *KeyImageSpend `json:"spend,omitempty" ts_doc:"The spending transaction, present only if the key image is spent."`
+}
+
+// TxVote is the governance vote of the staker stored in the coinstake transaction
+type TxVote struct {
+	Proposal int `json:"proposal" ts_doc:"Id of the proposal."`
+	Option   int `json:"option" ts_doc:"Option the staker votes for."`
+}
+
+// VoteOption contains the tally of the votes for one option of a proposal
+type VoteOption struct {
+	Option       int     `json:"option" ts_doc:"The option."`
+	Blocks       int     `json:"blocks" ts_doc:"Number of blocks voting for the option."`
+	StakeSat     *Amount `json:"stake" ts_doc:"Sum of the stake of the blocks voting for the option (in satoshi)."`
+	StakePercent float64 `json:"stakePercent" ts_doc:"Share of the option in the stake of all blocks voting for the proposal, in percent."`
+}
+
+// Votes contains the stake-weighted tally of the votes for a Particl proposal in a range of blocks
+type Votes struct {
+	Proposal      int          `json:"proposal" ts_doc:"Id of the proposal."`
+	FromHeight    int          `json:"fromHeight" ts_doc:"First block of the tally."`
+	ToHeight      int          `json:"toHeight" ts_doc:"Last block of the tally."`
+	Blocks        int          `json:"blocks" ts_doc:"Number of blocks in the height range."`
+	VotedBlocks   int          `json:"votedBlocks" ts_doc:"Number of blocks voting for the proposal."`
+	VotedStakeSat *Amount      `json:"votedStake" ts_doc:"Sum of the stake of the blocks voting for the proposal (in satoshi)."`
+	Options       []VoteOption `json:"options" ts_doc:"Tally per option, ordered by the option."`
+}
+
+// TreasuryPayout contains the payout of the coinstake transaction to the treasury fund
+type TreasuryPayout struct {
+	Txid       string             `json:"txid" ts_doc:"Transaction ID of the coinstake transaction."`
+	Height     int                `json:"height" ts_doc:"Block height of the payout."`
+	Blocktime  int64              `json:"blockTime" ts_doc:"Unix timestamp of the block of the payout."`
+	AmountSat  *Amount            `json:"amount" ts_doc:"Amount paid to the treasury fund (in satoshi)."`
+	FiatValues map[string]float64 `json:"values,omitempty" ts_doc:"Value of the payout in fiat currencies at the rate of the time of its block."`
+}
+
+// Treasury contains the balance and the payout history of the Particl treasury fund
+type Treasury struct {
+	Paging
+	Addresses         []string           `json:"addresses" ts_doc:"Treasury fund addresses of the network."`
+	BalanceSat        *Amount            `json:"balance" ts_doc:"Current balance of the treasury fund addresses (in satoshi)."`
+	FiatValues        map[string]float64 `json:"values,omitempty" ts_doc:"Current value of the balance in fiat currencies."`
+	TotalPaidSat      *Amount            `json:"totalPaid" ts_doc:"Sum of the payouts in the time range (in satoshi)."`
+	CarriedForwardSat *Amount            `json:"carriedForward" ts_doc:"Amount accrued for the next payout at the end of the time range (in satoshi)."`
+	Payouts           []TreasuryPayout   `json:"payouts" ts_doc:"Page of the payouts in the time range, oldest first."`
+}
+
+// SupplyConversions contains the values moved between plain and hidden outputs in the block
+type SupplyConversions struct {
+	PlainToHiddenSat *Amount `json:"plainToHidden" ts_doc:"Value moved from plain to blind and anon outputs (in satoshi)."`
+	HiddenToPlainSat *Amount `json:"hiddenToPlain" ts_doc:"Value moved from blind and anon outputs to plain outputs and fees (in satoshi)."`
+}
+
+// Supply contains the Particl money supply at the block split to the plain and the hidden value
+type Supply struct {
+	Height      int               `json:"height" ts_doc:"Block height."`
+	Hash        string            `json:"hash" ts_doc:"Block hash."`
+	TotalSat    *Amount           `json:"total" ts_doc:"Total money supply (in satoshi)."`
+	PlainSat    *Amount           `json:"plain" ts_doc:"Value of the unspent plain (standard) outputs (in satoshi)."`
+	HiddenSat   *Amount           `json:"hidden" ts_doc:"Total value of the unspent blind and anon outputs, derived from the plain value and the fees of the privacy transactions (in satoshi)."`
+	Conversions SupplyConversions `json:"conversions" ts_doc:"Conversions between plain and hidden outputs in the block."`
+	Inexact     bool              `json:"inexact,omitempty" ts_doc:"Set if a spent output was not found during the indexing, the supply is not exact."`
+}
+
+// BlockStake contains the proof-of-stake data of Particl block
+type BlockStake struct {
+	CoinstakeTxid string  `json:"coinstakeTxid" ts_doc:"Transaction ID of the coinstake transaction."`
+	KernelTxid    string  `json:"kernelTxid" ts_doc:"Transaction ID of the kernel, the staked output which won the block."`
+	KernelVout    int     `json:"kernelVout" ts_doc:"Index of the kernel output."`
+	Staker        string  `json:"staker" ts_doc:"Address which staked the block, the staking address in case of cold staking."`
+	ColdStaked    bool    `json:"coldStaked" ts_doc:"True if the block was cold staked."`
+	StakeSat      *Amount `json:"stake" ts_doc:"Value of the inputs of the coinstake transaction (in satoshi)."`
+	RewardSat     *Amount `json:"reward" ts_doc:"Reward of the coinstake transaction, the value of its outputs minus the stake (in satoshi)."`
+}
+
+// StakingInfo contains the staking state of the Particl network
+type StakingInfo struct {
+	PercentYearReward float64 `json:"percentYearReward,omitempty" ts_doc:"Annual staking reward in percent, reported by the backend."`
+	MoneySupplySat    *Amount `json:"moneySupply,omitempty" ts_doc:"Money supply reported by the backend (in satoshi)."`
+	Difficulty        string  `json:"difficulty,omitempty" ts_doc:"Current proof-of-stake difficulty reported by the backend."`
+	NetStakeWeightSat *Amount `json:"netStakeWeight,omitempty" ts_doc:"Estimated stake weight of the network reported by the backend (in satoshi)."`
+	BackendError      string  `json:"backendError,omitempty" ts_doc:"Error of getstakinginfo, the backend requires enabled wallet to report the staking state."`
+	FromHeight        int     `json:"fromHeight" ts_doc:"First block of the statistics."`
+	ToHeight          int     `json:"toHeight" ts_doc:"Last block of the statistics."`
+	Blocks            int     `json:"blocks" ts_doc:"Number of proof-of-stake blocks in the height range."`
+	ColdStakedBlocks  int     `json:"coldStakedBlocks" ts_doc:"Number of cold staked blocks in the height range."`
+	ColdStakedPercent float64 `json:"coldStakedPercent" ts_doc:"Share of cold staked blocks, in percent."`
+	AverageBlockTime  float64 `json:"averageBlockTime" ts_doc:"Average time between the blocks in the height range, in seconds."`
+	AverageStakeSat   *Amount `json:"averageStake" ts_doc:"Average stake of the blocks in the height range (in satoshi)."`
+	AverageRewardSat  *Amount `json:"averageReward" ts_doc:"Average reward of the blocks in the height range (in satoshi)."`
+	WeightSat         *Amount `json:"weight,omitempty" ts_doc:"The stake weight given by the request (in satoshi)."`
+	ExpectedTime      int64   `json:"expectedTime,omitempty" ts_doc:"Expected time to stake a block with the given weight, in seconds."`
+}
+
+// PrivacyStatsItem contains the statistics of Particl privacy transactions in a time interval
+type PrivacyStatsItem struct {
+	Time            int64   `json:"time,omitempty" ts_doc:"Start of the time interval (Unix timestamp), missing in the total."`
+	Blocks          int     `json:"blocks" ts_doc:"Number of blocks with privacy transactions."`
+	PrivacyTxs      int     `json:"privacyTxs" ts_doc:"Number of transactions with a blind or anon input or output."`
+	PlainToBlindTxs int     `json:"plainToBlindTxs" ts_doc:"Number of transactions moving value from plain to blind outputs."`
+	PlainToAnonTxs  int     `json:"plainToAnonTxs" ts_doc:"Number of transactions moving value from plain to anon outputs."`
+	BlindToAnonTxs  int     `json:"blindToAnonTxs" ts_doc:"Number of transactions moving value from blind to anon outputs."`
+	BlindToPlainTxs int     `json:"blindToPlainTxs" ts_doc:"Number of transactions moving value from blind to plain outputs."`
+	AnonToPlainTxs  int     `json:"anonToPlainTxs" ts_doc:"Number of transactions moving value from anon to plain outputs."`
+	AnonToBlindTxs  int     `json:"anonToBlindTxs" ts_doc:"Number of transactions moving value from anon to blind outputs."`
+	AnonInputs      int     `json:"anonInputs" ts_doc:"Number of real anon inputs."`
+	AverageRingSize float64 `json:"averageRingSize,omitempty" ts_doc:"Average ring size of the anon inputs."`
+	CTFeeSat        *Amount `json:"ctFee" ts_doc:"Sum of the fees of the privacy transactions (in satoshi)."`
+	ringSizeSum     uint64
+}
+
+// PrivacyStats contains the statistics of Particl privacy transactions grouped by time intervals
+type PrivacyStats struct {
+	FromHeight int                `json:"fromHeight" ts_doc:"First block of the statistics."`
+	ToHeight   int                `json:"toHeight" ts_doc:"Last block of the statistics."`
+	Total      PrivacyStatsItem   `json:"total" ts_doc:"Statistics of the whole time range."`
+	Stats      []PrivacyStatsItem `json:"stats" ts_doc:"Statistics by the time intervals, intervals without privacy transactions are omitted."`
+}
+
+// RangeProofInfo contains the public parameters of the range proof of Particl blind or anon output
+type RangeProofInfo struct {
+	Exponent    int     `json:"exponent" ts_doc:"Decimal exponent of the proven value, -1 if the proof discloses the exact value."`
+	Mantissa    int     `json:"mantissa" ts_doc:"Number of bits of the proven value."`
+	MinValueSat *Amount `json:"minValue" ts_doc:"Minimum value of the output (in satoshi)."`
+	MaxValueSat *Amount `json:"maxValue" ts_doc:"Maximum value of the output (in satoshi)."`
+	ExactValue  bool    `json:"exactValue,omitempty" ts_doc:"The proof discloses the value of the output, it is equal to minValue."`
+}
+
+// TxTypeFee contains the estimated fee of Particl transaction of one type
+type TxTypeFee struct {
+	Type           string  `json:"type" ts_doc:"Transaction type: plain, blind or anon."`
+	VSize          int     `json:"vsize" ts_doc:"Estimated virtual size of the transaction in vbytes."`
+	FeeSat         *Amount `json:"fee" ts_doc:"Estimated absolute fee of the transaction (in satoshi)."`
+	Samples        int     `json:"samples" ts_doc:"Number of transactions of the type in the last blocks the size estimation is calibrated from."`
+	Calibration    float64 `json:"calibration" ts_doc:"Ratio of the real and modelled size of the transactions of the type in the last blocks, 1 if there were none."`
+	RingMemberSize float64 `json:"ringMemberSize,omitempty" ts_doc:"Size of one ring member of the anon input in vbytes, fitted to the anon transactions in the last blocks."`
+}
+
+// TxFeeEstimate contains the estimated fees of Particl plain, blind and anon transactions
+type TxFeeEstimate struct {
+	Blocks     int         `json:"blocks" ts_doc:"Block confirmation target of the estimation."`
+	FeePerUnit *Amount     `json:"feePerUnit" ts_doc:"Estimated fee rate (in satoshi per kB)."`
+	Inputs     int         `json:"inputs" ts_doc:"Number of inputs of the estimated transactions."`
+	Outputs    int         `json:"outputs" ts_doc:"Number of outputs of the estimated transactions, without the fee data output of blind and anon transactions."`
+	RingSize   int         `json:"ringSize" ts_doc:"Ring size of the anon inputs."`
+	FromHeight int         `json:"fromHeight" ts_doc:"First block of the calibration."`
+	ToHeight   int         `json:"toHeight" ts_doc:"Last block of the calibration."`
+	Fees       []TxTypeFee `json:"fees" ts_doc:"Estimated fees by the transaction type."`
+}
+
+// StealthAddress contains decoded Particl stealth address
+type StealthAddress struct {
+	ScanPubKey    string   `json:"scanPubKey" ts_doc:"Public scan key (hex), used by the receiver to find the payments."`
+	SpendPubKeys  []string `json:"spendPubKeys" ts_doc:"Public spend keys (hex)."`
+	NumSignatures int      `json:"numSignatures" ts_doc:"Number of signatures required to spend."`
+	PrefixBits    int      `json:"prefixBits,omitempty" ts_doc:"Number of bits of the prefix, zero if the address has no prefix."`
+	Prefix        string   `json:"prefix,omitempty" ts_doc:"Prefix bitfield (hex), present only if prefixBits is not zero."`
+	Note          string   `json:"note" ts_doc:"Explanation why the address has no balance and history."`
+}
+
+// AnonOutput contains RingCT anon output with its global index
+type AnonOutput struct {
+	Index           uint64 `json:"index" ts_doc:"Global index of the anon output."`
+	Txid            string `json:"txid" ts_doc:"Transaction ID containing the output."`
+	Vout            int    `json:"vout" ts_doc:"Index of the output in the transaction."`
+	PubKey          string `json:"pubkey" ts_doc:"Public key of the output (hex)."`
+	ValueCommitment string `json:"valueCommitment" ts_doc:"Pedersen commitment of the output (hex)."`
+	Height          int    `json:"height" ts_doc:"Block height of the transaction."`
+	Confirmations   int    `json:"confirmations" ts_doc:"Number of confirmations of the transaction."`
+}
+
+// AnonOutputs contains a range of RingCT anon outputs ordered by their global index
+type AnonOutputs struct {
+	LastIndex uint64       `json:"lastIndex" ts_doc:"Highest global index of anon output known to the backend."`
+	Outputs   []AnonOutput `json:"outputs" ts_doc:"Anon outputs starting from the requested index."`
+}
+
+// StealthOutput is an output paid to a stealth address, found by the scan key
+type StealthOutput struct {
+	Txid            string  `json:"txid" ts_doc:"Transaction ID of the output."`
+	Vout            int     `json:"vout" ts_doc:"Index of the output in the transaction."`
+	Height          int     `json:"height" ts_doc:"Block height of the transaction."`
+	Confirmations   int     `json:"confirmations" ts_doc:"Number of confirmations of the transaction."`
+	Blocktime       int64   `json:"blockTime" ts_doc:"Unix timestamp of the block."`
+	Type            string  `json:"type" ts_doc:"Output type: standard, blind or anon."`
+	AmountSat       *Amount `json:"value,omitempty" ts_doc:"Value of the output, for blind and anon outputs recovered from the range proof, missing if it cannot be recovered."`
+	ValueCommitment string  `json:"valueCommitment,omitempty" ts_doc:"Pedersen commitment of the value of blind and anon outputs (hex)."`
+	EphemeralPubKey string  `json:"ephemeralPubKey" ts_doc:"Ephemeral public key of the payment (hex)."`
+	PubKey          string  `json:"pubKey" ts_doc:"One-time public key of the output (hex)."`
+	SharedSecret    string  `json:"sharedSecret" ts_doc:"Shared secret (hex), added to the spend secret gives the private key of the output."`
+}
+
+// StealthScan contains the outputs paid to a stealth address in a range of blocks
+type StealthScan struct {
+	Address    string          `json:"address" ts_doc:"The scanned stealth address."`
+	FromHeight int             `json:"fromHeight" ts_doc:"First scanned block height."`
+	ToHeight   int             `json:"toHeight" ts_doc:"Last scanned block height."`
+	Outputs    []StealthOutput `json:"outputs" ts_doc:"Outputs paid to the stealth address, ordered by block height."`
+}
+
 // Blocks is list of blocks with paging information
 type Blocks struct {
 	Paging
 	Blocks []db.BlockInfo `json:"blocks" ts_doc:"List of blocks."`
 }
 
+// Reorg contains the record of a reorganization of the chain handled by Blockbook
+type Reorg struct {
+	ID         int      `json:"id" ts_doc:"Sequence number of the reorg."`
+	Time       int64    `json:"time" ts_doc:"Time when the reorg was handled (Unix timestamp)."`
+	Depth      int      `json:"depth" ts_doc:"Number of disconnected blocks."`
+	ForkHeight int      `json:"forkHeight" ts_doc:"Height of the last block common to the old and the new chain."`
+	OldHeight  int      `json:"oldHeight" ts_doc:"Height of the tip of the chain before the reorg."`
+	OldHash    string   `json:"oldHash" ts_doc:"Hash of the tip of the chain before the reorg."`
+	NewHeight  int      `json:"newHeight" ts_doc:"Height of the tip of the chain after the reorg."`
+	NewHash    string   `json:"newHash" ts_doc:"Hash of the tip of the chain after the reorg."`
+	Txids      []string `json:"txids" ts_doc:"Txids of the disconnected transactions not included in the new chain, they are returned to mempool by the backend."`
+}
+
+// Reorgs is a paged list of the reorgs of the chain, from the newest
+type Reorgs struct {
+	Paging
+	Reorgs []Reorg `json:"reorgs" ts_doc:"List of reorgs."`
+}
+
 // BlockInfo contains extended block header data and a list of block txids
 type BlockInfo struct {
 	Hash          string            `json:"hash" ts_doc:"Block hash."`
@@ -525,6 +832,7 @@ type BlockInfo struct {
 	Bits          string            `json:"bits" ts_doc:"Compact representation of the target threshold."`
 	Difficulty    string            `json:"difficulty" ts_doc:"Difficulty target for mining this block."`
 	Txids         []string          `json:"tx,omitempty" ts_doc:"List of transaction IDs included in this block."`
+	Stake         *BlockStake       `json:"stake,omitempty" ts_doc:"Proof-of-stake data of Particl block, derived from its coinstake transaction."`
 }
 
 // Block contains information about block
@@ -35,6 +35,7 @@ type Worker struct {
 	is                *common.InternalState
 	fiatRates         *fiat.FiatRates
 	metrics           *common.Metrics
+	txSizeStats       txSizeStatsCache
 }
 
 // contractInfoCache is a temporary cache of contract information for ethereum token transfers
@@ -388,6 +389,7 @@ func (w *Worker) getTransactionFromBchainTx(bchainTx *bchain.Tx, height int, spe
 		vin.InputType = bchainVin.InputType
 		vin.AnonInputs = bchainVin.AnonInputs
 		vin.RingSize = bchainVin.RingSize
+		vin.KeyImages = bchainVin.KeyImages
 
 		if w.chainType == bchain.ChainBitcoinType {
 			//  bchainVin.Txid=="" is coinbase transaction
@@ -469,6 +471,10 @@ func (w *Worker) getTransactionFromBchainTx(bchainTx *bchain.Tx, height int, spe
 		vout.ValueCommitment = bchainVout.ValueCommitment
 		vout.Data = bchainVout.Data
 		vout.RangeProof = bchainVout.RangeProof
+		vout.RangeProofInfo = rangeProofInfo(bchainVout.RangeProof)
+		vout.PubKey = bchainVout.PubKey
+		vout.AnonIndex = bchainVout.AnonIndex
+		vout.Vote = coinstakeVote(bchainTx, bchainVout)
 
 		vout.AddrDesc, vout.Addresses, vout.IsAddress, err = w.getAddressesFromVout(bchainVout)
 		if err != nil {
@@ -492,11 +498,10 @@ func (w *Worker) getTransactionFromBchainTx(bchainTx *bchain.Tx, height int, spe
 		}
 	}
 	if w.chainType == bchain.ChainBitcoinType {
-		// Check if this is a Particl CT/RingCT transaction with embedded fee
-		if particlData, ok := bchainTx.CoinSpecificData.(*part.ParticlTxData); ok && particlData != nil && particlData.CTFee > 0 {
-			// For Particl CT/RingCT transactions, use the CT fee from the data output
-			// Convert from PART to satoshis (1 PART = 100,000,000 satoshis)
-			feesSat.SetInt64(int64(particlData.CTFee * 100000000))
+		// Check if this is a Particl CT/RingCT transaction with the fee embedded in the data output
+		if ctFee := part.TxCTFeeSat(bchainTx); ctFee > 0 {
+			// For Particl CT/RingCT transactions, the values of blind and anon outputs are hidden
+			feesSat.SetInt64(ctFee)
 		} else {
 			// For standard Bitcoin-like transactions: fee = inputs - outputs
 			// for coinbase transactions valIn is 0
@@ -915,10 +920,11 @@ func (w *Worker) getAddressTxids(addrDesc bchain.AddressDescriptor, mempool bool
 	return txids, nil
 }
 
-func (t *Tx) getAddrVoutValue(addrDesc bchain.AddressDescriptor) *big.Int {
+// getAddrVoutValue returns the value of the outputs owned by the address, parser resolves the owner of the output
+func (t *Tx) getAddrVoutValue(addrDesc bchain.AddressDescriptor, parser bchain.BlockChainParser) *big.Int {
 	var val big.Int
 	for _, vout := range t.Vout {
-		if bytes.Equal(vout.AddrDesc, addrDesc) && vout.ValueSat != nil {
+		if vout.ValueSat != nil && bytes.Equal(parser.GetIndexedAddrDescs(vout.AddrDesc)[0], addrDesc) {
 			val.Add(&val, (*big.Int)(vout.ValueSat))
 		}
 	}
@@ -938,10 +944,11 @@ func (t *Tx) getAddrEthereumTypeMempoolInputValue(addrDesc bchain.AddressDescrip
 	return &val
 }
 
-func (t *Tx) getAddrVinValue(addrDesc bchain.AddressDescriptor) *big.Int {
+// getAddrVinValue returns the value of the inputs owned by the address, parser resolves the owner of the spent output
+func (t *Tx) getAddrVinValue(addrDesc bchain.AddressDescriptor, parser bchain.BlockChainParser) *big.Int {
 	var val big.Int
 	for _, vin := range t.Vin {
-		if bytes.Equal(vin.AddrDesc, addrDesc) && vin.ValueSat != nil {
+		if vin.ValueSat != nil && bytes.Equal(parser.GetIndexedAddrDescs(vin.AddrDesc)[0], addrDesc) {
 			val.Add(&val, (*big.Int)(vin.ValueSat))
 		}
 	}
@@ -988,6 +995,12 @@ func (w *Worker) txFromTxAddress(txid string, ta *db.TxAddresses, bi *db.BlockIn
 		}
 		aggregateAddresses(addresses, vin.Addresses, vin.IsAddress)
 	}
+	if w.db.HasExtendedIndex() {
+		// commitments and range proofs are not part of TxAddresses, load them only for the rendered transaction
+		if err = w.db.LoadTxOutputBlobs(txid, ta); err != nil {
+			glog.Errorf("LoadTxOutputBlobs error %v, tx %v", err, txid)
+		}
+	}
 	vouts := make([]Vout, len(ta.Outputs))
 	for i := range ta.Outputs {
 		tao := &ta.Outputs[i]
@@ -1010,6 +1023,7 @@ func (w *Worker) txFromTxAddress(txid string, ta *db.TxAddresses, bi *db.BlockIn
 			vout.Type = tao.OutputType
 			vout.ValueCommitment = tao.ValueCommitment
 			vout.RangeProof = tao.RangeProof
+			vout.RangeProofInfo = rangeProofInfo(tao.RangeProof)
 		}
 		aggregateAddresses(addresses, vout.Addresses, vout.IsAddress)
 	}
@@ -1451,6 +1465,9 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 	if err != nil {
 		return nil, err
 	}
+	if sa := part.StealthAddressFromAddrDesc(addrDesc); sa != nil {
+		return w.getStealthAddress(address, sa), nil
+	}
 	if w.chainType == bchain.ChainEthereumType {
 		ba, ed, err = w.getEthereumTypeAddressBalances(addrDesc, option, filter, secondaryCoin)
 		if err != nil {
@@ -1493,12 +1510,12 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 				// skip already confirmed txs, mempool may be out of sync
 				if tx.Confirmations == 0 {
 					unconfirmedTxs++
-					uBalReceiving.Add(&uBalReceiving, tx.getAddrVoutValue(addrDesc))
+					uBalReceiving.Add(&uBalReceiving, tx.getAddrVoutValue(addrDesc, w.chainParser))
 					// ethereum has a different logic - value not in input and add maximum possible fees
 					if w.chainType == bchain.ChainEthereumType {
 						uBalSending.Add(&uBalSending, tx.getAddrEthereumTypeMempoolInputValue(addrDesc))
 					} else {
-						uBalSending.Add(&uBalSending, tx.getAddrVinValue(addrDesc))
+						uBalSending.Add(&uBalSending, tx.getAddrVinValue(addrDesc, w.chainParser))
 					}
 					if page == 0 {
 						if option == AccountDetailsTxidHistory {
@@ -1572,6 +1589,10 @@ func (w *Worker) GetAddress(address string, page int, txsOnPage int, option Acco
 		BalanceSat:            (*Amount)(&ba.BalanceSat),
 		TotalReceivedSat:      (*Amount)(totalReceived),
 		TotalSentSat:          (*Amount)(totalSent),
+		DelegatedBalanceSat:   amountOrNil(&ba.DelegatedSat),
+		StakingForOthersSat:   amountOrNil(&ba.StakingSat),
+		BlindReceived:         int(ba.BlindReceived),
+		BlindSpent:            int(ba.BlindSpent),
 		Txs:                   int(ba.Txs),
 		NonTokenTxs:           ed.nonContractTxs,
 		InternalTxs:           ed.internalTxs,
@@ -1907,13 +1928,18 @@ func (w *Worker) getAddrDescUtxo(addrDesc bchain.AddressDescriptor, ba *db.AddrB
 								if len(bchainTx.Vin) == 1 && len(bchainTx.Vin[0].Coinbase) > 0 {
 									coinbase = true
 								}
-								utxos = append(utxos, Utxo{
+								utxo := Utxo{
 									Txid:      bchainTx.Txid,
 									Vout:      int32(i),
 									AmountSat: (*Amount)(&vout.ValueSat),
 									Locktime:  bchainTx.LockTime,
 									Coinbase:  coinbase,
-								})
+								}
+								if vout.OutputType == "blind" {
+									utxo.Blind = true
+									utxo.ValueCommitment = vout.ValueCommitment
+								}
+								utxos = append(utxos, utxo)
 								inMempool[bchainTx.Txid] = struct{}{}
 							}
 						}
@@ -1978,6 +2004,11 @@ func (w *Worker) getAddrDescUtxo(addrDesc bchain.AddressDescriptor, ba *db.AddrB
 				glog.Warning("DB inconsistency:  ", addrDesc, ": checksum is not zero, checksum=", checksum.Int64())
 			}
 		}
+		if ba != nil && len(ba.BlindUtxos) > 0 {
+			if utxos, err = w.appendBlindUtxos(utxos, ba, spentInMempool, inMempool); err != nil {
+				return nil, err
+			}
+		}
 	}
 	return utxos, nil
 }
@@ -2030,6 +2061,48 @@ func (w *Worker) GetBlocks(page int, blocksOnPage int) (*Blocks, error) {
 	return r, nil
 }
 
+// NewReorg converts the reorg record of the db to the api type
+func NewReorg(r *db.Reorg) *Reorg {
+	return &Reorg{
+		ID:         int(r.ID),
+		Time:       r.Time,
+		Depth:      int(r.Depth()),
+		ForkHeight: int(r.ForkHeight),
+		OldHeight:  int(r.OldHeight),
+		OldHash:    r.OldHash,
+		NewHeight:  int(r.NewHeight),
+		NewHash:    r.NewHash,
+		Txids:      r.Txids,
+	}
+}
+
+// GetReorgs returns page of the reorgs of the chain handled by Blockbook, from the newest
+func (w *Worker) GetReorgs(page int, reorgsOnPage int) (*Reorgs, error) {
+	if w.chainType != bchain.ChainBitcoinType {
+		return nil, NewAPIError("Not supported", true)
+	}
+	start := time.Now()
+	page--
+	if page < 0 {
+		page = 0
+	}
+	last := int(w.db.GetLastReorgID())
+	pg, from, to, page := computePaging(last, page, reorgsOnPage)
+	r := &Reorgs{Paging: pg, Reorgs: make([]Reorg, 0, to-from)}
+	for i := from; i < to; i++ {
+		reorg, err := w.db.GetReorg(uint32(last - i))
+		if err != nil {
+			return nil, errors.Annotatef(err, "GetReorg %v", last-i)
+		}
+		if reorg == nil {
+			continue
+		}
+		r.Reorgs = append(r.Reorgs, *NewReorg(reorg))
+	}
+	glog.Info("GetReorgs page ", page, ", ", time.Since(start))
+	return r, nil
+}
+
 // removeEmpty removes empty strings from a slice
 func removeEmpty(stringSlice []string) []string {
 	var ret []string
@@ -2403,6 +2476,7 @@ func (w *Worker) GetBlock(bid string, page int, txsOnPage int) (*Block, error) {
 			Nonce:         string(bi.Nonce),
 			Txids:         bi.Txids,
 			Version:       bi.Version,
+			Stake:         w.blockStake(bi.Height),
 		},
 		TxCount:        txCount,
 		Transactions:   txs,
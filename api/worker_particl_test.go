@@ -0,0 +1,457 @@
+//go:build unittest
+
+package api
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/bchain/coins/btc"
+	"github.com/trezor/blockbook/bchain/coins/part"
+	"github.com/trezor/blockbook/common"
+	"github.com/trezor/blockbook/db"
+	"github.com/trezor/blockbook/fiat"
+	"github.com/trezor/blockbook/tests/dbtestdata"
+)
+
+// setupParticlWorker connects the blocks of the Particl fixture chain to a new database and returns the worker over it
+func setupParticlWorker(t *testing.T, blocks []*bchain.Block) (*Worker, *db.RocksDB, func()) {
+	parser := part.NewParticlParser(part.GetChainParams("main"), &btc.Configuration{BlockAddressesToKeep: 10})
+	chain, err := dbtestdata.NewFakeBlockChainParticlType(parser, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp, err := os.MkdirTemp("", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := db.NewRocksDB(tmp, 100000, -1, parser, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cleanup := func() {
+		d.Close()
+		os.RemoveAll(tmp)
+	}
+	// the unit test coin name loads the block times synchronously
+	config := common.Config{CoinName: "coin-unittest", CoinShortcut: "PART"}
+	is, err := d.LoadInternalState(&config)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	d.SetInternalState(is)
+	for i := uint32(0); i < blocks[0].Height; i++ {
+		is.BlockTimes = append(is.BlockTimes, 0)
+	}
+	for _, b := range blocks {
+		if err := d.ConnectBlock(b); err != nil {
+			cleanup()
+			t.Fatal(err)
+		}
+	}
+	is.FinishedSync(blocks[len(blocks)-1].Height)
+	metrics, err := common.GetMetrics("Particl" + t.Name())
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	mempool, err := chain.CreateMempool(chain)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	txCache, err := db.NewTxCache(d, chain, metrics, is, false)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	fiatRates, err := fiat.NewFiatRates(d, &config, nil, nil)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	w, err := NewWorker(d, chain, mempool, txCache, metrics, is, fiatRates)
+	if err != nil {
+		cleanup()
+		t.Fatal(err)
+	}
+	return w, d, cleanup
+}
+
+func amountInt64(a *Amount) int64 {
+	if a == nil {
+		return 0
+	}
+	return (*big.Int)(a).Int64()
+}
+
+type particlAddressState struct {
+	address          string
+	txs              int
+	balance          int64
+	delegated        int64
+	stakingForOthers int64
+	blindReceived    int
+	blindSpent       int
+}
+
+func checkParticlAddresses(t *testing.T, w *Worker, name string, want []particlAddressState) {
+	for _, a := range want {
+		got, err := w.GetAddress(a.address, 0, 1000, AccountDetailsTxidHistory, &AddressFilter{Vout: AddressFilterVoutOff}, "")
+		if err != nil {
+			t.Fatalf("%s: GetAddress(%s) error %v", name, a.address, err)
+		}
+		g := particlAddressState{
+			address:          got.AddrStr,
+			txs:              got.Txs,
+			balance:          amountInt64(got.BalanceSat),
+			delegated:        amountInt64(got.DelegatedBalanceSat),
+			stakingForOthers: amountInt64(got.StakingForOthersSat),
+			blindReceived:    got.BlindReceived,
+			blindSpent:       got.BlindSpent,
+		}
+		if g != a {
+			t.Errorf("%s: GetAddress(%s) = %+v, want %+v", name, a.address, g, a)
+		}
+	}
+}
+
+func checkParticlStakingRewards(t *testing.T, w *Worker, name string, address string, wantBlocks uint32, wantReward int64) {
+	r, err := w.GetStakingRewards(address, 0, 0, nil, 86400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Blocks != wantBlocks || amountInt64(r.TotalRewardSat) != wantReward {
+		t.Errorf("%s: GetStakingRewards(%s) = blocks %d, reward %d, want %d, %d", name, address, r.Blocks, amountInt64(r.TotalRewardSat), wantBlocks, wantReward)
+	}
+}
+
+func checkParticlColdStaking(t *testing.T, w *Worker, name string, wantDelegated int64, wantDelegationTxid string, wantChanges int) {
+	cs, err := w.GetColdStaking(dbtestdata.AddrPartStaker, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountInt64(cs.TotalWeightSat) != wantDelegated || len(cs.Delegations) != 1 || cs.Delegations[0].Txid != wantDelegationTxid || len(cs.History) != wantChanges {
+		t.Errorf("%s: GetColdStaking() = delegated %d, delegations %+v, changes %d, want %d, %s, %d", name,
+			amountInt64(cs.TotalWeightSat), cs.Delegations, len(cs.History), wantDelegated, wantDelegationTxid, wantChanges)
+	}
+	if len(cs.History) > 0 && (cs.History[len(cs.History)-1].Txid != dbtestdata.TxidPartB500T1 || cs.TotalPages != 1) {
+		t.Errorf("%s: GetColdStaking() = history %+v, total pages %d, want the delegation %s on one page", name, cs.History, cs.TotalPages, dbtestdata.TxidPartB500T1)
+	}
+	if len(cs.Owners) != 1 || cs.Owners[0].Address != dbtestdata.AddrPartOwner || amountInt64(cs.Owners[0].AmountSat) != wantDelegated {
+		t.Errorf("%s: GetColdStaking() owners = %+v, want %s with %d", name, cs.Owners, dbtestdata.AddrPartOwner, wantDelegated)
+	}
+}
+
+func checkParticlSupply(t *testing.T, w *Worker, name string, wantHeight int, wantPlain, wantHidden, wantPlainToHidden, wantHiddenToPlain int64) {
+	s, err := w.GetSupply("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Height != wantHeight || amountInt64(s.PlainSat) != wantPlain || amountInt64(s.HiddenSat) != wantHidden || amountInt64(s.TotalSat) != wantPlain+wantHidden ||
+		amountInt64(s.Conversions.PlainToHiddenSat) != wantPlainToHidden || amountInt64(s.Conversions.HiddenToPlainSat) != wantHiddenToPlain {
+		t.Errorf("%s: GetSupply() = height %d, plain %d, hidden %d, total %d, conversions %d, %d, want %d, %d, %d, %d, %d", name, s.Height,
+			amountInt64(s.PlainSat), amountInt64(s.HiddenSat), amountInt64(s.TotalSat),
+			amountInt64(s.Conversions.PlainToHiddenSat), amountInt64(s.Conversions.HiddenToPlainSat),
+			wantHeight, wantPlain, wantHidden, wantPlainToHidden, wantHiddenToPlain)
+	}
+}
+
+func checkParticlPrivacyStats(t *testing.T, w *Worker, name string, want PrivacyStatsItem) {
+	ps, err := w.GetPrivacyStats(0, 0, 86400)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := ps.Total
+	if got.PrivacyTxs != want.PrivacyTxs || got.PlainToBlindTxs != want.PlainToBlindTxs || got.BlindToAnonTxs != want.BlindToAnonTxs ||
+		got.AnonToPlainTxs != want.AnonToPlainTxs || got.AnonInputs != want.AnonInputs || got.AverageRingSize != want.AverageRingSize ||
+		amountInt64(got.CTFeeSat) != amountInt64(want.CTFeeSat) {
+		t.Errorf("%s: GetPrivacyStats() total = %+v, want %+v", name, got, want)
+	}
+}
+
+func TestWorker_ParticlFixtureChain(t *testing.T) {
+	parser := part.NewParticlParser(part.GetChainParams("main"), &btc.Configuration{})
+	blocks := dbtestdata.GetTestParticlTypeBlocks(parser)
+	w, d, cleanup := setupParticlWorker(t, blocks)
+	defer cleanup()
+
+	ctFee := dbtestdata.SatPartCTFee.Int64()
+	checkParticlAddresses(t, w, "block503", []particlAddressState{
+		{address: dbtestdata.AddrPartOwner, txs: 3, balance: dbtestdata.SatPartB503Cold.Int64(), delegated: dbtestdata.SatPartB503Cold.Int64()},
+		{address: dbtestdata.AddrPartStaker, txs: 3, stakingForOthers: dbtestdata.SatPartB503Cold.Int64()},
+		{address: dbtestdata.AddrPartA, txs: 2, balance: dbtestdata.SatPartB501AChange.Int64()},
+		{address: dbtestdata.AddrPartB, txs: 4, balance: dbtestdata.SatPartB502B.Int64(), blindReceived: 1, blindSpent: 1},
+		{address: dbtestdata.AddrPartC, txs: 1, balance: dbtestdata.SatPartB503C.Int64()},
+	})
+
+	// the fee of CT transaction is taken from the data output, the values of the blind outputs are hidden
+	tx, err := w.GetTransaction(dbtestdata.TxidPartB501T2, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountInt64(tx.FeesSat) != ctFee || amountInt64(tx.ValueOutSat) != dbtestdata.SatPartB501AChange.Int64() || tx.Confirmations != 3 {
+		t.Errorf("GetTransaction(%s) = fee %d, value %d, confirmations %d", tx.Txid, amountInt64(tx.FeesSat), amountInt64(tx.ValueOutSat), tx.Confirmations)
+	}
+	wantRangeProofInfo := RangeProofInfo{Exponent: 2, Mantissa: 32, MinValueSat: &Amount{}, MaxValueSat: (*Amount)(big.NewInt(429496729500))}
+	if ri := tx.Vout[1].RangeProofInfo; ri == nil || ri.Exponent != wantRangeProofInfo.Exponent || ri.Mantissa != wantRangeProofInfo.Mantissa ||
+		amountInt64(ri.MaxValueSat) != amountInt64(wantRangeProofInfo.MaxValueSat) || ri.ExactValue {
+		t.Errorf("GetTransaction(%s) RangeProofInfo = %+v, want %+v", tx.Txid, ri, wantRangeProofInfo)
+	}
+	tx, err = w.GetTransaction(dbtestdata.TxidPartB503T2, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amountInt64(tx.FeesSat) != ctFee || tx.Vin[0].AnonInputs != 1 || tx.Vin[0].RingSize != 5 {
+		t.Errorf("GetTransaction(%s) = fee %d, vin %+v", tx.Txid, amountInt64(tx.FeesSat), tx.Vin[0])
+	}
+
+	// the reward of cold staking is credited only to the staking address
+	checkParticlStakingRewards(t, w, "block503", dbtestdata.AddrPartOwner, 0, 0)
+	checkParticlStakingRewards(t, w, "block503", dbtestdata.AddrPartStaker, 2, 90000000)
+	checkParticlStakingRewards(t, w, "block503", dbtestdata.AddrPartB, 1, 30000000)
+	// the coinstakes restaking the delegated output are not part of the history, only the delegation in block 500
+	checkParticlColdStaking(t, w, "block503", dbtestdata.SatPartB503Cold.Int64(), dbtestdata.TxidPartB503T1, 1)
+
+	ki, err := w.GetKeyImage(dbtestdata.KeyImagePart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ki.Spent || ki.Spend.Txid != dbtestdata.TxidPartB503T2 || ki.Spend.Height != 503 || ki.Spend.Vin != 0 {
+		t.Errorf("GetKeyImage() = %+v, spend %+v", ki, ki.Spend)
+	}
+
+	si, err := w.GetStakingInfo("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if si.Blocks != 3 || si.ColdStakedBlocks != 2 || si.AverageBlockTime != 120 || amountInt64(si.AverageRewardSat) != 40000000 || si.BackendError != "" {
+		t.Errorf("GetStakingInfo() = %+v", si)
+	}
+
+	// plain to blind in block 501, blind to anon in 502 and anon to plain with anon change in 503, each paying the fee from the hidden value,
+	// the treasury fund payout of the coinstake 501 is in the plain supply
+	checkParticlSupply(t, w, "block503", 503, 15630000000, 1499354400, 0, dbtestdata.SatPartB503C.Int64()+ctFee)
+	checkParticlPrivacyStats(t, w, "block503", PrivacyStatsItem{
+		PrivacyTxs:      3,
+		PlainToBlindTxs: 1,
+		BlindToAnonTxs:  1,
+		AnonToPlainTxs:  1,
+		AnonInputs:      1,
+		AverageRingSize: 5,
+		CTFeeSat:        (*Amount)(big.NewInt(3 * ctFee)),
+	})
+
+	// reorg, the block 503 is replaced by the block with the coinstake of the address B
+	if err := d.DisconnectBlockRangeBitcoinType(503, 503); err != nil {
+		t.Fatal(err)
+	}
+	blocks[3] = dbtestdata.GetTestParticlTypeBlock503R(parser)
+	if err := d.ConnectBlock(blocks[3]); err != nil {
+		t.Fatal(err)
+	}
+
+	checkParticlAddresses(t, w, "reorg", []particlAddressState{
+		{address: dbtestdata.AddrPartOwner, txs: 2, balance: dbtestdata.SatPartB501Cold.Int64(), delegated: dbtestdata.SatPartB501Cold.Int64()},
+		{address: dbtestdata.AddrPartStaker, txs: 2, stakingForOthers: dbtestdata.SatPartB501Cold.Int64()},
+		{address: dbtestdata.AddrPartA, txs: 2, balance: dbtestdata.SatPartB501AChange.Int64()},
+		{address: dbtestdata.AddrPartB, txs: 5, balance: dbtestdata.SatPartB503RB.Int64(), blindReceived: 1, blindSpent: 1},
+		{address: dbtestdata.AddrPartC, txs: 0},
+	})
+	if _, err := w.GetTransaction(dbtestdata.TxidPartB503T2, false, false); err == nil {
+		t.Errorf("GetTransaction(%s) of the disconnected block did not fail", dbtestdata.TxidPartB503T2)
+	}
+	checkParticlStakingRewards(t, w, "reorg", dbtestdata.AddrPartOwner, 0, 0)
+	checkParticlStakingRewards(t, w, "reorg", dbtestdata.AddrPartStaker, 1, 50000000)
+	checkParticlStakingRewards(t, w, "reorg", dbtestdata.AddrPartB, 2, 50000000)
+	checkParticlColdStaking(t, w, "reorg", dbtestdata.SatPartB501Cold.Int64(), dbtestdata.TxidPartB501T1, 1)
+
+	ki, err = w.GetKeyImage(dbtestdata.KeyImagePart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ki.Spent || ki.Spend != nil {
+		t.Errorf("GetKeyImage() after reorg = %+v, spend %+v, want unspent", ki, ki.Spend)
+	}
+
+	checkParticlSupply(t, w, "reorg", 503, 15110000000, 1999569600, 0, 0)
+	checkParticlPrivacyStats(t, w, "reorg", PrivacyStatsItem{
+		PrivacyTxs:      2,
+		PlainToBlindTxs: 1,
+		BlindToAnonTxs:  1,
+		CTFeeSat:        (*Amount)(big.NewInt(2 * ctFee)),
+	})
+
+	// the sync switches back to the original block 503 and records the reorg
+	reorgs, err := w.GetReorgs(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reorgs.Reorgs) != 0 {
+		t.Errorf("GetReorgs() = %+v, want no reorgs", reorgs)
+	}
+	sw, err := db.NewSyncWorker(d, w.chain, 1, 0, 0, false, nil, w.metrics, w.is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks[3] = dbtestdata.GetTestParticlTypeBlock503(parser)
+	if err := sw.ResyncIndex(nil, false); err != nil {
+		t.Fatal(err)
+	}
+	checkParticlAddresses(t, w, "sync reorg", []particlAddressState{
+		{address: dbtestdata.AddrPartB, txs: 4, balance: dbtestdata.SatPartB502B.Int64(), blindReceived: 1, blindSpent: 1},
+		{address: dbtestdata.AddrPartC, txs: 1, balance: dbtestdata.SatPartB503C.Int64()},
+	})
+	reorgs, err = w.GetReorgs(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reorgs.Reorgs) != 1 || reorgs.TotalPages != 1 {
+		t.Fatalf("GetReorgs() = %+v, want one reorg", reorgs)
+	}
+	r := reorgs.Reorgs[0]
+	if r.ID != 1 || r.Depth != 1 || r.ForkHeight != 502 || r.OldHeight != 503 || r.OldHash != dbtestdata.GetTestParticlTypeBlock503R(parser).Hash ||
+		r.NewHeight != 503 || r.NewHash != blocks[3].Hash || len(r.Txids) != 1 || r.Txids[0] != dbtestdata.TxidPartB503R1 {
+		t.Errorf("GetReorgs() reorg = %+v", r)
+	}
+}
+
+// the blind and anon outputs paid to the stealth address with the scan secret 1, with the Borromean range proofs
+// of the values 1234500000 and 765400000 created with the nonce derived from the shared secret of the payment
+const (
+	stealthPartAddress         = "SPGwb6oqzdPSXUZvknW3UcN6MupFjKR1SVaBDdaCAConLdJNR7Hq5wZdpXBHAp5ayJUhERb4tFQ7Ksu3X7og5td8FiaA7GTj2f8cES"
+	stealthPartScanSecret      = "0000000000000000000000000000000000000000000000000000000000000001"
+	stealthPartTxid            = "a500000000000000000000000000000000000000000000000000000000000005"
+	stealthPartBlindEphem      = "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
+	stealthPartBlindP2PKH      = "76a914379b0b2dfcede1cc1fe69d1b6290410d1031eecb88ac"
+	stealthPartBlindCommitment = "0811629d18e0d7eed0e171e5ed2b9f6cb6d6a1b8dc69599dadb93cd533a2a31cd9"
+	stealthPartBlindRangeProof = "450d3e2f1af32b5c9064a7f12a8780cee2f6b12a2c7467b62a451d976eef4ae7930719ea2f5097f39359944871f0a2bf9c98409c3b81966174fcd4dfba5503b7f538e74f870ff2df889e4faa01131dc97c9e7de9448c15c7a06d6611bdc9c2a705ff89ff7026ded9580ff1ec65d2f60311985f5f19bd6059ff082e44f7abfe60cfdb03d3017f115dfd27162c2d493e699c6ab0c4b93d34ab99404703c529e675668181f7d9334fa437e715e7359076fdd13dc3aa547f4e825a98edb966b5ea219253761adc626802f97d61bf9382535261df089869deadc43c08268498bfdfd24497157ae18edb39c7abac3747912ef44e6d9be1893b1623c16275905dfe4b02d16c11a4b7ade63b5c03416138bebb59962113ae2c6afd8c15767b79dafa01cac12210038c34b0fc2c1ae8e867f87507d767ef15da5be57e0e53fe8dd0dd79f27051f73e72f9802de2c8cd4aa45f879a78299e7ac5ebd8d2689131c06c44ae1c13d65aece104a69de6abbb476b2f0eff45f2405ab35f5fe238da44b353c0c636adaf0ebcf1235dc8fbefb6293e9c9f17de8afdec8488ddf3b8d2837cde6c01d697a82cd1723758729d6afd19907b2ab6d6a601ca09f1a3c3da4ce0b9da97c8f1321dafba50409dd19179c21d17a319494f0e0bdebdb5290ab45a665f55b9934dcb8f8b394911807f3df3fa64903b1034b14ef41ecaaa10a9007fe7b1e992beec76452f1ba33d67fbfaf8e71068d35b7398fdc6d49eb38b4a24e9aa9df641209bfbb4ae93bb948292bb956a927709c8aa9979e6f3bc2df0838a153c73e20d1edcfc6cff54d64854965e5b85468900653082403a8c6e047a542eb2b27f0244f991118c937e4c72afa0b99b9a4a333bb961d97c0e4d69ac7ace0d2a658f1b2088cf49a4bcc7192a073ce83fbe21df05659f2edacc21e47c23968eb563d0e7387e7ba05cffd55523810f7f86b44d10f59de313878df6a507c7a0c03ab6d5fb7c56ac10c9b475cf65ece47b9fe3c43379144bf7d1df93380d841901407967a5e566279eb33778b8b89f023429aa21437084f6dd69951c2bfee88209258f9808f8220194b1a7c2279e767552eba3467dc3258254685eac1777118b35a96d3f027b64dceee014dd899c3397c506f1efcb0192dd17150805fd0db6465cb7347f23bcc97ae0931a5f61d7f848d12a382cb67f4f1ef295e1c47ab6e5562430088c8bea74723b99f41d351e9ddf1323a858a632b12abf06e35da8b57d812ca388deaa982ab3f2c5b9cddc249de8f1e8667e9a372172f11018ce51ff47fbc74d1a7847afb7d3d692d6201a0ab7d84836d98c5029da10df0ef4e4b6b04375d8bdd461d43db60977156eadfd31b9cf00e52e0f0372b637db4a06604376547a28764c2f5436ff232425fa7618b3b487787a803e221e1be9498e38b1d35b82944ed034f20b4fb4ca51fd8a9ea68d0cb7fc1a0e3e7f6ee9e897cae2a95c22a957363b2bfddb1d2a21cf4a7bda93f05f6ae213065d2a03fa123f67c397608a544697368958c14e6e93a5d7ebd0b94e43432e7f1119caa6ccbd5ec931ea9d25e1a468254fd721ba1aef121a58"
+	stealthPartAnonEphem       = "02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9"
+	stealthPartAnonPubKey      = "02f8389a282545295b716cc05b3b2519b9a45baacb150f086a90d80d65c38e032f"
+	stealthPartAnonCommitment  = "08a4b1485f2e8a5435f8e9c4023fb0eeac8d901814d92169e4f54c9de5db893781"
+	stealthPartAnonRangeProof  = "450c2c784f077b71e8b586ce47e41cc85904df975cdc0b9a37850ca1c863246dfc742d8dd8105c868f1863589dec2252c67595d55acf6af2b6a5945b41e5373873bf234d67cc45a589fd1eb6d35f4792bb84bcdca0d3e3ec8b200ac2ffc596b0eb7439ae88040aceb58309a8cd1c24a68bc80a424af6fa0462fd1161558fe05a4fba3f8fd10b375ee8a9e6d145e5c87f3ab597ccda756ca1f2ead96b2ef745d93cd89b2a24fbe0998f4e26b6ecba8e7d5f11160bfafdac4bd9fc8013ebb1f64208655ffe837d82df8d199d21c43b0c987ecb05f94be65b435f903f34f994e9835f5ebc8bfae048d629c30fe9a6ba2b6dfd8cd8b829e186de60f10acaa8bfacde0cf6e17a8fb15776677c0e16688faa768a724855ac040d1a7508d13307cd21309417badf9d6e322e67d237c25c57c57f00efba9286ced395ebc7a3c1eb044b77e53bdd7f33701b16a633be780c011ad905b0fdd1e707d648d94ca446cb57dc39914a9b10492d28debe65c3f76d57a74519678fa7eebe7b4f0c6ae48f571390b24c6dc68af4127166291c2b0335b5918e09ad0ad7f2ed3487fe37e59d20e515aacecf8cc17192e846dd7efd6db03c832d64ac9e0c337573940a8b0a7da6f625ed33fda52e54df160e6ab8b236c93f4b76abe13394a5d973aca3889f6d7954175ec01e31ac45b4825bb782f6888f3173fd78596fa220e5740361817ca7664308b8bc9a12b4c558d2a2f73294ec753d1f0bff548763ccc8d3a1db374e7b58f4fab88bdf64102c1d0002d5220250d3cc065d684eb5cb87b269cff770dae274c981ba0ca4afbaae83dc9a0f8371b0c922ef603d66dbd4aa4c903d3a2f0eba7b52966bdb4333755b0c2b1dc55760336cb7c2b7675804c1bc1a3fa4974e83fbad89909df02ecbf695eeb1fccc025d83bede63a68fc274f8bdfb71e66d3822f771bf768c55d29376ecc79990632457722c17db6f0ee5480c1f0ced464b206260dd29e1cc75b31165763193bae16c35f2936fd91aa3d22a5090b8076371e8fb99cc52966af42d5201e3e93215b7dee53498fb3eb35e77d4ad137c183462bd30b1605037838077b2bd02a17025801f43a254ca0ab2f662b1636cc13016d67d7089a96fd09d18163a3b7611f20310a4c84ec5709e7ab3217e50c44316a5f9b86a0491f79833fe092b49b3e02af6f82e08fa2c6b251b54c55d42f973951325ce5a33ce5fdd218e3657dfb50f6272b4eaa253adccc04c792017e3c14dfef7f1dc13b5e8d546b3a59738630e1f41a1ab83075fa977ba651ed6e0decfb911b62f579995b969d6ebd8fef8812ce44013563ab27dc51a327cc00d7bbc785622c896e2fa711fb8a02caa2c9303bc1c50c63daa9cd5eb2a357ae94d2251a2c9b0a04c33c7aeb83b298e42e3df38b95556e641e0d8f5f309b0e44f224db98c1754c2cbc08f6b0827667d426b5dda8a136c32ab7020a471ea734f34a8ca7160367d1d33552a9f635847511801a3"
+)
+
+func TestWorker_ParticlStealthScan(t *testing.T) {
+	block := &bchain.Block{
+		BlockHeader: bchain.BlockHeader{Height: 500, Hash: "0000000000000000000000000000000000000000000000000000000000000500", Time: 1650000000},
+		Txs: []bchain.Tx{
+			{
+				Txid:        stealthPartTxid,
+				BlockHeight: 500,
+				Vin:         []bchain.Vin{{InputType: "anon", AnonInputs: 1, RingSize: 5, KeyImages: []string{dbtestdata.KeyImagePart}}},
+				Vout: []bchain.Vout{
+					{N: 0, OutputType: "data", Data: dbtestdata.CTFeePartData},
+					{N: 1, OutputType: "blind", Data: stealthPartBlindEphem, ValueCommitment: stealthPartBlindCommitment, RangeProof: stealthPartBlindRangeProof,
+						ScriptPubKey: bchain.ScriptPubKey{Hex: stealthPartBlindP2PKH}},
+					{N: 2, OutputType: "anon", Data: stealthPartAnonEphem, PubKey: stealthPartAnonPubKey, ValueCommitment: stealthPartAnonCommitment, RangeProof: stealthPartAnonRangeProof},
+					// the proof which cannot be rewound, the value of the output stays hidden
+					{N: 3, OutputType: "blind", Data: stealthPartBlindEphem, ValueCommitment: stealthPartAnonCommitment, RangeProof: dbtestdata.RangeProofPart,
+						ScriptPubKey: bchain.ScriptPubKey{Hex: stealthPartBlindP2PKH}},
+				},
+			},
+		},
+	}
+	w, _, cleanup := setupParticlWorker(t, []*bchain.Block{block})
+	defer cleanup()
+	w.is.EnableStealthScan = true
+
+	scan, err := w.GetStealthScan(stealthPartAddress, stealthPartScanSecret, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		vout       int
+		outputType string
+		value      *Amount
+	}{
+		{vout: 1, outputType: "blind", value: (*Amount)(big.NewInt(1234500000))},
+		{vout: 2, outputType: "anon", value: (*Amount)(big.NewInt(765400000))},
+		{vout: 3, outputType: "blind"},
+	}
+	if len(scan.Outputs) != len(want) {
+		t.Fatalf("GetStealthScan() = %+v, want %d outputs", scan, len(want))
+	}
+	for i, o := range scan.Outputs {
+		if o.Vout != want[i].vout || o.Type != want[i].outputType || (o.AmountSat == nil) != (want[i].value == nil) ||
+			amountInt64(o.AmountSat) != amountInt64(want[i].value) || o.ValueCommitment == "" {
+			t.Errorf("GetStealthScan() output %d = %+v, want %+v", i, o, want[i])
+		}
+	}
+}
+
+func TestTxSizeStatsFit(t *testing.T) {
+	tests := []struct {
+		name           string
+		txs            [][3]float64
+		wantCalib      float64
+		wantMemberSize float64
+	}{
+		{name: "no transactions", wantCalib: 1, wantMemberSize: anonRingMemberSize},
+		{
+			name:           "different ring sizes",
+			txs:            [][3]float64{{1.1*1602 + 30*5, 1602, 5}, {1.1*1602 + 30*11, 1602, 11}, {1.1*1662 + 30*20, 1662, 20}},
+			wantCalib:      1.1,
+			wantMemberSize: 30,
+		},
+		{
+			// the ring members cannot be separated from the rest of the transaction
+			name:           "same ring size",
+			txs:            [][3]float64{{2 * (1602 + 17*5), 1602, 5}, {2 * (1602 + 17*5), 1602, 5}},
+			wantCalib:      2,
+			wantMemberSize: anonRingMemberSize,
+		},
+		{name: "plain", txs: [][3]float64{{270, 225, 0}, {540, 450, 0}}, wantCalib: 1.2, wantMemberSize: anonRingMemberSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s *txSizeStats
+			for _, tx := range tt.txs {
+				if s == nil {
+					s = &txSizeStats{}
+				}
+				s.add(tx[0], tx[1], tx[2])
+			}
+			calib, memberSize := s.fit()
+			if math.Abs(calib-tt.wantCalib) > 1e-6 || math.Abs(memberSize-tt.wantMemberSize) > 1e-6 {
+				t.Errorf("fit() = %v, %v, want %v, %v", calib, memberSize, tt.wantCalib, tt.wantMemberSize)
+			}
+		})
+	}
+}
+
+func TestWorker_ParticlTxSizeStatsShared(t *testing.T) {
+	parser := part.NewParticlParser(part.GetChainParams("main"), &btc.Configuration{})
+	w, d, cleanup := setupParticlWorker(t, dbtestdata.GetTestParticlTypeBlocks(parser))
+	defer cleanup()
+	bestHeight, bestHash, err := d.GetBestBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the concurrent requests get the statistics of one computation
+	results := make([]map[string]*txSizeStats, 8)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stats, _, err := w.getTxSizeStats(bestHeight, bestHash)
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = stats
+		}(i)
+	}
+	wg.Wait()
+	for i := range results {
+		if results[i] == nil || fmt.Sprintf("%p", results[i]) != fmt.Sprintf("%p", w.txSizeStats.stats) {
+			t.Errorf("getTxSizeStats() result %d is not the cached statistics", i)
+		}
+	}
+}
@@ -34,11 +34,26 @@ func (b *BaseChain) GetBlockRaw(hash string) (string, error) {
 	return "", errors.New("GetBlockRaw: not supported")
 }
 
+// GetNotifiedBlocks returns no blocks by default, the blocks are synchronized by requests to the backend
+func (b *BaseChain) GetNotifiedBlocks() []*Block {
+	return nil
+}
+
 // GetMempoolEntry is not supported by default
 func (b *BaseChain) GetMempoolEntry(txid string) (*MempoolEntry, error) {
 	return nil, errors.New("GetMempoolEntry: not supported")
 }
 
+// GetStakingInfo is not supported by default
+func (b *BaseChain) GetStakingInfo() (*StakingInfo, error) {
+	return nil, errors.New("GetStakingInfo: not supported")
+}
+
+// GetAnonOutput is not supported by default
+func (b *BaseChain) GetAnonOutput(index uint64) (*AnonOutput, error) {
+	return nil, errors.New("GetAnonOutput: not supported")
+}
+
 // LongTermFeeRate returns smallest fee rate from historic blocks.
 func (b *BaseChain) LongTermFeeRate() (*LongTermFeeRate, error) {
 	return nil, errors.New("not supported")
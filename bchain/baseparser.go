@@ -29,6 +29,11 @@ func (p *BaseParser) ParseTx(b []byte) (*Tx, error) {
 	return nil, errors.New("ParseTx: not implemented")
 }
 
+// GetIndexedAddrDescs returns the address descriptor itself, the output is indexed only under its address descriptor
+func (p *BaseParser) GetIndexedAddrDescs(addrDesc AddressDescriptor) []AddressDescriptor {
+	return []AddressDescriptor{addrDesc}
+}
+
 // GetAddrDescForUnknownInput returns nil AddressDescriptor
 func (p *BaseParser) GetAddrDescForUnknownInput(tx *Tx, input int) AddressDescriptor {
 	var iTxid string
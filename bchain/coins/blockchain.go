@@ -236,6 +236,20 @@ func (c *blockChainWithMetrics) GetChainInfo() (v *bchain.ChainInfo, err error)
 	return c.b.GetChainInfo()
 }
 
+func (c *blockChainWithMetrics) GetStakingInfo() (v *bchain.StakingInfo, err error) {
+	defer func(s time.Time) { c.observeRPCLatency("GetStakingInfo", s, err) }(time.Now())
+	return c.b.GetStakingInfo()
+}
+
+func (c *blockChainWithMetrics) GetAnonOutput(index uint64) (v *bchain.AnonOutput, err error) {
+	defer func(s time.Time) { c.observeRPCLatency("GetAnonOutput", s, err) }(time.Now())
+	return c.b.GetAnonOutput(index)
+}
+
+func (c *blockChainWithMetrics) GetNotifiedBlocks() []*bchain.Block {
+	return c.b.GetNotifiedBlocks()
+}
+
 func (c *blockChainWithMetrics) GetBestBlockHash() (v string, err error) {
 	defer func(s time.Time) { c.observeRPCLatency("GetBestBlockHash", s, err) }(time.Now())
 	return c.b.GetBestBlockHash()
@@ -34,6 +34,12 @@ type BitcoinRPC struct {
 	mempoolFilterScripts   string
 	mempoolUseZeroedKey    bool
 	alternativeFeeProvider alternativeFeeProviderInterface
+	// RawBlockHeaderSize is the size of the block header hashed to the block hash, used in the raw mode of the MQ
+	RawBlockHeaderSize int
+	// IsStakeTx recognizes the stake transactions of PoS chains, which are notified with the block but never get to the mempool
+	IsStakeTx func(tx *bchain.Tx) bool
+	rawBlocks rawBlockCache
+	rawQueue  chan rawQueueItem
 }
 
 // Configuration represents json config file
@@ -64,6 +70,7 @@ type Configuration struct {
 	MempoolGolombFilterP         uint8  `json:"mempool_golomb_filter_p,omitempty"`
 	MempoolFilterScripts         string `json:"mempool_filter_scripts,omitempty"`
 	MempoolFilterUseZeroedKey    bool   `json:"mempool_filter_use_zeroed_key,omitempty"`
+	MessageQueueRaw              bool   `json:"message_queue_raw,omitempty"`
 }
 
 // NewBitcoinRPC returns new BitcoinRPC instance.
@@ -112,6 +119,7 @@ func NewBitcoinRPC(config json.RawMessage, pushHandler func(bchain.NotificationT
 		mempoolGolombFilterP: c.MempoolGolombFilterP,
 		mempoolFilterScripts: c.MempoolFilterScripts,
 		mempoolUseZeroedKey:  c.MempoolFilterUseZeroedKey,
+		RawBlockHeaderSize:   wire.MaxBlockHeaderPayload,
 	}
 
 	return s, nil
@@ -181,7 +189,7 @@ func (b *BitcoinRPC) CreateMempool(chain bchain.BlockChain) (bchain.Mempool, err
 	return b.Mempool, nil
 }
 
-// InitializeMempool creates ZeroMQ subscription and sets AddrDescForOutpointFunc to the Mempool
+// InitializeMempool creates ZeroMQ subscription, if there is a push handler, and sets AddrDescForOutpointFunc to the Mempool
 func (b *BitcoinRPC) InitializeMempool(addrDescForOutpoint bchain.AddrDescForOutpointFunc, onNewTxAddr bchain.OnNewTxAddrFunc, onNewTx bchain.OnNewTxFunc) error {
 	if b.Mempool == nil {
 		return errors.New("Mempool not created")
@@ -189,8 +197,16 @@ func (b *BitcoinRPC) InitializeMempool(addrDescForOutpoint bchain.AddrDescForOut
 	b.Mempool.AddrDescForOutpoint = addrDescForOutpoint
 	b.Mempool.OnNewTxAddr = onNewTxAddr
 	b.Mempool.OnNewTx = onNewTx
-	if b.mq == nil {
-		mq, err := bchain.NewMQ(b.ChainConfig.MessageQueueBinding, b.pushHandler)
+	// without the push handler nobody is interested in the notifications (secondary instance)
+	if b.mq == nil && b.pushHandler != nil {
+		var mq *bchain.MQ
+		var err error
+		if b.ChainConfig.MessageQueueRaw {
+			b.startRawQueue()
+			mq, err = bchain.NewRawMQ(b.ChainConfig.MessageQueueBinding, b.pushHandler, b.handleRawNotification)
+		} else {
+			mq, err = bchain.NewMQ(b.ChainConfig.MessageQueueBinding, b.pushHandler)
+		}
 		if err != nil {
 			glog.Error("mq: ", err)
 			return err
@@ -208,6 +224,11 @@ func (b *BitcoinRPC) Shutdown(ctx context.Context) error {
 			return err
 		}
 	}
+	// the MQ loop is finished, nothing is sent to the queue anymore
+	if b.rawQueue != nil {
+		close(b.rawQueue)
+		b.rawQueue = nil
+	}
 	return nil
 }
 
@@ -652,6 +673,9 @@ func (b *BitcoinRPC) GetBlockRaw(hash string) (string, error) {
 
 // GetBlockBytes returns block with given hash as bytes
 func (b *BitcoinRPC) GetBlockBytes(hash string) ([]byte, error) {
+	if data := b.rawBlocks.get(hash); data != nil {
+		return data, nil
+	}
 	block, err := b.GetBlockRaw(hash)
 	if err != nil {
 		return nil, err
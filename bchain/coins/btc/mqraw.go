@@ -0,0 +1,185 @@
+package btc
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/martinboehm/btcd/chaincfg/chainhash"
+	"github.com/trezor/blockbook/bchain"
+)
+
+// rawBlockCacheSize is the number of blocks received in rawblock notifications kept until they are synchronized
+const rawBlockCacheSize = 8
+
+// rawQueueSize is the number of raw notifications waiting for the processing outside of the MQ loop,
+// if the queue is full, the notification is dropped and the mempool is resynchronized
+const rawQueueSize = 1000
+
+// rawBlockCache keeps the last blocks received in rawblock notifications, the block sync gets them without the getblock RPC call.
+// The transactions of the cached blocks are remembered as confirmed, their rawtx notifications are ignored.
+type rawBlockCache struct {
+	mux       sync.Mutex
+	hashes    []string
+	blocks    map[string][]byte
+	txids     map[string][]string
+	confirmed map[string]struct{}
+	notified  []*bchain.Block
+}
+
+func (c *rawBlockCache) add(hash string, data []byte, block *bchain.Block) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.blocks == nil {
+		c.blocks = make(map[string][]byte)
+		c.txids = make(map[string][]string)
+		c.confirmed = make(map[string]struct{})
+	}
+	if _, found := c.blocks[hash]; found {
+		return
+	}
+	if len(c.hashes) >= rawBlockCacheSize {
+		for _, txid := range c.txids[c.hashes[0]] {
+			delete(c.confirmed, txid)
+		}
+		delete(c.blocks, c.hashes[0])
+		delete(c.txids, c.hashes[0])
+		c.hashes = c.hashes[1:]
+	}
+	c.hashes = append(c.hashes, hash)
+	c.blocks[hash] = data
+	if block != nil {
+		txids := make([]string, len(block.Txs))
+		for i := range block.Txs {
+			txids[i] = block.Txs[i].Txid
+			c.confirmed[txids[i]] = struct{}{}
+		}
+		c.txids[hash] = txids
+		if len(c.notified) >= rawBlockCacheSize {
+			c.notified = c.notified[1:]
+		}
+		c.notified = append(c.notified, block)
+	}
+}
+
+func (c *rawBlockCache) get(hash string) []byte {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return c.blocks[hash]
+}
+
+func (c *rawBlockCache) isConfirmed(txid string) bool {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	_, found := c.confirmed[txid]
+	return found
+}
+
+// popNotified returns the blocks added since the last call
+func (c *rawBlockCache) popNotified() []*bchain.Block {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	blocks := c.notified
+	c.notified = nil
+	return blocks
+}
+
+// rawQueueItem is a transaction to be added to the mempool or a block, whose transactions are to be removed from the mempool
+type rawQueueItem struct {
+	tx    *bchain.Tx
+	block *bchain.Block
+}
+
+// startRawQueue starts the goroutine processing the raw notifications outside of the MQ loop,
+// the resolution of the inputs of the transactions would block the receiving of the notifications
+func (b *BitcoinRPC) startRawQueue() {
+	b.rawQueue = make(chan rawQueueItem, rawQueueSize)
+	go func() {
+		for item := range b.rawQueue {
+			b.processRawQueueItem(item)
+		}
+	}()
+}
+
+func (b *BitcoinRPC) processRawQueueItem(item rawQueueItem) {
+	if item.tx != nil {
+		// the rawtx notification may come after the block containing the transaction
+		if b.rawBlocks.isConfirmed(item.tx.Txid) {
+			return
+		}
+		b.Mempool.AddTransaction(item.tx)
+	}
+	if item.block != nil {
+		txids := make([]string, len(item.block.Txs))
+		for i := range item.block.Txs {
+			txids[i] = item.block.Txs[i].Txid
+		}
+		b.Mempool.RemoveTransactions(txids)
+	}
+}
+
+func (b *BitcoinRPC) enqueueRaw(item rawQueueItem) bool {
+	select {
+	case b.rawQueue <- item:
+		return true
+	default:
+		glog.Warning("MQ: raw queue full")
+		return false
+	}
+}
+
+// handleRawNotification processes the payload of rawtx and rawblock notifications of the MQ in raw mode.
+// The transactions are queued to be added to the mempool, the blocks are cached for the block sync
+// and their transactions are queued to be removed from the mempool.
+func (b *BitcoinRPC) handleRawNotification(nt bchain.NotificationType, payload []byte) bool {
+	switch nt {
+	case bchain.NotificationNewTx:
+		tx, err := b.Parser.ParseTx(payload)
+		if err != nil {
+			glog.Error("MQ: rawtx ", err)
+			return false
+		}
+		// the transactions of new blocks are notified as well, the coinbase and stake transactions never get to the mempool
+		if len(tx.Vin) > 0 && tx.Vin[0].Coinbase != "" {
+			return true
+		}
+		if b.IsStakeTx != nil && b.IsStakeTx(tx) {
+			return true
+		}
+		if b.rawBlocks.isConfirmed(tx.Txid) {
+			return true
+		}
+		return b.enqueueRaw(rawQueueItem{tx: tx})
+	case bchain.NotificationNewBlock:
+		// the raw block can be used only by the binary parser
+		if !b.ParseBlocks {
+			return false
+		}
+		if len(payload) < b.RawBlockHeaderSize {
+			glog.Error("MQ: rawblock too short, ", len(payload), " bytes")
+			return false
+		}
+		hash := chainhash.DoubleHashH(payload[:b.RawBlockHeaderSize]).String()
+		glog.V(1).Info("MQ: rawblock ", hash)
+		block, err := b.Parser.ParseBlock(payload)
+		if err != nil {
+			glog.Error("MQ: rawblock ", hash, " ", err)
+			b.rawBlocks.add(hash, payload, nil)
+			return false
+		}
+		// the previous block hash follows the version in the header
+		prev, err := chainhash.NewHash(payload[4:36])
+		if err != nil {
+			return false
+		}
+		block.Hash = hash
+		block.Prev = prev.String()
+		b.rawBlocks.add(hash, payload, block)
+		return b.enqueueRaw(rawQueueItem{block: block})
+	}
+	return false
+}
+
+// GetNotifiedBlocks returns the blocks received in the rawblock notifications since the last call
+func (b *BitcoinRPC) GetNotifiedBlocks() []*bchain.Block {
+	return b.rawBlocks.popNotified()
+}
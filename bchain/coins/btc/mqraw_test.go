@@ -0,0 +1,147 @@
+//go:build unittest
+
+package btc
+
+import (
+	"encoding/hex"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/trezor/blockbook/bchain"
+)
+
+func TestBitcoinRPC_handleRawNotification(t *testing.T) {
+	parser := NewBitcoinParser(GetChainParams("main"), &Configuration{})
+	b := &BitcoinRPC{
+		BaseChain:          &bchain.BaseChain{Parser: parser},
+		ParseBlocks:        true,
+		RawBlockHeaderSize: 80,
+	}
+	b.Mempool = bchain.NewMempoolBitcoinType(b, 1, 1, 0, "", false)
+	b.rawQueue = make(chan rawQueueItem, rawQueueSize)
+	// process the queued notifications synchronously instead of the queue goroutine
+	processQueue := func() {
+		for len(b.rawQueue) > 0 {
+			b.processRawQueueItem(<-b.rawQueue)
+		}
+	}
+	inputAddrDesc, _ := parser.GetAddrDescFromAddress("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2")
+	b.Mempool.AddrDescForOutpoint = func(outpoint bchain.Outpoint) (bchain.AddressDescriptor, *big.Int) {
+		return inputAddrDesc, big.NewInt(40000)
+	}
+
+	payload, _ := hex.DecodeString(testTx1.Hex)
+	if !b.handleRawNotification(bchain.NotificationNewTx, payload) {
+		t.Fatal("handleRawNotification(rawtx) = false")
+	}
+	outputAddrDesc, _ := hex.DecodeString(testTx1.Vout[0].ScriptPubKey.Hex)
+	if got, _ := b.Mempool.GetAddrDescTransactions(outputAddrDesc); len(b.rawQueue) != 1 || len(got) != 0 {
+		t.Fatal("rawtx not queued")
+	}
+	processQueue()
+	for _, tt := range []struct {
+		addrDesc bchain.AddressDescriptor
+		want     []bchain.Outpoint
+	}{
+		{outputAddrDesc, []bchain.Outpoint{{Txid: testTx1.Txid, Vout: 0}}},
+		{inputAddrDesc, []bchain.Outpoint{{Txid: testTx1.Txid, Vout: ^int32(testTx1.Vin[0].Vout)}}},
+	} {
+		got, err := b.Mempool.GetAddrDescTransactions(tt.addrDesc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetAddrDescTransactions(%v) = %+v, want %+v", tt.addrDesc, got, tt.want)
+		}
+	}
+	if b.handleRawNotification(bchain.NotificationNewTx, payload[:10]) {
+		t.Error("handleRawNotification(invalid rawtx) = true")
+	}
+
+	// the stake transactions are ignored
+	b.Mempool.RemoveTransactions([]string{testTx1.Txid})
+	b.IsStakeTx = func(tx *bchain.Tx) bool { return tx.Txid == testTx1.Txid }
+	if !b.handleRawNotification(bchain.NotificationNewTx, payload) || len(b.rawQueue) != 0 {
+		t.Error("stake rawtx queued")
+	}
+	b.IsStakeTx = nil
+	if !b.handleRawNotification(bchain.NotificationNewTx, payload) {
+		t.Fatal("handleRawNotification(rawtx) = false")
+	}
+	processQueue()
+
+	// the genesis block, the transactions are not needed to compute the hash
+	block, _ := hex.DecodeString("0100000000000000000000000000000000000000000000000000000000000000000000003ba3edfd7a7b12b27ac72c3e67768f617fc81bc3888a51323a9fb8aa4b1e5e4a29ab5f49ffff001d1dac2b7c00")
+	if !b.handleRawNotification(bchain.NotificationNewBlock, block) {
+		t.Fatal("handleRawNotification(rawblock) = false")
+	}
+	got, err := b.GetBlockBytes("000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, block) {
+		t.Errorf("GetBlockBytes() = %x, want %x", got, block)
+	}
+	notified := b.GetNotifiedBlocks()
+	if len(notified) != 1 || notified[0].Hash != "000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f" ||
+		notified[0].Prev != "0000000000000000000000000000000000000000000000000000000000000000" || len(notified[0].Txs) != 0 {
+		t.Errorf("GetNotifiedBlocks() = %+v", notified)
+	}
+	if notified = b.GetNotifiedBlocks(); len(notified) != 0 {
+		t.Errorf("GetNotifiedBlocks() second call = %+v", notified)
+	}
+
+	// the transactions of the block are removed from the mempool and their later rawtx notifications are ignored
+	blockWithTx := append(append(append([]byte{}, block[:80]...), 1), payload...)
+	blockWithTx[79]++ // different nonce, different hash
+	if !b.handleRawNotification(bchain.NotificationNewBlock, blockWithTx) {
+		t.Fatal("handleRawNotification(rawblock with tx) = false")
+	}
+	processQueue()
+	if got, _ := b.Mempool.GetAddrDescTransactions(outputAddrDesc); len(got) != 0 {
+		t.Errorf("confirmed tx in mempool: %+v", got)
+	}
+	if !b.handleRawNotification(bchain.NotificationNewTx, payload) || len(b.rawQueue) != 0 {
+		t.Error("confirmed rawtx queued")
+	}
+}
+
+func TestRawBlockCache(t *testing.T) {
+	var c rawBlockCache
+	for i := 0; i < rawBlockCacheSize+2; i++ {
+		c.add(string(rune('a'+i)), []byte{byte(i)}, &bchain.Block{Txs: []bchain.Tx{{Txid: string(rune('A' + i))}}})
+	}
+	for _, hash := range []string{"a", "b"} {
+		if c.get(hash) != nil {
+			t.Errorf("get(%s) not evicted", hash)
+		}
+	}
+	if got := c.get("c"); !reflect.DeepEqual(got, []byte{2}) {
+		t.Errorf("get(c) = %v, want [2]", got)
+	}
+	if len(c.hashes) != rawBlockCacheSize || len(c.blocks) != rawBlockCacheSize || len(c.confirmed) != rawBlockCacheSize {
+		t.Errorf("cache size %d, %d, %d, want %d", len(c.hashes), len(c.blocks), len(c.confirmed), rawBlockCacheSize)
+	}
+	if c.isConfirmed("A") || !c.isConfirmed("C") {
+		t.Error("isConfirmed() not evicted with the block")
+	}
+	if n := c.popNotified(); len(n) != rawBlockCacheSize || n[0].Txs[0].Txid != "C" {
+		t.Errorf("popNotified() = %+v", n)
+	}
+}
+
+func TestBitcoinRPC_InitializeMempoolWithoutPushHandler(t *testing.T) {
+	parser := NewBitcoinParser(GetChainParams("main"), &Configuration{})
+	b := &BitcoinRPC{
+		BaseChain:   &bchain.BaseChain{Parser: parser},
+		ChainConfig: &Configuration{MessageQueueBinding: "tcp://127.0.0.1:28332", MessageQueueRaw: true},
+	}
+	b.Mempool = bchain.NewMempoolBitcoinType(b, 1, 1, 0, "", false)
+	if err := b.InitializeMempool(nil, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if b.mq != nil || b.rawQueue != nil {
+		t.Error("MQ subscribed without push handler")
+	}
+}
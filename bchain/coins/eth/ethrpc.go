@@ -251,8 +251,10 @@ func (b *EthereumRPC) subscribeEvents() error {
 				break
 			}
 			b.UpdateBestHeader(h)
-			// notify blockbook
-			b.PushHandler(bchain.NotificationNewBlock)
+			// notify blockbook, if it is interested in the notifications
+			if b.PushHandler != nil {
+				b.PushHandler(bchain.NotificationNewBlock)
+			}
 		}
 	}()
 
@@ -286,7 +288,9 @@ func (b *EthereumRPC) subscribeEvents() error {
 			}
 			added := b.Mempool.AddTransactionToMempool(hex)
 			if added {
-				b.PushHandler(bchain.NotificationNewTx)
+				if b.PushHandler != nil {
+					b.PushHandler(bchain.NotificationNewTx)
+				}
 			}
 		}
 	}()
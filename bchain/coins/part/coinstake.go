@@ -0,0 +1,85 @@
+package part
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+
+	"github.com/trezor/blockbook/bchain"
+)
+
+// Particl coinstake data output
+// The first output of coinstake transaction is a data output, which starts with the height of the block (4 bytes, little endian).
+// It is followed by optional records, each starting with the data output prefix:
+// DOVote with the vote token (4 bytes), DOSmsgDifficulty (4 bytes), DOSmsgFee and DODevFundCfwd (varint).
+// The lower 16 bits of the vote token are the proposal id, the upper 16 bits the option the staker votes for,
+// proposal 0 means no vote.
+
+// CoinstakeData is the decoded data output of coinstake transaction
+type CoinstakeData struct {
+	Height   uint32
+	HasVote  bool
+	Proposal uint16
+	Option   uint16
+	// DevFundCfwd is the treasury fund amount carried forward to the next payout
+	DevFundCfwd int64
+}
+
+// ParseCoinstakeData decodes the data output of coinstake transaction,
+// unknown records end the decoding, the records before them are returned
+func ParseCoinstakeData(data []byte) (*CoinstakeData, bool) {
+	if len(data) < 4 {
+		return nil, false
+	}
+	cd := &CoinstakeData{
+		Height: binary.LittleEndian.Uint32(data),
+	}
+	for o := 4; o < len(data); {
+		switch data[o] {
+		case DOVote:
+			if len(data) < o+5 {
+				return cd, true
+			}
+			token := binary.LittleEndian.Uint32(data[o+1:])
+			cd.Proposal = uint16(token)
+			cd.Option = uint16(token >> 16)
+			cd.HasVote = cd.Proposal != 0
+			o += 5
+		case DOSmsgDifficulty:
+			o += 5
+		case DOSmsgFee, DODevFundCfwd:
+			v, l := getPartVarInt(data[o+1:])
+			if l <= 0 {
+				return cd, true
+			}
+			if data[o] == DODevFundCfwd {
+				cd.DevFundCfwd = int64(v)
+			}
+			o += 1 + l
+		default:
+			return cd, true
+		}
+	}
+	return cd, true
+}
+
+// CoinstakeDataFromTx returns the decoded data output of coinstake transaction, nil for other transactions
+func CoinstakeDataFromTx(tx *bchain.Tx) *CoinstakeData {
+	if !IsCoinStakeTx(tx) {
+		return nil
+	}
+	for i := range tx.Vout {
+		if tx.Vout[i].OutputType != outputTypeNames[OutputData] {
+			continue
+		}
+		data, err := hex.DecodeString(tx.Vout[i].Data)
+		if err != nil {
+			return nil
+		}
+		cd, ok := ParseCoinstakeData(data)
+		if !ok {
+			return nil
+		}
+		return cd
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package part
+
+import (
+	"crypto/sha256"
+	"strings"
+
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcutil/chaincfg"
+	"github.com/martinboehm/btcutil/hdkeychain"
+	"github.com/trezor/blockbook/bchain"
+)
+
+// Particl extended public keys
+// Particl Core exports the account keys with Particl version bytes (PPAR on mainnet, ppar on testnet and regtest),
+// the Bitcoin version bytes (xpub, tpub) are accepted as well. The accounts created with the 256-bit option
+// receive to P2PKH256 addresses, which are requested by the pkh256(<key>) descriptor.
+
+const pkh256DescriptorPrefix = "pkh256("
+
+func extKeyVersion(id [4]byte) uint32 {
+	return uint32(id[0])<<24 | uint32(id[1])<<16 | uint32(id[2])<<8 | uint32(id[3])
+}
+
+// isSupportedExtPubKeyVersion checks that the version of the extended public key belongs to the network of the parser
+func (p *ParticlParser) isSupportedExtPubKeyVersion(version uint32) bool {
+	btcID := chaincfg.TestNet3Params.HDPublicKeyID
+	if p.Params.Net == MainnetMagic {
+		btcID = chaincfg.MainNetParams.HDPublicKeyID
+	}
+	switch version {
+	case extKeyVersion(p.Params.HDPublicKeyID), extKeyVersion(btcID):
+		return true
+	}
+	return version != 0 && (version == p.XPubMagic || version == p.XPubMagicSegwitP2sh || version == p.XPubMagicSegwitNative)
+}
+
+// ParseXpub parses xpub (or xpub descriptor) and returns XpubDescriptor
+// In addition to the Bitcoin descriptors, it handles the Particl extended key versions and the pkh256 descriptor
+func (p *ParticlParser) ParseXpub(xpub string) (*bchain.XpubDescriptor, error) {
+	s := xpub
+	is256 := strings.HasPrefix(xpub, pkh256DescriptorPrefix)
+	if is256 {
+		s = "pkh(" + xpub[len(pkh256DescriptorPrefix):]
+	}
+	descriptor, err := p.BitcoinLikeParser.ParseXpub(s)
+	if err != nil {
+		return nil, err
+	}
+	extKey := descriptor.ExtKey.(*hdkeychain.ExtendedKey)
+	if extKey.IsPrivate() {
+		return nil, errors.New("Extended private key is not supported, use the extended public key")
+	}
+	if !p.isSupportedExtPubKeyVersion(extKey.Version()) {
+		return nil, errors.Errorf("Unsupported extended public key version %08x", extKey.Version())
+	}
+	descriptor.XpubDescriptor = xpub
+	if is256 {
+		descriptor.Type = bchain.P2PKH256
+	}
+	return descriptor, nil
+}
+
+// addrDesc256FromExtKey returns the P2PKH256 output script of the key, the hash is a single SHA256 of the public key
+func addrDesc256FromExtKey(extKey *hdkeychain.ExtendedKey) bchain.AddressDescriptor {
+	h := sha256.Sum256(extKey.PubKeyBytes())
+	script := make([]byte, 0, 37)
+	script = append(script, 0x76, 0xa8, 0x20) // OP_DUP OP_SHA256 PUSH32
+	script = append(script, h[:]...)
+	script = append(script, 0x88, 0xac) // OP_EQUALVERIFY OP_CHECKSIG
+	return bchain.AddressDescriptor(script)
+}
+
+// DeriveAddressDescriptors derives address descriptors from given xpub for listed indexes
+func (p *ParticlParser) DeriveAddressDescriptors(descriptor *bchain.XpubDescriptor, change uint32, indexes []uint32) ([]bchain.AddressDescriptor, error) {
+	if descriptor.Type != bchain.P2PKH256 {
+		return p.BitcoinLikeParser.DeriveAddressDescriptors(descriptor, change, indexes)
+	}
+	changeExtKey, err := descriptor.ExtKey.(*hdkeychain.ExtendedKey).Derive(change)
+	if err != nil {
+		return nil, err
+	}
+	ad := make([]bchain.AddressDescriptor, len(indexes))
+	for i, index := range indexes {
+		indexExtKey, err := changeExtKey.Derive(index)
+		if err != nil {
+			return nil, err
+		}
+		ad[i] = addrDesc256FromExtKey(indexExtKey)
+	}
+	return ad, nil
+}
+
+// DeriveAddressDescriptorsFromTo derives address descriptors from given xpub for addresses in index range
+func (p *ParticlParser) DeriveAddressDescriptorsFromTo(descriptor *bchain.XpubDescriptor, change uint32, fromIndex uint32, toIndex uint32) ([]bchain.AddressDescriptor, error) {
+	if descriptor.Type != bchain.P2PKH256 {
+		return p.BitcoinLikeParser.DeriveAddressDescriptorsFromTo(descriptor, change, fromIndex, toIndex)
+	}
+	if toIndex <= fromIndex {
+		return nil, errors.New("toIndex<=fromIndex")
+	}
+	indexes := make([]uint32, 0, toIndex-fromIndex)
+	for index := fromIndex; index < toIndex; index++ {
+		indexes = append(indexes, index)
+	}
+	return p.DeriveAddressDescriptors(descriptor, change, indexes)
+}
@@ -0,0 +1,421 @@
+package part
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcd/chaincfg/chainhash"
+	"github.com/martinboehm/btcd/wire"
+)
+
+// ParticlTxnVersion is the lowest version byte of Particl formatted transactions,
+// transactions with lower version use the Bitcoin serialization
+const ParticlTxnVersion = 0xa0
+
+// Particl transaction types, stored in the second version byte
+const (
+	TxnStandard  = 0
+	TxnCoinbase  = 1
+	TxnCoinstake = 2
+)
+
+// Particl output types
+const (
+	OutputStandard = 1
+	OutputCT       = 2
+	OutputRingCT   = 3
+	OutputData     = 4
+)
+
+// AnonMarker is the prevout index marking RingCT (anon) inputs
+const AnonMarker = 0xffffffa0
+
+// Particl data output prefixes
+const (
+	// DOStealth is followed by the ephemeral public key of the standard output paid to stealth address
+	DOStealth = 3
+	// DOStealthPrefix is followed by the 4 bytes stealth prefix of the output
+	DOStealthPrefix = 4
+	// DOVote is followed by the vote token of the staker in coinstake data output
+	DOVote = 5
+	// DOFee is followed by the CT fee
+	DOFee = 6
+	// DODevFundCfwd is followed by the treasury fund amount carried forward in coinstake data output
+	DODevFundCfwd = 7
+	// DOSmsgFee is followed by the SMSG fee rate voted by the staker (varint)
+	DOSmsgFee = 9
+	// DOSmsgDifficulty is followed by the SMSG difficulty voted by the staker (4 bytes)
+	DOSmsgDifficulty = 10
+)
+
+// KeyImageSize is the size of the key image of RingCT input
+const KeyImageSize = 33
+
+const (
+	commitmentSize = 33
+	pubKeySize     = 33
+	// maxVarBytes limits the size of a single serialized field
+	maxVarBytes = wire.MaxMessagePayload
+)
+
+// ErrNotParticlTx is returned when the serialized transaction does not use the Particl format
+var ErrNotParticlTx = errors.New("Not a Particl formatted transaction")
+
+// ParticlTxIn is an input of Particl transaction
+type ParticlTxIn struct {
+	PreviousOutPoint wire.OutPoint
+	SignatureScript  []byte
+	Sequence         uint32
+	// DataStack contains anon info and key images of RingCT inputs
+	DataStack [][]byte
+	Witness   [][]byte
+}
+
+// IsAnon returns true for RingCT inputs
+func (in *ParticlTxIn) IsAnon() bool {
+	return in.PreviousOutPoint.Index == AnonMarker
+}
+
+// AnonInfo returns number of real inputs and ring size of RingCT input
+func (in *ParticlTxIn) AnonInfo() (uint32, uint32, bool) {
+	if len(in.DataStack) < 1 || len(in.DataStack[0]) < 2 {
+		return 0, 0, false
+	}
+	inputs, l := getPartVarInt(in.DataStack[0])
+	if l <= 0 {
+		return 0, 0, false
+	}
+	ringSize, rl := getPartVarInt(in.DataStack[0][l:])
+	if rl <= 0 {
+		return 0, 0, false
+	}
+	return uint32(inputs), uint32(ringSize), true
+}
+
+// KeyImages returns the key images of RingCT input, one for each real input
+func (in *ParticlTxIn) KeyImages() [][]byte {
+	inputs, _, ok := in.AnonInfo()
+	if !ok || len(in.DataStack) < 2 {
+		return nil
+	}
+	data := in.DataStack[1]
+	if uint64(len(data)) < uint64(inputs)*KeyImageSize {
+		return nil
+	}
+	keyImages := make([][]byte, inputs)
+	for i := range keyImages {
+		keyImages[i] = data[i*KeyImageSize : (i+1)*KeyImageSize]
+	}
+	return keyImages
+}
+
+// ParticlTxOut is a typed output of Particl transaction
+type ParticlTxOut struct {
+	Type       byte
+	Value      int64
+	PkScript   []byte
+	Commitment []byte
+	PubKey     []byte
+	Data       []byte
+	RangeProof []byte
+}
+
+// CTFee returns the fee of a blind/anon transaction stored in a data output
+func (out *ParticlTxOut) CTFee() (int64, bool) {
+	if out.Type != OutputData || len(out.Data) < 2 || out.Data[0] != DOFee {
+		return 0, false
+	}
+	fee, l := getPartVarInt(out.Data[1:])
+	if l <= 0 {
+		return 0, false
+	}
+	return int64(fee), true
+}
+
+// ParticlMsgTx is a transaction in Particl serialization format
+type ParticlMsgTx struct {
+	Version  int32
+	LockTime uint32
+	TxIn     []*ParticlTxIn
+	TxOut    []*ParticlTxOut
+}
+
+// TxType returns the Particl transaction type
+func (msg *ParticlMsgTx) TxType() byte {
+	return byte(msg.Version >> 8)
+}
+
+// IsCoinBase returns true for coinbase transactions
+func (msg *ParticlMsgTx) IsCoinBase() bool {
+	if msg.TxType() == TxnCoinbase {
+		return true
+	}
+	if len(msg.TxIn) != 1 {
+		return false
+	}
+	prevOut := &msg.TxIn[0].PreviousOutPoint
+	return prevOut.Index == wire.MaxPrevOutIndex && prevOut.Hash == chainhash.Hash{}
+}
+
+// IsCoinStake returns true for coinstake transactions
+func (msg *ParticlMsgTx) IsCoinStake() bool {
+	return msg.TxType() == TxnCoinstake && len(msg.TxIn) > 0 && len(msg.TxOut) > 1 &&
+		msg.TxOut[0].Type == OutputData
+}
+
+// Deserialize decodes Particl transaction, returns ErrNotParticlTx if the transaction uses the Bitcoin format
+func (msg *ParticlMsgTx) Deserialize(r *bytes.Reader) error {
+	var v [2]byte
+	if _, err := io.ReadFull(r, v[:]); err != nil {
+		return err
+	}
+	if v[0] < ParticlTxnVersion {
+		return ErrNotParticlTx
+	}
+	msg.Version = int32(v[0]) | int32(v[1])<<8
+	var err error
+	if msg.LockTime, err = readUint32(r); err != nil {
+		return err
+	}
+	n, err := readCount(r)
+	if err != nil {
+		return err
+	}
+	msg.TxIn = make([]*ParticlTxIn, n)
+	for i := range msg.TxIn {
+		in := &ParticlTxIn{}
+		if _, err = io.ReadFull(r, in.PreviousOutPoint.Hash[:]); err != nil {
+			return err
+		}
+		if in.PreviousOutPoint.Index, err = readUint32(r); err != nil {
+			return err
+		}
+		if in.SignatureScript, err = readVarBytes(r); err != nil {
+			return err
+		}
+		if in.Sequence, err = readUint32(r); err != nil {
+			return err
+		}
+		if in.IsAnon() {
+			if in.DataStack, err = readStack(r); err != nil {
+				return err
+			}
+		}
+		msg.TxIn[i] = in
+	}
+	if n, err = readCount(r); err != nil {
+		return err
+	}
+	msg.TxOut = make([]*ParticlTxOut, n)
+	for i := range msg.TxOut {
+		out := &ParticlTxOut{}
+		if out.Type, err = r.ReadByte(); err != nil {
+			return err
+		}
+		switch out.Type {
+		case OutputStandard:
+			var value uint64
+			if err = binary.Read(r, binary.LittleEndian, &value); err != nil {
+				return err
+			}
+			out.Value = int64(value)
+			if out.PkScript, err = readVarBytes(r); err != nil {
+				return err
+			}
+		case OutputCT:
+			if out.Commitment, err = readFixedBytes(r, commitmentSize); err != nil {
+				return err
+			}
+			if out.Data, err = readVarBytes(r); err != nil {
+				return err
+			}
+			if out.PkScript, err = readVarBytes(r); err != nil {
+				return err
+			}
+			if out.RangeProof, err = readVarBytes(r); err != nil {
+				return err
+			}
+		case OutputRingCT:
+			if out.PubKey, err = readFixedBytes(r, pubKeySize); err != nil {
+				return err
+			}
+			if out.Commitment, err = readFixedBytes(r, commitmentSize); err != nil {
+				return err
+			}
+			if out.Data, err = readVarBytes(r); err != nil {
+				return err
+			}
+			if out.RangeProof, err = readVarBytes(r); err != nil {
+				return err
+			}
+		case OutputData:
+			if out.Data, err = readVarBytes(r); err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("Unknown output type %d", out.Type)
+		}
+		msg.TxOut[i] = out
+	}
+	// witness is optional, it is missing in the serialization without witness
+	if r.Len() == 0 {
+		return nil
+	}
+	for _, in := range msg.TxIn {
+		if in.Witness, err = readStack(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SerializeNoWitness encodes the transaction without witness and range proofs, the form used to compute txid
+func (msg *ParticlMsgTx) SerializeNoWitness(w io.Writer) error {
+	if _, err := w.Write([]byte{byte(msg.Version), byte(msg.Version >> 8)}); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, msg.LockTime); err != nil {
+		return err
+	}
+	if err := wire.WriteVarInt(w, 0, uint64(len(msg.TxIn))); err != nil {
+		return err
+	}
+	for _, in := range msg.TxIn {
+		if _, err := w.Write(in.PreviousOutPoint.Hash[:]); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, in.PreviousOutPoint.Index); err != nil {
+			return err
+		}
+		if err := wire.WriteVarBytes(w, 0, in.SignatureScript); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.LittleEndian, in.Sequence); err != nil {
+			return err
+		}
+		if in.IsAnon() {
+			if err := writeStack(w, in.DataStack); err != nil {
+				return err
+			}
+		}
+	}
+	if err := wire.WriteVarInt(w, 0, uint64(len(msg.TxOut))); err != nil {
+		return err
+	}
+	for _, out := range msg.TxOut {
+		if _, err := w.Write([]byte{out.Type}); err != nil {
+			return err
+		}
+		var err error
+		switch out.Type {
+		case OutputStandard:
+			if err = binary.Write(w, binary.LittleEndian, out.Value); err == nil {
+				err = wire.WriteVarBytes(w, 0, out.PkScript)
+			}
+		case OutputCT:
+			if _, err = w.Write(out.Commitment); err == nil {
+				if err = wire.WriteVarBytes(w, 0, out.Data); err == nil {
+					if err = wire.WriteVarBytes(w, 0, out.PkScript); err == nil {
+						err = wire.WriteVarInt(w, 0, 0)
+					}
+				}
+			}
+		case OutputRingCT:
+			if _, err = w.Write(out.PubKey); err == nil {
+				if _, err = w.Write(out.Commitment); err == nil {
+					if err = wire.WriteVarBytes(w, 0, out.Data); err == nil {
+						err = wire.WriteVarInt(w, 0, 0)
+					}
+				}
+			}
+		case OutputData:
+			err = wire.WriteVarBytes(w, 0, out.Data)
+		default:
+			err = errors.Errorf("Unknown output type %d", out.Type)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TxHash computes the txid of the transaction
+func (msg *ParticlMsgTx) TxHash() chainhash.Hash {
+	buf := bytes.NewBuffer(make([]byte, 0, 256))
+	_ = msg.SerializeNoWitness(buf)
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// getPartVarInt decodes Particl varint (7 bits per byte, least significant group first)
+// returns the value and the number of bytes read or 0 if the data are invalid
+func getPartVarInt(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * uint(i))
+		if b[i]&0x80 == 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+func readUint32(r io.Reader) (uint32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b[:]), nil
+}
+
+// readCount reads the number of following items, checking it against the remaining data
+func readCount(r *bytes.Reader) (int, error) {
+	n, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return 0, err
+	}
+	if n > uint64(r.Len()) {
+		return 0, errors.Errorf("Item count %d exceeds remaining data", n)
+	}
+	return int(n), nil
+}
+
+func readFixedBytes(r io.Reader, n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func readVarBytes(r io.Reader) ([]byte, error) {
+	return wire.ReadVarBytes(r, 0, maxVarBytes, "varbytes")
+}
+
+func readStack(r *bytes.Reader) ([][]byte, error) {
+	n, err := readCount(r)
+	if err != nil {
+		return nil, err
+	}
+	stack := make([][]byte, n)
+	for i := range stack {
+		if stack[i], err = readVarBytes(r); err != nil {
+			return nil, err
+		}
+	}
+	return stack, nil
+}
+
+func writeStack(w io.Writer, stack [][]byte) error {
+	if err := wire.WriteVarInt(w, 0, uint64(len(stack))); err != nil {
+		return err
+	}
+	for _, s := range stack {
+		if err := wire.WriteVarBytes(w, 0, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
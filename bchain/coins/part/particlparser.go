@@ -3,10 +3,13 @@ package part
 import (
 	"bytes"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
+	"math"
 	"math/big"
 
+	"github.com/juju/errors"
 	"github.com/martinboehm/btcd/wire"
 	"github.com/martinboehm/btcutil/base58"
 	"github.com/martinboehm/btcutil/chaincfg"
@@ -38,6 +41,8 @@ func init() {
 	MainNetParams.ScriptHashAddrID = []byte{60}  // starting with 'p'
 	MainNetParams.PrivateKeyID = []byte{108}
 	MainNetParams.Bech32HRPSegwit = "pw"
+	MainNetParams.HDPublicKeyID = [4]byte{0x69, 0x6e, 0x82, 0xd1}  // starting with 'PPAR'
+	MainNetParams.HDPrivateKeyID = [4]byte{0x8f, 0x1d, 0xae, 0xb8} // starting with 'XPAR'
 
 	// Particl testnet Address encoding magics
 	TestNetParams = chaincfg.TestNet3Params
@@ -47,6 +52,8 @@ func init() {
 	TestNetParams.ScriptHashAddrID = []byte{122} // starting with 'r'
 	TestNetParams.PrivateKeyID = []byte{46}
 	TestNetParams.Bech32HRPSegwit = "tp"
+	TestNetParams.HDPublicKeyID = [4]byte{0xe1, 0x42, 0x78, 0x00}  // starting with 'ppar'
+	TestNetParams.HDPrivateKeyID = [4]byte{0x04, 0x88, 0x94, 0x78} // starting with 'xpar'
 
 	// Particl regtest Address encoding magics
 	RegtestParams = chaincfg.RegressionNetParams
@@ -56,6 +63,8 @@ func init() {
 	RegtestParams.ScriptHashAddrID = []byte{122}
 	RegtestParams.PrivateKeyID = []byte{46}
 	RegtestParams.Bech32HRPSegwit = "tpw"
+	RegtestParams.HDPublicKeyID = [4]byte{0xe1, 0x42, 0x78, 0x00}
+	RegtestParams.HDPrivateKeyID = [4]byte{0x04, 0x88, 0x94, 0x78}
 }
 
 // ParticlParser handle
@@ -63,6 +72,8 @@ type ParticlParser struct {
 	*btc.BitcoinLikeParser
 	baseparser                         *bchain.BaseParser
 	BitcoinOutputScriptToAddressesFunc btc.OutputScriptToAddressesFunc
+	treasuryAddresses                  []string
+	treasuryAddrDescs                  map[string]struct{}
 }
 
 // NewParticlParser returns new ParticlParser instance
@@ -74,11 +85,12 @@ func NewParticlParser(params *chaincfg.Params, c *btc.Configuration) *ParticlPar
 	p.BitcoinOutputScriptToAddressesFunc = p.OutputScriptToAddressesFunc
 	p.OutputScriptToAddressesFunc = p.outputScriptToAddresses
 	p.VSizeSupport = true
+	p.SetTreasuryAddresses(treasuryAddresses[params.Net])
 	return p
 }
 
 // GetAddrDescFromAddress returns internal address representation from string address
-// Handles Particl-specific P2CS and P2SH256 addresses
+// Handles Particl-specific P2CS, P2SH256 and stealth addresses
 func (p *ParticlParser) GetAddrDescFromAddress(address string) (bchain.AddressDescriptor, error) {
 	// Try standard address parsing first (handles P2PKH, P2SH, bech32, etc.)
 	desc, err := p.BitcoinLikeParser.GetAddrDescFromAddress(address)
@@ -104,6 +116,11 @@ func (p *ParticlParser) GetAddrDescFromAddress(address string) (bchain.AddressDe
 		return nil, bchain.ErrAddressMissing
 	}
 
+	// Stealth address, it is not an output script, payments to it go to one-time addresses
+	if desc := p.stealthAddrDesc(version, payload); desc != nil {
+		return desc, nil
+	}
+
 	// Check for P2CS (version 0x39) - 32-byte hash
 	if version == 0x39 && len(payload) == 32 {
 		// P2CS (Pay-to-Cold-Staking) - 32-byte hash
@@ -163,55 +180,67 @@ func GetChainParams(chain string) *chaincfg.Params {
 	}
 }
 
+// particlBlockHeaderSize is the size of Particl block header,
+// it contains the witness merkle root in addition to the Bitcoin header fields
+const particlBlockHeaderSize = 112
+
 // ParseBlock parses raw block to our Block struct
 func (p *ParticlParser) ParseBlock(b []byte) (*bchain.Block, error) {
-	r := bytes.NewReader(b)
-	w := wire.MsgBlock{}
-	h := wire.BlockHeader{}
-
-	// Deserialize the standard 80-byte Bitcoin block header
-	err := h.Deserialize(r)
-	if err != nil {
-		return nil, err
+	if len(b) < particlBlockHeaderSize {
+		return nil, errors.New("ParseBlock: block too short")
 	}
-
-	// Particl blocks have an additional 32-byte witness merkle root after the standard header
-	// Skip past it to get to the transaction data
-	_, err = r.Seek(32, 1) // 1 = io.SeekCurrent
+	// header: version, prev block, merkle root, witness merkle root, time, bits, nonce
+	blockTime := binary.LittleEndian.Uint32(b[100:104])
+	r := bytes.NewReader(b[particlBlockHeaderSize:])
+	txCount, err := readCount(r)
 	if err != nil {
 		return nil, err
 	}
-
-	// Now decode just the transactions (not the whole block)
-	txCount, err := wire.ReadVarInt(r, 0)
-	if err != nil {
-		return nil, err
-	}
-
-	w.Transactions = make([]*wire.MsgTx, 0, txCount)
-	for i := uint64(0); i < txCount; i++ {
-		tx := wire.MsgTx{}
-		err := tx.BtcDecode(r, 0, wire.BaseEncoding)
+	txs := make([]bchain.Tx, txCount)
+	for ti := range txs {
+		tx, err := p.decodeTx(r, false)
 		if err != nil {
 			return nil, err
 		}
-		w.Transactions = append(w.Transactions, &tx)
-	}
-
-	txs := make([]bchain.Tx, len(w.Transactions))
-	for ti, t := range w.Transactions {
-		txs[ti] = p.TxFromMsgTx(t, false)
+		txs[ti] = *tx
 	}
+	// the block signature of PoS blocks follows the transactions, it is not needed
 
 	return &bchain.Block{
 		BlockHeader: bchain.BlockHeader{
 			Size: len(b),
-			Time: h.Timestamp.Unix(),
+			Time: int64(blockTime),
 		},
-		Txs: txs,
+		Txs:              txs,
+		CoinSpecificData: p.BlockStakeFromTxs(txs),
 	}, nil
 }
 
+// decodeTx decodes one transaction from the reader, using the Particl serialization if the tx version requires it
+func (p *ParticlParser) decodeTx(r *bytes.Reader, parseAddresses bool) (*bchain.Tx, error) {
+	version, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if err = r.UnreadByte(); err != nil {
+		return nil, err
+	}
+	if version >= ParticlTxnVersion {
+		t := ParticlMsgTx{}
+		if err = t.Deserialize(r); err != nil {
+			return nil, err
+		}
+		tx := p.TxFromParticlMsgTx(&t, parseAddresses)
+		return &tx, nil
+	}
+	t := wire.MsgTx{}
+	if err = t.BtcDecode(r, 0, wire.WitnessEncoding); err != nil {
+		return nil, err
+	}
+	tx := p.TxFromMsgTx(&t, parseAddresses)
+	return &tx, nil
+}
+
 // PackTx packs transaction to byte array using protobuf
 func (p *ParticlParser) PackTx(tx *bchain.Tx, height uint32, blockTime int64) ([]byte, error) {
 	return p.baseparser.PackTx(tx, height, blockTime)
@@ -224,14 +253,97 @@ func (p *ParticlParser) UnpackTx(buf []byte) (*bchain.Tx, uint32, error) {
 
 // ParseTx parses byte array containing transaction and returns Tx struct
 func (p *ParticlParser) ParseTx(b []byte) (*bchain.Tx, error) {
-	t := wire.MsgTx{}
-	r := bytes.NewReader(b)
-	if err := t.Deserialize(r); err != nil {
+	tx, err := p.decodeTx(bytes.NewReader(b), true)
+	if err != nil {
 		return nil, err
 	}
-	tx := p.TxFromMsgTx(&t, true)
 	tx.Hex = hex.EncodeToString(b)
-	return &tx, nil
+	return tx, nil
+}
+
+// outputTypeNames maps Particl output types to the names used by particld RPC
+var outputTypeNames = map[byte]string{
+	OutputStandard: "standard",
+	OutputCT:       "blind",
+	OutputRingCT:   "anon",
+	OutputData:     "data",
+}
+
+// OutputTypeName returns the name of Particl output type used by particld RPC
+func OutputTypeName(outputType byte) string {
+	return outputTypeNames[outputType]
+}
+
+// TxFromParticlMsgTx converts ParticlMsgTx to bchain.Tx, the result matches ParseTxFromJson
+func (p *ParticlParser) TxFromParticlMsgTx(t *ParticlMsgTx, parseAddresses bool) bchain.Tx {
+	coinbase := t.IsCoinBase()
+	vin := make([]bchain.Vin, len(t.TxIn))
+	for i, in := range t.TxIn {
+		if coinbase {
+			vin[i] = bchain.Vin{
+				Coinbase: hex.EncodeToString(in.SignatureScript),
+				Sequence: in.Sequence,
+			}
+			continue
+		}
+		if in.IsAnon() {
+			inputs, ringSize, _ := in.AnonInfo()
+			vin[i] = bchain.Vin{
+				Sequence:   in.Sequence,
+				InputType:  "anon",
+				AnonInputs: inputs,
+				RingSize:   ringSize,
+			}
+			for _, ki := range in.KeyImages() {
+				vin[i].KeyImages = append(vin[i].KeyImages, hex.EncodeToString(ki))
+			}
+			continue
+		}
+		vin[i] = bchain.Vin{
+			Txid:     in.PreviousOutPoint.Hash.String(),
+			Vout:     in.PreviousOutPoint.Index,
+			Sequence: in.Sequence,
+			ScriptSig: bchain.ScriptSig{
+				Hex: hex.EncodeToString(in.SignatureScript),
+			},
+		}
+	}
+
+	var ctFee int64
+	vout := make([]bchain.Vout, len(t.TxOut))
+	for i, out := range t.TxOut {
+		addrs := []string{}
+		if parseAddresses && len(out.PkScript) > 0 {
+			addrs, _, _ = p.OutputScriptToAddressesFunc(out.PkScript)
+		}
+		vout[i] = bchain.Vout{
+			N: uint32(i),
+			ScriptPubKey: bchain.ScriptPubKey{
+				Hex:       hex.EncodeToString(out.PkScript),
+				Addresses: addrs,
+			},
+			OutputType:      outputTypeNames[out.Type],
+			ValueCommitment: hex.EncodeToString(out.Commitment),
+			Data:            hex.EncodeToString(out.Data),
+			RangeProof:      hex.EncodeToString(out.RangeProof),
+			PubKey:          hex.EncodeToString(out.PubKey),
+		}
+		if out.Type == OutputStandard {
+			vout[i].ValueSat.SetInt64(out.Value)
+		}
+		if fee, ok := out.CTFee(); ok && ctFee == 0 {
+			ctFee = fee
+		}
+	}
+
+	return bchain.Tx{
+		Txid:             t.TxHash().String(),
+		Version:          t.Version,
+		LockTime:         t.LockTime,
+		Vin:              vin,
+		Vout:             vout,
+		CoinSpecificData: &ParticlTxData{CTFee: float64(ctFee) / 1e8},
+	}
 }
 
 // TxFromMsgTx converts wire.MsgTx to bchain.Tx
@@ -292,10 +404,11 @@ func (p *ParticlParser) TxFromMsgTx(t *wire.MsgTx, parseAddresses bool) bchain.T
 
 // ParticlScriptPubKey extends the standard ScriptPubKey with Particl-specific fields
 type ParticlScriptPubKey struct {
-	Hex       string   `json:"hex,omitempty"`
-	Addresses []string `json:"addresses"`
-	Address   string   `json:"address"`   // For cold staking outputs
-	Type      string   `json:"type"`      // Script type (e.g., "nonstandard")
+	Hex          string   `json:"hex,omitempty"`
+	Addresses    []string `json:"addresses"`
+	Address      string   `json:"address"`      // For cold staking outputs
+	StakeAddress string   `json:"stakeaddress"` // Staking address of cold staking outputs
+	Type         string   `json:"type"`         // Script type (e.g., "nonstandard")
 }
 
 // ParticlVin extends the standard Vin with Particl-specific fields for anon inputs
@@ -310,6 +423,7 @@ type ParticlVin struct {
 	InputType   string   `json:"type,omitempty"`       // "anon" for RingCT inputs
 	AnonInputs  uint32   `json:"num_inputs,omitempty"` // Number of real inputs (e.g., 1)
 	RingSize    uint32   `json:"ring_size,omitempty"`  // Size of the ring/decoy set (e.g., 5)
+	KeyImages   []string `json:"key_images,omitempty"` // Key images of the real inputs
 }
 
 // ParticlVout extends the standard Vout to use ParticlScriptPubKey
@@ -321,7 +435,10 @@ type ParticlVout struct {
 	OutputType      string              `json:"type,omitempty"`
 	ValueCommitment string              `json:"valueCommitment,omitempty"`
 	Data            string              `json:"data,omitempty"`
+	DataHex         string              `json:"data_hex,omitempty"` // particld returns output data as data_hex
 	RangeProof      string              `json:"rangeproof,omitempty"`
+	PubKey          string              `json:"pubkey,omitempty"`
+	AnonIndex       int64               `json:"anon_index,omitempty"` // global index of anon output
 	CTFee           float64             `json:"ct_fee,omitempty"` // CT fee in PART for anon/blind txs
 }
 
@@ -343,6 +460,27 @@ type ParticlTxData struct {
 	CTFee float64 // CT fee in PART for blind/anon transactions
 }
 
+// TxCTFeeSat returns the fee of blind/anon transaction in satoshi, stored in its data output, 0 for other transactions
+func TxCTFeeSat(tx *bchain.Tx) int64 {
+	for i := range tx.Vout {
+		if tx.Vout[i].OutputType != outputTypeNames[OutputData] {
+			continue
+		}
+		data, err := hex.DecodeString(tx.Vout[i].Data)
+		if err != nil {
+			continue
+		}
+		out := ParticlTxOut{Type: OutputData, Data: data}
+		if fee, ok := out.CTFee(); ok {
+			return fee
+		}
+	}
+	if d, ok := tx.CoinSpecificData.(*ParticlTxData); ok && d.CTFee > 0 {
+		return int64(math.Round(d.CTFee * 1e8))
+	}
+	return 0
+}
+
 // ParseTxFromJson parses JSON message containing transaction and returns Tx struct
 func (p *ParticlParser) ParseTxFromJson(msg json.RawMessage) (*bchain.Tx, error) {
 	var ptx ParticlTx
@@ -367,6 +505,7 @@ func (p *ParticlParser) ParseTxFromJson(msg json.RawMessage) (*bchain.Tx, error)
 			InputType:   pvin.InputType,
 			AnonInputs:  pvin.AnonInputs,
 			RingSize:    pvin.RingSize,
+			KeyImages:   pvin.KeyImages,
 		}
 	}
 
@@ -400,6 +539,9 @@ func (p *ParticlParser) ParseTxFromJson(msg json.RawMessage) (*bchain.Tx, error)
 		if len(addresses) == 0 && pvout.ScriptPubKey.Address != "" {
 			// Cold staking outputs have a single "address" field
 			addresses = []string{pvout.ScriptPubKey.Address}
+			if pvout.ScriptPubKey.StakeAddress != "" {
+				addresses = append(addresses, pvout.ScriptPubKey.StakeAddress)
+			}
 		}
 		if addresses == nil {
 			addresses = []string{}
@@ -413,7 +555,12 @@ func (p *ParticlParser) ParseTxFromJson(msg json.RawMessage) (*bchain.Tx, error)
 		vout[i].OutputType = pvout.OutputType
 		vout[i].ValueCommitment = pvout.ValueCommitment
 		vout[i].Data = pvout.Data
+		if vout[i].Data == "" {
+			vout[i].Data = pvout.DataHex
+		}
 		vout[i].RangeProof = pvout.RangeProof
+		vout[i].PubKey = pvout.PubKey
+		vout[i].AnonIndex = pvout.AnonIndex
 	}
 
 	// Extract CT fee from the first data output (for blind/anon transactions)
@@ -441,111 +588,77 @@ func (p *ParticlParser) ParseTxFromJson(msg json.RawMessage) (*bchain.Tx, error)
 	return tx, nil
 }
 
-// outputScriptToAddresses converts ScriptPubKey to addresses
-// Handles standard Bitcoin-like transactions (P2PKH, P2SH, P2WPKH, P2WSH, etc.)
-// and Particl-specific P2CS (cold staking) transactions
-// Note: For CT (blind) and RingCT (anon) transactions in RPC mode,
-// addresses are extracted from JSON by ParseTxFromJson, not from raw scripts
-func (p *ParticlParser) outputScriptToAddresses(script []byte) ([]string, bool, error) {
-	// Check for Particl coinstake scripts (composite script with P2PKH + staking address)
-	// Two variants:
-	// 1. 66 bytes: OP_ISCOINSTAKE OP_IF <P2PKH 25-bytes> OP_ELSE <P2CS 37-bytes> OP_ENDIF
-	// 2. 64 bytes: OP_ISCOINSTAKE OP_IF <P2PKH 25-bytes> OP_ELSE <P2SH256 35-bytes> OP_ENDIF
-
-	if (len(script) == 66 || len(script) == 64) &&
-		script[0] == 0xb8 && // OP_ISCOINSTAKE
-		script[1] == 0x63 { // OP_IF
-
-		// The P2PKH portion starts at position 2
-		// Check for standard P2PKH: OP_DUP OP_HASH160 PUSH20 <20-bytes> OP_EQUALVERIFY OP_CHECKSIG (25 bytes)
-		if script[2] == 0x76 && // OP_DUP
-			script[3] == 0xa9 && // OP_HASH160
-			script[4] == 0x14 { // PUSH20
-
-			// P2PKH is 25 bytes (positions 2-26), so OP_ELSE should be at position 27
-			if script[27] == 0x67 && script[len(script)-1] == 0x68 { // OP_ELSE and OP_ENDIF
-				// Extract staking script (between ELSE and ENDIF)
-				stakingScript := script[28 : len(script)-1]
-
-				// Check for P2CS format: OP_DUP OP_SHA256 <32 bytes> OP_EQUALVERIFY OP_CHECKSIG (37 bytes)
-				if len(stakingScript) == 37 &&
-					stakingScript[0] == 0x76 && // OP_DUP
-					stakingScript[1] == 0xa8 && // OP_SHA256
-					stakingScript[2] == 0x20 && // Push 32 bytes
-					stakingScript[35] == 0x88 && // OP_EQUALVERIFY
-					stakingScript[36] == 0xac { // OP_CHECKSIG
-
-					// P2CS address (version 0x39, starts with "2")
-					pubKeyHash := stakingScript[3:35]
-					addr := &ParticlP2CSAddress{
-						pubKeyHash:  pubKeyHash,
-						versionByte: 0x39, // PUBKEY_ADDRESS_256
-						params:      p.Params,
-					}
-					return []string{addr.String()}, true, nil
-				}
-
-				// Check for P2SH256 format: OP_SHA256 PUSH32 <32 bytes> OP_EQUAL (35 bytes)
-				if len(stakingScript) == 35 &&
-					stakingScript[0] == 0xa8 && // OP_SHA256
-					stakingScript[1] == 0x20 && // Push 32 bytes
-					stakingScript[34] == 0x87 { // OP_EQUAL
-
-					// P2SH256 address (version 0x3d, starts with "33")
-					scriptHash := stakingScript[2:34]
-					addr := &ParticlP2CSAddress{
-						pubKeyHash:  scriptHash,
-						versionByte: 0x3d, // SCRIPT_ADDRESS_256
-						params:      p.Params,
-					}
-					return []string{addr.String()}, true, nil
-				}
-			}
-		}
-	}
-
-	// Check for standalone Particl P2CS (cold staking) script: OP_DUP OP_SHA256 <32 bytes> OP_EQUALVERIFY OP_CHECKSIG
-	// Format: 0x76 0xa8 0x20 <32-byte-pubkey-hash> 0x88 0xac (total 37 bytes)
-	// This differs from standard P2PKH which uses OP_HASH160 (0xa9) with 20 bytes
-	if len(script) == 37 &&
+// isP2CSScript checks for Particl P2CS (cold staking) script: OP_DUP OP_SHA256 <32 bytes> OP_EQUALVERIFY OP_CHECKSIG
+// This differs from standard P2PKH which uses OP_HASH160 (0xa9) with 20 bytes
+func isP2CSScript(script []byte) bool {
+	return len(script) == 37 &&
 		script[0] == 0x76 && // OP_DUP
 		script[1] == 0xa8 && // OP_SHA256
 		script[2] == 0x20 && // Push 32 bytes
 		script[35] == 0x88 && // OP_EQUALVERIFY
-		script[36] == 0xac { // OP_CHECKSIG
-
-		// Extract the 32-byte pubkey hash
-		pubKeyHash := script[3:35]
+		script[36] == 0xac // OP_CHECKSIG
+}
 
-		// Encode as Particl P2CS address with version byte 0x39 (produces addresses starting with "2")
-		addr := &ParticlP2CSAddress{
-			pubKeyHash:  pubKeyHash,
-			versionByte: 0x39, // PUBKEY_ADDRESS_256
-			params:      p.Params,
-		}
+// isP2SH256Script checks for Particl's 256-bit version of P2SH: OP_SHA256 PUSH32 <32 bytes> OP_EQUAL
+func isP2SH256Script(script []byte) bool {
+	return len(script) == 35 &&
+		script[0] == 0xa8 && // OP_SHA256
+		script[1] == 0x20 && // Push 32 bytes
+		script[34] == 0x87 // OP_EQUAL
+}
 
-		return []string{addr.String()}, true, nil
+// ColdStakingAddrDescs splits Particl cold staking script to the address descriptors of the spend and the staking address
+// Two variants of the script:
+// 1. 66 bytes: OP_ISCOINSTAKE OP_IF <P2PKH 25-bytes> OP_ELSE <P2CS 37-bytes> OP_ENDIF
+// 2. 64 bytes: OP_ISCOINSTAKE OP_IF <P2PKH 25-bytes> OP_ELSE <P2SH256 35-bytes> OP_ENDIF
+// The spend (P2PKH) address owns the coins, the staking address can only use them in coinstake transactions
+// Returns nil descriptors if the script is not a cold staking script
+func ColdStakingAddrDescs(script []byte) (bchain.AddressDescriptor, bchain.AddressDescriptor) {
+	if (len(script) != 66 && len(script) != 64) ||
+		script[0] != 0xb8 || // OP_ISCOINSTAKE
+		script[1] != 0x63 || // OP_IF
+		script[27] != 0x67 || // OP_ELSE
+		script[len(script)-1] != 0x68 { // OP_ENDIF
+		return nil, nil
 	}
+	// standard P2PKH: OP_DUP OP_HASH160 PUSH20 <20-bytes> OP_EQUALVERIFY OP_CHECKSIG at positions 2-26
+	spend := script[2:27]
+	if spend[0] != 0x76 || spend[1] != 0xa9 || spend[2] != 0x14 || spend[23] != 0x88 || spend[24] != 0xac {
+		return nil, nil
+	}
+	staking := script[28 : len(script)-1]
+	if !isP2CSScript(staking) && !isP2SH256Script(staking) {
+		return nil, nil
+	}
+	return bchain.AddressDescriptor(spend), bchain.AddressDescriptor(staking)
+}
 
-	// Check for standalone P2SH256 script: OP_SHA256 PUSH32 <32 bytes> OP_EQUAL
-	// Format: 0xa8 0x20 <32-byte-script-hash> 0x87 (total 35 bytes)
-	// This is Particl's 256-bit version of P2SH (standard P2SH uses HASH160 with 20 bytes)
-	if len(script) == 35 &&
-		script[0] == 0xa8 && // OP_SHA256
-		script[1] == 0x20 && // Push 32 bytes
-		script[34] == 0x87 { // OP_EQUAL
+// IsCoinStakeTx returns true if the transaction is Particl coinstake
+// The transaction type is stored in the second byte of the version, both in the raw and in the JSON form of the transaction
+func IsCoinStakeTx(tx *bchain.Tx) bool {
+	return byte(tx.Version) >= ParticlTxnVersion && byte(tx.Version>>8) == TxnCoinstake &&
+		len(tx.Vin) > 0 && tx.Vin[0].Coinbase == ""
+}
 
-		// Extract the 32-byte script hash
-		scriptHash := script[2:34]
+// outputScriptToAddresses converts ScriptPubKey to addresses
+// Handles standard Bitcoin-like transactions (P2PKH, P2SH, P2WPKH, P2WSH, etc.)
+// and Particl-specific P2CS (cold staking) transactions
+// Note: For CT (blind) and RingCT (anon) transactions in RPC mode,
+// addresses are extracted from JSON by ParseTxFromJson, not from raw scripts
+func (p *ParticlParser) outputScriptToAddresses(script []byte) ([]string, bool, error) {
+	if a, ok := p.stealthAddress(script); ok {
+		return []string{a}, true, nil
+	}
 
-		// Encode as Particl P2SH256 address with version byte 0x3d (produces addresses starting with "33")
-		addr := &ParticlP2CSAddress{
-			pubKeyHash:  scriptHash,
-			versionByte: 0x3d, // SCRIPT_ADDRESS_256
-			params:      p.Params,
-		}
+	// Particl coinstake scripts (composite script with P2PKH + staking address)
+	// return both the spend and the staking address
+	if spend, staking := ColdStakingAddrDescs(script); spend != nil {
+		rv, _, _ := p.BitcoinOutputScriptToAddressesFunc(spend)
+		return append(rv, p.address256(staking)), true, nil
+	}
 
-		return []string{addr.String()}, true, nil
+	if isP2CSScript(script) || isP2SH256Script(script) {
+		return []string{p.address256(script)}, true, nil
 	}
 
 	// Standard Bitcoin-like addresses (P2PKH, P2SH, P2WPKH, P2WSH, etc.)
@@ -553,6 +666,26 @@ func (p *ParticlParser) outputScriptToAddresses(script []byte) ([]string, bool,
 	return rv, s, nil
 }
 
+// address256 encodes P2CS or P2SH256 script as Particl 256-bit hash address
+func (p *ParticlParser) address256(script []byte) string {
+	if isP2CSScript(script) {
+		// P2CS address (version 0x39, starts with "2")
+		addr := &ParticlP2CSAddress{
+			pubKeyHash:  script[3:35],
+			versionByte: 0x39, // PUBKEY_ADDRESS_256
+			params:      p.Params,
+		}
+		return addr.String()
+	}
+	// P2SH256 address (version 0x3d, starts with "33")
+	addr := &ParticlP2CSAddress{
+		pubKeyHash:  script[2:34],
+		versionByte: 0x3d, // SCRIPT_ADDRESS_256
+		params:      p.Params,
+	}
+	return addr.String()
+}
+
 // ParticlP2CSAddress represents a Particl 256-bit hash address (P2CS or P2SH256)
 type ParticlP2CSAddress struct {
 	pubKeyHash  []byte
@@ -568,6 +701,14 @@ func (a *ParticlP2CSAddress) String() string {
 	return encoded
 }
 
+// GetIndexedAddrDescs returns the spend and the staking address of cold staking output, the spend address owns the output
+func (p *ParticlParser) GetIndexedAddrDescs(addrDesc bchain.AddressDescriptor) []bchain.AddressDescriptor {
+	if spend, staking := ColdStakingAddrDescs(addrDesc); spend != nil {
+		return []bchain.AddressDescriptor{spend, staking}
+	}
+	return []bchain.AddressDescriptor{addrDesc}
+}
+
 // GetAddrDescForUnknownInput returns address descriptor for unknown input
 func (p *ParticlParser) GetAddrDescForUnknownInput(tx *bchain.Tx, input int) bchain.AddressDescriptor {
 	if len(tx.Vin) > input {
@@ -7,6 +7,7 @@ import (
 	"math/big"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/martinboehm/btcutil/chaincfg"
@@ -48,6 +49,30 @@ func TestGetAddrDescFromAddress(t *testing.T) {
 			want:    "a82016b5038ba914c48cc67b15c980731c7d4628fb2e18591db9058bead591aefd7687",
 			wantErr: false,
 		},
+		{
+			name:    "stealth",
+			args:    args{address: "SPGwb6oqzdPSXUZvknW3UcN6MupFjKR1SVaBDdaCAConLdJNR7Hq5wZdpXBHAp5ayJUhERb4tFQ7Ksu3X7og5td8FiaA7GTj2f8cES"},
+			want:    "ff000279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f817980102c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee50100",
+			wantErr: false,
+		},
+		{
+			name:    "stealth with prefix",
+			args:    args{address: "9XXE3RboGJMwgkyzc2LThEk5Vz2XziSqTuduPnNfA7YPd6Eru3DtSfwh46HfBK3Z6XZdLum72KBzEeMKSVbVsFaRFqDv8ATnygPByqYFu"},
+			want:    "ff000279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f817980102c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5010a3402",
+			wantErr: false,
+		},
+		{
+			name:    "stealth bad checksum",
+			args:    args{address: "SPGwb6oqzdPSXUZvknW3UcN6MupFjKR1SVaBDdaCAConLdJNR7Hq5wZdpXBHAp5ayJUhERb4tFQ7Ksu3X7og5td8FiaA7GTj2f8cET"},
+			want:    "",
+			wantErr: true,
+		},
+		{
+			name:    "testnet stealth",
+			args:    args{address: "TetXkUibnhnTF93YpxrtAbJ25x1TkJw7ehPSCJAH7L3hiZhegMWnsqrL2D6nimfEgsRpNs8hj1oEcgr2YjJNvtMwAwUoRTJNNMzwMW"},
+			want:    "",
+			wantErr: true,
+		},
 	}
 	parser := NewParticlParser(GetChainParams("main"), &btc.Configuration{})
 
@@ -101,14 +126,21 @@ func TestGetAddressesFromAddrDesc(t *testing.T) {
 		{
 			name:    "Coinstake 66-byte with P2CS",
 			args:    args{script: "b86376a914912e2b234f941f30b18afbb4fa46171214bf66c888ac6776a8207be3f09c8d809bc6fa2ced97e35c65d813f29129645bfb45fa3362d28d46123188ac68"},
-			want:    []string{"2vjzfpCeiohkBtg3gcQJFLFaV6Yjqehw6Q4SwiKqRwKWfut2zPD"},
+			want:    []string{"PmARRjwsRMZUwRdt6vaqNrWR9ZpxhoGB6P", "2vjzfpCeiohkBtg3gcQJFLFaV6Yjqehw6Q4SwiKqRwKWfut2zPD"},
 			want2:   true,
 			wantErr: false,
 		},
 		{
 			name:    "Coinstake 64-byte with P2SH256",
 			args:    args{script: "b86376a914912e2b234f941f30b18afbb4fa46171214bf66c888ac67a82016b5038ba914c48cc67b15c980731c7d4628fb2e18591db9058bead591aefd768768"},
-			want:    []string{"33kQnRT9ecMedncHvns3eHbbNzgKPqXMcTCFuWV697J8PiX6myG"},
+			want:    []string{"PmARRjwsRMZUwRdt6vaqNrWR9ZpxhoGB6P", "33kQnRT9ecMedncHvns3eHbbNzgKPqXMcTCFuWV697J8PiX6myG"},
+			want2:   true,
+			wantErr: false,
+		},
+		{
+			name:    "stealth",
+			args:    args{script: "ff000279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f817980102c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee50100"},
+			want:    []string{"SPGwb6oqzdPSXUZvknW3UcN6MupFjKR1SVaBDdaCAConLdJNR7Hq5wZdpXBHAp5ayJUhERb4tFQ7Ksu3X7og5td8FiaA7GTj2f8cES"},
 			want2:   true,
 			wantErr: false,
 		},
@@ -133,6 +165,197 @@ func TestGetAddressesFromAddrDesc(t *testing.T) {
 	}
 }
 
+func TestColdStakingAddrDescs(t *testing.T) {
+	parser := NewParticlParser(GetChainParams("main"), &btc.Configuration{})
+	tests := []struct {
+		name        string
+		script      string
+		wantSpend   string
+		wantStaking string
+	}{
+		{
+			name:        "Coinstake 66-byte with P2CS",
+			script:      "b86376a914912e2b234f941f30b18afbb4fa46171214bf66c888ac6776a8207be3f09c8d809bc6fa2ced97e35c65d813f29129645bfb45fa3362d28d46123188ac68",
+			wantSpend:   "76a914912e2b234f941f30b18afbb4fa46171214bf66c888ac",
+			wantStaking: "76a8207be3f09c8d809bc6fa2ced97e35c65d813f29129645bfb45fa3362d28d46123188ac",
+		},
+		{
+			name:        "Coinstake 64-byte with P2SH256",
+			script:      "b86376a914912e2b234f941f30b18afbb4fa46171214bf66c888ac67a82016b5038ba914c48cc67b15c980731c7d4628fb2e18591db9058bead591aefd768768",
+			wantSpend:   "76a914912e2b234f941f30b18afbb4fa46171214bf66c888ac",
+			wantStaking: "a82016b5038ba914c48cc67b15c980731c7d4628fb2e18591db9058bead591aefd7687",
+		},
+		{
+			name:   "P2PKH",
+			script: "76a914a5cea39a684776fa5d6782faf02baf04251b53bc88ac",
+		},
+		{
+			name:   "P2CS",
+			script: "76a8200637bcc74ffe834bc66f1e8c5bd6a13bc7a17276338be0016ba8151a2c5473fa88ac",
+		},
+		{
+			name:   "Coinstake with invalid staking script",
+			script: "b86376a914912e2b234f941f30b18afbb4fa46171214bf66c888ac6776a9207be3f09c8d809bc6fa2ced97e35c65d813f29129645bfb45fa3362d28d46123188ac68",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tt.script)
+			spend, staking := ColdStakingAddrDescs(b)
+			if hex.EncodeToString(spend) != tt.wantSpend {
+				t.Errorf("ColdStakingAddrDescs() spend = %x, want %v", spend, tt.wantSpend)
+			}
+			if hex.EncodeToString(staking) != tt.wantStaking {
+				t.Errorf("ColdStakingAddrDescs() staking = %x, want %v", staking, tt.wantStaking)
+			}
+			want := []string{tt.script}
+			if tt.wantSpend != "" {
+				want = []string{tt.wantSpend, tt.wantStaking}
+			}
+			var got []string
+			for _, ad := range parser.GetIndexedAddrDescs(b) {
+				got = append(got, hex.EncodeToString(ad))
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetIndexedAddrDescs() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestStealthAddressFromAddrDesc(t *testing.T) {
+	b, _ := hex.DecodeString("ff000279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f817980102c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5010a3402")
+	sa := StealthAddressFromAddrDesc(b)
+	if sa == nil {
+		t.Fatal("StealthAddressFromAddrDesc() = nil")
+	}
+	if hex.EncodeToString(sa.ScanPubKey) != "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798" ||
+		len(sa.SpendPubKeys) != 1 || hex.EncodeToString(sa.SpendPubKeys[0]) != "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5" ||
+		sa.NumSignatures != 1 || sa.PrefixBits != 10 || sa.Prefix != 0x0234 {
+		t.Errorf("StealthAddressFromAddrDesc() = %+v", sa)
+	}
+	// P2PKH script is not stealth address
+	b, _ = hex.DecodeString("76a914a5cea39a684776fa5d6782faf02baf04251b53bc88ac")
+	if sa := StealthAddressFromAddrDesc(b); sa != nil {
+		t.Errorf("StealthAddressFromAddrDesc(P2PKH) = %+v, want nil", sa)
+	}
+}
+
+func TestStealthScanKey(t *testing.T) {
+	parser := NewParticlParser(GetChainParams("main"), &btc.Configuration{})
+	addrDesc, err := parser.GetAddrDescFromAddress("SPGwb6oqzdPSXUZvknW3UcN6MupFjKR1SVaBDdaCAConLdJNR7Hq5wZdpXBHAp5ayJUhERb4tFQ7Ksu3X7og5td8FiaA7GTj2f8cES")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sa := StealthAddressFromAddrDesc(addrDesc)
+	scanSecret, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000001")
+	wrongSecret, _ := hex.DecodeString("0000000000000000000000000000000000000000000000000000000000000002")
+	if _, err := NewStealthScanKey(sa, wrongSecret); err == nil {
+		t.Error("NewStealthScanKey() with wrong secret, expected error")
+	}
+	k, err := NewStealthScanKey(sa, scanSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the ephemeral key stored in data output of standard payment and in the data of blind output
+	data, _ := hex.DecodeString("0302f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f90400000000")
+	ephem := StealthEphemeralPubKey(OutputData, data)
+	if !reflect.DeepEqual(ephem, StealthEphemeralPubKey(OutputCT, data[1:])) {
+		t.Errorf("StealthEphemeralPubKey() = %x, %x", ephem, StealthEphemeralPubKey(OutputCT, data[1:]))
+	}
+	pk, shared, err := k.DestinationPubKey(ephem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hex.EncodeToString(pk); got != "02f8389a282545295b716cc05b3b2519b9a45baacb150f086a90d80d65c38e032f" {
+		t.Errorf("DestinationPubKey() pubkey = %v", got)
+	}
+	if got := hex.EncodeToString(shared); got != "eae10cdd2f289bdad44615809cb422d2fabe9622ed706ad5d9d3ffd2cdd1c001" {
+		t.Errorf("DestinationPubKey() shared secret = %v", got)
+	}
+	if ephem := StealthEphemeralPubKey(OutputData, data[1:]); ephem != nil {
+		t.Errorf("StealthEphemeralPubKey() without DOStealth prefix = %x, want nil", ephem)
+	}
+}
+
+func TestParseXpub(t *testing.T) {
+	const ppar = "PPARTKTAAk1KmPZnSCn4HmMgMHUUQz9TKrdCukGBTXMR7mMrFZPRGCX29MKHBbfQ5XJ9UgMbYd1tYMTRhYwU8hQ3vMifePydggPfiPS2UtvEsKmd"
+	tests := []struct {
+		name    string
+		chain   string
+		xpub    string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "PPAR",
+			chain: "main",
+			xpub:  ppar,
+			want:  []string{"Ppy5EKcXd25ttRztrhPMe5KXuJDhgpncoR", "Par76cgRHdaC67i1v8UbTpxCiavMMdUKMS"},
+		},
+		{
+			name:  "Bitcoin xpub of the same key",
+			chain: "main",
+			xpub:  "xpub6BosfCnifzxcFwrSzQiqu2DBVTshkCXacvNsWGYJVVhhawA7d4R5WSWGFNbi8Aw6ZRc1brxMyWMzG3DSSSSoekkudhUd9yLb6qx39T9nMdj",
+			want:  []string{"Ppy5EKcXd25ttRztrhPMe5KXuJDhgpncoR", "Par76cgRHdaC67i1v8UbTpxCiavMMdUKMS"},
+		},
+		{
+			name:  "PPAR 256-bit",
+			chain: "main",
+			xpub:  "pkh256(" + ppar + ")",
+			want:  []string{"2w2cenhfoGDM5bwo3pJq39xTT18c5NyQXWESmTTfdePMdFmxevy", "2vfjfVujfwTpYfjaoFdpevfP7D7cW4UZBZRbQWZ8CuPpk52AQe2"},
+		},
+		{
+			name:  "ppar on testnet",
+			chain: "test",
+			xpub:  "pparszKRjspUdbVeoubcN78gjc5dYbRBARG2wp8ZGFDeqWNh5QSdzyM2A5LzHAzgQoGaCmmdDsTRA6DEe9AcewRC5nzvYkz1n3VL79cbuLvWrT2i",
+			want:  []string{"pmvUH46PeCpDbJeGNi37hrCJvaCBpRu8XP", "pXoW9MAHJpJWnzMPS98MXbpyjrtqQoHG2y"},
+		},
+		{
+			name:    "PPAR on testnet",
+			chain:   "test",
+			xpub:    ppar,
+			wantErr: true,
+		},
+		{
+			name:    "XPAR",
+			chain:   "main",
+			xpub:    "XPARHAr37YxmFP8wykfTcGVm7Gv23vphmZkNfQRXJNormphubBm8PfwdUSbiKq7ANJSRBXHYFcb3FVDmreimZrk753zuAgZTHfz1aRZsdzGdwc2d",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := NewParticlParser(GetChainParams(tt.chain), &btc.Configuration{})
+			descriptor, err := parser.ParseXpub(tt.xpub)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseXpub() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if descriptor.XpubDescriptor != tt.xpub {
+				t.Errorf("ParseXpub() XpubDescriptor = %v, want %v", descriptor.XpubDescriptor, tt.xpub)
+			}
+			ads, err := parser.DeriveAddressDescriptorsFromTo(descriptor, 1, 0, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(ads))
+			for i, ad := range ads {
+				a, _, err := parser.GetAddressesFromAddrDesc(ad)
+				if err != nil || len(a) != 1 {
+					t.Fatalf("GetAddressesFromAddrDesc() = %v, %v", a, err)
+				}
+				got[i] = a[0]
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DeriveAddressDescriptorsFromTo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGetAddrDescFromVout(t *testing.T) {
 	type args struct {
 		vout bchain.Vout
@@ -277,6 +500,10 @@ func TestParseTxFromJson(t *testing.T) {
 			t.Errorf("ParseTxFromJson() vout[0].OutputType = %v, want 'data'", tx.Vout[0].OutputType)
 		}
 
+		if !IsCoinStakeTx(tx) {
+			t.Errorf("IsCoinStakeTx() = false, want true")
+		}
+
 		if len(tx.Vout[0].ScriptPubKey.Addresses) != 0 {
 			t.Errorf("ParseTxFromJson() vout[0] addresses = %v, want empty", tx.Vout[0].ScriptPubKey.Addresses)
 		}
@@ -620,3 +847,450 @@ func TestUnpackTx(t *testing.T) {
 		})
 	}
 }
+
+const (
+	testTxHex     = "a002000000000176637c7055c62925e905a43788c508e2b7ebbeef67b8681d069a80d4de077f540100000000ffffffff02041152e81e0007f291b9c26409010affffff1e01e924f67b1f0000001976a914a5cea39a684776fa5d6782faf02baf04251b53bc88ac02473044022004aa8ebef4855db22ea020fa80e36987357fc444b8714f37dc3cd8c4c68f37050220513aa4f756fd8bab89271294b0744b4cfeb237ccd975967d764e84ebd223e5410121038553566dbf0b3c5464d64a8364002ff0b6a087f4c680eca3837dcfa713473ee5"
+	testPrivTxHex = "a00000000000010000000000000000000000000000000000000000000000000000000000000000a0ffffff00ffffffff020201052102111111111111111111111111111111111111111111111111111111111111111103040406a0910d02082222222222222222222222222222222222222222222222222222222222222222210333333333333333333333333333333333333333333333333333333333333333331976a914a5cea39a684776fa5d6782faf02baf04251b53bc88ac03aabbcc030244444444444444444444444444444444444444444444444444444444444444440955555555555555555555555555555555555555555555555555555555555555550002ddee0102ffff"
+)
+
+func TestParseTx(t *testing.T) {
+	parser := NewParticlParser(GetChainParams("main"), &btc.Configuration{})
+
+	b, _ := hex.DecodeString(testTxHex)
+	got, err := parser.ParseTx(b)
+	if err != nil {
+		t.Fatalf("ParseTx() error = %v", err)
+	}
+	want := &bchain.Tx{
+		Hex:      testTxHex,
+		Txid:     "b480399e2dd29d6edd836a57567289d641a42ea2d7608708e734afec06999c1c",
+		Version:  672,
+		LockTime: 0,
+		Vin: []bchain.Vin{
+			{
+				Txid:      "547f07ded4809a061d68b867efbeebb7e208c58837a405e92529c655707c6376",
+				Vout:      1,
+				ScriptSig: bchain.ScriptSig{Hex: ""},
+				Sequence:  4294967295,
+			},
+		},
+		Vout: []bchain.Vout{
+			{
+				N:            0,
+				ScriptPubKey: bchain.ScriptPubKey{Hex: "", Addresses: []string{}},
+				OutputType:   "data",
+				Data:         "52e81e0007f291b9c26409010affffff1e",
+			},
+			{
+				ValueSat: *big.NewInt(135223715049),
+				N:        1,
+				ScriptPubKey: bchain.ScriptPubKey{
+					Hex:       "76a914a5cea39a684776fa5d6782faf02baf04251b53bc88ac",
+					Addresses: []string{"Po3VBGWztKbFnU9rFGKNx2Rtg1zWoS4zTR"},
+				},
+				OutputType: "standard",
+			},
+		},
+		CoinSpecificData: &ParticlTxData{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTx() = %+v, want %+v", got, want)
+	}
+	if !IsCoinStakeTx(got) {
+		t.Errorf("IsCoinStakeTx() = false, want true")
+	}
+}
+
+func TestParseTxMatchesJson(t *testing.T) {
+	parser := NewParticlParser(GetChainParams("main"), &btc.Configuration{})
+
+	b, _ := hex.DecodeString(testTxHex)
+	fromWire, err := parser.ParseTx(b)
+	if err != nil {
+		t.Fatalf("ParseTx() error = %v", err)
+	}
+	fromJson, err := parser.ParseTxFromJson([]byte(`{
+		"hex": "` + testTxHex + `",
+		"txid": "b480399e2dd29d6edd836a57567289d641a42ea2d7608708e734afec06999c1c",
+		"version": 672,
+		"locktime": 0,
+		"vin": [{
+			"txid": "547f07ded4809a061d68b867efbeebb7e208c58837a405e92529c655707c6376",
+			"vout": 1,
+			"scriptSig": {"hex": ""},
+			"sequence": 4294967295
+		}],
+		"vout": [{
+			"n": 0,
+			"type": "data",
+			"data_hex": "52e81e0007f291b9c26409010affffff1e"
+		}, {
+			"n": 1,
+			"type": "standard",
+			"value": 1352.23715049,
+			"scriptPubKey": {
+				"hex": "76a914a5cea39a684776fa5d6782faf02baf04251b53bc88ac",
+				"address": "Po3VBGWztKbFnU9rFGKNx2Rtg1zWoS4zTR",
+				"type": "pubkeyhash"
+			}
+		}]
+	}`))
+	if err != nil {
+		t.Fatalf("ParseTxFromJson() error = %v", err)
+	}
+	if !reflect.DeepEqual(fromWire, fromJson) {
+		t.Errorf("ParseTx() = %+v, ParseTxFromJson() = %+v", fromWire, fromJson)
+	}
+}
+
+func TestParsePrivacyTx(t *testing.T) {
+	parser := NewParticlParser(GetChainParams("main"), &btc.Configuration{})
+
+	b, _ := hex.DecodeString(testPrivTxHex)
+	got, err := parser.ParseTx(b)
+	if err != nil {
+		t.Fatalf("ParseTx() error = %v", err)
+	}
+	wantVin := []bchain.Vin{
+		{
+			Sequence:   4294967295,
+			InputType:  "anon",
+			AnonInputs: 1,
+			RingSize:   5,
+			KeyImages:  []string{"021111111111111111111111111111111111111111111111111111111111111111"},
+		},
+	}
+	if !reflect.DeepEqual(got.Vin, wantVin) {
+		t.Errorf("ParseTx() vin = %+v, want %+v", got.Vin, wantVin)
+	}
+	wantVout := []bchain.Vout{
+		{
+			N:            0,
+			ScriptPubKey: bchain.ScriptPubKey{Hex: "", Addresses: []string{}},
+			OutputType:   "data",
+			Data:         "06a0910d",
+		},
+		{
+			N: 1,
+			ScriptPubKey: bchain.ScriptPubKey{
+				Hex:       "76a914a5cea39a684776fa5d6782faf02baf04251b53bc88ac",
+				Addresses: []string{"Po3VBGWztKbFnU9rFGKNx2Rtg1zWoS4zTR"},
+			},
+			OutputType:      "blind",
+			ValueCommitment: "082222222222222222222222222222222222222222222222222222222222222222",
+			Data:            "033333333333333333333333333333333333333333333333333333333333333333",
+			RangeProof:      "aabbcc",
+		},
+		{
+			N:               2,
+			ScriptPubKey:    bchain.ScriptPubKey{Hex: "", Addresses: []string{}},
+			OutputType:      "anon",
+			ValueCommitment: "095555555555555555555555555555555555555555555555555555555555555555",
+			RangeProof:      "ddee",
+			PubKey:          "024444444444444444444444444444444444444444444444444444444444444444",
+		},
+	}
+	if !reflect.DeepEqual(got.Vout, wantVout) {
+		t.Errorf("ParseTx() vout = %+v, want %+v", got.Vout, wantVout)
+	}
+	if d, ok := got.CoinSpecificData.(*ParticlTxData); !ok || d.CTFee != 0.002152 {
+		t.Errorf("ParseTx() CoinSpecificData = %+v, want CTFee 0.002152", got.CoinSpecificData)
+	}
+	if IsCoinStakeTx(got) {
+		t.Errorf("IsCoinStakeTx() = true, want false")
+	}
+}
+
+func TestParseBlock(t *testing.T) {
+	parser := NewParticlParser(GetChainParams("main"), &btc.Configuration{})
+
+	// 112 byte header with time 1761309840, two transactions and the block signature
+	header := "00000000" + strings.Repeat("00", 96) + "9074fb68" + "ffff001f" + "00000000"
+	blockHex := header + "02" + testTxHex + testPrivTxHex + "0100"
+	b, _ := hex.DecodeString(blockHex)
+	block, err := parser.ParseBlock(b)
+	if err != nil {
+		t.Fatalf("ParseBlock() error = %v", err)
+	}
+	if block.Time != 1761309840 {
+		t.Errorf("ParseBlock() time = %v, want 1761309840", block.Time)
+	}
+	if block.Size != len(b) {
+		t.Errorf("ParseBlock() size = %v, want %v", block.Size, len(b))
+	}
+	if len(block.Txs) != 2 {
+		t.Fatalf("ParseBlock() txs = %v, want 2", len(block.Txs))
+	}
+	if block.Txs[0].Txid != "b480399e2dd29d6edd836a57567289d641a42ea2d7608708e734afec06999c1c" {
+		t.Errorf("ParseBlock() txs[0].Txid = %v", block.Txs[0].Txid)
+	}
+	if block.Txs[1].Vout[1].OutputType != "blind" || block.Txs[1].Vin[0].InputType != "anon" {
+		t.Errorf("ParseBlock() txs[1] = %+v", block.Txs[1])
+	}
+	stakerDesc, _ := hex.DecodeString("76a914a5cea39a684776fa5d6782faf02baf04251b53bc88ac")
+	wantStake := &BlockStake{
+		CoinstakeTxid:  "b480399e2dd29d6edd836a57567289d641a42ea2d7608708e734afec06999c1c",
+		KernelTxid:     "547f07ded4809a061d68b867efbeebb7e208c58837a405e92529c655707c6376",
+		KernelVout:     1,
+		StakerAddrDesc: stakerDesc,
+	}
+	if !reflect.DeepEqual(block.CoinSpecificData, wantStake) {
+		t.Errorf("ParseBlock() stake = %+v, want %+v", block.CoinSpecificData, wantStake)
+	}
+}
+
+// mainnetTreasuryAddresses are the treasury fund addresses of the Particl mainnet
+var mainnetTreasuryAddresses = []string{"RJAPhgckEgRGVPZa9WoGSWW24spskSfLTQ", "RBiiQBnQsVPPQkUaJVQTjsZM9K2xMKozST", "RQYUDd3EJohpjq62So4ftcV5XZfxZxJPe9"}
+
+func TestBlockStakeFromTxs(t *testing.T) {
+	parser := NewParticlParser(GetChainParams("main"), &btc.Configuration{})
+	coldStakingScript := "b86376a914912e2b234f941f30b18afbb4fa46171214bf66c888ac6776a8207be3f09c8d809bc6fa2ced97e35c65d813f29129645bfb45fa3362d28d46123188ac68"
+	treasuryDesc, err := parser.GetAddrDescFromAddress(parser.TreasuryAddresses()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	txs := []bchain.Tx{
+		{
+			Txid:    "3333333333333333333333333333333333333333333333333333333333333333",
+			Version: 0x2a0,
+			Vin:     []bchain.Vin{{Txid: "1111111111111111111111111111111111111111111111111111111111111111", Vout: 2}},
+			Vout: []bchain.Vout{
+				{N: 0, OutputType: "data", Data: "52e81e00"},
+				// the treasury fund payout is not the output of the staker
+				{N: 1, ScriptPubKey: bchain.ScriptPubKey{Hex: hex.EncodeToString(treasuryDesc)}},
+				{N: 2, ScriptPubKey: bchain.ScriptPubKey{Hex: coldStakingScript}},
+			},
+		},
+	}
+	stakingDesc, _ := hex.DecodeString("76a8207be3f09c8d809bc6fa2ced97e35c65d813f29129645bfb45fa3362d28d46123188ac")
+	want := &BlockStake{
+		CoinstakeTxid:  "3333333333333333333333333333333333333333333333333333333333333333",
+		KernelTxid:     "1111111111111111111111111111111111111111111111111111111111111111",
+		KernelVout:     2,
+		StakerAddrDesc: stakingDesc,
+		ColdStaked:     true,
+	}
+	if got := parser.BlockStakeFromTxs(txs); !reflect.DeepEqual(got, want) {
+		t.Errorf("BlockStakeFromTxs() = %+v, want %+v", got, want)
+	}
+	txs[0].Version = 2
+	if got := parser.BlockStakeFromTxs(txs); got != nil {
+		t.Errorf("BlockStakeFromTxs() = %+v, want nil for a block without coinstake", got)
+	}
+}
+
+func TestParseCoinstakeData(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want *CoinstakeData
+	}{
+		{
+			name: "no vote",
+			// height 2025554, treasury carried forward, SMSG fee and difficulty
+			data: "52e81e0007f291b9c26409010affffff1e",
+			want: &CoinstakeData{Height: 2025554, DevFundCfwd: 26982893810},
+		},
+		{
+			name: "vote",
+			// vote for proposal 12 option 2
+			data: "52e81e00050c000200" + "09010affffff1e",
+			want: &CoinstakeData{Height: 2025554, HasVote: true, Proposal: 12, Option: 2},
+		},
+		{
+			name: "vote for proposal 0",
+			data: "52e81e000500000100",
+			want: &CoinstakeData{Height: 2025554, Option: 1},
+		},
+		{
+			name: "truncated vote",
+			data: "52e81e00050c00",
+			want: &CoinstakeData{Height: 2025554},
+		},
+		{
+			name: "too short",
+			data: "52e81e",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tt.data)
+			got, ok := ParseCoinstakeData(b)
+			if ok != (tt.want != nil) {
+				t.Fatalf("ParseCoinstakeData() ok = %v", ok)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCoinstakeData() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	b, _ := hex.DecodeString(testTxHex)
+	tx, err := NewParticlParser(GetChainParams("main"), &btc.Configuration{}).ParseTx(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cd := CoinstakeDataFromTx(tx); cd == nil || cd.Height != 2025554 || cd.HasVote || cd.DevFundCfwd != 26982893810 {
+		t.Errorf("CoinstakeDataFromTx() = %+v", cd)
+	}
+}
+
+func TestTreasuryAddresses(t *testing.T) {
+	tests := []struct {
+		chain     string
+		addresses []string
+	}{
+		{chain: "main", addresses: mainnetTreasuryAddresses},
+		{chain: "test", addresses: []string{"rTvv9vsbu269mjYYEecPYinDG8Bt7D86qD"}},
+		{chain: "regtest", addresses: []string{"pqZDE7YNWv5PJWidiaEG8tqfebkd6PNZDV"}},
+	}
+	for _, tt := range tests {
+		parser := NewParticlParser(GetChainParams(tt.chain), &btc.Configuration{})
+		if !reflect.DeepEqual(parser.TreasuryAddresses(), tt.addresses) {
+			t.Errorf("%s: TreasuryAddresses() = %v, want %v", tt.chain, parser.TreasuryAddresses(), tt.addresses)
+		}
+		for _, a := range tt.addresses {
+			addrDesc, err := parser.GetAddrDescFromAddress(a)
+			if err != nil {
+				t.Fatalf("%s: GetAddrDescFromAddress(%s) error %v", tt.chain, a, err)
+			}
+			if !parser.IsTreasuryAddrDesc(addrDesc) {
+				t.Errorf("%s: IsTreasuryAddrDesc(%s) = false", tt.chain, a)
+			}
+		}
+	}
+	parser := NewParticlParser(GetChainParams("main"), &btc.Configuration{})
+	addrDesc, err := parser.GetAddrDescFromAddress("Po3VBGWztKbFnU9rFGKNx2Rtg1zWoS4zTR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parser.IsTreasuryAddrDesc(addrDesc) {
+		t.Error("IsTreasuryAddrDesc() = true for a standard address")
+	}
+	// the configured addresses replace the defaults of the network
+	parser.SetTreasuryAddresses([]string{"Po3VBGWztKbFnU9rFGKNx2Rtg1zWoS4zTR"})
+	if !parser.IsTreasuryAddrDesc(addrDesc) {
+		t.Error("IsTreasuryAddrDesc() = false for the configured address")
+	}
+	if addrDesc, err = parser.GetAddrDescFromAddress(mainnetTreasuryAddresses[0]); err != nil {
+		t.Fatal(err)
+	}
+	if parser.IsTreasuryAddrDesc(addrDesc) {
+		t.Error("IsTreasuryAddrDesc() = true for the overridden default address")
+	}
+}
+
+func TestTxCTFeeSat(t *testing.T) {
+	tests := []struct {
+		name string
+		tx   bchain.Tx
+		want int64
+	}{
+		{
+			name: "data output",
+			tx:   bchain.Tx{Vout: []bchain.Vout{{OutputType: "data", Data: "06a0910d"}, {OutputType: "blind"}}},
+			want: 215200,
+		},
+		{
+			name: "coin specific data",
+			tx:   bchain.Tx{Vout: []bchain.Vout{{OutputType: "anon"}}, CoinSpecificData: &ParticlTxData{CTFee: 0.00215201}},
+			want: 215201,
+		},
+		{
+			name: "standard tx",
+			tx:   bchain.Tx{Vout: []bchain.Vout{{OutputType: "standard"}}},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TxCTFeeSat(&tt.tx); got != tt.want {
+				t.Errorf("TxCTFeeSat() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRangeProofInfo(t *testing.T) {
+	// the proof body is not checked, header is followed by zeros up to the length of the Borromean proof of the mantissa
+	proof := func(header string, mantissa int) []byte {
+		b, err := hex.DecodeString(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rsizes := rangeProofRings(mantissa)
+		npub := 0
+		for _, r := range rsizes {
+			npub += r
+		}
+		return append(b, make([]byte, 32*(npub+len(rsizes)-1)+32+(len(rsizes)+6)>>3)...)
+	}
+	tests := []struct {
+		name    string
+		proof   []byte
+		want    *RangeProofInfo
+		wantErr bool
+	}{
+		{
+			name:  "range with min value",
+			proof: proof("621f00000000000003e8", 32),
+			want:  &RangeProofInfo{Exponent: 2, Mantissa: 32, MinValue: 1000, MaxValue: 429496730500},
+		},
+		{
+			name:  "range without min value",
+			proof: proof("4033", 52),
+			want:  &RangeProofInfo{Exponent: 0, Mantissa: 52, MinValue: 0, MaxValue: 4503599627370495},
+		},
+		{
+			name:  "full 64 bit range",
+			proof: proof("403f", 64),
+			want:  &RangeProofInfo{Exponent: 0, Mantissa: 64, MinValue: 0, MaxValue: 18446744073709551615},
+		},
+		{
+			name:  "exact value",
+			proof: proof("2000000000075bcd15", 0),
+			want:  &RangeProofInfo{Exponent: -1, Mantissa: 0, MinValue: 123456789, MaxValue: 123456789},
+		},
+		{
+			name:    "range overflow",
+			proof:   proof("413f", 64),
+			wantErr: true,
+		},
+		{
+			name:    "exponent too big",
+			proof:   proof("5320", 33),
+			wantErr: true,
+		},
+		{
+			name:    "invalid flag",
+			proof:   proof("c020", 33),
+			wantErr: true,
+		},
+		{
+			name:    "too short",
+			proof:   proof("", 0)[:64],
+			wantErr: true,
+		},
+		{
+			// the Bulletproofs do not have the header and the layout of the Borromean proof
+			name:    "bulletproof",
+			proof:   append(proof("4033", 0)[:2], make([]byte, 673)...),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRangeProofInfo(tt.proof)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRangeProofInfo() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRangeProofInfo() = %+v, want %+v", got, tt.want)
+			}
+			if got != nil && got.ExactValue() != (tt.want.Exponent == -1) {
+				t.Errorf("ExactValue() = %v", got.ExactValue())
+			}
+		})
+	}
+}
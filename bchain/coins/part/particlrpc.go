@@ -2,8 +2,11 @@ package part
 
 import (
 	"encoding/json"
+	"strconv"
+	"strings"
 
 	"github.com/golang/glog"
+	"github.com/juju/errors"
 	"github.com/trezor/blockbook/bchain"
 	"github.com/trezor/blockbook/bchain/coins/btc"
 	"github.com/trezor/blockbook/common"
@@ -12,6 +15,12 @@ import (
 // ParticlRPC is an interface to JSON-RPC particld service.
 type ParticlRPC struct {
 	*btc.BitcoinRPC
+	particlConfig particlConfiguration
+}
+
+// particlConfiguration contains the Particl specific parameters of the coin configuration
+type particlConfiguration struct {
+	TreasuryAddresses []string `json:"treasury_addresses,omitempty"`
 }
 
 // NewParticlRPC returns new ParticlRPC instance.
@@ -22,9 +31,14 @@ func NewParticlRPC(config json.RawMessage, pushHandler func(bchain.NotificationT
 	}
 
 	s := &ParticlRPC{
-		b.(*btc.BitcoinRPC),
+		BitcoinRPC: b.(*btc.BitcoinRPC),
+	}
+	if err = json.Unmarshal(config, &s.particlConfig); err != nil {
+		return nil, errors.Annotatef(err, "Invalid configuration file")
 	}
 	s.RPCMarshaler = btc.JSONMarshalerV2{}
+	s.RawBlockHeaderSize = particlBlockHeaderSize
+	s.IsStakeTx = IsCoinStakeTx
 	s.ChainConfig.SupportsEstimateFee = true
 	s.ChainConfig.SupportsEstimateSmartFee = true
 
@@ -43,7 +57,12 @@ func (b *ParticlRPC) Initialize() error {
 	params := GetChainParams(chainName)
 
 	// always create parser
-	b.Parser = NewParticlParser(params, b.ChainConfig)
+	parser := NewParticlParser(params, b.ChainConfig)
+	if len(b.particlConfig.TreasuryAddresses) > 0 {
+		glog.Info("rpc: treasury addresses overridden by configuration ", b.particlConfig.TreasuryAddresses)
+		parser.SetTreasuryAddresses(b.particlConfig.TreasuryAddresses)
+	}
+	b.Parser = parser
 
 	// parameters for getInfo request
 	if params.Net == MainnetMagic {
@@ -235,6 +254,148 @@ func (b *ParticlRPC) GetBlockFull(hash string) (*bchain.Block, error) {
 		BlockHeader: res.Result.BlockHeader,
 		Txs:         txs,
 	}
+	if p, ok := b.Parser.(*ParticlParser); ok {
+		block.CoinSpecificData = p.BlockStakeFromTxs(txs)
+	}
 
 	return block, nil
 }
+
+// getstakinginfo
+
+type cmdGetStakingInfo struct {
+	Method string `json:"method"`
+}
+
+type resGetStakingInfo struct {
+	Error  *bchain.RPCError    `json:"error"`
+	Result *bchain.StakingInfo `json:"result"`
+}
+
+// GetStakingInfo returns the staking state of the backend, the RPC requires particld with enabled wallet
+func (b *ParticlRPC) GetStakingInfo() (*bchain.StakingInfo, error) {
+	glog.V(1).Info("rpc: getstakinginfo")
+
+	res := resGetStakingInfo{}
+	req := cmdGetStakingInfo{Method: "getstakinginfo"}
+	err := b.Call(&req, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.Result == nil {
+		return nil, errors.New("getstakinginfo: empty result")
+	}
+	return res.Result, nil
+}
+
+// anonoutput
+
+type cmdGetAnonOutput struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+}
+
+type resGetAnonOutput struct {
+	Error  *bchain.RPCError   `json:"error"`
+	Result *bchain.AnonOutput `json:"result"`
+}
+
+// GetAnonOutput returns the anon output with given global index from the backend
+func (b *ParticlRPC) GetAnonOutput(index uint64) (*bchain.AnonOutput, error) {
+	glog.V(1).Info("rpc: anonoutput ", index)
+
+	res := resGetAnonOutput{}
+	req := cmdGetAnonOutput{Method: "anonoutput"}
+	req.Params = []string{strconv.FormatUint(index, 10)}
+	err := b.Call(&req, &res)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.Result == nil {
+		return nil, errors.New("anonoutput: empty result")
+	}
+	return res.Result, nil
+}
+
+// testmempoolaccept
+
+type cmdTestMempoolAccept struct {
+	Method string     `json:"method"`
+	Params [][]string `json:"params"`
+}
+
+type resTestMempoolAccept struct {
+	Error  *bchain.RPCError `json:"error"`
+	Result []struct {
+		Txid         string `json:"txid"`
+		Allowed      bool   `json:"allowed"`
+		RejectReason string `json:"reject-reason"`
+	} `json:"result"`
+}
+
+// rejectReasons translates the reject reasons of particld to readable messages,
+// the reasons are matched by substring as the backend adds details to some of them
+var rejectReasons = []struct {
+	pattern string
+	message string
+}{
+	{"dup-ki", "Key image already spent, the anon input is a double spend"},
+	{"keyimage", "Key image already spent, the anon input is a double spend"},
+	{"rangeproof", "Invalid range proof of a blind or anon output"},
+	{"commitment", "Value commitments of the inputs and outputs do not balance"},
+	{"anonin", "Invalid anon (RingCT) input"},
+	{"ringsize", "Invalid ring size of the anon input"},
+	{"mempool-conflict", "Transaction conflicts with a transaction in mempool"},
+	{"missingorspent", "Inputs are missing or already spent"},
+	{"missing-inputs", "Inputs are missing or already spent"},
+	{"already-known", "Transaction is already in mempool"},
+	{"already-in-mempool", "Transaction is already in mempool"},
+	{"min relay fee not met", "Fee is too low"},
+	{"insufficient fee", "Fee is too low"},
+	{"non-final", "Transaction is not final"},
+	{"dust", "Transaction contains dust output"},
+}
+
+// rejectReasonMessage returns the error message for the reject reason of testmempoolaccept
+func rejectReasonMessage(reason string) string {
+	r := strings.ToLower(reason)
+	for _, rr := range rejectReasons {
+		if strings.Contains(r, rr.pattern) {
+			return "Transaction rejected: " + rr.message + " (" + reason + ")"
+		}
+	}
+	if reason == "" {
+		return "Transaction rejected"
+	}
+	return "Transaction rejected: " + reason
+}
+
+// SendRawTransaction validates the transaction by testmempoolaccept before it is broadcast,
+// so that the rejected transactions are not relayed and the reason of the rejection is returned
+func (b *ParticlRPC) SendRawTransaction(tx string, disableAlternativeRPC bool) (string, error) {
+	glog.V(1).Info("rpc: testmempoolaccept")
+
+	res := resTestMempoolAccept{}
+	req := cmdTestMempoolAccept{Method: "testmempoolaccept"}
+	req.Params = [][]string{{tx}}
+	err := b.Call(&req, &res)
+	if err != nil {
+		return "", err
+	}
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if len(res.Result) != 1 {
+		return "", errors.New("Unexpected result of testmempoolaccept")
+	}
+	if !res.Result[0].Allowed {
+		return "", errors.New(rejectReasonMessage(res.Result[0].RejectReason))
+	}
+	return b.BitcoinRPC.SendRawTransaction(tx, disableAlternativeRPC)
+}
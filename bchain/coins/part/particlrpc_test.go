@@ -0,0 +1,48 @@
+//go:build unittest
+
+package part
+
+import "testing"
+
+func TestRejectReasonMessage(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   string
+	}{
+		{
+			reason: "bad-anonin-dup-ki",
+			want:   "Transaction rejected: Key image already spent, the anon input is a double spend (bad-anonin-dup-ki)",
+		},
+		{
+			reason: "bad-rangeproof-verify",
+			want:   "Transaction rejected: Invalid range proof of a blind or anon output (bad-rangeproof-verify)",
+		},
+		{
+			reason: "bad-commitment-sum",
+			want:   "Transaction rejected: Value commitments of the inputs and outputs do not balance (bad-commitment-sum)",
+		},
+		{
+			reason: "min relay fee not met, 100 < 1000",
+			want:   "Transaction rejected: Fee is too low (min relay fee not met, 100 < 1000)",
+		},
+		{
+			reason: "bad-txns-inputs-missingorspent",
+			want:   "Transaction rejected: Inputs are missing or already spent (bad-txns-inputs-missingorspent)",
+		},
+		{
+			reason: "bad-txns-vout-empty",
+			want:   "Transaction rejected: bad-txns-vout-empty",
+		},
+		{
+			reason: "",
+			want:   "Transaction rejected",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			if got := rejectReasonMessage(tt.reason); got != tt.want {
+				t.Errorf("rejectReasonMessage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package part
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/juju/errors"
+)
+
+// Particl blind and anon outputs carry secp256k1-zkp (Borromean) range proof or Bulletproof of the committed value.
+// Only the Borromean range proof has the public header described below.
+// The header of the proof is public: the first byte holds the flags and the exponent, followed by the number
+// of mantissa bits and optionally the minimum value. The proof shows that the value is
+// min value + x * 10^exponent, where x has at most mantissa bits. The proof without range (exponent -1)
+// discloses the exact value, which is equal to the minimum value.
+
+const (
+	rangeProofMinSize     = 65
+	rangeProofHasRange    = 0x40
+	rangeProofHasMinValue = 0x20
+	rangeProofMaxExponent = 18
+	rangeProofMaxMantissa = 64
+)
+
+// RangeProofInfo is the decoded header of the range proof
+type RangeProofInfo struct {
+	Exponent int // -1 if the proof discloses the exact value
+	Mantissa int // number of bits of the proven value
+	MinValue uint64
+	MaxValue uint64
+}
+
+// ExactValue returns true if the proof discloses the value of the output
+func (ri *RangeProofInfo) ExactValue() bool {
+	return ri.Exponent < 0
+}
+
+// ParseRangeProofInfo decodes the header of the Borromean range proof, the same way as secp256k1_rangeproof_info.
+// The Bulletproofs do not have the public header, error is returned if the proof does not have the layout of the Borromean proof.
+func ParseRangeProofInfo(proof []byte) (*RangeProofInfo, error) {
+	ri, _, _, _, err := borromeanRangeProofLayout(proof)
+	return ri, err
+}
+
+// parseRangeProofHeader decodes the header of the range proof and returns also the scale of the proven value
+// (10^exponent) and the length of the header
+func parseRangeProofHeader(proof []byte) (*RangeProofInfo, uint64, int, error) {
+	if len(proof) < rangeProofMinSize || proof[0]&0x80 != 0 {
+		return nil, 0, 0, errors.New("Invalid range proof header")
+	}
+	ri := &RangeProofInfo{Exponent: -1}
+	o := 0
+	if proof[0]&rangeProofHasRange != 0 {
+		ri.Exponent = int(proof[0] & 0x1f)
+		if ri.Exponent > rangeProofMaxExponent {
+			return nil, 0, 0, errors.New("Invalid range proof exponent")
+		}
+		o++
+		ri.Mantissa = int(proof[o]) + 1
+		if ri.Mantissa > rangeProofMaxMantissa {
+			return nil, 0, 0, errors.New("Invalid range proof mantissa")
+		}
+		ri.MaxValue = math.MaxUint64 >> uint(rangeProofMaxMantissa-ri.Mantissa)
+	}
+	o++
+	scale := uint64(1)
+	for i := 0; i < ri.Exponent; i++ {
+		if ri.MaxValue > math.MaxUint64/10 {
+			return nil, 0, 0, errors.New("Invalid range proof range")
+		}
+		ri.MaxValue *= 10
+		scale *= 10
+	}
+	if proof[0]&rangeProofHasMinValue != 0 {
+		if len(proof)-o < 8 {
+			return nil, 0, 0, errors.New("Invalid range proof min value")
+		}
+		ri.MinValue = binary.BigEndian.Uint64(proof[o : o+8])
+		o += 8
+	}
+	if ri.MaxValue > math.MaxUint64-ri.MinValue {
+		return nil, 0, 0, errors.New("Invalid range proof range")
+	}
+	ri.MaxValue += ri.MinValue
+	return ri, scale, o, nil
+}
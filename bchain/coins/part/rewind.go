@@ -0,0 +1,459 @@
+package part
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcd/btcec"
+)
+
+// Rewind of the Borromean range proof
+// The prover derives all the random values of the range proof from a nonce. The one who knows the nonce
+// regenerates them and recovers the value and the blinding factor of the output from the proof, the same way as
+// secp256k1_rangeproof_rewind of secp256k1-zkp. The recovered value is checked against the Pedersen commitment
+// value * H + blind * G of the output.
+
+// generatorH is the generator of the value in Pedersen commitments (secp256k1_generator_h)
+var generatorH = ecPoint{
+	x: hexToBigInt("50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0"),
+	y: hexToBigInt("31d3c6863973926e049e637cb1b5f40a36dac28af1766968c30c2313f3a38904"),
+}
+
+func hexToBigInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 16)
+	return i
+}
+
+// ecPoint is a point of secp256k1 in affine coordinates, nil x is the point at infinity
+type ecPoint struct {
+	x, y *big.Int
+}
+
+func (p ecPoint) infinity() bool {
+	return p.x == nil
+}
+
+func ecAdd(a, b ecPoint) ecPoint {
+	if a.infinity() {
+		return b
+	}
+	if b.infinity() {
+		return a
+	}
+	curve := btcec.S256()
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) != 0 {
+			return ecPoint{}
+		}
+		x, y := curve.Double(a.x, a.y)
+		return ecPoint{x, y}
+	}
+	x, y := curve.Add(a.x, a.y, b.x, b.y)
+	return ecPoint{x, y}
+}
+
+func ecNeg(p ecPoint) ecPoint {
+	if p.infinity() {
+		return p
+	}
+	return ecPoint{p.x, new(big.Int).Sub(btcec.S256().P, p.y)}
+}
+
+func ecMul(p ecPoint, k *big.Int) ecPoint {
+	k = new(big.Int).Mod(k, btcec.S256().N)
+	if p.infinity() || k.Sign() == 0 {
+		return ecPoint{}
+	}
+	x, y := btcec.S256().ScalarMult(p.x, p.y, k.Bytes())
+	return ecPoint{x, y}
+}
+
+func ecBaseMul(k *big.Int) ecPoint {
+	k = new(big.Int).Mod(k, btcec.S256().N)
+	if k.Sign() == 0 {
+		return ecPoint{}
+	}
+	x, y := btcec.S256().ScalarBaseMult(k.Bytes())
+	return ecPoint{x, y}
+}
+
+// pedersenCommit returns blind * G + value * H
+func pedersenCommit(blind *big.Int, value uint64) ecPoint {
+	return ecAdd(ecBaseMul(blind), ecMul(generatorH, new(big.Int).SetUint64(value)))
+}
+
+// isQuad returns true if y is a quadratic residue modulo the field size
+func isQuad(y *big.Int) bool {
+	return big.Jacobi(y, btcec.S256().P) == 1
+}
+
+// pointFromXQuad returns the point with the coordinate x and the y which is a quadratic residue
+func pointFromXQuad(xb []byte) (ecPoint, bool) {
+	curve := btcec.S256()
+	x := new(big.Int).SetBytes(xb)
+	if x.Cmp(curve.P) >= 0 {
+		return ecPoint{}, false
+	}
+	rhs := new(big.Int).Mul(x, x)
+	rhs.Mul(rhs, x)
+	rhs.Add(rhs, curve.B)
+	rhs.Mod(rhs, curve.P)
+	// P = 3 mod 4, the square root is rhs^((P+1)/4), which is itself a quadratic residue
+	e := new(big.Int).Add(curve.P, big.NewInt(1))
+	e.Rsh(e, 2)
+	y := new(big.Int).Exp(rhs, e, curve.P)
+	if new(big.Int).Exp(y, big.NewInt(2), curve.P).Cmp(rhs) != 0 {
+		return ecPoint{}, false
+	}
+	return ecPoint{x, y}, true
+}
+
+// parseCommitment decodes the serialized Pedersen commitment, the first byte is 8 if y is a quadratic residue, otherwise 9
+func parseCommitment(b []byte) (ecPoint, error) {
+	if len(b) != commitmentSize || b[0]&0xfe != 8 {
+		return ecPoint{}, errors.New("Invalid commitment")
+	}
+	p, ok := pointFromXQuad(b[1:])
+	if !ok {
+		return ecPoint{}, errors.New("Invalid commitment")
+	}
+	if b[0]&1 != 0 {
+		p = ecNeg(p)
+	}
+	return p, nil
+}
+
+// serializeRangeProofPoint serializes the point the way the range proof hashes it, the first byte is 0 if y is a quadratic residue
+func serializeRangeProofPoint(p ecPoint) []byte {
+	b := make([]byte, 33)
+	if !isQuad(p.y) {
+		b[0] = 1
+	}
+	p.x.FillBytes(b[1:])
+	return b
+}
+
+func serializeCompressedPoint(p ecPoint) []byte {
+	return (&btcec.PublicKey{Curve: btcec.S256(), X: p.x, Y: p.y}).SerializeCompressed()
+}
+
+// scalarFromBytes returns the scalar and true if the 32 bytes overflow the group order, as secp256k1_scalar_set_b32
+func scalarFromBytes(b []byte) (*big.Int, bool) {
+	s := new(big.Int).SetBytes(b)
+	if s.Cmp(btcec.S256().N) >= 0 {
+		s.Sub(s, btcec.S256().N)
+		return s, true
+	}
+	return s, false
+}
+
+func scalarBytes(s *big.Int) []byte {
+	b := make([]byte, 32)
+	s.FillBytes(b)
+	return b
+}
+
+// rfc6979Rng is the deterministic random generator of secp256k1 (secp256k1_rfc6979_hmac_sha256)
+type rfc6979Rng struct {
+	k, v  []byte
+	retry bool
+}
+
+func hmacSHA256(key []byte, data ...[]byte) []byte {
+	h := hmac.New(sha256.New, key)
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func newRfc6979Rng(seed []byte) *rfc6979Rng {
+	r := &rfc6979Rng{k: make([]byte, 32), v: bytes.Repeat([]byte{1}, 32)}
+	r.k = hmacSHA256(r.k, r.v, []byte{0}, seed)
+	r.v = hmacSHA256(r.k, r.v)
+	r.k = hmacSHA256(r.k, r.v, []byte{1}, seed)
+	r.v = hmacSHA256(r.k, r.v)
+	return r
+}
+
+func (r *rfc6979Rng) generate() []byte {
+	if r.retry {
+		r.k = hmacSHA256(r.k, r.v, []byte{0})
+		r.v = hmacSHA256(r.k, r.v)
+	}
+	r.v = hmacSHA256(r.k, r.v)
+	r.retry = true
+	return append([]byte(nil), r.v...)
+}
+
+// rangeProofRings returns the sizes of the rings of the proof with the given mantissa, the digits are in radix 4,
+// the last digit is binary if the mantissa is odd
+func rangeProofRings(mantissa int) []int {
+	if mantissa == 0 {
+		return []int{1}
+	}
+	rsizes := make([]int, mantissa>>1, (mantissa+1)>>1)
+	for i := range rsizes {
+		rsizes[i] = 4
+	}
+	if mantissa&1 != 0 {
+		rsizes = append(rsizes, 2)
+	}
+	return rsizes
+}
+
+// rangeProofGenRand regenerates the secret values of the rings and the random signatures of the proof from the nonce,
+// the message (if not nil) is xored into the signatures and replaced by them, as secp256k1_rangeproof_genrand
+func rangeProofGenRand(nonce []byte, commit ecPoint, header []byte, rsizes []int, message []byte) ([]*big.Int, []*big.Int) {
+	seed := make([]byte, 0, 32+33+33+len(header))
+	seed = append(seed, nonce...)
+	seed = append(seed, serializeRangeProofPoint(commit)...)
+	seed = append(seed, serializeRangeProofPoint(generatorH)...)
+	seed = append(seed, header...)
+	rng := newRfc6979Rng(seed)
+	sec := make([]*big.Int, len(rsizes))
+	var s []*big.Int
+	acc := new(big.Int)
+	for i, rsize := range rsizes {
+		if i < len(rsizes)-1 {
+			rng.generate()
+			for {
+				var overflow bool
+				sec[i], overflow = scalarFromBytes(rng.generate())
+				if !overflow && sec[i].Sign() != 0 {
+					break
+				}
+			}
+			acc.Add(acc, sec[i])
+		} else {
+			sec[i] = new(big.Int).Neg(acc)
+			sec[i].Mod(sec[i], btcec.S256().N)
+		}
+		for j := 0; j < rsize; j++ {
+			tmp := rng.generate()
+			if message != nil {
+				m := message[(i*4+j)*32 : (i*4+j+1)*32]
+				for b := range tmp {
+					tmp[b] ^= m[b]
+				}
+				copy(m, tmp)
+			}
+			v, _ := scalarFromBytes(tmp)
+			s = append(s, v)
+		}
+	}
+	return sec, s
+}
+
+// rangeProofPubExpand fills the public keys of the digits of each ring: pubs[j] = pubs[0] - j * 4^ring * 10^exp * H
+func rangeProofPubExpand(pubs []ecPoint, exp int, rsizes []int) {
+	base := ecNeg(generatorH)
+	if exp > 0 {
+		base = ecMul(base, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(exp)), nil))
+	}
+	n := 0
+	for i, rsize := range rsizes {
+		for j := 1; j < rsize; j++ {
+			pubs[n+j] = ecAdd(pubs[n+j-1], base)
+		}
+		if i < len(rsizes)-1 {
+			base = ecMul(base, big.NewInt(4))
+		}
+		n += rsize
+	}
+}
+
+func borromeanHash(m []byte, e []byte, ridx, eidx int) []byte {
+	var pos [8]byte
+	binary.BigEndian.PutUint32(pos[:4], uint32(ridx))
+	binary.BigEndian.PutUint32(pos[4:], uint32(eidx))
+	h := sha256.New()
+	h.Write(e)
+	h.Write(m)
+	h.Write(pos[:])
+	return h.Sum(nil)
+}
+
+// borromeanVerify verifies the Borromean ring signature and returns the challenges of all the signatures
+func borromeanVerify(e0 []byte, s []*big.Int, pubs []ecPoint, rsizes []int, m []byte) ([]*big.Int, bool) {
+	ev := make([]*big.Int, len(pubs))
+	he0 := sha256.New()
+	count := 0
+	for i, rsize := range rsizes {
+		ens, overflow := scalarFromBytes(borromeanHash(m, e0, i, 0))
+		for j := 0; j < rsize; j++ {
+			if overflow || s[count].Sign() == 0 || ens.Sign() == 0 || pubs[count].infinity() {
+				return nil, false
+			}
+			ev[count] = ens
+			r := ecAdd(ecMul(pubs[count], ens), ecBaseMul(s[count]))
+			if r.infinity() {
+				return nil, false
+			}
+			tmp := serializeCompressedPoint(r)
+			if j != rsize-1 {
+				ens, overflow = scalarFromBytes(borromeanHash(m, tmp, i, j+1))
+			} else {
+				he0.Write(tmp)
+			}
+			count++
+		}
+	}
+	he0.Write(m)
+	return ev, bytes.Equal(he0.Sum(nil), e0)
+}
+
+// recoverX returns the secret x of the non-forged signature s = k - e * x
+func recoverX(k, e, s *big.Int) *big.Int {
+	n := btcec.S256().N
+	x := new(big.Int).Sub(k, s)
+	x.Mul(x, new(big.Int).ModInverse(e, n))
+	return x.Mod(x, n)
+}
+
+// borromeanRangeProofLayout returns the number of rings and the sizes of the rings of the Borromean range proof
+// and the length of its header, error if the proof does not have the layout of the Borromean proof
+func borromeanRangeProofLayout(proof []byte) (*RangeProofInfo, uint64, int, []int, error) {
+	ri, scale, offset, err := parseRangeProofHeader(proof)
+	if err != nil {
+		return nil, 0, 0, nil, err
+	}
+	rsizes := rangeProofRings(ri.Mantissa)
+	npub := 0
+	for _, r := range rsizes {
+		npub += r
+	}
+	rings := len(rsizes)
+	if len(proof)-offset != 32*(npub+rings-1)+32+(rings+6)>>3 {
+		return nil, 0, 0, nil, errors.New("Not a Borromean range proof")
+	}
+	return ri, scale, offset, rsizes, nil
+}
+
+// IsBorromeanRangeProof returns true if the proof has the layout of the Borromean range proof,
+// the Bulletproofs used after the fork do not have it
+func IsBorromeanRangeProof(proof []byte) bool {
+	_, _, _, _, err := borromeanRangeProofLayout(proof)
+	return err == nil
+}
+
+// RangeProofNonce returns the nonce of the range proof of blind or anon output paid to a stealth address,
+// SHA256 of the shared secret of the payment
+func RangeProofNonce(shared []byte) []byte {
+	nonce := sha256.Sum256(shared)
+	return nonce[:]
+}
+
+// RewindRangeProof recovers the value and the blinding factor of the output from its Borromean range proof
+// using the nonce of the proof and checks them against the commitment of the output
+func RewindRangeProof(proof []byte, commitment []byte, nonce []byte) (uint64, []byte, error) {
+	ri, scale, offset, rsizes, err := borromeanRangeProofLayout(proof)
+	if err != nil {
+		return 0, nil, err
+	}
+	commit, err := parseCommitment(commitment)
+	if err != nil {
+		return 0, nil, err
+	}
+	rings := len(rsizes)
+	header := proof[:offset]
+	hm := sha256.New()
+	hm.Write(serializeRangeProofPoint(commit))
+	hm.Write(serializeRangeProofPoint(generatorH))
+	hm.Write(header)
+	signs := proof[offset : offset+(rings+6)>>3]
+	offset += len(signs)
+	if (rings-1)&7 != 0 && signs[len(signs)-1]>>uint((rings-1)&7) != 0 {
+		return 0, nil, errors.New("Invalid range proof signs")
+	}
+	npub := 0
+	for _, r := range rsizes {
+		npub += r
+	}
+	pubs := make([]ecPoint, npub)
+	acc := ecPoint{}
+	if ri.MinValue != 0 {
+		acc = ecMul(generatorH, new(big.Int).SetUint64(ri.MinValue))
+	}
+	n := 0
+	for i := 0; i < rings-1; i++ {
+		c, ok := pointFromXQuad(proof[offset : offset+32])
+		if !ok {
+			return 0, nil, errors.New("Invalid range proof point")
+		}
+		sign := (signs[i>>3] >> uint(i&7)) & 1
+		if sign != 0 {
+			c = ecNeg(c)
+		}
+		hm.Write([]byte{sign})
+		hm.Write(proof[offset : offset+32])
+		pubs[n] = c
+		acc = ecAdd(acc, c)
+		offset += 32
+		n += rsizes[i]
+	}
+	pubs[n] = ecAdd(commit, ecNeg(acc))
+	if pubs[n].infinity() {
+		return 0, nil, errors.New("Invalid range proof")
+	}
+	rangeProofPubExpand(pubs, ri.Exponent, rsizes)
+	e0 := proof[offset : offset+32]
+	offset += 32
+	s := make([]*big.Int, npub)
+	for i := range s {
+		var overflow bool
+		if s[i], overflow = scalarFromBytes(proof[offset : offset+32]); overflow {
+			return 0, nil, errors.New("Invalid range proof signature")
+		}
+		offset += 32
+	}
+	ev, ok := borromeanVerify(e0, s, pubs, rsizes, hm.Sum(nil))
+	if !ok {
+		return 0, nil, errors.New("Invalid range proof")
+	}
+	// regenerate the random values of the prover, one of the forged signatures of the last ring encodes the value
+	prep := make([]byte, 32*4*rings)
+	sec, sOrig := rangeProofGenRand(nonce, commit, header, rsizes, prep)
+	var value uint64
+	var blind *big.Int
+	if rings == 1 && rsizes[0] == 1 {
+		blind = recoverX(sOrig[0], ev[0], s[0])
+	} else {
+		last := (rings - 1) * 4
+		j := 0
+		for ; j < 2; j++ {
+			idx := last + rsizes[rings-1] - 1 - j
+			tmp := scalarBytes(s[idx])
+			for b := range tmp {
+				tmp[b] ^= prep[idx*32+b]
+			}
+			if tmp[0]&0x80 != 0 && bytes.Equal(tmp[16:24], tmp[24:32]) && bytes.Equal(tmp[8:16], tmp[16:24]) {
+				value = binary.BigEndian.Uint64(tmp[24:32])
+				break
+			}
+		}
+		if j > 1 {
+			return 0, nil, errors.New("Cannot rewind range proof, wrong nonce")
+		}
+		skip1 := rsizes[rings-1] - 1 - j
+		skip2 := int((value >> uint((rings-1)<<1)) & 3)
+		if skip1 == skip2 {
+			return 0, nil, errors.New("Cannot rewind range proof, value in wrong position")
+		}
+		skip2 += last
+		// the secret of the last ring is the blinding factor minus the sum of the secrets of the other rings
+		blind = recoverX(sOrig[skip2], ev[skip2], s[skip2])
+		blind.Sub(blind, sec[rings-1])
+		blind.Mod(blind, btcec.S256().N)
+	}
+	value = value*scale + ri.MinValue
+	c := pedersenCommit(blind, value)
+	if c.infinity() || c.x.Cmp(commit.x) != 0 || c.y.Cmp(commit.y) != 0 {
+		return 0, nil, errors.New("Rewound value does not match the commitment")
+	}
+	return value, scalarBytes(blind), nil
+}
@@ -0,0 +1,215 @@
+//go:build unittest
+
+package part
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"math/bits"
+	"testing"
+
+	"github.com/martinboehm/btcd/btcec"
+)
+
+func serializeCommitment(p ecPoint) []byte {
+	b := make([]byte, commitmentSize)
+	b[0] = 9
+	if isQuad(p.y) {
+		b[0] = 8
+	}
+	p.x.FillBytes(b[1:])
+	return b
+}
+
+// signRangeProof creates the Borromean range proof of the value the way secp256k1_rangeproof_sign does,
+// so that the test can rewind proofs of any parameters
+func signRangeProof(t *testing.T, value, minValue uint64, blind, nonce []byte, exp, minBits int) ([]byte, []byte) {
+	t.Helper()
+	blindScalar := new(big.Int).SetBytes(blind)
+	commit := pedersenCommit(blindScalar, value)
+	// secp256k1_range_proveparams
+	maxBits := 64
+	if minValue != 0 {
+		maxBits = bits.LeadingZeros64(minValue)
+	}
+	if minBits > maxBits {
+		minBits = maxBits
+	}
+	v := value - minValue
+	v2 := uint64(0)
+	if minBits != 0 {
+		v2 = ^uint64(0) >> uint(64-minBits)
+	}
+	i := 0
+	for ; i < exp && v2 <= ^uint64(0)/10; i++ {
+		v /= 10
+		v2 *= 10
+	}
+	exp = i
+	scale := uint64(1)
+	v2 = v
+	for i = 0; i < exp; i++ {
+		v2 *= 10
+		scale *= 10
+	}
+	minValue = value - v2
+	mantissa := 1
+	if v != 0 {
+		mantissa = 64 - bits.LeadingZeros64(v)
+	}
+	if minBits > mantissa {
+		mantissa = minBits
+	}
+	rsizes := rangeProofRings(mantissa)
+	rings := len(rsizes)
+	secidx := make([]int, rings)
+	npub := 0
+	for i := range rsizes {
+		secidx[i] = int(v>>uint(i*2)) & 3
+		npub += rsizes[i]
+	}
+	proof := []byte{byte(64 | exp), byte(mantissa - 1)}
+	if minValue != 0 {
+		proof[0] |= 32
+		proof = append(proof, make([]byte, 8)...)
+		for i := 0; i < 8; i++ {
+			proof[2+i] = byte(minValue >> uint((7-i)*8))
+		}
+	}
+	header := append([]byte(nil), proof...)
+	hm := sha256.New()
+	hm.Write(serializeRangeProofPoint(commit))
+	hm.Write(serializeRangeProofPoint(generatorH))
+	hm.Write(header)
+	prep := make([]byte, 32*4*rings)
+	if rsizes[rings-1] > 1 {
+		idx := rsizes[rings-1] - 1
+		if secidx[rings-1] == idx {
+			idx--
+		}
+		idx = ((rings-1)*4 + idx) * 32
+		for i := 0; i < 8; i++ {
+			b := byte(v >> uint(56-i*8))
+			prep[8+i+idx], prep[16+i+idx], prep[24+i+idx] = b, b, b
+			prep[i+idx] = 0
+		}
+		prep[idx] = 128
+	}
+	sec, s := rangeProofGenRand(nonce, commit, header, rsizes, prep)
+	k := make([]*big.Int, rings)
+	for i := range rsizes {
+		k[i] = s[i*4+secidx[i]]
+		s[i*4+secidx[i]] = nil
+	}
+	sec[rings-1] = new(big.Int).Add(sec[rings-1], blindScalar)
+	sec[rings-1].Mod(sec[rings-1], btcec.S256().N)
+	signs := make([]byte, (rings+6)>>3)
+	var points []byte
+	pubs := make([]ecPoint, npub)
+	n := 0
+	for i := range rsizes {
+		pubs[n] = pedersenCommit(sec[i], (uint64(secidx[i])*scale)<<uint(i*2))
+		if i < rings-1 {
+			c := serializeRangeProofPoint(pubs[n])
+			hm.Write(c)
+			signs[i>>3] |= c[0] << uint(i&7)
+			points = append(points, c[1:]...)
+		}
+		n += rsizes[i]
+	}
+	rangeProofPubExpand(pubs, exp, rsizes)
+	m := hm.Sum(nil)
+	// secp256k1_borromean_sign
+	he0 := sha256.New()
+	n = 0
+	for i, rsize := range rsizes {
+		tmp := serializeCompressedPoint(ecBaseMul(k[i]))
+		for j := secidx[i] + 1; j < rsize; j++ {
+			ens, _ := scalarFromBytes(borromeanHash(m, tmp, i, j))
+			tmp = serializeCompressedPoint(ecAdd(ecMul(pubs[n+j], ens), ecBaseMul(s[n+j])))
+		}
+		he0.Write(tmp)
+		n += rsize
+	}
+	he0.Write(m)
+	e0 := he0.Sum(nil)
+	n = 0
+	for i, rsize := range rsizes {
+		ens, _ := scalarFromBytes(borromeanHash(m, e0, i, 0))
+		j := 0
+		for ; j < secidx[i]; j++ {
+			tmp := serializeCompressedPoint(ecAdd(ecMul(pubs[n+j], ens), ecBaseMul(s[n+j])))
+			ens, _ = scalarFromBytes(borromeanHash(m, tmp, i, j+1))
+		}
+		sj := new(big.Int).Mul(ens, sec[i])
+		sj.Sub(k[i], sj)
+		s[n+j] = sj.Mod(sj, btcec.S256().N)
+		n += rsize
+	}
+	proof = append(proof, signs...)
+	proof = append(proof, points...)
+	proof = append(proof, e0...)
+	for i := range s {
+		proof = append(proof, scalarBytes(s[i])...)
+	}
+	return proof, serializeCommitment(commit)
+}
+
+func TestGeneratorH(t *testing.T) {
+	if !btcec.S256().IsOnCurve(generatorH.x, generatorH.y) {
+		t.Fatal("generator H is not on the curve")
+	}
+	c, err := parseCommitment(serializeCommitment(generatorH))
+	if err != nil || c.y.Cmp(generatorH.y) != 0 {
+		t.Errorf("parseCommitment(H) = %v, %v", c, err)
+	}
+}
+
+func TestRewindRangeProof(t *testing.T) {
+	blind, _ := hex.DecodeString("5b1f2d3c4e6a7b8c9d0e1f2a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e")
+	nonce := RangeProofNonce([]byte("shared secret"))
+	tests := []struct {
+		name     string
+		value    uint64
+		minValue uint64
+		exp      int
+		minBits  int
+		mantissa int
+	}{
+		{name: "32 bit mantissa", value: 123456789, exp: 2, minBits: 32, mantissa: 32},
+		{name: "odd mantissa", value: 5, exp: 0, minBits: 0, mantissa: 3},
+		{name: "min value", value: 1000000007, minValue: 1000000000, exp: 0, minBits: 0, mantissa: 3},
+		{name: "zero", value: 0, exp: 0, minBits: 0, mantissa: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proof, commitment := signRangeProof(t, tt.value, tt.minValue, blind, nonce, tt.exp, tt.minBits)
+			if !IsBorromeanRangeProof(proof) {
+				t.Fatal("IsBorromeanRangeProof() = false")
+			}
+			ri, err := ParseRangeProofInfo(proof)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ri.Mantissa != tt.mantissa {
+				t.Errorf("ParseRangeProofInfo() mantissa = %d, want %d", ri.Mantissa, tt.mantissa)
+			}
+			value, b, err := RewindRangeProof(proof, commitment, nonce)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != tt.value || !bytes.Equal(b, blind) {
+				t.Errorf("RewindRangeProof() = %d, %x, want %d, %x", value, b, tt.value, blind)
+			}
+			if _, _, err := RewindRangeProof(proof, commitment, RangeProofNonce([]byte("other secret"))); err == nil {
+				t.Error("RewindRangeProof() with wrong nonce, expected error")
+			}
+			other := serializeCommitment(pedersenCommit(big.NewInt(7), tt.value))
+			if _, _, err := RewindRangeProof(proof, other, nonce); err == nil {
+				t.Error("RewindRangeProof() with other commitment, expected error")
+			}
+		})
+	}
+}
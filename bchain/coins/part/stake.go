@@ -0,0 +1,60 @@
+package part
+
+import (
+	"github.com/trezor/blockbook/bchain"
+)
+
+// Particl proof-of-stake block
+// The first transaction of a proof-of-stake block is the coinstake. Its first input spends the kernel, the output
+// which won the right to stake the block, the first output with an address other than the treasury fund returns
+// the stake with the reward to the script of the kernel. The staker of the block is derived from this output,
+// in case of cold staking the output script contains both the spend and the staking key and the block is staked
+// by the holder of the staking key.
+
+// BlockStake is the proof-of-stake data of Particl block derived from its coinstake transaction
+type BlockStake struct {
+	CoinstakeTxid string
+	KernelTxid    string
+	KernelVout    uint32
+	// StakerAddrDesc is the address staking the block, the staking address in case of cold staking
+	StakerAddrDesc bchain.AddressDescriptor
+	ColdStaked     bool
+}
+
+// BlockStakeFromTxs returns the stake data of the block with given transactions, nil for the blocks without coinstake
+func (p *ParticlParser) BlockStakeFromTxs(txs []bchain.Tx) *BlockStake {
+	for i := range txs {
+		tx := &txs[i]
+		if !IsCoinStakeTx(tx) || len(tx.Vin) == 0 {
+			continue
+		}
+		s := &BlockStake{
+			CoinstakeTxid: tx.Txid,
+			KernelTxid:    tx.Vin[0].Txid,
+			KernelVout:    tx.Vin[0].Vout,
+		}
+		for j := range tx.Vout {
+			addrDesc, err := p.GetAddrDescFromVout(&tx.Vout[j])
+			if err != nil || len(addrDesc) == 0 || p.IsTreasuryAddrDesc(addrDesc) {
+				continue
+			}
+			if _, stakingDesc := ColdStakingAddrDescs(addrDesc); stakingDesc != nil {
+				s.StakerAddrDesc = stakingDesc
+				s.ColdStaked = true
+			} else {
+				s.StakerAddrDesc = addrDesc
+			}
+			break
+		}
+		return s
+	}
+	return nil
+}
+
+// BlockStakeFromBlock returns the stake data stored in the block by the RPC or parser, derives it if it is missing
+func (p *ParticlParser) BlockStakeFromBlock(block *bchain.Block) *BlockStake {
+	if s, ok := block.CoinSpecificData.(*BlockStake); ok {
+		return s
+	}
+	return p.BlockStakeFromTxs(block.Txs)
+}
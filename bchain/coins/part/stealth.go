@@ -0,0 +1,200 @@
+package part
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math/big"
+
+	"github.com/juju/errors"
+	"github.com/martinboehm/btcd/btcec"
+	"github.com/martinboehm/btcd/wire"
+	"github.com/martinboehm/btcutil/base58"
+	"github.com/trezor/blockbook/bchain"
+)
+
+// Particl stealth address
+// The address contains the scan and spend public keys of the receiver. The sender derives a new one-time address
+// for every payment using an ephemeral key, which is stored in the data of the output, so that the payments
+// to the stealth address cannot be linked to each other or to the stealth address itself.
+// Raw format: options (1 byte), scan pubkey (33), number of spend pubkeys (1), spend pubkeys (33 each),
+// number of signatures (1), prefix number of bits (1), prefix bitfield (1-4 bytes, only if the number of bits is not zero)
+
+// stealth address version bytes
+const (
+	MainnetStealthAddressID = 0x14
+	TestnetStealthAddressID = 0x15
+)
+
+// StealthAddrDescMarker is the first byte of the address descriptor of stealth address,
+// OP_INVALIDOPCODE cannot start a valid output script, the descriptor therefore never collides with an indexed output
+const StealthAddrDescMarker = 0xff
+
+const (
+	maxStealthSpendPubKeys = 1
+	maxStealthPrefixBits   = 32
+)
+
+// StealthAddress is decoded Particl stealth address
+type StealthAddress struct {
+	Options       byte
+	ScanPubKey    []byte
+	SpendPubKeys  [][]byte
+	NumSignatures byte
+	PrefixBits    byte
+	Prefix        uint32
+}
+
+func isCompressedPubKey(b []byte) bool {
+	return len(b) == pubKeySize && (b[0] == 0x02 || b[0] == 0x03)
+}
+
+// parseStealthAddressRaw decodes the raw form of stealth address, without the version byte
+func parseStealthAddressRaw(raw []byte) (*StealthAddress, bool) {
+	// options, scan pubkey, number of spend pubkeys
+	if len(raw) < 1+pubKeySize+1 {
+		return nil, false
+	}
+	sa := &StealthAddress{
+		Options:    raw[0],
+		ScanPubKey: raw[1 : 1+pubKeySize],
+	}
+	if !isCompressedPubKey(sa.ScanPubKey) {
+		return nil, false
+	}
+	o := 1 + pubKeySize
+	n := int(raw[o])
+	o++
+	if n == 0 || n > maxStealthSpendPubKeys || len(raw) < o+n*pubKeySize+2 {
+		return nil, false
+	}
+	for i := 0; i < n; i++ {
+		pk := raw[o : o+pubKeySize]
+		if !isCompressedPubKey(pk) {
+			return nil, false
+		}
+		sa.SpendPubKeys = append(sa.SpendPubKeys, pk)
+		o += pubKeySize
+	}
+	sa.NumSignatures = raw[o]
+	sa.PrefixBits = raw[o+1]
+	o += 2
+	if sa.PrefixBits > maxStealthPrefixBits {
+		return nil, false
+	}
+	prefixBytes := (int(sa.PrefixBits) + 7) / 8
+	if len(raw) != o+prefixBytes {
+		return nil, false
+	}
+	var bitfield [4]byte
+	copy(bitfield[:], raw[o:])
+	sa.Prefix = binary.LittleEndian.Uint32(bitfield[:])
+	return sa, true
+}
+
+// StealthAddressFromAddrDesc returns the decoded stealth address if the address descriptor belongs to a stealth address
+func StealthAddressFromAddrDesc(addrDesc bchain.AddressDescriptor) *StealthAddress {
+	if len(addrDesc) == 0 || addrDesc[0] != StealthAddrDescMarker {
+		return nil
+	}
+	sa, ok := parseStealthAddressRaw(addrDesc[1:])
+	if !ok {
+		return nil
+	}
+	return sa
+}
+
+func stealthAddressID(net wire.BitcoinNet) byte {
+	if net == MainnetMagic {
+		return MainnetStealthAddressID
+	}
+	return TestnetStealthAddressID
+}
+
+// stealthAddrDesc returns the address descriptor of stealth address or nil if the address is not a valid stealth address
+func (p *ParticlParser) stealthAddrDesc(version byte, payload []byte) bchain.AddressDescriptor {
+	if version != stealthAddressID(p.Params.Net) {
+		return nil
+	}
+	if _, ok := parseStealthAddressRaw(payload); !ok {
+		return nil
+	}
+	return append(bchain.AddressDescriptor{StealthAddrDescMarker}, payload...)
+}
+
+// stealthAddress encodes the address descriptor of stealth address to its string form
+func (p *ParticlParser) stealthAddress(addrDesc bchain.AddressDescriptor) (string, bool) {
+	if StealthAddressFromAddrDesc(addrDesc) == nil {
+		return "", false
+	}
+	return base58.CheckEncode(addrDesc[1:], []byte{stealthAddressID(p.Params.Net)}, base58.Sha256D), true
+}
+
+// StealthEphemeralPubKey returns the ephemeral public key stored in the data of the output.
+// Blind and anon outputs start their data with the key, the standard outputs paid to stealth address
+// have it in a separate data output with DOStealth prefix.
+func StealthEphemeralPubKey(outputType byte, data []byte) []byte {
+	switch outputType {
+	case OutputCT, OutputRingCT:
+		if len(data) >= pubKeySize && isCompressedPubKey(data[:pubKeySize]) {
+			return data[:pubKeySize]
+		}
+	case OutputData:
+		if len(data) >= 1+pubKeySize && data[0] == DOStealth && isCompressedPubKey(data[1:1+pubKeySize]) {
+			return data[1 : 1+pubKeySize]
+		}
+	}
+	return nil
+}
+
+// StealthScanKey recognizes the outputs paid to a stealth address using the scan secret of the address
+type StealthScanKey struct {
+	scanSecret  []byte
+	spendPubKey *btcec.PublicKey
+}
+
+// NewStealthScanKey returns the key for scanning of the outputs paid to the stealth address,
+// the scan secret must match the scan public key of the address
+func NewStealthScanKey(sa *StealthAddress, scanSecret []byte) (*StealthScanKey, error) {
+	curve := btcec.S256()
+	d := new(big.Int).SetBytes(scanSecret)
+	if len(scanSecret) != 32 || d.Sign() == 0 || d.Cmp(curve.N) >= 0 {
+		return nil, errors.New("Invalid scan secret")
+	}
+	_, scanPubKey := btcec.PrivKeyFromBytes(curve, scanSecret)
+	if !bytes.Equal(scanPubKey.SerializeCompressed(), sa.ScanPubKey) {
+		return nil, errors.New("Scan secret does not match the stealth address")
+	}
+	if len(sa.SpendPubKeys) == 0 {
+		return nil, errors.New("Stealth address without spend public key")
+	}
+	spendPubKey, err := btcec.ParsePubKey(sa.SpendPubKeys[0], curve)
+	if err != nil {
+		return nil, err
+	}
+	return &StealthScanKey{
+		scanSecret:  scanSecret,
+		spendPubKey: spendPubKey,
+	}, nil
+}
+
+// DestinationPubKey returns the one-time public key of the payment with the given ephemeral public key
+// and the shared secret, which added to the spend secret gives the private key of the payment.
+// The shared secret is SHA256 of the compressed point scan secret * ephemeral key,
+// the one-time key is spend public key + shared secret * G.
+func (k *StealthScanKey) DestinationPubKey(ephemPubKey []byte) ([]byte, []byte, error) {
+	curve := btcec.S256()
+	ephem, err := btcec.ParsePubKey(ephemPubKey, curve)
+	if err != nil {
+		return nil, nil, err
+	}
+	x, y := curve.ScalarMult(ephem.X, ephem.Y, k.scanSecret)
+	shared := sha256.Sum256((&btcec.PublicKey{Curve: curve, X: x, Y: y}).SerializeCompressed())
+	c := new(big.Int).SetBytes(shared[:])
+	if c.Sign() == 0 || c.Cmp(curve.N) >= 0 {
+		return nil, nil, errors.New("Invalid shared secret")
+	}
+	cx, cy := curve.ScalarBaseMult(shared[:])
+	x, y = curve.Add(k.spendPubKey.X, k.spendPubKey.Y, cx, cy)
+	return (&btcec.PublicKey{Curve: curve, X: x, Y: y}).SerializeCompressed(), shared[:], nil
+}
@@ -0,0 +1,45 @@
+package part
+
+import (
+	"github.com/golang/glog"
+	"github.com/martinboehm/btcd/wire"
+	"github.com/trezor/blockbook/bchain"
+)
+
+// Particl treasury fund
+// A share of the staking reward belongs to the treasury fund. The coinstake transactions pay it to the treasury address
+// once per period of blocks, in the blocks between the payouts the accrued amount is carried forward
+// in the coinstake data output (DODevFundCfwd).
+
+// treasuryAddresses are the treasury fund addresses from the Particl chain params, in the order of their activation,
+// they can be overridden by the treasury_addresses parameter of the coin configuration
+var treasuryAddresses = map[wire.BitcoinNet][]string{
+	MainnetMagic: {"RJAPhgckEgRGVPZa9WoGSWW24spskSfLTQ", "RBiiQBnQsVPPQkUaJVQTjsZM9K2xMKozST", "RQYUDd3EJohpjq62So4ftcV5XZfxZxJPe9"},
+	TestnetMagic: {"rTvv9vsbu269mjYYEecPYinDG8Bt7D86qD"},
+	RegtestMagic: {"pqZDE7YNWv5PJWidiaEG8tqfebkd6PNZDV"},
+}
+
+// SetTreasuryAddresses replaces the treasury fund addresses of the network and converts them to address descriptors
+func (p *ParticlParser) SetTreasuryAddresses(addresses []string) {
+	p.treasuryAddresses = addresses
+	p.treasuryAddrDescs = make(map[string]struct{}, len(addresses))
+	for _, a := range addresses {
+		addrDesc, err := p.GetAddrDescFromAddress(a)
+		if err != nil {
+			glog.Error("Invalid treasury address ", a, ": ", err)
+			continue
+		}
+		p.treasuryAddrDescs[string(addrDesc)] = struct{}{}
+	}
+}
+
+// TreasuryAddresses returns the treasury fund addresses of the network
+func (p *ParticlParser) TreasuryAddresses() []string {
+	return p.treasuryAddresses
+}
+
+// IsTreasuryAddrDesc returns true if the address descriptor belongs to a treasury fund address
+func (p *ParticlParser) IsTreasuryAddrDesc(addrDesc bchain.AddressDescriptor) bool {
+	_, found := p.treasuryAddrDescs[string(addrDesc)]
+	return found
+}
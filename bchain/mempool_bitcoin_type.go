@@ -3,6 +3,7 @@ package bchain
 import (
 	"encoding/hex"
 	"math/big"
+	"sync"
 	"time"
 
 	"github.com/golang/glog"
@@ -23,6 +24,8 @@ type MempoolBitcoinType struct {
 	golombFilterP       uint8
 	filterScripts       string
 	useZeroedKey        bool
+	// resyncMux serializes Resync and AddTransaction
+	resyncMux sync.Mutex
 }
 
 // NewMempoolBitcoinType creates new mempool handler.
@@ -43,7 +46,7 @@ func NewMempoolBitcoinType(chain BlockChain, workers int, subworkers int, golomb
 	for i := 0; i < workers; i++ {
 		go func(i int) {
 			chanInput := make(chan chanInputPayload, 1)
-			chanResult := make(chan *addrIndex, 1)
+			chanResult := make(chan []addrIndex, 1)
 			for j := 0; j < subworkers; j++ {
 				go func(j int) {
 					for payload := range chanInput {
@@ -65,7 +68,8 @@ func NewMempoolBitcoinType(chain BlockChain, workers int, subworkers int, golomb
 	return m
 }
 
-func (m *MempoolBitcoinType) getInputAddress(payload *chanInputPayload) *addrIndex {
+// getInputAddress returns the indexes of the addresses of the input, the outputs of some coins are indexed under several addresses
+func (m *MempoolBitcoinType) getInputAddress(payload *chanInputPayload) []addrIndex {
 	var addrDesc AddressDescriptor
 	var value *big.Int
 	vin := &payload.tx.Vin[payload.index]
@@ -95,8 +99,12 @@ func (m *MempoolBitcoinType) getInputAddress(payload *chanInputPayload) *addrInd
 	}
 	vin.AddrDesc = addrDesc
 	vin.ValueSat = *value
-	return &addrIndex{string(addrDesc), ^int32(vin.Vout)}
-
+	descs := m.chain.GetChainParser().GetIndexedAddrDescs(addrDesc)
+	ai := make([]addrIndex, len(descs))
+	for i := range descs {
+		ai[i] = addrIndex{string(descs[i]), ^int32(vin.Vout)}
+	}
+	return ai
 }
 
 func (m *MempoolBitcoinType) computeGolombFilter(mtx *MempoolTx, tx *Tx) string {
@@ -117,57 +125,64 @@ func (m *MempoolBitcoinType) computeGolombFilter(mtx *MempoolTx, tx *Tx) string
 	return hex.EncodeToString(fb)
 }
 
-func (m *MempoolBitcoinType) getTxAddrs(txid string, chanInput chan chanInputPayload, chanResult chan *addrIndex) ([]addrIndex, string, bool) {
+func (m *MempoolBitcoinType) getTxAddrs(txid string, chanInput chan chanInputPayload, chanResult chan []addrIndex) ([]addrIndex, string, bool) {
 	tx, err := m.chain.GetTransactionForMempool(txid)
 	if err != nil {
 		glog.Error("cannot get transaction ", txid, ": ", err)
 		return nil, "", false
 	}
 	glog.V(2).Info("mempool: gettxaddrs ", txid, ", ", len(tx.Vin), " inputs")
+	io, golombFilter := m.txAddrs(tx, func(mtx *MempoolTx, io []addrIndex) []addrIndex {
+		dispatched := 0
+		for i := range tx.Vin {
+			input := &tx.Vin[i]
+			if input.Coinbase != "" {
+				continue
+			}
+			payload := chanInputPayload{mtx, i}
+		loop:
+			for {
+				select {
+				// store as many processed results as possible
+				case ai := <-chanResult:
+					io = append(io, ai...)
+					dispatched--
+				// send input to be processed
+				case chanInput <- payload:
+					dispatched++
+					break loop
+				}
+			}
+		}
+		for i := 0; i < dispatched; i++ {
+			ai := <-chanResult
+			io = append(io, ai...)
+		}
+		return io
+	})
+	return io, golombFilter, true
+}
+
+// txAddrs maps the outputs and inputs of the transaction to addresses, the addresses of the inputs are resolved by the function inputs
+func (m *MempoolBitcoinType) txAddrs(tx *Tx, inputs func(mtx *MempoolTx, io []addrIndex) []addrIndex) ([]addrIndex, string) {
 	mtx := m.txToMempoolTx(tx)
 	io := make([]addrIndex, 0, len(tx.Vout)+len(tx.Vin))
 	for _, output := range tx.Vout {
 		addrDesc, err := m.chain.GetChainParser().GetAddrDescFromVout(&output)
 		if err != nil {
-			glog.Error("error in addrDesc in ", txid, " ", output.N, ": ", err)
+			glog.Error("error in addrDesc in ", tx.Txid, " ", output.N, ": ", err)
 			continue
 		}
 		if len(addrDesc) > 0 {
-			io = append(io, addrIndex{string(addrDesc), int32(output.N)})
+			for _, ad := range m.chain.GetChainParser().GetIndexedAddrDescs(addrDesc) {
+				io = append(io, addrIndex{string(ad), int32(output.N)})
+			}
 		}
 		if m.OnNewTxAddr != nil {
 			m.OnNewTxAddr(tx, addrDesc)
 		}
 	}
-	dispatched := 0
-	for i := range tx.Vin {
-		input := &tx.Vin[i]
-		if input.Coinbase != "" {
-			continue
-		}
-		payload := chanInputPayload{mtx, i}
-	loop:
-		for {
-			select {
-			// store as many processed results as possible
-			case ai := <-chanResult:
-				if ai != nil {
-					io = append(io, *ai)
-				}
-				dispatched--
-			// send input to be processed
-			case chanInput <- payload:
-				dispatched++
-				break loop
-			}
-		}
-	}
-	for i := 0; i < dispatched; i++ {
-		ai := <-chanResult
-		if ai != nil {
-			io = append(io, *ai)
-		}
-	}
+	io = inputs(mtx, io)
 	var golombFilter string
 	if m.golombFilterP > 0 {
 		golombFilter = m.computeGolombFilter(mtx, tx)
@@ -175,13 +190,65 @@ func (m *MempoolBitcoinType) getTxAddrs(txid string, chanInput chan chanInputPay
 	if m.OnNewTx != nil {
 		m.OnNewTx(mtx)
 	}
-	return io, golombFilter, true
+	return io, golombFilter
+}
+
+func (m *MempoolBitcoinType) addEntry(txid string, entry txEntry) {
+	if len(entry.addrIndexes) > 0 {
+		m.mux.Lock()
+		m.txEntries[txid] = entry
+		for _, si := range entry.addrIndexes {
+			m.addrDescToTx[si.addrDesc] = append(m.addrDescToTx[si.addrDesc], Outpoint{txid, si.n})
+		}
+		m.mux.Unlock()
+	}
+}
+
+// AddTransaction adds the transaction received in the payload of ZeroMQ rawtx notification to the mempool
+// without fetching it from the backend. The addresses of the inputs are resolved synchronously.
+// The transaction is removed by the next Resync if it is not in the mempool of the backend.
+func (m *MempoolBitcoinType) AddTransaction(tx *Tx) {
+	m.resyncMux.Lock()
+	defer m.resyncMux.Unlock()
+	m.mux.Lock()
+	_, exists := m.txEntries[tx.Txid]
+	m.mux.Unlock()
+	if exists {
+		return
+	}
+	glog.V(2).Info("mempool: addtransaction ", tx.Txid, ", ", len(tx.Vin), " inputs")
+	io, golombFilter := m.txAddrs(tx, func(mtx *MempoolTx, io []addrIndex) []addrIndex {
+		for i := range tx.Vin {
+			if tx.Vin[i].Coinbase != "" {
+				continue
+			}
+			io = append(io, m.getInputAddress(&chanInputPayload{mtx, i})...)
+		}
+		return io
+	})
+	m.addEntry(tx.Txid, txEntry{io, uint32(time.Now().Unix()), golombFilter})
+}
+
+// RemoveTransactions removes the transactions confirmed in the block received in ZeroMQ rawblock notification from the mempool
+// without the full resynchronization of the mempool.
+func (m *MempoolBitcoinType) RemoveTransactions(txids []string) {
+	m.resyncMux.Lock()
+	defer m.resyncMux.Unlock()
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	for _, txid := range txids {
+		if entry, exists := m.txEntries[txid]; exists {
+			m.removeEntryFromMempool(txid, entry)
+		}
+	}
 }
 
 // Resync gets mempool transactions and maps outputs to transactions.
 // Resync is not reentrant, it should be called from a single thread.
 // Read operations (GetTransactions) are safe.
 func (m *MempoolBitcoinType) Resync() (int, error) {
+	m.resyncMux.Lock()
+	defer m.resyncMux.Unlock()
 	start := time.Now()
 	glog.V(1).Info("mempool: resync")
 	txs, err := m.chain.GetMempoolTransactions()
@@ -189,16 +256,6 @@ func (m *MempoolBitcoinType) Resync() (int, error) {
 		return 0, err
 	}
 	glog.V(2).Info("mempool: resync ", len(txs), " txs")
-	onNewEntry := func(txid string, entry txEntry) {
-		if len(entry.addrIndexes) > 0 {
-			m.mux.Lock()
-			m.txEntries[txid] = entry
-			for _, si := range entry.addrIndexes {
-				m.addrDescToTx[si.addrDesc] = append(m.addrDescToTx[si.addrDesc], Outpoint{txid, si.n})
-			}
-			m.mux.Unlock()
-		}
-	}
 	txsMap := make(map[string]struct{}, len(txs))
 	dispatched := 0
 	txTime := uint32(time.Now().Unix())
@@ -212,7 +269,7 @@ func (m *MempoolBitcoinType) Resync() (int, error) {
 				select {
 				// store as many processed transactions as possible
 				case tio := <-m.chanAddrIndex:
-					onNewEntry(tio.txid, txEntry{tio.io, txTime, tio.filter})
+					m.addEntry(tio.txid, txEntry{tio.io, txTime, tio.filter})
 					dispatched--
 				// send transaction to be processed
 				case m.chanTxid <- txid:
@@ -224,7 +281,7 @@ func (m *MempoolBitcoinType) Resync() (int, error) {
 	}
 	for i := 0; i < dispatched; i++ {
 		tio := <-m.chanAddrIndex
-		onNewEntry(tio.txid, txEntry{tio.io, txTime, tio.filter})
+		m.addEntry(tio.txid, txEntry{tio.io, txTime, tio.filter})
 	}
 
 	for txid, entry := range m.txEntries {
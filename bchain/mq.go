@@ -11,11 +11,14 @@ import (
 
 // MQ is message queue listener handle
 type MQ struct {
-	context   *zmq.Context
-	socket    *zmq.Socket
-	isRunning bool
-	finished  chan error
-	binding   string
+	context    *zmq.Context
+	socket     *zmq.Socket
+	isRunning  bool
+	finished   chan error
+	binding    string
+	topics     []string
+	rawHandler MQRawHandler
+	sequences  map[string]uint32
 }
 
 // NotificationType is type of notification
@@ -30,9 +33,28 @@ const (
 	NotificationNewTx NotificationType = iota
 )
 
+// MQRawHandler processes the payload of rawtx or rawblock notification,
+// returns false if the payload could not be processed
+type MQRawHandler func(nt NotificationType, payload []byte) bool
+
 // NewMQ creates new Bitcoind ZeroMQ listener
 // callback function receives messages
 func NewMQ(binding string, callback func(NotificationType)) (*MQ, error) {
+	// on each notification we do sync or syncmempool respectively
+	return newMQ(binding, []string{"hashblock", "hashtx"}, callback, nil)
+}
+
+// NewRawMQ creates new Bitcoind ZeroMQ listener of rawblock and rawtx notifications, their payloads are passed to rawHandler.
+// The sequence numbers of the notifications are tracked, callback receives NotificationNewTx only if some rawtx
+// notifications were lost or not processed, so that the mempool is resynchronized from the backend.
+// After each block callback receives NotificationNewBlock, the raw handler removes the confirmed transactions from the mempool.
+// It is followed by NotificationNewTx, which resynchronizes the mempool, only if the block was not processed
+// or some notifications were lost.
+func NewRawMQ(binding string, callback func(NotificationType), rawHandler MQRawHandler) (*MQ, error) {
+	return newMQ(binding, []string{"rawblock", "rawtx"}, callback, rawHandler)
+}
+
+func newMQ(binding string, topics []string, callback func(NotificationType), rawHandler MQRawHandler) (*MQ, error) {
 	context, err := zmq.NewContext()
 	if err != nil {
 		return nil, err
@@ -41,28 +63,69 @@ func NewMQ(binding string, callback func(NotificationType)) (*MQ, error) {
 	if err != nil {
 		return nil, err
 	}
-	err = socket.SetSubscribe("hashblock")
-	if err != nil {
-		return nil, err
-	}
-	err = socket.SetSubscribe("hashtx")
-	if err != nil {
-		return nil, err
+	for _, topic := range topics {
+		err = socket.SetSubscribe(topic)
+		if err != nil {
+			return nil, err
+		}
 	}
-	// for now do not use raw subscriptions - we would have to handle skipped/lost notifications from zeromq
-	// on each notification we do sync or syncmempool respectively
-	// socket.SetSubscribe("rawblock")
-	// socket.SetSubscribe("rawtx")
 	err = socket.Connect(binding)
 	if err != nil {
 		return nil, err
 	}
-	glog.Info("MQ listening to ", binding)
-	mq := &MQ{context, socket, true, make(chan error), binding}
+	glog.Info("MQ listening to ", binding, ", topics ", topics)
+	mq := &MQ{
+		context:    context,
+		socket:     socket,
+		isRunning:  true,
+		finished:   make(chan error),
+		binding:    binding,
+		topics:     topics,
+		rawHandler: rawHandler,
+		sequences:  make(map[string]uint32),
+	}
 	go mq.run(callback)
 	return mq, nil
 }
 
+// checkSequence stores the sequence number of the notification of the topic,
+// returns false if it does not follow the previous notification of the same topic, i.e. some notifications were lost
+func (mq *MQ) checkSequence(topic string, msg [][]byte) bool {
+	if len(msg[len(msg)-1]) != 4 {
+		return true
+	}
+	sequence := binary.LittleEndian.Uint32(msg[len(msg)-1])
+	last, found := mq.sequences[topic]
+	mq.sequences[topic] = sequence
+	if found && sequence != last+1 {
+		glog.Warningf("MQ: %s sequence gap, expected %d, received %d", topic, last+1, sequence)
+		return false
+	}
+	return true
+}
+
+// processRaw passes the payload of raw notification to the raw handler and sends the resulting notifications to callback
+func (mq *MQ) processRaw(topic string, msg [][]byte, callback func(NotificationType)) {
+	inSequence := mq.checkSequence(topic, msg)
+	switch topic {
+	case "rawblock":
+		processed := mq.rawHandler(NotificationNewBlock, msg[1])
+		callback(NotificationNewBlock)
+		// the raw handler removes the transactions of the block from the mempool,
+		// full mempool resync is necessary only if the block was not processed or a notification was missed
+		if !processed || !inSequence {
+			glog.V(1).Info("MQ: rawblock mempool resync, processed ", processed, ", in sequence ", inSequence)
+			callback(NotificationNewTx)
+		}
+	case "rawtx":
+		if !mq.rawHandler(NotificationNewTx, msg[1]) || !inSequence {
+			callback(NotificationNewTx)
+		}
+	default:
+		glog.Infof("MQ: NotificationUnknown %v", topic)
+	}
+}
+
 func (mq *MQ) run(callback func(NotificationType)) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -93,6 +156,10 @@ func (mq *MQ) run(callback func(NotificationType)) {
 			repeatedError = false
 		}
 		if len(msg) >= 3 {
+			if mq.rawHandler != nil {
+				mq.processRaw(string(msg[0]), msg, callback)
+				continue
+			}
 			var nt NotificationType
 			switch string(msg[0]) {
 			case "hashblock":
@@ -121,13 +188,11 @@ func (mq *MQ) Shutdown(ctx context.Context) error {
 	if mq.isRunning {
 		go func() {
 			// if errors in the closing sequence, let it close ungracefully
-			if err := mq.socket.SetUnsubscribe("hashtx"); err != nil {
-				mq.finished <- err
-				return
-			}
-			if err := mq.socket.SetUnsubscribe("hashblock"); err != nil {
-				mq.finished <- err
-				return
+			for i := len(mq.topics) - 1; i >= 0; i-- {
+				if err := mq.socket.SetUnsubscribe(mq.topics[i]); err != nil {
+					mq.finished <- err
+					return
+				}
 			}
 			if err := mq.socket.Unbind(mq.binding); err != nil {
 				mq.finished <- err
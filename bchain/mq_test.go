@@ -0,0 +1,63 @@
+//go:build unittest
+
+package bchain
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func mqTestMessage(topic string, payload string, sequence uint32) [][]byte {
+	s := make([]byte, 4)
+	binary.LittleEndian.PutUint32(s, sequence)
+	return [][]byte{[]byte(topic), []byte(payload), s}
+}
+
+func TestMQ_processRaw(t *testing.T) {
+	var payloads []string
+	processed := true
+	mq := &MQ{
+		sequences: make(map[string]uint32),
+		rawHandler: func(nt NotificationType, payload []byte) bool {
+			payloads = append(payloads, string(payload))
+			return processed
+		},
+	}
+	tests := []struct {
+		name      string
+		msg       [][]byte
+		processed bool
+		want      []NotificationType
+	}{
+		{name: "first rawtx", msg: mqTestMessage("rawtx", "tx1", 10), processed: true},
+		{name: "rawtx in sequence", msg: mqTestMessage("rawtx", "tx2", 11), processed: true},
+		{name: "rawblock with own sequence", msg: mqTestMessage("rawblock", "block1", 5), processed: true, want: []NotificationType{NotificationNewBlock}},
+		{name: "rawtx gap", msg: mqTestMessage("rawtx", "tx3", 13), processed: true, want: []NotificationType{NotificationNewTx}},
+		{name: "rawtx after gap", msg: mqTestMessage("rawtx", "tx4", 14), processed: true},
+		{name: "rawtx not processed", msg: mqTestMessage("rawtx", "tx5", 15), processed: false, want: []NotificationType{NotificationNewTx}},
+		{name: "rawtx sequence restarted", msg: mqTestMessage("rawtx", "tx6", 0), processed: true, want: []NotificationType{NotificationNewTx}},
+		{name: "rawblock gap", msg: mqTestMessage("rawblock", "block2", 7), processed: true, want: []NotificationType{NotificationNewBlock, NotificationNewTx}},
+		{name: "rawblock not processed", msg: mqTestMessage("rawblock", "block3", 8), processed: false, want: []NotificationType{NotificationNewBlock, NotificationNewTx}},
+		{name: "rawblock in sequence", msg: mqTestMessage("rawblock", "block4", 9), processed: true, want: []NotificationType{NotificationNewBlock}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []NotificationType
+			processed = tt.processed
+			payloads = nil
+			mq.processRaw(string(tt.msg[0]), tt.msg, func(nt NotificationType) {
+				got = append(got, nt)
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("processRaw() notifications = %v, want %v", got, tt.want)
+			}
+			if len(payloads) != 1 || payloads[0] != string(tt.msg[1]) {
+				t.Errorf("processRaw() payloads = %v, want [%s]", payloads, tt.msg[1])
+			}
+		})
+	}
+	if mq.sequences["rawtx"] != 0 || mq.sequences["rawblock"] != 9 {
+		t.Errorf("sequences = %v", mq.sequences)
+	}
+}
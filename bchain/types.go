@@ -60,9 +60,10 @@ type Vin struct {
 	Witness   [][]byte  `json:"-" ts_doc:"Witness data for SegWit inputs (not exposed via JSON)."`
 
 	// Particl privacy transaction fields for anon (RingCT) inputs
-	InputType   string `json:"type,omitempty" ts_doc:"Input type for Particl: 'standard' or 'anon'."`
-	AnonInputs  uint32 `json:"num_inputs,omitempty" ts_doc:"Number of real inputs in RingCT transaction."`
-	RingSize    uint32 `json:"ring_size,omitempty" ts_doc:"Size of the ring (decoy set) for RingCT inputs."`
+	InputType  string   `json:"type,omitempty" ts_doc:"Input type for Particl: 'standard' or 'anon'."`
+	AnonInputs uint32   `json:"num_inputs,omitempty" ts_doc:"Number of real inputs in RingCT transaction."`
+	RingSize   uint32   `json:"ring_size,omitempty" ts_doc:"Size of the ring (decoy set) for RingCT inputs."`
+	KeyImages  []string `json:"key_images,omitempty" ts_doc:"Key images (hex) of the real inputs of RingCT input."`
 }
 
 // ScriptPubKey contains data about output script
@@ -85,6 +86,8 @@ type Vout struct {
 	ValueCommitment   string `json:"valueCommitment,omitempty" ts_doc:"Pedersen commitment for blind/anon outputs (hex)."`
 	Data              string `json:"data,omitempty" ts_doc:"Ephemeral public key for CT outputs (hex)."`
 	RangeProof        string `json:"rangeproof,omitempty" ts_doc:"Bulletproof range proof for CT outputs (hex)."`
+	PubKey            string `json:"pubkey,omitempty" ts_doc:"Public key of anon output (hex)."`
+	AnonIndex         int64  `json:"anon_index,omitempty" ts_doc:"Global index of anon output, if known."`
 }
 
 // Tx is blockchain transaction
@@ -222,6 +225,25 @@ type ChainInfo struct {
 	Consensus        interface{} `json:"consensus,omitempty" ts_doc:"Additional consensus details, structure depends on chain."`
 }
 
+// StakingInfo contains the staking state of proof-of-stake backend
+type StakingInfo struct {
+	Enabled           bool              `json:"enabled" ts_doc:"True if staking is enabled in the backend wallet."`
+	Staking           bool              `json:"staking" ts_doc:"True if the backend wallet is currently staking."`
+	PercentYearReward common.JSONNumber `json:"percentyearreward" ts_doc:"Annual staking reward in percent."`
+	MoneySupply       common.JSONNumber `json:"moneysupply" ts_doc:"Money supply of the network."`
+	Difficulty        common.JSONNumber `json:"difficulty" ts_doc:"Current proof-of-stake difficulty."`
+	NetStakeWeight    common.JSONNumber `json:"netstakeweight" ts_doc:"Estimated stake weight of the network (in satoshi)."`
+}
+
+// AnonOutput is RingCT anon output identified by its global index in the backend
+type AnonOutput struct {
+	Index       uint64 `json:"index"`
+	PubKey      string `json:"publickey"`
+	Txid        string `json:"txnhash"`
+	Vout        uint32 `json:"n"`
+	BlockHeight uint32 `json:"blockheight"`
+}
+
 // LongTermFeeRate gets information about the fee rate over longer period of time.
 type LongTermFeeRate struct {
 	FeePerUnit big.Int `json:"feePerUnit" ts_doc:"Long term fee rate (in sat/kByte)."`
@@ -276,6 +298,8 @@ const (
 	P2SHWPKH
 	P2WPKH
 	P2TR
+	// P2PKH256 is Particl P2PKH with 256-bit hash of the public key (OP_DUP OP_SHA256 <32 bytes> OP_EQUALVERIFY OP_CHECKSIG)
+	P2PKH256
 )
 
 // XpubDescriptor contains parsed data from xpub descriptor
@@ -327,6 +351,8 @@ type BlockChain interface {
 	GetSubversion() string
 	GetCoinName() string
 	GetChainInfo() (*ChainInfo, error)
+	GetStakingInfo() (*StakingInfo, error)
+	GetAnonOutput(index uint64) (*AnonOutput, error)
 	// requests
 	GetBestBlockHash() (string, error)
 	GetBestBlockHeight() (uint32, error)
@@ -335,6 +361,8 @@ type BlockChain interface {
 	GetBlock(hash string, height uint32) (*Block, error)
 	GetBlockInfo(hash string) (*BlockInfo, error)
 	GetBlockRaw(hash string) (string, error)
+	// blocks received in the notifications of the backend since the last call, to be connected without requests
+	GetNotifiedBlocks() []*Block
 	GetMempoolTransactions() ([]string, error)
 	GetTransaction(txid string) (*Tx, error)
 	GetTransactionForMempool(txid string) (*Tx, error)
@@ -386,6 +414,8 @@ type BlockChainParser interface {
 	GetAddressesFromAddrDesc(addrDesc AddressDescriptor) ([]string, bool, error)
 	GetScriptFromAddrDesc(addrDesc AddressDescriptor) ([]byte, error)
 	IsAddrDescIndexable(addrDesc AddressDescriptor) bool
+	// GetIndexedAddrDescs returns the address descriptors under which the output is indexed, the first one owns the output
+	GetIndexedAddrDescs(addrDesc AddressDescriptor) []AddressDescriptor
 	// transactions
 	PackedTxidLen() int
 	PackTxid(txid string) ([]byte, error)
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"log"
 	"math/rand"
@@ -74,6 +75,8 @@ var (
 
 	enableSubNewTx = flag.Bool("enablesubnewtx", false, "enable support for subscribing to all new transactions")
 
+	enableStealthScan = flag.Bool("enablestealthscan", false, "enable scanning for outputs paid to Particl stealth addresses using the scan key submitted by the client")
+
 	computeColumnStats  = flag.Bool("computedbstats", false, "compute column stats and exit")
 	computeFeeStatsFlag = flag.Bool("computefeestats", false, "compute fee stats for blocks in blockheight-blockuntil range and exit")
 	dbStatsPeriodHours  = flag.Int("dbstatsperiod", 24, "period of db stats collection in hours, 0 disables stats collection")
@@ -85,6 +88,17 @@ var (
 	resyncMempoolPeriodMs = flag.Int("resyncmempoolperiod", 60017, "resync mempool period in milliseconds")
 
 	extendedIndex = flag.Bool("extendedindex", false, "if true, create index of input txids and spending transactions")
+
+	createSnapshot  = flag.String("createsnapshot", "", "create snapshot archive of the database in the given file and exit")
+	snapshotDir     = flag.String("snapshotdir", "", "directory of the database snapshot archives created by the internal admin interface (default snapshots disabled)")
+	restoreSnapshot = flag.String("restoresnapshot", "", "restore the database in -datadir from the snapshot archive in the given file before start")
+
+	secondary     = flag.Bool("secondary", false, "open the database in -datadir of the running primary instance as read only secondary instance and serve the API from it")
+	secondaryDir  = flag.String("secondarydir", "", "directory for the files of the secondary instance (default temporary directory)")
+	replicaSocket = flag.String("replicasocket", "", "path of the unix socket on which the primary instance publishes notifications to the secondary instances (default no notifications)")
+
+	watchOnly     = flag.Bool("watchonly", false, "index only the addresses and xpubs in the watchlist managed by the internal admin interface")
+	watchlistFile = flag.String("watchlist", "", "json file with the addresses and xpubs added to the watchlist at start, implies -watchonly")
 )
 
 var (
@@ -106,6 +120,7 @@ var (
 	callbacksOnNewTxAddr          []bchain.OnNewTxAddrFunc
 	callbacksOnNewTx              []bchain.OnNewTxFunc
 	callbacksOnNewFiatRatesTicker []fiat.OnNewFiatRatesTicker
+	callbacksOnReorg              []db.OnReorgFunc
 	chanOsSignal                  chan os.Signal
 )
 
@@ -138,6 +153,15 @@ func mainWithExitCode() int {
 
 	glog.Infof("Blockbook: %+v, debug mode %v", common.GetVersionInfo(), *debugMode)
 
+	if *secondary && (*synchronize || *repair || *fixUtxo || *rollbackHeight >= 0 || *createSnapshot != "" || *restoreSnapshot != "") {
+		glog.Error("secondary: -secondary cannot be combined with -sync, -repair, -fixutxo, -rollback, -createsnapshot or -restoresnapshot")
+		return exitCodeFatal
+	}
+	if *secondary && (*watchOnly || *watchlistFile != "") {
+		glog.Error("secondary: the watch-only mode is set by the primary instance, -watchonly and -watchlist cannot be used")
+		return exitCodeFatal
+	}
+
 	if *prof != "" {
 		go func() {
 			log.Println(http.ListenAndServe(*prof, nil))
@@ -164,26 +188,59 @@ func mainWithExitCode() int {
 		return exitCodeFatal
 	}
 
-	if chain, mempool, err = getBlockChainWithRetry(config.CoinName, *configFile, pushSynchronizationHandler, metrics, 120); err != nil {
+	pushHandler := pushSynchronizationHandler
+	if *secondary {
+		// the secondary instance is notified by the primary instance, it does not subscribe to the notifications of the backend
+		pushHandler = nil
+	}
+	if chain, mempool, err = getBlockChainWithRetry(config.CoinName, *configFile, pushHandler, metrics, 120); err != nil {
 		glog.Error("rpc: ", err)
 		return exitCodeFatal
 	}
 
-	index, err = db.NewRocksDB(*dbPath, *dbCache, *dbMaxOpenFiles, chain.GetChainParser(), metrics, *extendedIndex)
+	if *restoreSnapshot != "" {
+		si, err := db.RestoreSnapshot(*restoreSnapshot, *dbPath, *dbMaxOpenFiles, chain, config, *extendedIndex)
+		if err != nil {
+			glog.Error("restoreSnapshot: ", err)
+			return exitCodeFatal
+		}
+		glog.Infof("restoreSnapshot: database restored from snapshot of block %d %s, created %v", si.BestHeight, si.BestHash, si.Time)
+	}
+
+	if *secondary {
+		path := *secondaryDir
+		if path == "" {
+			if path, err = os.MkdirTemp("", "blockbook-secondary"); err != nil {
+				glog.Error("secondary: ", err)
+				return exitCodeFatal
+			}
+			defer os.RemoveAll(path)
+		}
+		index, err = db.NewRocksDBSecondary(*dbPath, path, *dbCache, chain.GetChainParser(), metrics, *extendedIndex)
+	} else {
+		index, err = db.NewRocksDB(*dbPath, *dbCache, *dbMaxOpenFiles, chain.GetChainParser(), metrics, *extendedIndex)
+	}
 	if err != nil {
 		glog.Error("rocksDB: ", err)
 		return exitCodeFatal
 	}
 	defer index.Close()
 
-	internalState, err = newInternalState(config, index, *enableSubNewTx)
+	if *watchOnly || *watchlistFile != "" {
+		if err = index.InitWatchlist(); err != nil {
+			glog.Error("watchlist: ", err)
+			return exitCodeFatal
+		}
+	}
+
+	internalState, err = newInternalState(config, index, *enableSubNewTx, *enableStealthScan, *snapshotDir)
 	if err != nil {
 		glog.Error("internalState: ", err)
 		return exitCodeFatal
 	}
 
-	// fix possible inconsistencies in the UTXO index
-	if *fixUtxo || !internalState.UtxoChecked {
+	// fix possible inconsistencies in the UTXO index, the secondary instance leaves it to the primary instance
+	if !*secondary && (*fixUtxo || !internalState.UtxoChecked) {
 		err = index.FixUtxos(chanOsSignal)
 		if err != nil {
 			glog.Error("fixUtxos: ", err)
@@ -193,7 +250,7 @@ func mainWithExitCode() int {
 	}
 
 	// sort addressContracts if necessary
-	if !internalState.SortedAddressContracts {
+	if !*secondary && !internalState.SortedAddressContracts {
 		err = index.SortAddressContracts(chanOsSignal)
 		if err != nil {
 			glog.Error("sortAddressContracts: ", err)
@@ -202,6 +259,12 @@ func mainWithExitCode() int {
 		internalState.SortedAddressContracts = true
 	}
 
+	// the global indexes of the anon outputs are assigned by the database, they must match the backend
+	if err = index.VerifyAnonIndex(chain); err != nil {
+		glog.Error("anonIndex: ", err)
+		return exitCodeFatal
+	}
+
 	index.SetInternalState(internalState)
 	if *fixUtxo {
 		err = index.StoreInternalState(internalState)
@@ -217,7 +280,9 @@ func mainWithExitCode() int {
 			glog.Error("internalState: database is in inconsistent state and cannot be used")
 			return exitCodeFatal
 		}
-		glog.Warning("internalState: database was left in open state, possibly previous ungraceful shutdown")
+		if !*secondary {
+			glog.Warning("internalState: database was left in open state, possibly previous ungraceful shutdown")
+		}
 	}
 
 	if *computeFeeStatsFlag {
@@ -230,6 +295,14 @@ func mainWithExitCode() int {
 		return exitCodeOK
 	}
 
+	if *createSnapshot != "" {
+		if _, err = index.CreateSnapshot(*createSnapshot); err != nil {
+			glog.Error("createSnapshot: ", err)
+			return exitCodeFatal
+		}
+		return exitCodeOK
+	}
+
 	if *computeColumnStats {
 		internalState.DbState = common.DbStateOpen
 		err = index.ComputeInternalStateColumnStats(chanOsSignal)
@@ -241,18 +314,34 @@ func mainWithExitCode() int {
 		return exitCodeOK
 	}
 
-	syncWorker, err = db.NewSyncWorker(index, chain, *syncWorkers, *syncChunk, *blockFrom, *dryRun, chanOsSignal, metrics, internalState)
-	if err != nil {
-		glog.Errorf("NewSyncWorker %v", err)
-		return exitCodeFatal
-	}
+	if !*secondary {
+		syncWorker, err = db.NewSyncWorker(index, chain, *syncWorkers, *syncChunk, *blockFrom, *dryRun, chanOsSignal, metrics, internalState)
+		if err != nil {
+			glog.Errorf("NewSyncWorker %v", err)
+			return exitCodeFatal
+		}
+		syncWorker.SetOnReorg(onReorg)
 
-	// set the DbState to open at this moment, after all important workers are initialized
-	internalState.DbState = common.DbStateOpen
-	err = index.StoreInternalState(internalState)
-	if err != nil {
-		glog.Error("internalState: ", err)
-		return exitCodeFatal
+		// set the DbState to open at this moment, after all important workers are initialized
+		internalState.DbState = common.DbStateOpen
+		err = index.StoreInternalState(internalState)
+		if err != nil {
+			glog.Error("internalState: ", err)
+			return exitCodeFatal
+		}
+
+		if *watchOnly || *watchlistFile != "" {
+			if err = resumeWatchlistRescan(); err != nil {
+				glog.Error("watchlist: ", err)
+				return exitCodeFatal
+			}
+		}
+		if *watchlistFile != "" {
+			if err = addWatchlistFile(*watchlistFile); err != nil {
+				glog.Error("watchlist: ", err)
+				return exitCodeFatal
+			}
+		}
 	}
 
 	if *rollbackHeight >= 0 {
@@ -307,26 +396,54 @@ func mainWithExitCode() int {
 			return exitCodeOK
 		}
 		// initialize mempool after the initial sync is complete
-		var addrDescForOutpoint bchain.AddrDescForOutpointFunc
-		if chain.GetChainParser().GetChainType() == bchain.ChainBitcoinType {
-			addrDescForOutpoint = index.AddrDescForOutpoint
+		if err = initializeMempool(); err != nil {
+			return exitCodeFatal
 		}
-		err = chain.InitializeMempool(addrDescForOutpoint, onNewTxAddr, onNewTx)
-		if err != nil {
-			glog.Error("initializeMempool ", err)
+		go syncIndexLoop()
+		go syncMempoolLoop()
+		internalState.InitialSync = false
+	}
+	var replicaSubscriber *server.ReplicaSubscriber
+	if *secondary {
+		internalState.SyncMode = true
+		// the sync worker of the secondary instance only refreshes the info about the backend
+		if syncWorker, err = db.NewSyncWorker(index, chain, *syncWorkers, *syncChunk, *blockFrom, *dryRun, chanOsSignal, metrics, internalState); err != nil {
+			glog.Errorf("NewSyncWorker %v", err)
 			return exitCodeFatal
 		}
-		var mempoolCount int
-		if mempoolCount, err = mempool.Resync(); err != nil {
-			glog.Error("resyncMempool ", err)
+		syncWorker.UpdateBackendInfo()
+		if err = index.CatchUpWithPrimary(nil, nil); err != nil {
+			glog.Error("secondary: ", err)
 			return exitCodeFatal
 		}
-		internalState.FinishedMempoolSync(mempoolCount)
-		go syncIndexLoop()
+		if err = initializeMempool(); err != nil {
+			return exitCodeFatal
+		}
+		go secondaryIndexLoop()
 		go syncMempoolLoop()
-		internalState.InitialSync = false
+		if *replicaSocket != "" {
+			replicaSubscriber = server.NewReplicaSubscriber(*replicaSocket, pushSynchronizationHandler)
+		} else {
+			glog.Warning("secondary: -replicasocket not set, catching up with the primary instance only each -resyncindexperiod")
+		}
+	} else {
+		go storeInternalStateLoop()
+	}
+
+	var replicaServer *server.ReplicaServer
+	if !*secondary && *replicaSocket != "" {
+		if replicaServer, err = server.NewReplicaServer(*replicaSocket); err != nil {
+			glog.Error("replica server: ", err)
+			return exitCodeFatal
+		}
+		go func() {
+			if err := replicaServer.Run(); err != nil {
+				glog.Error("replica server: ", err)
+			}
+		}()
+		callbacksOnNewBlock = append(callbacksOnNewBlock, replicaServer.OnNewBlock)
+		callbacksOnNewTx = append(callbacksOnNewTx, replicaServer.OnNewTx)
 	}
-	go storeInternalStateLoop()
 
 	if publicServer != nil {
 		// start full public interface
@@ -334,6 +451,7 @@ func mainWithExitCode() int {
 		callbacksOnNewTxAddr = append(callbacksOnNewTxAddr, publicServer.OnNewTxAddr)
 		callbacksOnNewTx = append(callbacksOnNewTx, publicServer.OnNewTx)
 		callbacksOnNewFiatRatesTicker = append(callbacksOnNewFiatRatesTicker, publicServer.OnNewFiatRatesTicker)
+		callbacksOnReorg = append(callbacksOnReorg, publicServer.OnReorg)
 		publicServer.ConnectFullPublicInterface()
 	}
 
@@ -344,7 +462,7 @@ func mainWithExitCode() int {
 		height := uint32(*blockFrom)
 		until := uint32(*blockUntil)
 
-		if !*synchronize {
+		if !*synchronize && !*secondary {
 			if err = syncWorker.BulkConnectBlocks(height, until); err != nil {
 				if err != db.ErrOperationInterrupted {
 					glog.Error("connectBlocksParallel ", err)
@@ -357,10 +475,25 @@ func mainWithExitCode() int {
 
 	if internalServer != nil || publicServer != nil || chain != nil {
 		// start fiat rates downloader only if not shutting down immediately
-		initDownloaders(index, chain, config)
+		// the secondary instance does not write to the database, the downloaders run in the primary instance
+		if !*secondary {
+			initDownloaders(index, chain, config)
+		}
 		waitForSignalAndShutdown(internalServer, publicServer, chain, 10*time.Second)
 	}
 
+	if replicaServer != nil {
+		replicaServer.Close()
+	}
+	if replicaSubscriber != nil {
+		replicaSubscriber.Close()
+	}
+	if *secondary {
+		close(chanSyncIndex)
+		close(chanSyncMempool)
+		<-chanSyncIndexDone
+		<-chanSyncMempoolDone
+	}
 	if *synchronize {
 		close(chanSyncIndex)
 		close(chanSyncMempool)
@@ -490,13 +623,50 @@ func blockbookAppInfoMetric(db *db.RocksDB, chain bchain.BlockChain, txCache *db
 	return nil
 }
 
-func newInternalState(config *common.Config, d *db.RocksDB, enableSubNewTx bool) (*common.InternalState, error) {
+// resumeWatchlistRescan finishes the rescan of the watchlist entries interrupted by the previous run
+func resumeWatchlistRescan() error {
+	r, err := index.ResumeWatchlistRescan(chain)
+	if err != nil || r == nil {
+		return err
+	}
+	glog.Infof("watchlist: resumed rescan of %d addresses and %d xpubs from the block %d", r.AddedAddresses, r.AddedXpubs, r.FromHeight)
+	if len(r.Incomplete) > 0 {
+		glog.Warningf("watchlist: addresses %v have history before the block %d", r.Incomplete, r.FromHeight)
+	}
+	return nil
+}
+
+// addWatchlistFile adds the entries from the json file to the watchlist, the new entries are indexed by the rescan
+// of the already connected blocks
+func addWatchlistFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var req db.WatchlistRequest
+	if err = json.Unmarshal(data, &req); err != nil {
+		return errors.Annotatef(err, "Watchlist file %s", path)
+	}
+	r, err := index.AddToWatchlist(chain, &req, nil)
+	if err != nil {
+		return err
+	}
+	glog.Infof("watchlist: added %d addresses and %d xpubs from %s", r.AddedAddresses, r.AddedXpubs, path)
+	if len(r.Incomplete) > 0 {
+		glog.Warningf("watchlist: addresses %v have history before the block %d", r.Incomplete, r.FromHeight)
+	}
+	return nil
+}
+
+func newInternalState(config *common.Config, d *db.RocksDB, enableSubNewTx, enableStealthScan bool, snapshotDir string) (*common.InternalState, error) {
 	is, err := d.LoadInternalState(config)
 	if err != nil {
 		return nil, err
 	}
 
 	is.EnableSubNewTx = enableSubNewTx
+	is.EnableStealthScan = enableStealthScan
+	is.SnapshotDir = snapshotDir
 	name, err := os.Hostname()
 	if err != nil {
 		glog.Error("get hostname ", err)
@@ -512,6 +682,15 @@ func newInternalState(config *common.Config, d *db.RocksDB, enableSubNewTx bool)
 		glog.Info("WsGetAccountInfoLimit enabled with limit ", is.WsGetAccountInfoLimit)
 		is.WsLimitExceedingIPs = make(map[string]int)
 	}
+	is.DisableSendTx = config.DisableSendTx
+	if is.DisableSendTx {
+		glog.Info("Transaction broadcasting is disabled")
+	}
+	is.SendTxLimit = config.SendTxLimit
+	if is.SendTxLimit > 0 {
+		glog.Info("SendTxLimit enabled with limit ", is.SendTxLimit, " transactions per minute")
+	}
+	is.TrustProxyHeaders = config.TrustProxyHeaders
 	return is, nil
 }
 
@@ -532,6 +711,19 @@ func syncIndexLoop() {
 	glog.Info("syncIndexLoop stopped")
 }
 
+// secondaryIndexLoop keeps the secondary instance up to date with the database of the primary instance
+func secondaryIndexLoop() {
+	defer close(chanSyncIndexDone)
+	glog.Info("secondaryIndexLoop starting")
+	common.TickAndDebounce(time.Duration(*resyncIndexPeriodMs)*time.Millisecond, debounceResyncIndexMs*time.Millisecond, chanSyncIndex, func() {
+		if err := index.CatchUpWithPrimary(onNewBlockHash, onReorg); err != nil {
+			glog.Error("secondaryIndexLoop ", errors.ErrorStack(err))
+		}
+		syncWorker.UpdateBackendInfo()
+	})
+	glog.Info("secondaryIndexLoop stopped")
+}
+
 func onNewBlockHash(hash string, height uint32) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -555,6 +747,37 @@ func onNewFiatRatesTicker(ticker *common.CurrencyRatesTicker) {
 	}
 }
 
+func onReorg(reorg *db.Reorg, addrTxids map[string][]string) {
+	defer func() {
+		if r := recover(); r != nil {
+			glog.Error("onReorg recovered from panic: ", r)
+			debug.PrintStack()
+		}
+	}()
+	for _, c := range callbacksOnReorg {
+		c(reorg, addrTxids)
+	}
+}
+
+func initializeMempool() error {
+	var addrDescForOutpoint bchain.AddrDescForOutpointFunc
+	if chain.GetChainParser().GetChainType() == bchain.ChainBitcoinType {
+		addrDescForOutpoint = index.AddrDescForOutpoint
+	}
+	err := chain.InitializeMempool(addrDescForOutpoint, onNewTxAddr, onNewTx)
+	if err != nil {
+		glog.Error("initializeMempool ", err)
+		return err
+	}
+	mempoolCount, err := mempool.Resync()
+	if err != nil {
+		glog.Error("resyncMempool ", err)
+		return err
+	}
+	internalState.FinishedMempoolSync(mempoolCount)
+	return nil
+}
+
 func syncMempoolLoop() {
 	defer close(chanSyncMempoolDone)
 	glog.Info("syncMempoolLoop starting")
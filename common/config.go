@@ -20,6 +20,9 @@ type Config struct {
 	BlockGolombFilterP      uint8  `json:"block_golomb_filter_p"`
 	BlockFilterScripts      string `json:"block_filter_scripts"`
 	BlockFilterUseZeroedKey bool   `json:"block_filter_use_zeroed_key"`
+	DisableSendTx           bool   `json:"disable_sendtx"`
+	SendTxLimit             int    `json:"sendtx_limit"`
+	TrustProxyHeaders       bool   `json:"trust_proxy_headers"`
 }
 
 // configFile represents the nested JSON structure used in configs/coins/*.json
@@ -60,6 +63,9 @@ type configFile struct {
 				BlockGolombFilterP      uint8  `json:"block_golomb_filter_p"`
 				BlockFilterScripts      string `json:"block_filter_scripts"`
 				BlockFilterUseZeroedKey bool   `json:"block_filter_use_zeroed_key"`
+				DisableSendTx           bool   `json:"disable_sendtx"`
+				SendTxLimit             int    `json:"sendtx_limit"`
+				TrustProxyHeaders       bool   `json:"trust_proxy_headers"`
 			} `json:"additional_params"`
 		} `json:"block_chain"`
 	} `json:"blockbook"`
@@ -96,6 +102,9 @@ func (c *Config) UnmarshalJSON(data []byte) error {
 	c.BlockGolombFilterP = cf.Blockbook.BlockChain.AdditionalParams.BlockGolombFilterP
 	c.BlockFilterScripts = cf.Blockbook.BlockChain.AdditionalParams.BlockFilterScripts
 	c.BlockFilterUseZeroedKey = cf.Blockbook.BlockChain.AdditionalParams.BlockFilterUseZeroedKey
+	c.DisableSendTx = cf.Blockbook.BlockChain.AdditionalParams.DisableSendTx
+	c.SendTxLimit = cf.Blockbook.BlockChain.AdditionalParams.SendTxLimit
+	c.TrustProxyHeaders = cf.Blockbook.BlockChain.AdditionalParams.TrustProxyHeaders
 
 	return nil
 }
@@ -62,6 +62,7 @@ type InternalState struct {
 
 	DbState       uint32 `json:"dbState" ts_doc:"State of the database (closed=0, open=1, inconsistent=2)."`
 	ExtendedIndex bool   `json:"extendedIndex" ts_doc:"Indicates if an extended indexing strategy is used."`
+	WatchOnly     bool   `json:"watchOnly,omitempty" ts_doc:"If true, only the addresses in the watchlist are indexed."`
 
 	LastStore time.Time `json:"lastStore" ts_doc:"Time when the internal state was last stored/persisted."`
 
@@ -87,7 +88,9 @@ type InternalState struct {
 	HistoricalFiatRatesTime      time.Time `json:"historicalFiatRatesTime" ts_doc:"Timestamp of the last historical fiat rates update."`
 	HistoricalTokenFiatRatesTime time.Time `json:"historicalTokenFiatRatesTime" ts_doc:"Timestamp of the last historical token fiat rates update."`
 
-	EnableSubNewTx bool `json:"-" ts_doc:"Internal flag controlling subscription to new transactions (not exposed)."`
+	EnableSubNewTx    bool   `json:"-" ts_doc:"Internal flag controlling subscription to new transactions (not exposed)."`
+	EnableStealthScan bool   `json:"-" ts_doc:"Internal flag enabling the scan for outputs paid to Particl stealth addresses (not exposed)."`
+	SnapshotDir       string `json:"-" ts_doc:"Directory of the database snapshots created by the internal admin interface, empty if disabled (not exposed)."`
 
 	BackendInfo BackendInfo `json:"-" ts_doc:"Information about the connected blockchain backend (not exposed in JSON)."`
 
@@ -103,6 +106,13 @@ type InternalState struct {
 	// allowed number of fetched accounts over websocket
 	WsGetAccountInfoLimit int            `json:"-" ts_doc:"Limit of how many getAccountInfo calls can be made via WS (not exposed)."`
 	WsLimitExceedingIPs   map[string]int `json:"-" ts_doc:"Tracks IP addresses exceeding the WS limit (not exposed)."`
+
+	// transaction broadcasting settings
+	DisableSendTx bool `json:"-" ts_doc:"If true, broadcasting of transactions is disabled on all public interfaces (not exposed)."`
+	SendTxLimit   int  `json:"-" ts_doc:"Maximum number of transactions broadcast from one IP address per minute, 0 means no limit (not exposed)."`
+
+	// if set, the client ip is taken from the headers set by a reverse proxy
+	TrustProxyHeaders bool `json:"-" ts_doc:"If true, the client IP address is taken from the cf-connecting-ip or X-Real-Ip headers (not exposed)."`
 }
 
 // StartedSync signals start of synchronization
@@ -350,6 +360,20 @@ func (is *InternalState) Pack() ([]byte, error) {
 	return json.Marshal(is)
 }
 
+// UpdateFromStored updates the state maintained by the instance writing to the database (column statistics,
+// the times of the last store and of the fiat rates and the migrations) from the state stored by it.
+// It is used by the secondary instance, which does not compute the state itself.
+func (is *InternalState) UpdateFromStored(stored *InternalState) {
+	is.mux.Lock()
+	defer is.mux.Unlock()
+	is.DbColumns = stored.DbColumns
+	is.LastStore = stored.LastStore
+	is.HistoricalFiatRatesTime = stored.HistoricalFiatRatesTime
+	is.HistoricalTokenFiatRatesTime = stored.HistoricalTokenFiatRatesTime
+	is.UtxoChecked = stored.UtxoChecked
+	is.SortedAddressContracts = stored.SortedAddressContracts
+}
+
 // UnpackInternalState unmarshals internal state from json
 func UnpackInternalState(buf []byte) (*InternalState, error) {
 	var is InternalState
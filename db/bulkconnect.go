@@ -17,6 +17,7 @@ import (
 type bulkAddresses struct {
 	bi        BlockInfo
 	addresses addressesMap
+	particl   *particlBlock
 }
 
 // BulkConnect is used to connect blocks in bulk, faster but if interrupted inconsistent way
@@ -30,6 +31,8 @@ type BulkConnect struct {
 	blockFilters       map[string][]byte
 	balances           map[string]*AddrBalance
 	addressContracts   map[string]*unpackedAddrContracts
+	lastAnonIndex      uint64
+	lastSupply         *Supply
 	height             uint32
 }
 
@@ -54,6 +57,15 @@ func (d *RocksDB) InitBulkConnect() (*BulkConnect, error) {
 		addressContracts: make(map[string]*unpackedAddrContracts),
 		blockFilters:     make(map[string][]byte),
 	}
+	if d.isParticl() {
+		var err error
+		if b.lastAnonIndex, err = d.GetLastAnonIndex(); err != nil {
+			return nil, err
+		}
+		if b.lastSupply, err = d.GetLastSupply(); err != nil {
+			return nil, err
+		}
+	}
 	if err := d.SetInconsistentState(true); err != nil {
 		return nil, err
 	}
@@ -167,7 +179,11 @@ func (b *BulkConnect) storeBulkAddresses(wb *grocksdb.WriteBatch) error {
 		if err := b.d.writeHeight(wb, ba.bi.Height, &ba.bi, opInsert); err != nil {
 			return err
 		}
+		if err := b.d.storeParticlBlock(wb, ba.bi.Height, ba.particl); err != nil {
+			return err
+		}
 	}
+	b.d.storeWatchlist(wb, b.d.watchlist)
 	b.bulkAddressesCount = 0
 	b.bulkAddresses = b.bulkAddresses[:0]
 	return nil
@@ -195,6 +211,16 @@ func (b *BulkConnect) connectBlockBitcoinType(block *bchain.Block, storeBlockTxs
 	if err := b.d.processAddressesBitcoinType(block, addresses, b.txAddressesMap, b.balances, gf); err != nil {
 		return err
 	}
+	var pb *particlBlock
+	if b.d.isParticl() {
+		if pb, err = b.d.processParticlBlock(block, b.txAddressesMap, b.lastAnonIndex, b.lastSupply); err != nil {
+			return err
+		}
+		b.lastAnonIndex = pb.lastAnonIndex
+		if pb.supply != nil {
+			b.lastSupply = pb.supply
+		}
+	}
 	var storeAddressesChan, storeBalancesChan chan error
 	var sa bool
 	if len(b.txAddressesMap) > maxBulkTxAddresses || len(b.balances) > maxBulkBalances {
@@ -217,6 +243,7 @@ func (b *BulkConnect) connectBlockBitcoinType(block *bchain.Block, storeBlockTxs
 			Height: block.Height,
 		},
 		addresses: addresses,
+		particl:   pb,
 	})
 	b.bulkAddressesCount += len(addresses)
 	if gf != nil {
@@ -234,7 +261,7 @@ func (b *BulkConnect) connectBlockBitcoinType(block *bchain.Block, storeBlockTxs
 			}
 		}
 		if storeBlockTxs {
-			if err := b.d.storeAndCleanupBlockTxs(wb, block); err != nil {
+			if err := b.d.storeAndCleanupBlockTxs(wb, block, pb.blockKeyImages()); err != nil {
 				return err
 			}
 		}
@@ -70,3 +70,16 @@ func createAndSetDBOptions(bloomBits int, c *grocksdb.Cache, maxOpenFiles int) *
         }
 	return opts
 }
+
+// createAndSetBlobDBOptions returns the options of the column txOutputBlobs, the large commitments and range proofs fit better to larger blocks
+func createAndSetBlobDBOptions(c *grocksdb.Cache, maxOpenFiles int) *grocksdb.Options {
+	blockOpts := grocksdb.NewDefaultBlockBasedTableOptions()
+	blockOpts.SetBlockSize(64 << 10) // 64kB
+	blockOpts.SetBlockCache(c)
+	blockOpts.SetFilterPolicy(grocksdb.NewBloomFilter(float64(10)))
+	blockOpts.SetFormatVersion(4)
+
+	opts := createAndSetDBOptions(0, c, maxOpenFiles)
+	opts.SetBlockBasedTableFactory(blockOpts)
+	return opts
+}
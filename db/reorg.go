@@ -0,0 +1,230 @@
+package db
+
+import (
+	vlq "github.com/bsm/go-vlq"
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/linxGnu/grocksdb"
+	"github.com/trezor/blockbook/bchain"
+)
+
+// Reorg history
+// The column reorgs maps the sequence number of the reorg to the record of the fork of the chain handled by the sync:
+// the old and the new tip, the last common block and the txids of the disconnected transactions not confirmed again in the new chain.
+// The records are stored only for Bitcoin type coins.
+
+// Reorg is the record of the reorganization of the chain, the blocks above ForkHeight were disconnected
+type Reorg struct {
+	ID         uint32
+	Time       int64
+	ForkHeight uint32 // height of the last block common to the old and the new chain
+	OldHeight  uint32
+	OldHash    string
+	NewHeight  uint32
+	NewHash    string
+	Txids      []string // txids of the disconnected transactions not in the new chain, in the order of the blocks
+}
+
+// Depth returns the number of the disconnected blocks
+func (r *Reorg) Depth() uint32 {
+	return r.OldHeight - r.ForkHeight
+}
+
+// getReorgTxs returns the txids of the blocks lower-higher, which are to be disconnected,
+// and the map of the address descriptors of the inputs and outputs of the transactions to the txids of the address
+func (d *RocksDB) getReorgTxs(lower uint32, higher uint32) ([]string, map[string][]string, error) {
+	txids := make([]string, 0)
+	addrTxids := make(map[string][]string)
+	for height := lower; height <= higher; height++ {
+		bt, err := d.getBlockTxs(height)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range bt {
+			txid, err := d.chainParser.UnpackTxid(bt[i].btxID)
+			if err != nil {
+				return nil, nil, err
+			}
+			txids = append(txids, txid)
+			ta, err := d.getTxAddresses(bt[i].btxID)
+			if err != nil {
+				return nil, nil, err
+			}
+			if ta == nil {
+				continue
+			}
+			add := func(addrDesc bchain.AddressDescriptor) {
+				if len(addrDesc) == 0 {
+					return
+				}
+				s := string(addrDesc)
+				at := addrTxids[s]
+				if len(at) == 0 || at[len(at)-1] != txid {
+					addrTxids[s] = append(at, txid)
+				}
+			}
+			for j := range ta.Inputs {
+				add(ta.Inputs[j].AddrDesc)
+			}
+			for j := range ta.Outputs {
+				add(ta.Outputs[j].AddrDesc)
+			}
+		}
+	}
+	return txids, addrTxids, nil
+}
+
+// removeReconfirmedTxs removes the transactions confirmed again in the new chain from the reorg and from the map of the addresses,
+// it must be called after the new chain is connected
+func (d *RocksDB) removeReconfirmedTxs(reorg *Reorg, addrTxids map[string][]string) error {
+	reconfirmed := make(map[string]struct{})
+	txids := reorg.Txids[:0]
+	for _, txid := range reorg.Txids {
+		ta, err := d.GetTxAddresses(txid)
+		if err != nil {
+			return err
+		}
+		if ta != nil {
+			reconfirmed[txid] = struct{}{}
+			continue
+		}
+		txids = append(txids, txid)
+	}
+	reorg.Txids = txids
+	if len(reconfirmed) == 0 {
+		return nil
+	}
+	for addrDesc, at := range addrTxids {
+		txids := at[:0]
+		for _, txid := range at {
+			if _, found := reconfirmed[txid]; !found {
+				txids = append(txids, txid)
+			}
+		}
+		if len(txids) == 0 {
+			delete(addrTxids, addrDesc)
+		} else {
+			addrTxids[addrDesc] = txids
+		}
+	}
+	return nil
+}
+
+func (d *RocksDB) packReorg(r *Reorg) ([]byte, error) {
+	oldHash, err := d.chainParser.PackBlockHash(r.OldHash)
+	if err != nil {
+		return nil, err
+	}
+	newHash, err := d.chainParser.PackBlockHash(r.NewHash)
+	if err != nil {
+		return nil, err
+	}
+	pl := d.chainParser.PackedTxidLen()
+	if len(oldHash) != pl || len(newHash) != pl {
+		return nil, errors.New("Non standard block hash")
+	}
+	buf := make([]byte, 0, 32+(len(r.Txids)+2)*pl)
+	varBuf := make([]byte, vlq.MaxLen64)
+	l := packVarint(int(r.Time), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	for _, v := range []uint32{r.ForkHeight, r.OldHeight} {
+		l = packVaruint(uint(v), varBuf)
+		buf = append(buf, varBuf[:l]...)
+	}
+	buf = append(buf, oldHash...)
+	l = packVaruint(uint(r.NewHeight), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	buf = append(buf, newHash...)
+	l = packVaruint(uint(len(r.Txids)), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	for _, txid := range r.Txids {
+		btxID, err := d.chainParser.PackTxid(txid)
+		if err != nil {
+			return nil, err
+		}
+		buf = append(buf, btxID...)
+	}
+	return buf, nil
+}
+
+func (d *RocksDB) unpackReorg(id uint32, buf []byte) (*Reorg, error) {
+	r := &Reorg{ID: id}
+	t, l := unpackVarint(buf)
+	r.Time = int64(t)
+	for _, v := range []*uint32{&r.ForkHeight, &r.OldHeight} {
+		u, ll := unpackVaruint(buf[l:])
+		*v = uint32(u)
+		l += ll
+	}
+	pl := d.chainParser.PackedTxidLen()
+	if len(buf)-l < 2*pl+2 {
+		return nil, errors.New("Inconsistent data in reorgs")
+	}
+	var err error
+	if r.OldHash, err = d.chainParser.UnpackBlockHash(buf[l : l+pl]); err != nil {
+		return nil, err
+	}
+	l += pl
+	u, ll := unpackVaruint(buf[l:])
+	r.NewHeight = uint32(u)
+	l += ll
+	if r.NewHash, err = d.chainParser.UnpackBlockHash(buf[l : l+pl]); err != nil {
+		return nil, err
+	}
+	l += pl
+	n, ll := unpackVaruint(buf[l:])
+	l += ll
+	if len(buf)-l != int(n)*pl {
+		return nil, errors.New("Inconsistent data in reorgs")
+	}
+	r.Txids = make([]string, n)
+	for i := range r.Txids {
+		if r.Txids[i], err = d.chainParser.UnpackTxid(buf[l : l+pl]); err != nil {
+			return nil, err
+		}
+		l += pl
+	}
+	return r, nil
+}
+
+// GetLastReorgID returns the sequence number of the last stored reorg, 0 if there is none
+func (d *RocksDB) GetLastReorgID() uint32 {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfReorgs])
+	defer it.Close()
+	if it.SeekToLast(); it.Valid() {
+		return unpackUint(it.Key().Data())
+	}
+	return 0
+}
+
+// StoreReorg stores the reorg under the next sequence number, which is set to the ID of the reorg
+func (d *RocksDB) StoreReorg(r *Reorg) error {
+	r.ID = d.GetLastReorgID() + 1
+	buf, err := d.packReorg(r)
+	if err != nil {
+		return err
+	}
+	wb := grocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	wb.PutCF(d.cfh[cfReorgs], packUint(r.ID), buf)
+	return d.WriteBatch(wb)
+}
+
+// GetReorg returns the reorg with the sequence number id or nil if it does not exist
+func (d *RocksDB) GetReorg(id uint32) (*Reorg, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfReorgs], packUint(id))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	buf := val.Data()
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	r, err := d.unpackReorg(id, buf)
+	if err != nil {
+		glog.Error("rocksdb: unpackReorg ", id, ": ", err)
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,239 @@
+//go:build unittest
+
+package db
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/common"
+	"github.com/trezor/blockbook/tests/dbtestdata"
+)
+
+func TestSyncWorker_handleForkReorg(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+	blocks := dbtestdata.GetTestParticlTypeBlocks(d.chainParser)
+	for i := uint32(0); i < blocks[0].Height; i++ {
+		d.is.BlockTimes = append(d.is.BlockTimes, 0)
+	}
+	for _, b := range blocks {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chain, err := dbtestdata.NewFakeBlockChainParticlType(d.chainParser, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := common.GetMetrics("ParticlReorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewSyncWorker(d, chain, 1, 0, 0, false, nil, metrics, d.is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notified *Reorg
+	var notifiedAddrTxids map[string][]string
+	w.SetOnReorg(func(reorg *Reorg, addrTxids map[string][]string) {
+		notified = reorg
+		notifiedAddrTxids = addrTxids
+	})
+	if id := d.GetLastReorgID(); id != 0 {
+		t.Fatalf("GetLastReorgID() = %d, want 0", id)
+	}
+
+	old := blocks[3]
+	blocks[3] = dbtestdata.GetTestParticlTypeBlock503R(d.chainParser)
+	if err := w.handleFork(old.Height, old.Hash, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	height, hash, err := d.GetBestBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != 503 || hash != blocks[3].Hash {
+		t.Fatalf("GetBestBlock() = %d %s, want 503 %s", height, hash, blocks[3].Hash)
+	}
+
+	want := &Reorg{
+		ID:         1,
+		ForkHeight: 502,
+		OldHeight:  503,
+		OldHash:    old.Hash,
+		NewHeight:  503,
+		NewHash:    blocks[3].Hash,
+		Txids:      []string{dbtestdata.TxidPartB503T1, dbtestdata.TxidPartB503T2},
+	}
+	if notified == nil {
+		t.Fatal("onReorg not called")
+	}
+	want.Time = notified.Time
+	if !reflect.DeepEqual(notified, want) {
+		t.Errorf("onReorg reorg = %+v, want %+v", notified, want)
+	}
+	if notified.Depth() != 1 {
+		t.Errorf("Depth() = %d, want 1", notified.Depth())
+	}
+	wantAddrTxids := map[string][]string{
+		string(hexToBytes(dbtestdata.ColdStakingPartScript)):                                   {dbtestdata.TxidPartB503T1},
+		string(hexToBytes(dbtestdata.AddressToPubKeyHex(dbtestdata.AddrPartC, d.chainParser))): {dbtestdata.TxidPartB503T2},
+	}
+	if !reflect.DeepEqual(notifiedAddrTxids, wantAddrTxids) {
+		t.Errorf("onReorg addrTxids = %v, want %v", notifiedAddrTxids, wantAddrTxids)
+	}
+
+	got, err := d.GetReorg(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetReorg(1) = %+v, want %+v", got, want)
+	}
+	if id := d.GetLastReorgID(); id != 1 {
+		t.Errorf("GetLastReorgID() = %d, want 1", id)
+	}
+	if got, err = d.GetReorg(2); err != nil || got != nil {
+		t.Errorf("GetReorg(2) = %+v, %v, want nil", got, err)
+	}
+}
+
+type failingBlockChain struct {
+	bchain.BlockChain
+	fail bool
+}
+
+func (c *failingBlockChain) GetBestBlockHash() (string, error) {
+	if c.fail {
+		return "", errors.New("backend not available")
+	}
+	return c.BlockChain.GetBestBlockHash()
+}
+
+func setupParticlForkTest(t *testing.T, d *RocksDB, metricsName string, wrap func(bchain.BlockChain) bchain.BlockChain) ([]*bchain.Block, *SyncWorker, *[]*Reorg) {
+	blocks := dbtestdata.GetTestParticlTypeBlocks(d.chainParser)
+	for i := uint32(0); i < blocks[0].Height; i++ {
+		d.is.BlockTimes = append(d.is.BlockTimes, 0)
+	}
+	for _, b := range blocks {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	chain, err := dbtestdata.NewFakeBlockChainParticlType(d.chainParser, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wrap != nil {
+		chain = wrap(chain)
+	}
+	metrics, err := common.GetMetrics(metricsName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewSyncWorker(d, chain, 1, 0, 0, false, nil, metrics, d.is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var notified []*Reorg
+	w.SetOnReorg(func(reorg *Reorg, addrTxids map[string][]string) {
+		notified = append(notified, reorg)
+	})
+	return blocks, w, &notified
+}
+
+func TestSyncWorker_handleForkReconfirmedTx(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+	blocks, w, notified := setupParticlForkTest(t, d, "ParticlReorgReconfirmed", nil)
+	var notifiedAddrTxids map[string][]string
+	w.SetOnReorg(func(reorg *Reorg, addrTxids map[string][]string) {
+		*notified = append(*notified, reorg)
+		notifiedAddrTxids = addrTxids
+	})
+
+	// the anon transaction of the disconnected block is confirmed again in the new block
+	old := blocks[3]
+	blocks[3] = dbtestdata.GetTestParticlTypeBlock503R(d.chainParser)
+	blocks[3].Txs = append(blocks[3].Txs, old.Txs[1])
+	if err := w.handleFork(old.Height, old.Hash, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(*notified) != 1 {
+		t.Fatalf("onReorg called %d times, want 1", len(*notified))
+	}
+	if want := []string{dbtestdata.TxidPartB503T1}; !reflect.DeepEqual((*notified)[0].Txids, want) {
+		t.Errorf("onReorg Txids = %v, want %v", (*notified)[0].Txids, want)
+	}
+	wantAddrTxids := map[string][]string{
+		string(hexToBytes(dbtestdata.ColdStakingPartScript)): {dbtestdata.TxidPartB503T1},
+	}
+	if !reflect.DeepEqual(notifiedAddrTxids, wantAddrTxids) {
+		t.Errorf("onReorg addrTxids = %v, want %v", notifiedAddrTxids, wantAddrTxids)
+	}
+	got, err := d.GetReorg(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || !reflect.DeepEqual(got.Txids, []string{dbtestdata.TxidPartB503T1}) {
+		t.Errorf("GetReorg(1) = %+v, want Txids [%s]", got, dbtestdata.TxidPartB503T1)
+	}
+}
+
+func TestSyncWorker_handleForkResyncError(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+	var chain *failingBlockChain
+	blocks, w, notified := setupParticlForkTest(t, d, "ParticlReorgResyncError", func(c bchain.BlockChain) bchain.BlockChain {
+		chain = &failingBlockChain{BlockChain: c, fail: true}
+		return chain
+	})
+
+	old := blocks[3]
+	blocks[3] = dbtestdata.GetTestParticlTypeBlock503R(d.chainParser)
+	if err := w.handleFork(old.Height, old.Hash, nil, false); err == nil {
+		t.Fatal("handleFork() error = nil, want the resync error")
+	}
+	if len(*notified) != 0 {
+		t.Errorf("onReorg called %d times, want 0", len(*notified))
+	}
+	if id := d.GetLastReorgID(); id != 0 {
+		t.Errorf("GetLastReorgID() = %d, want 0", id)
+	}
+
+	// the pending reorg is completed by the next successful resync
+	chain.fail = false
+	if err := w.ResyncIndex(nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(*notified) != 1 {
+		t.Fatalf("onReorg called %d times, want 1", len(*notified))
+	}
+	r := (*notified)[0]
+	if r.ForkHeight != old.Height-1 || r.OldHeight != old.Height || r.OldHash != old.Hash || r.NewHeight != blocks[3].Height || r.NewHash != blocks[3].Hash {
+		t.Errorf("reorg = %+v", r)
+	}
+	if id := d.GetLastReorgID(); id != 1 {
+		t.Errorf("GetLastReorgID() = %d, want 1", id)
+	}
+	if w.pendingReorg != nil {
+		t.Error("pendingReorg not cleared")
+	}
+}
+
+func TestSyncWorker_addPendingReorg(t *testing.T) {
+	w := &SyncWorker{}
+	w.addPendingReorg(&Reorg{ForkHeight: 500, OldHeight: 503, OldHash: "503", Txids: []string{"a", "b"}}, map[string][]string{"x": {"a"}})
+	// the second fork happens before the first reorg is completed, deeper and with one of the txs again
+	w.addPendingReorg(&Reorg{ForkHeight: 499, OldHeight: 502, OldHash: "502R", Txids: []string{"b", "c"}}, map[string][]string{"x": {"c"}, "y": {"b"}})
+	want := &pendingReorg{
+		reorg:     &Reorg{ForkHeight: 499, OldHeight: 503, OldHash: "503", Txids: []string{"a", "b", "c"}},
+		addrTxids: map[string][]string{"x": {"a", "c"}, "y": {"b"}},
+	}
+	if !reflect.DeepEqual(w.pendingReorg, want) {
+		t.Errorf("pendingReorg = %+v %v, want %+v %v", w.pendingReorg.reorg, w.pendingReorg.addrTxids, want.reorg, want.addrTxids)
+	}
+}
@@ -23,7 +23,7 @@ import (
 	"github.com/trezor/blockbook/common"
 )
 
-const dbVersion = 7
+const dbVersion = 8
 
 const packedHeightBytes = 4
 const maxAddrDescLen = 1024
@@ -77,6 +77,9 @@ type RocksDB struct {
 	connectBlockMux       sync.Mutex
 	addrContractsCacheMux sync.Mutex
 	addrContractsCache    map[string]*unpackedAddrContracts
+	secondary             *secondaryState
+	watchlist             *watchlist
+	watchlistRescan       *watchlist
 }
 
 const (
@@ -90,6 +93,21 @@ const (
 	cfAddressBalance
 	cfTxAddresses
 	cfBlockFilter
+	cfReorgs
+	cfWatchlist
+	// Particl
+	cfColdStakingBalance
+	cfStakingRewards
+	cfKeyImages
+	cfAnonOutputs
+	cfBlindOutputs
+	cfStealthOutputs
+	cfTxOutputBlobs
+	cfVotes
+	cfTreasury
+	cfSupply
+	cfCoinstakes
+	cfPrivacyStats
 
 	__break__
 
@@ -109,10 +127,11 @@ var cfNames []string
 var cfBaseNames = []string{"default", "height", "addresses", "blockTxs", "transactions", "fiatRates"}
 
 // type specific columns
-var cfNamesBitcoinType = []string{"addressBalance", "txAddresses", "blockFilter"}
+var cfNamesBitcoinType = []string{"addressBalance", "txAddresses", "blockFilter", "reorgs", "watchlist"}
+var cfNamesParticl = []string{"coldStakingBalance", "stakingRewards", "keyImages", "anonOutputs", "blindOutputs", "stealthOutputs", "txOutputBlobs", "votes", "treasury", "supply", "coinstakes", "privacyStats"}
 var cfNamesEthereumType = []string{"addressContracts", "internalData", "contracts", "functionSignatures", "blockInternalDataErrors", "addressAliases"}
 
-func openDB(path string, c *grocksdb.Cache, openFiles int) (*grocksdb.DB, []*grocksdb.ColumnFamilyHandle, error) {
+func openDB(path string, secondaryPath string, c *grocksdb.Cache, openFiles int) (*grocksdb.DB, []*grocksdb.ColumnFamilyHandle, error) {
 	// opts with bloom filter
 	opts := createAndSetDBOptions(10, c, openFiles)
 	// opts for addresses without bloom filter
@@ -120,12 +139,24 @@ func openDB(path string, c *grocksdb.Cache, openFiles int) (*grocksdb.DB, []*gro
 	optsAddresses := createAndSetDBOptions(0, c, openFiles)
 	// default, height, addresses, blockTxids, transactions
 	cfOptions := []*grocksdb.Options{opts, opts, optsAddresses, opts, opts, opts}
+	// opts for the incompressible commitments and range proofs
+	optsBlobs := createAndSetBlobDBOptions(c, openFiles)
 	// append type specific options
-	count := len(cfNames) - len(cfOptions)
-	for i := 0; i < count; i++ {
-		cfOptions = append(cfOptions, opts)
+	for _, name := range cfNames[len(cfOptions):] {
+		if name == "txOutputBlobs" {
+			cfOptions = append(cfOptions, optsBlobs)
+		} else {
+			cfOptions = append(cfOptions, opts)
+		}
+	}
+	var db *grocksdb.DB
+	var cfh []*grocksdb.ColumnFamilyHandle
+	var err error
+	if secondaryPath != "" {
+		db, cfh, err = grocksdb.OpenDbAsSecondaryColumnFamilies(opts, path, secondaryPath, cfNames, cfOptions)
+	} else {
+		db, cfh, err = grocksdb.OpenDbColumnFamilies(opts, path, cfNames, cfOptions)
 	}
-	db, cfh, err := grocksdb.OpenDbColumnFamilies(opts, path, cfNames, cfOptions)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -136,11 +167,18 @@ func openDB(path string, c *grocksdb.Cache, openFiles int) (*grocksdb.DB, []*gro
 // needs to be called to release it.
 func NewRocksDB(path string, cacheSize, maxOpenFiles int, parser bchain.BlockChainParser, metrics *common.Metrics, extendedIndex bool) (d *RocksDB, err error) {
 	glog.Infof("rocksdb: opening %s, required data version %v, cache size %v, max open files %v", path, dbVersion, cacheSize, maxOpenFiles)
+	return newRocksDB(path, "", cacheSize, maxOpenFiles, parser, metrics, extendedIndex)
+}
+
+func newRocksDB(path string, secondaryPath string, cacheSize, maxOpenFiles int, parser bchain.BlockChainParser, metrics *common.Metrics, extendedIndex bool) (d *RocksDB, err error) {
 
 	cfNames = append([]string{}, cfBaseNames...)
 	chainType := parser.GetChainType()
 	if chainType == bchain.ChainBitcoinType {
 		cfNames = append(cfNames, cfNamesBitcoinType...)
+		if _, ok := parser.(*part.ParticlParser); ok {
+			cfNames = append(cfNames, cfNamesParticl...)
+		}
 	} else if chainType == bchain.ChainEthereumType {
 		cfNames = append(cfNames, cfNamesEthereumType...)
 		extendedIndex = false
@@ -149,14 +187,16 @@ func NewRocksDB(path string, cacheSize, maxOpenFiles int, parser bchain.BlockCha
 	}
 
 	c := grocksdb.NewLRUCache(uint64(cacheSize))
-	db, cfh, err := openDB(path, c, maxOpenFiles)
+	db, cfh, err := openDB(path, secondaryPath, c, maxOpenFiles)
 	if err != nil {
 		return nil, err
 	}
 	wo := grocksdb.NewDefaultWriteOptions()
 	ro := grocksdb.NewDefaultReadOptions()
-	r := &RocksDB{path, db, wo, ro, cfh, parser, nil, metrics, c, maxOpenFiles, connectBlockStats{}, extendedIndex, sync.Mutex{}, sync.Mutex{}, make(map[string]*unpackedAddrContracts)}
-	if chainType == bchain.ChainEthereumType {
+	r := &RocksDB{path, db, wo, ro, cfh, parser, nil, metrics, c, maxOpenFiles, connectBlockStats{}, extendedIndex, sync.Mutex{}, sync.Mutex{}, make(map[string]*unpackedAddrContracts), nil, nil, nil}
+	if secondaryPath != "" {
+		r.secondary = &secondaryState{path: secondaryPath}
+	} else if chainType == bchain.ChainEthereumType {
 		go r.periodicStoreAddrContractsCache()
 	}
 	return r, nil
@@ -173,6 +213,14 @@ func (d *RocksDB) closeDB() error {
 
 // Close releases the RocksDB environment opened in NewRocksDB.
 func (d *RocksDB) Close() error {
+	if d.db != nil && d.secondary != nil {
+		// the secondary instance does not write to the database
+		glog.Infof("rocksdb: close secondary")
+		d.closeDB()
+		d.wo.Destroy()
+		d.ro.Destroy()
+		return nil
+	}
 	if d.db != nil {
 		// store cached address contracts
 		if d.chainParser.GetChainType() == bchain.ChainEthereumType {
@@ -201,7 +249,11 @@ func (d *RocksDB) Reopen() error {
 		return err
 	}
 	d.db = nil
-	db, cfh, err := openDB(d.path, d.cache, d.maxOpenFiles)
+	var secondaryPath string
+	if d.secondary != nil {
+		secondaryPath = d.secondary.path
+	}
+	db, cfh, err := openDB(d.path, secondaryPath, d.cache, d.maxOpenFiles)
 	if err != nil {
 		return err
 	}
@@ -376,13 +428,31 @@ func (d *RocksDB) ConnectBlock(block *bchain.Block) error {
 		if err := d.processAddressesBitcoinType(block, addresses, txAddressesMap, balances, gf); err != nil {
 			return err
 		}
+		var pb *particlBlock
+		if d.isParticl() {
+			lastAnonIndex, err := d.GetLastAnonIndex()
+			if err != nil {
+				return err
+			}
+			lastSupply, err := d.GetLastSupply()
+			if err != nil {
+				return err
+			}
+			if pb, err = d.processParticlBlock(block, txAddressesMap, lastAnonIndex, lastSupply); err != nil {
+				return err
+			}
+		}
 		if err := d.storeTxAddresses(wb, txAddressesMap); err != nil {
 			return err
 		}
 		if err := d.storeBalances(wb, balances); err != nil {
 			return err
 		}
-		if err := d.storeAndCleanupBlockTxs(wb, block); err != nil {
+		if err := d.storeParticlBlock(wb, block.Height, pb); err != nil {
+			return err
+		}
+		d.storeWatchlist(wb, d.watchlist)
+		if err := d.storeAndCleanupBlockTxs(wb, block, pb.blockKeyImages()); err != nil {
 			return err
 		}
 		if gf != nil {
@@ -470,7 +540,8 @@ type TxOutput struct {
 	SpentIndex  uint32
 	SpentHeight uint32
 	// Particl privacy transaction fields
-	OutputType      string
+	OutputType string
+	// ValueCommitment and RangeProof are not stored in txAddresses, they are filled by LoadTxOutputBlobs
 	ValueCommitment string
 	RangeProof      string
 }
@@ -505,6 +576,18 @@ type AddrBalance struct {
 	BalanceSat big.Int
 	Utxos      []Utxo
 	utxosMap   map[string]int
+	// Particl cold staking, DelegatedSat is the part of BalanceSat delegated to a staking address,
+	// StakingSat is the value delegated to the address by other owners, it is not part of BalanceSat,
+	// StakingUtxos are the outputs delegated to the address, they are not part of Utxos
+	DelegatedSat      big.Int
+	StakingSat        big.Int
+	StakingUtxos      []Utxo
+	coldStakingStored bool
+	// Particl blind (CT) outputs with hidden value, not part of BalanceSat and Utxos
+	BlindReceived uint32
+	BlindSpent    uint32
+	BlindUtxos    []BlindUtxo
+	blindStored   bool
 }
 
 // ReceivedSat computes received amount from total balance and sent amount
@@ -576,17 +659,17 @@ func (ab *AddrBalance) addUtxoInDisconnect(u *Utxo) {
 	ab.manageUtxoMap(u)
 }
 
-// markUtxoAsSpent finds outpoint btxID:vout in utxos and marks it as spent
+// markUtxoAsSpent finds outpoint btxID:vout in utxos and marks it as spent, returns false if the outpoint is not found
 // for small number of utxos the linear search is done, for larger number there is a hashmap index
 // it is much faster than removing the utxo from the slice as it would cause in memory reallocations
-func (ab *AddrBalance) markUtxoAsSpent(btxID []byte, vout int32) {
+func (ab *AddrBalance) markUtxoAsSpent(btxID []byte, vout int32) bool {
 	if len(ab.utxosMap) == 0 {
 		for i := range ab.Utxos {
 			utxo := &ab.Utxos[i]
 			if utxo.Vout == vout && *(*int)(unsafe.Pointer(&utxo.BtxID[0])) == *(*int)(unsafe.Pointer(&btxID[0])) && bytes.Equal(utxo.BtxID, btxID) {
 				// mark utxo as spent by setting vout=-1
 				utxo.Vout = -1
-				return
+				return true
 			}
 		}
 	} else {
@@ -598,7 +681,7 @@ func (ab *AddrBalance) markUtxoAsSpent(btxID []byte, vout int32) {
 					if bytes.Equal(utxo.BtxID, btxID) {
 						// mark utxo as spent by setting vout=-1
 						utxo.Vout = -1
-						return
+						return true
 					}
 					break
 				}
@@ -606,6 +689,7 @@ func (ab *AddrBalance) markUtxoAsSpent(btxID []byte, vout int32) {
 		}
 	}
 	glog.Errorf("Utxo %s:%d not found, utxosMap size %d", hex.EncodeToString(btxID), vout, len(ab.utxosMap))
+	return false
 }
 
 type blockTxs struct {
@@ -631,6 +715,18 @@ func (d *RocksDB) GetAndResetConnectBlockStats() string {
 }
 
 func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses addressesMap, txAddressesMap map[string]*TxAddresses, balances map[string]*AddrBalance, gf *bchain.GolombFilter) error {
+	return d.processAddressesBitcoinTypeWatched(block, addresses, txAddressesMap, balances, gf, d.watchlist)
+}
+
+// processAddressesBitcoinTypeWatched processes the addresses of the block, in the watch-only mode (wl is not nil)
+// the addresses and balances are updated only for the addresses in the watchlist wl
+func (d *RocksDB) processAddressesBitcoinTypeWatched(block *bchain.Block, addresses addressesMap, txAddressesMap map[string]*TxAddresses, balances map[string]*AddrBalance, gf *bchain.GolombFilter, wl *watchlist) error {
+	// the outputs of the block may extend the derivation of the watched xpubs and make watched other addresses of the block
+	if err := wl.watchOutputs(block); err != nil {
+		return err
+	}
+	// the blind and cold staking outputs are indexed only for Particl
+	particl := d.isParticl()
 	blockTxIDs := make([][]byte, len(block.Txs))
 	blockTxAddresses := make([]*TxAddresses, len(block.Txs))
 	// first process all outputs so that inputs can refer to txs in this block
@@ -648,13 +744,8 @@ func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses add
 			} else {
 				ta.VSize = uint32(len(tx.Hex))
 			}
-			// Extract CT fee from CoinSpecificData for privacy transactions
-			if tx.CoinSpecificData != nil {
-				if particlData, ok := tx.CoinSpecificData.(*part.ParticlTxData); ok && particlData.CTFee > 0 {
-					// Convert PART to satoshis (1 PART = 100,000,000 satoshis)
-					ta.CTFeeSat = int64(particlData.CTFee * 100000000)
-				}
-			}
+			// CT fee of privacy transactions, from the data output or CoinSpecificData
+			ta.CTFeeSat = part.TxCTFeeSat(tx)
 		}
 		ta.Outputs = make([]TxOutput, len(tx.Vout))
 		txAddressesMap[string(btxID)] = &ta
@@ -665,8 +756,6 @@ func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses add
 			tao.ValueSat = output.ValueSat
 			// Particl privacy output fields
 			tao.OutputType = output.OutputType
-			tao.ValueCommitment = output.ValueCommitment
-			tao.RangeProof = output.RangeProof
 			addrDesc, err := d.chainParser.GetAddrDescFromVout(output)
 			if err != nil || len(addrDesc) == 0 || len(addrDesc) > maxAddrDescLen {
 				if err != nil {
@@ -684,20 +773,29 @@ func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses add
 			}
 			tao.AddrDesc = addrDesc
 			if d.chainParser.IsAddrDescIndexable(addrDesc) {
-				strAddrDesc := string(addrDesc)
-				balance, e := balances[strAddrDesc]
-				if !e {
-					balance, err = d.GetAddrDescBalance(addrDesc, addressBalanceDetailUTXOIndexed)
-					if err != nil {
-						return err
+				if particl {
+					if output.OutputType == "blind" {
+						if ownerDesc := blindOwnerAddrDesc(addrDesc); wl.contains(ownerDesc) {
+							if err = d.connectBlindOutput(addresses, balances, ownerDesc, btxID, int32(i), block.Height, output.ValueCommitment); err != nil {
+								return err
+							}
+						}
+						continue
 					}
-					if balance == nil {
-						balance = &AddrBalance{}
+					if spendDesc, stakingDesc := part.ColdStakingAddrDescs(addrDesc); spendDesc != nil {
+						if err = d.connectColdStakingOutput(addresses, balances, wl, spendDesc, stakingDesc, btxID, int32(i), block.Height, &output.ValueSat); err != nil {
+							return err
+						}
+						continue
 					}
-					balances[strAddrDesc] = balance
-					d.cbs.balancesMiss++
-				} else {
-					d.cbs.balancesHit++
+				}
+				if !wl.contains(addrDesc) {
+					continue
+				}
+				strAddrDesc := string(addrDesc)
+				balance, err := d.balanceForUpdate(balances, addrDesc)
+				if err != nil {
+					return err
 				}
 				balance.BalanceSat.Add(&balance.BalanceSat, &output.ValueSat)
 				balance.addUtxo(&Utxo{
@@ -759,7 +857,8 @@ func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses add
 				continue
 			}
 			spentOutput := &ita.Outputs[int(input.Vout)]
-			if spentOutput.Spent {
+			// the rescan of the watchlist processes the blocks with already spent outputs
+			if spentOutput.Spent && !wl.isRescan() {
 				glog.Warningf("rocksdb: height %d, tx %v, input tx %v vout %v is double spend", block.Height, tx.Txid, input.Txid, input.Vout)
 			}
 			if gf != nil {
@@ -788,27 +887,38 @@ func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses add
 				continue
 			}
 			if d.chainParser.IsAddrDescIndexable(spentOutput.AddrDesc) {
-				strAddrDesc := string(spentOutput.AddrDesc)
-				balance, e := balances[strAddrDesc]
-				if !e {
-					balance, err = d.GetAddrDescBalance(spentOutput.AddrDesc, addressBalanceDetailUTXOIndexed)
-					if err != nil {
-						return err
+				if particl {
+					if d.isBlindTxOutput(spentOutput) {
+						if ownerDesc := blindOwnerAddrDesc(spentOutput.AddrDesc); wl.contains(ownerDesc) {
+							if err = d.connectBlindInput(addresses, balances, wl, ownerDesc, spendingTxid, int32(i), btxID, int32(input.Vout)); err != nil {
+								return err
+							}
+						}
+						continue
 					}
-					if balance == nil {
-						balance = &AddrBalance{}
+					if spendDesc, stakingDesc := part.ColdStakingAddrDescs(spentOutput.AddrDesc); spendDesc != nil {
+						if err = d.connectColdStakingInput(addresses, balances, wl, spendDesc, stakingDesc, spendingTxid, int32(i), btxID, int32(input.Vout), &spentOutput.ValueSat); err != nil {
+							return err
+						}
+						continue
 					}
-					balances[strAddrDesc] = balance
-					d.cbs.balancesMiss++
-				} else {
-					d.cbs.balancesHit++
+				}
+				if !wl.contains(spentOutput.AddrDesc) {
+					continue
+				}
+				strAddrDesc := string(spentOutput.AddrDesc)
+				balance, err := d.balanceForUpdate(balances, spentOutput.AddrDesc)
+				if err != nil {
+					return err
 				}
 				counted := addToAddressesMap(addresses, strAddrDesc, spendingTxid, ^int32(i))
 				if !counted {
 					balance.Txs++
 				}
 				balance.BalanceSat.Sub(&balance.BalanceSat, &spentOutput.ValueSat)
-				balance.markUtxoAsSpent(btxID, int32(input.Vout))
+				if !balance.markUtxoAsSpent(btxID, int32(input.Vout)) {
+					wl.markIncomplete(spentOutput.AddrDesc)
+				}
 				if balance.BalanceSat.Sign() < 0 {
 					d.resetValueSatToZero(&balance.BalanceSat, spentOutput.AddrDesc, "balance")
 				}
@@ -819,6 +929,27 @@ func (d *RocksDB) processAddressesBitcoinType(block *bchain.Block, addresses add
 	return nil
 }
 
+// balanceForUpdate returns balance of the address from the balances map, loading it from db if it is not in the map yet
+func (d *RocksDB) balanceForUpdate(balances map[string]*AddrBalance, addrDesc bchain.AddressDescriptor) (*AddrBalance, error) {
+	strAddrDesc := string(addrDesc)
+	balance, e := balances[strAddrDesc]
+	if !e {
+		var err error
+		balance, err = d.GetAddrDescBalance(addrDesc, addressBalanceDetailUTXOIndexed)
+		if err != nil {
+			return nil, err
+		}
+		if balance == nil {
+			balance = &AddrBalance{}
+		}
+		balances[strAddrDesc] = balance
+		d.cbs.balancesMiss++
+	} else {
+		d.cbs.balancesHit++
+	}
+	return balance, nil
+}
+
 // addToAddressesMap maintains mapping between addresses and transactions in one block
 // the method assumes that outputs in the block are processed before the inputs
 // the return value is true if the tx was processed before, to not to count the tx multiple times
@@ -884,6 +1015,7 @@ func (d *RocksDB) storeBalances(wb *grocksdb.WriteBatch, abm map[string]*AddrBal
 	// allocate buffer initial buffer
 	buf := make([]byte, 1024)
 	varBuf := make([]byte, maxPackedBigintBytes)
+	particl := d.isParticl()
 	for addrDesc, ab := range abm {
 		// balance with 0 transactions is removed from db - happens on disconnect
 		if ab == nil || ab.Txs <= 0 {
@@ -892,6 +1024,10 @@ func (d *RocksDB) storeBalances(wb *grocksdb.WriteBatch, abm map[string]*AddrBal
 			buf = packAddrBalance(ab, buf, varBuf)
 			wb.PutCF(d.cfh[cfAddressBalance], bchain.AddressDescriptor(addrDesc), buf)
 		}
+		if particl {
+			d.storeColdStakingBalance(wb, bchain.AddressDescriptor(addrDesc), ab)
+			d.storeBlindBalance(wb, bchain.AddressDescriptor(addrDesc), ab)
+		}
 	}
 	return nil
 }
@@ -912,12 +1048,16 @@ func (d *RocksDB) cleanupBlockTxs(wb *grocksdb.WriteBatch, block *bchain.Block)
 			}
 			val.Free()
 			wb.DeleteCF(d.cfh[cfBlockTxs], key)
+			if d.isParticl() {
+				wb.DeleteCF(d.cfh[cfKeyImages], key)
+			}
 		}
 	}
 	return nil
 }
 
-func (d *RocksDB) storeAndCleanupBlockTxs(wb *grocksdb.WriteBatch, block *bchain.Block) error {
+// storeAndCleanupBlockTxs stores the txs and the key images of the block needed to disconnect the block and removes the old ones
+func (d *RocksDB) storeAndCleanupBlockTxs(wb *grocksdb.WriteBatch, block *bchain.Block, keyImages []blockKeyImage) error {
 	pl := d.chainParser.PackedTxidLen()
 	buf := make([]byte, 0, pl*len(block.Txs))
 	varBuf := make([]byte, vlq.MaxLen64)
@@ -950,6 +1090,7 @@ func (d *RocksDB) storeAndCleanupBlockTxs(wb *grocksdb.WriteBatch, block *bchain
 	}
 	key := packUint(block.Height)
 	wb.PutCF(d.cfh[cfBlockTxs], key, buf)
+	d.storeBlockKeyImages(wb, block, keyImages)
 	return d.cleanupBlockTxs(wb, block)
 }
 
@@ -995,7 +1136,19 @@ func (d *RocksDB) GetAddrDescBalance(addrDesc bchain.AddressDescriptor, detail A
 	if len(buf) < 3 {
 		return nil, nil
 	}
-	return unpackAddrBalance(buf, d.chainParser.PackedTxidLen(), detail)
+	ab, err := unpackAddrBalance(buf, d.chainParser.PackedTxidLen(), detail)
+	if err != nil {
+		return nil, err
+	}
+	if d.isParticl() {
+		if err = d.getColdStakingBalance(addrDesc, ab); err != nil {
+			return nil, err
+		}
+		if err = d.getBlindBalance(addrDesc, ab); err != nil {
+			return nil, err
+		}
+	}
+	return ab, nil
 }
 
 // GetAddressBalance returns address balance for an address or nil if address not found
@@ -1142,17 +1295,11 @@ func (d *RocksDB) appendTxOutput(txo *TxOutput, buf []byte, varBuf []byte) []byt
 		l = packVaruint(uint(txo.SpentHeight), varBuf)
 		buf = append(buf, varBuf[:l]...)
 	}
-	// Particl privacy fields (always stored, not just for extended index)
-	if d.extendedIndex {
+	// Particl output type, the commitment and the range proof are stored in the column txOutputBlobs
+	if d.storesOutputType() {
 		l = packVaruint(uint(len(txo.OutputType)), varBuf)
 		buf = append(buf, varBuf[:l]...)
 		buf = append(buf, []byte(txo.OutputType)...)
-		l = packVaruint(uint(len(txo.ValueCommitment)), varBuf)
-		buf = append(buf, varBuf[:l]...)
-		buf = append(buf, []byte(txo.ValueCommitment)...)
-		l = packVaruint(uint(len(txo.RangeProof)), varBuf)
-		buf = append(buf, varBuf[:l]...)
-		buf = append(buf, []byte(txo.RangeProof)...)
 	}
 	return buf
 }
@@ -1220,6 +1367,11 @@ func packAddrBalance(ab *AddrBalance, buf, varBuf []byte) []byte {
 }
 
 func (d *RocksDB) unpackTxAddresses(buf []byte) (*TxAddresses, error) {
+	return d.unpackTxAddressesVersion(buf, false)
+}
+
+// unpackTxAddressesVersion unpacks TxAddresses, v7 is the layout of DB version 7 with the commitments and range proofs in the outputs
+func (d *RocksDB) unpackTxAddressesVersion(buf []byte, v7 bool) (*TxAddresses, error) {
 	ta := TxAddresses{}
 	height, l := unpackVaruint(buf)
 	ta.Height = uint32(height)
@@ -1238,7 +1390,7 @@ func (d *RocksDB) unpackTxAddresses(buf []byte) (*TxAddresses, error) {
 	l += ll
 	ta.Outputs = make([]TxOutput, outputs)
 	for i := uint(0); i < outputs; i++ {
-		l += d.unpackTxOutput(&ta.Outputs[i], buf[l:])
+		l += d.unpackTxOutput(&ta.Outputs[i], buf[l:], v7)
 	}
 	// Unpack CT fee for privacy transactions (extended index only, backward compatible)
 	if d.extendedIndex && l < len(buf) {
@@ -1298,7 +1450,7 @@ func (d *RocksDB) unpackTxInput(ti *TxInput, buf []byte) int {
 	}
 }
 
-func (d *RocksDB) unpackTxOutput(to *TxOutput, buf []byte) int {
+func (d *RocksDB) unpackTxOutput(to *TxOutput, buf []byte, v7 bool) int {
 	al, l := unpackVarint(buf)
 	if al < 0 {
 		to.Spent = true
@@ -1320,8 +1472,8 @@ func (d *RocksDB) unpackTxOutput(to *TxOutput, buf []byte) int {
 		to.SpentHeight = uint32(i)
 		al += l
 	}
-	// Particl privacy fields (only if buffer has more data and extended index is enabled)
-	if d.extendedIndex && al < len(buf) {
+	// Particl privacy fields (only if buffer has more data and the output type is stored)
+	if d.storesOutputType() && al < len(buf) {
 		var fieldLen uint
 		fieldLen, l = unpackVaruint(buf[al:])
 		al += l
@@ -1329,6 +1481,9 @@ func (d *RocksDB) unpackTxOutput(to *TxOutput, buf []byte) int {
 			to.OutputType = string(buf[al : al+int(fieldLen)])
 			al += int(fieldLen)
 		}
+		if !v7 {
+			return al
+		}
 		fieldLen, l = unpackVaruint(buf[al:])
 		al += l
 		if fieldLen > 0 {
@@ -1606,10 +1761,10 @@ func (d *RocksDB) disconnectTxAddressesInputs(wb *grocksdb.WriteBatch, btxID []b
 	addressFoundInTx func(addrDesc bchain.AddressDescriptor, btxID []byte) bool) error {
 	var err error
 	var balance *AddrBalance
+	particl := d.isParticl()
 	for i, t := range txa.Inputs {
 		if len(t.AddrDesc) > 0 {
 			input := &inputs[i]
-			exist := addressFoundInTx(t.AddrDesc, btxID)
 			s := string(input.btxID)
 			sa, found := txAddressesToUpdate[s]
 			if !found {
@@ -1622,10 +1777,27 @@ func (d *RocksDB) disconnectTxAddressesInputs(wb *grocksdb.WriteBatch, btxID []b
 				}
 			}
 			var inputHeight uint32
+			var spentOutput *TxOutput
 			if sa != nil {
-				sa.Outputs[input.index].Spent = false
+				spentOutput = &sa.Outputs[input.index]
+				spentOutput.Spent = false
 				inputHeight = sa.Height
 			}
+			if particl {
+				if spentOutput != nil && d.isBlindTxOutput(spentOutput) && d.chainParser.IsAddrDescIndexable(t.AddrDesc) {
+					if err = d.disconnectBlindInput(btxID, input, inputHeight, blindOwnerAddrDesc(t.AddrDesc), getAddressBalance, addressFoundInTx); err != nil {
+						return err
+					}
+					continue
+				}
+				if spendDesc, stakingDesc := part.ColdStakingAddrDescs(t.AddrDesc); spendDesc != nil {
+					if err = d.disconnectColdStakingInput(btxID, input, inputHeight, spendDesc, stakingDesc, &t.ValueSat, getAddressBalance, addressFoundInTx); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			exist := addressFoundInTx(t.AddrDesc, btxID)
 			if d.chainParser.IsAddrDescIndexable(t.AddrDesc) {
 				balance, err = getAddressBalance(t.AddrDesc)
 				if err != nil {
@@ -1647,7 +1819,7 @@ func (d *RocksDB) disconnectTxAddressesInputs(wb *grocksdb.WriteBatch, btxID []b
 						Height:   inputHeight,
 						ValueSat: t.ValueSat,
 					})
-				} else {
+				} else if d.watchlist.contains(t.AddrDesc) {
 					ad, _, _ := d.chainParser.GetAddressesFromAddrDesc(t.AddrDesc)
 					glog.Warningf("Balance for address %s (%s) not found", ad, t.AddrDesc)
 				}
@@ -1660,8 +1832,24 @@ func (d *RocksDB) disconnectTxAddressesInputs(wb *grocksdb.WriteBatch, btxID []b
 func (d *RocksDB) disconnectTxAddressesOutputs(wb *grocksdb.WriteBatch, btxID []byte, txa *TxAddresses,
 	getAddressBalance func(addrDesc bchain.AddressDescriptor) (*AddrBalance, error),
 	addressFoundInTx func(addrDesc bchain.AddressDescriptor, btxID []byte) bool) error {
-	for i, t := range txa.Outputs {
+	particl := d.isParticl()
+	for i := range txa.Outputs {
+		t := &txa.Outputs[i]
 		if len(t.AddrDesc) > 0 {
+			if particl {
+				if d.isBlindTxOutput(t) && d.chainParser.IsAddrDescIndexable(t.AddrDesc) {
+					if err := d.disconnectBlindOutput(btxID, int32(i), blindOwnerAddrDesc(t.AddrDesc), getAddressBalance, addressFoundInTx); err != nil {
+						return err
+					}
+					continue
+				}
+				if spendDesc, stakingDesc := part.ColdStakingAddrDescs(t.AddrDesc); spendDesc != nil {
+					if err := d.disconnectColdStakingOutput(btxID, int32(i), spendDesc, stakingDesc, &t.ValueSat, getAddressBalance, addressFoundInTx); err != nil {
+						return err
+					}
+					continue
+				}
+			}
 			exist := addressFoundInTx(t.AddrDesc, btxID)
 			if d.chainParser.IsAddrDescIndexable(t.AddrDesc) {
 				balance, err := getAddressBalance(t.AddrDesc)
@@ -1678,7 +1866,7 @@ func (d *RocksDB) disconnectTxAddressesOutputs(wb *grocksdb.WriteBatch, btxID []
 						d.resetValueSatToZero(&balance.BalanceSat, t.AddrDesc, "balance")
 					}
 					balance.markUtxoAsSpent(btxID, int32(i))
-				} else {
+				} else if d.watchlist.contains(t.AddrDesc) {
 					ad, _, _ := d.chainParser.GetAddressesFromAddrDesc(t.AddrDesc)
 					glog.Warningf("Balance for address %s (%s) not found", ad, t.AddrDesc)
 				}
@@ -1771,6 +1959,9 @@ func (d *RocksDB) disconnectBlock(height uint32, blockTxs []blockTxs) error {
 		if err := d.disconnectTxAddressesOutputs(wb, btxID, txa, getAddressBalance, addressFoundInTx); err != nil {
 			return err
 		}
+		if d.isParticl() {
+			d.disconnectTxOutputBlobs(wb, btxID, txa)
+		}
 	}
 	for a := range blockAddressesTxs {
 		key := packAddressKey([]byte(a), height)
@@ -1786,6 +1977,11 @@ func (d *RocksDB) disconnectBlock(height uint32, blockTxs []blockTxs) error {
 		wb.DeleteCF(d.cfh[cfTransactions], b)
 		wb.DeleteCF(d.cfh[cfTxAddresses], b)
 	}
+	if d.isParticl() {
+		if err := d.disconnectParticlBlock(wb, height, blockAddressesTxs); err != nil {
+			return err
+		}
+	}
 	if err := d.disconnectBlockFilter(wb, height); err != nil {
 		return err
 	}
@@ -1795,6 +1991,8 @@ func (d *RocksDB) disconnectBlock(height uint32, blockTxs []blockTxs) error {
 // DisconnectBlockRangeBitcoinType removes all data belonging to blocks in range lower-higher
 // it is able to disconnect only blocks for which there are data in the blockTxs column
 func (d *RocksDB) DisconnectBlockRangeBitcoinType(lower uint32, higher uint32) error {
+	d.connectBlockMux.Lock()
+	defer d.connectBlockMux.Unlock()
 	blocks := make([][]blockTxs, higher-lower+1)
 	for height := lower; height <= higher; height++ {
 		blockTxs, err := d.getBlockTxs(height)
@@ -2061,6 +2259,76 @@ func (d *RocksDB) migrateVersion6To7(sc, nc *common.InternalStateColumn) error {
 	return nil
 }
 
+const migrateTxAddressesBatch = 100000
+
+// migrateTxAddressesToV8 rewrites the column txAddresses of the extended index without the commitments and range proofs,
+// which were stored in the outputs of all coins in DB version 7
+func (d *RocksDB) migrateTxAddressesToV8(approxRows int64) error {
+	glog.Info("MigrateTxAddresses: starting, will process approximately ", approxRows, " rows")
+	var row int64
+	var seekKey []byte
+	// do not use cache
+	ro := grocksdb.NewDefaultReadOptions()
+	ro.SetFillCache(false)
+	buf := make([]byte, 1024)
+	varBuf := make([]byte, maxPackedBigintBytes)
+	for {
+		var btxID []byte
+		it := d.db.NewIteratorCF(ro, d.cfh[cfTxAddresses])
+		if row == 0 {
+			it.SeekToFirst()
+		} else {
+			glog.Info("MigrateTxAddresses: row ", row)
+			it.Seek(seekKey)
+			it.Next()
+		}
+
+		wb := grocksdb.NewWriteBatch()
+		for count := 0; it.Valid() && count < migrateTxAddressesBatch; it.Next() {
+			btxID = append([]byte{}, it.Key().Data()...)
+			count++
+			row++
+			ta, err := d.unpackTxAddressesVersion(it.Value().Data(), true)
+			if err != nil {
+				glog.Error(err, ", ", hex.EncodeToString(btxID))
+				continue
+			}
+			buf = d.packTxAddresses(ta, buf, varBuf)
+			wb.PutCF(d.cfh[cfTxAddresses], btxID, buf)
+		}
+		err := d.WriteBatch(wb)
+		wb.Destroy()
+		if err != nil {
+			return errors.Errorf("error storing repacked data %v", err)
+		}
+
+		seekKey = btxID
+		valid := it.Valid()
+		it.Close()
+		if !valid {
+			break
+		}
+	}
+	glog.Info("MigrateTxAddresses: finished, migrated ", row, " rows")
+	return nil
+}
+
+func (d *RocksDB) migrateVersion7To8(sc, nc *common.InternalStateColumn) error {
+	// DB v8 changes the Particl specific index: the cold staking outputs are credited both to the spend and to the staking address
+	// and the commitments and range proofs are stored in the column txOutputBlobs, the index cannot be migrated
+	if d.isParticl() {
+		return errors.Errorf("DB version %v of column '%v' does not contain the Particl specific index of version %v, reindex required.", sc.Version, sc.Name, dbVersion)
+	}
+	// the extended index of other coins stored the (empty) commitments and range proofs in txAddresses, rewrite it without them
+	if d.chainParser.GetChainType() == bchain.ChainBitcoinType && d.extendedIndex && nc.Name == "txAddresses" {
+		if err := d.migrateTxAddressesToV8(sc.Rows); err != nil {
+			return err
+		}
+	}
+	glog.Infof("Column %s migrated from v%d to v%d", nc.Name, sc.Version, dbVersion)
+	return nil
+}
+
 func (d *RocksDB) checkColumns(is *common.InternalState) ([]common.InternalStateColumn, error) {
 	// make sure that column stats match the columns
 	sc := is.DbColumns
@@ -2068,6 +2336,10 @@ func (d *RocksDB) checkColumns(is *common.InternalState) ([]common.InternalState
 	for i := 0; i < len(nc); i++ {
 		nc[i].Name = cfNames[i]
 		nc[i].Version = dbVersion
+		// the Particl supply is a running total from the genesis block, it cannot be added to an existing DB
+		if len(sc) > 0 && nc[i].Name == "supply" && d.isParticl() && !containsColumn(sc, nc[i].Name) {
+			return nil, errors.Errorf("DB does not contain the column '%v', reindex required.", nc[i].Name)
+		}
 		for j := 0; j < len(sc); j++ {
 			if sc[j].Name == nc[i].Name {
 				// check the version of the column, if it does not match, the db is not compatible
@@ -2082,6 +2354,11 @@ func (d *RocksDB) checkColumns(is *common.InternalState) ([]common.InternalState
 						if err != nil {
 							return nil, err
 						}
+					} else if sc[j].Version == 7 && dbVersion == 8 {
+						err := d.migrateVersion7To8(&sc[j], &nc[i])
+						if err != nil {
+							return nil, err
+						}
 					} else {
 						return nil, errors.Errorf("DB version %v of column '%v' does not match the required version %v. DB is not compatible.", sc[j].Version, sc[j].Name, dbVersion)
 					}
@@ -2097,6 +2374,15 @@ func (d *RocksDB) checkColumns(is *common.InternalState) ([]common.InternalState
 	return nc, nil
 }
 
+func containsColumn(sc []common.InternalStateColumn, name string) bool {
+	for i := range sc {
+		if sc[i].Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // LoadInternalState loads from db internal state or initializes a new one if not yet stored
 func (d *RocksDB) LoadInternalState(config *common.Config) (*common.InternalState, error) {
 	val, err := d.db.GetCF(d.ro, d.cfh[cfDefault], []byte(internalStateKey))
@@ -2112,6 +2398,7 @@ func (d *RocksDB) LoadInternalState(config *common.Config) (*common.InternalStat
 			UtxoChecked:             true,
 			SortedAddressContracts:  true,
 			ExtendedIndex:           d.extendedIndex,
+			WatchOnly:               d.watchlist != nil,
 			BlockGolombFilterP:      config.BlockGolombFilterP,
 			BlockFilterScripts:      config.BlockFilterScripts,
 			BlockFilterUseZeroedKey: config.BlockFilterUseZeroedKey,
@@ -2131,6 +2418,10 @@ func (d *RocksDB) LoadInternalState(config *common.Config) (*common.InternalStat
 		if is.ExtendedIndex != d.extendedIndex {
 			return nil, errors.Errorf("ExtendedIndex setting does not match. DB extendedIndex %v, extendedIndex in options %v", is.ExtendedIndex, d.extendedIndex)
 		}
+		// the secondary instance only reads the index written by the primary instance
+		if d.secondary == nil && is.WatchOnly != (d.watchlist != nil) {
+			return nil, errors.Errorf("WatchOnly setting does not match. DB watchOnly %v, watchOnly in options %v", is.WatchOnly, d.watchlist != nil)
+		}
 		if is.BlockGolombFilterP != config.BlockGolombFilterP {
 			return nil, errors.Errorf("BlockGolombFilterP does not match. DB BlockGolombFilterP %v, config BlockGolombFilterP %v", is.BlockGolombFilterP, config.BlockGolombFilterP)
 		}
@@ -1469,6 +1469,8 @@ func (d *RocksDB) disconnectBlockTxsEthereumType(wb *grocksdb.WriteBatch, height
 // DisconnectBlockRangeEthereumType removes all data belonging to blocks in range lower-higher
 // it is able to disconnect only blocks for which there are data in the blockTxs column
 func (d *RocksDB) DisconnectBlockRangeEthereumType(lower uint32, higher uint32) error {
+	d.connectBlockMux.Lock()
+	defer d.connectBlockMux.Unlock()
 	blocks := make([][]ethBlockTx, higher-lower+1)
 	for height := lower; height <= higher; height++ {
 		blockTxs, err := d.getBlockTxsEthereumType(height)
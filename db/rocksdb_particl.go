@@ -0,0 +1,2093 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"sort"
+
+	vlq "github.com/bsm/go-vlq"
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/linxGnu/grocksdb"
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/bchain/coins/part"
+)
+
+// isParticl returns true if the database indexes Particl, only then the Particl columns exist
+func (d *RocksDB) isParticl() bool {
+	_, ok := d.chainParser.(*part.ParticlParser)
+	return ok
+}
+
+// particlBlock contains the Particl specific index of one block, which is stored in the Particl columns
+type particlBlock struct {
+	stakingRewards map[string]*big.Int
+	keyImages      []blockKeyImage
+	anonOutputs    []blockAnonOutput
+	lastAnonIndex  uint64
+	stealthOutputs []blockStealthOutput
+	txOutputBlobs  []blockTxOutputBlob
+	vote           *blockVote
+	treasury       *blockTreasury
+	supply         *Supply
+	coinstake      *Coinstake
+	privacyStats   *PrivacyStats
+}
+
+// processParticlBlock computes the Particl specific index of the block, lastAnonIndex and lastSupply are
+// the last global index of anon output and the supply before the block
+func (d *RocksDB) processParticlBlock(block *bchain.Block, txAddressesMap map[string]*TxAddresses, lastAnonIndex uint64, lastSupply *Supply) (*particlBlock, error) {
+	pb := &particlBlock{}
+	var err error
+	if pb.stakingRewards, err = d.blockStakingRewards(block, txAddressesMap); err != nil {
+		return nil, err
+	}
+	d.watchlist.filterStakingRewards(pb.stakingRewards)
+	if pb.keyImages, err = d.blockKeyImages(block); err != nil {
+		return nil, err
+	}
+	if pb.anonOutputs, pb.lastAnonIndex, err = d.blockAnonOutputs(block, lastAnonIndex); err != nil {
+		return nil, err
+	}
+	if pb.stealthOutputs, err = d.blockStealthOutputs(block); err != nil {
+		return nil, err
+	}
+	if pb.txOutputBlobs, err = d.blockTxOutputBlobs(block); err != nil {
+		return nil, err
+	}
+	if pb.vote, err = d.blockVote(block, txAddressesMap); err != nil {
+		return nil, err
+	}
+	if pb.treasury, err = d.blockTreasury(block); err != nil {
+		return nil, err
+	}
+	if pb.supply, err = d.blockSupply(block, txAddressesMap, lastSupply); err != nil {
+		return nil, err
+	}
+	if pb.coinstake, err = d.blockCoinstake(block, txAddressesMap); err != nil {
+		return nil, err
+	}
+	if pb.privacyStats, err = d.blockPrivacyStats(block, txAddressesMap); err != nil {
+		return nil, err
+	}
+	return pb, nil
+}
+
+// storeParticlBlock stores the Particl specific index of the block, nothing is stored for nil pb
+func (d *RocksDB) storeParticlBlock(wb *grocksdb.WriteBatch, height uint32, pb *particlBlock) error {
+	if pb == nil {
+		return nil
+	}
+	d.storeStakingRewards(wb, height, pb.stakingRewards)
+	d.storeKeyImages(wb, height, pb.keyImages)
+	d.storeAnonOutputs(wb, height, pb.anonOutputs)
+	d.storeStealthOutputs(wb, height, pb.stealthOutputs)
+	d.storeTxOutputBlobs(wb, pb.txOutputBlobs)
+	d.storeVote(wb, height, pb.vote)
+	d.storeTreasury(wb, height, pb.treasury)
+	d.storeSupply(wb, pb.supply)
+	if err := d.storeCoinstake(wb, pb.coinstake); err != nil {
+		return err
+	}
+	d.storePrivacyStats(wb, pb.privacyStats)
+	return nil
+}
+
+// blockKeyImages returns the key images of the block, nil for nil pb
+func (pb *particlBlock) blockKeyImages() []blockKeyImage {
+	if pb == nil {
+		return nil
+	}
+	return pb.keyImages
+}
+
+// disconnectParticlBlock removes the Particl specific index of the block at given height,
+// addresses are the addresses of the block
+func (d *RocksDB) disconnectParticlBlock(wb *grocksdb.WriteBatch, height uint32, addresses map[string]map[string]struct{}) error {
+	for a := range addresses {
+		wb.DeleteCF(d.cfh[cfStakingRewards], packAddressKey([]byte(a), height))
+	}
+	key := packUint(height)
+	wb.DeleteCF(d.cfh[cfStealthOutputs], key)
+	wb.DeleteCF(d.cfh[cfTreasury], key)
+	wb.DeleteCF(d.cfh[cfSupply], key)
+	wb.DeleteCF(d.cfh[cfCoinstakes], key)
+	wb.DeleteCF(d.cfh[cfPrivacyStats], key)
+	if err := d.disconnectKeyImages(wb, height); err != nil {
+		return err
+	}
+	if err := d.disconnectAnonOutputs(wb, height); err != nil {
+		return err
+	}
+	return d.disconnectVote(wb, height)
+}
+
+// Particl cold staking
+// The output with cold staking script is owned by the spend (P2PKH) address and can be staked by the staking (P2CS or P2SH256) address.
+// The value of the output is credited to the balance of the spend address and marked there as delegated,
+// the staking address keeps the value in StakingSat, which is not part of its balance.
+// Both addresses get the transaction in their history.
+
+// getColdStakingBalance loads the cold staking part of the address balance
+func (d *RocksDB) getColdStakingBalance(addrDesc bchain.AddressDescriptor, ab *AddrBalance) error {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfColdStakingBalance], addrDesc)
+	if err != nil {
+		return err
+	}
+	defer val.Free()
+	buf := val.Data()
+	if len(buf) < 2 {
+		return nil
+	}
+	delegatedSat, l := unpackBigint(buf)
+	stakingSat, ll := unpackBigint(buf[l:])
+	l += ll
+	ab.DelegatedSat = delegatedSat
+	ab.StakingSat = stakingSat
+	txidUnpackedLen := d.chainParser.PackedTxidLen()
+	for len(buf[l:]) >= txidUnpackedLen+3 {
+		u := Utxo{BtxID: append([]byte(nil), buf[l:l+txidUnpackedLen]...)}
+		l += txidUnpackedLen
+		vout, ll := unpackVaruint(buf[l:])
+		l += ll
+		height, ll := unpackVaruint(buf[l:])
+		l += ll
+		u.ValueSat, ll = unpackBigint(buf[l:])
+		l += ll
+		u.Vout = int32(vout)
+		u.Height = uint32(height)
+		ab.StakingUtxos = append(ab.StakingUtxos, u)
+	}
+	ab.coldStakingStored = true
+	return nil
+}
+
+// storeColdStakingBalance stores the cold staking part of the address balance, empty entries are removed
+func (d *RocksDB) storeColdStakingBalance(wb *grocksdb.WriteBatch, addrDesc bchain.AddressDescriptor, ab *AddrBalance) {
+	if ab == nil || ab.Txs <= 0 || (ab.DelegatedSat.Sign() == 0 && ab.StakingSat.Sign() == 0 && len(ab.StakingUtxos) == 0) {
+		if ab != nil && ab.coldStakingStored {
+			wb.DeleteCF(d.cfh[cfColdStakingBalance], addrDesc)
+			ab.coldStakingStored = false
+		}
+		return
+	}
+	varBuf := make([]byte, maxPackedBigintBytes)
+	buf := make([]byte, 0, 2*maxPackedBigintBytes+len(ab.StakingUtxos)*(d.chainParser.PackedTxidLen()+2*vlq.MaxLen64+maxPackedBigintBytes))
+	l := packBigint(&ab.DelegatedSat, varBuf)
+	buf = append(buf, varBuf[:l]...)
+	l = packBigint(&ab.StakingSat, varBuf)
+	buf = append(buf, varBuf[:l]...)
+	for i := range ab.StakingUtxos {
+		u := &ab.StakingUtxos[i]
+		buf = append(buf, u.BtxID...)
+		l = packVaruint(uint(u.Vout), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		l = packVaruint(uint(u.Height), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		l = packBigint(&u.ValueSat, varBuf)
+		buf = append(buf, varBuf[:l]...)
+	}
+	wb.PutCF(d.cfh[cfColdStakingBalance], addrDesc, buf)
+	ab.coldStakingStored = true
+}
+
+// removeStakingUtxo removes the output delegated to the staking address, returns false if the output is not found
+func (ab *AddrBalance) removeStakingUtxo(btxID []byte, vout int32) bool {
+	for i := range ab.StakingUtxos {
+		u := &ab.StakingUtxos[i]
+		if u.Vout == vout && bytes.Equal(u.BtxID, btxID) {
+			ab.StakingUtxos = append(ab.StakingUtxos[:i], ab.StakingUtxos[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// connectColdStakingOutput credits cold staking output to the spend and the staking address, only the addresses
+// in the watchlist wl are updated
+func (d *RocksDB) connectColdStakingOutput(addresses addressesMap, balances map[string]*AddrBalance, wl *watchlist, spendDesc, stakingDesc bchain.AddressDescriptor,
+	btxID []byte, vout int32, height uint32, valueSat *big.Int) error {
+	if wl.contains(spendDesc) {
+		balance, err := d.balanceForUpdate(balances, spendDesc)
+		if err != nil {
+			return err
+		}
+		balance.BalanceSat.Add(&balance.BalanceSat, valueSat)
+		balance.DelegatedSat.Add(&balance.DelegatedSat, valueSat)
+		balance.addUtxo(&Utxo{
+			BtxID:    btxID,
+			Vout:     vout,
+			Height:   height,
+			ValueSat: *valueSat,
+		})
+		if counted := addToAddressesMap(addresses, string(spendDesc), btxID, vout); !counted {
+			balance.Txs++
+		}
+	}
+	if wl.contains(stakingDesc) {
+		staking, err := d.balanceForUpdate(balances, stakingDesc)
+		if err != nil {
+			return err
+		}
+		staking.StakingSat.Add(&staking.StakingSat, valueSat)
+		staking.StakingUtxos = append(staking.StakingUtxos, Utxo{
+			BtxID:    btxID,
+			Vout:     vout,
+			Height:   height,
+			ValueSat: *valueSat,
+		})
+		if counted := addToAddressesMap(addresses, string(stakingDesc), btxID, vout); !counted {
+			staking.Txs++
+		}
+	}
+	return nil
+}
+
+// connectColdStakingInput debits spent cold staking output from the spend and the staking address, only the addresses
+// in the watchlist wl are updated
+func (d *RocksDB) connectColdStakingInput(addresses addressesMap, balances map[string]*AddrBalance, wl *watchlist, spendDesc, stakingDesc bchain.AddressDescriptor,
+	spendingTxid []byte, index int32, btxID []byte, vout int32, valueSat *big.Int) error {
+	if wl.contains(spendDesc) {
+		balance, err := d.balanceForUpdate(balances, spendDesc)
+		if err != nil {
+			return err
+		}
+		if counted := addToAddressesMap(addresses, string(spendDesc), spendingTxid, ^index); !counted {
+			balance.Txs++
+		}
+		balance.BalanceSat.Sub(&balance.BalanceSat, valueSat)
+		if !balance.markUtxoAsSpent(btxID, vout) {
+			wl.markIncomplete(spendDesc)
+		}
+		if balance.BalanceSat.Sign() < 0 {
+			d.resetValueSatToZero(&balance.BalanceSat, spendDesc, "balance")
+		}
+		balance.DelegatedSat.Sub(&balance.DelegatedSat, valueSat)
+		if balance.DelegatedSat.Sign() < 0 {
+			d.resetValueSatToZero(&balance.DelegatedSat, spendDesc, "delegated balance")
+		}
+		balance.SentSat.Add(&balance.SentSat, valueSat)
+	}
+	if wl.contains(stakingDesc) {
+		staking, err := d.balanceForUpdate(balances, stakingDesc)
+		if err != nil {
+			return err
+		}
+		if counted := addToAddressesMap(addresses, string(stakingDesc), spendingTxid, ^index); !counted {
+			staking.Txs++
+		}
+		staking.StakingSat.Sub(&staking.StakingSat, valueSat)
+		if staking.StakingSat.Sign() < 0 {
+			d.resetValueSatToZero(&staking.StakingSat, stakingDesc, "staking balance")
+		}
+		if !staking.removeStakingUtxo(btxID, vout) {
+			wl.markIncomplete(stakingDesc)
+		}
+	}
+	return nil
+}
+
+// disconnectColdStakingInput reverts connectColdStakingInput
+func (d *RocksDB) disconnectColdStakingInput(btxID []byte, input *outpoint, inputHeight uint32, spendDesc, stakingDesc bchain.AddressDescriptor, valueSat *big.Int,
+	getAddressBalance func(addrDesc bchain.AddressDescriptor) (*AddrBalance, error),
+	addressFoundInTx func(addrDesc bchain.AddressDescriptor, btxID []byte) bool) error {
+	exist := addressFoundInTx(spendDesc, btxID)
+	balance, err := getAddressBalance(spendDesc)
+	if err != nil {
+		return err
+	}
+	if balance != nil {
+		if !exist {
+			balance.Txs--
+		}
+		balance.SentSat.Sub(&balance.SentSat, valueSat)
+		if balance.SentSat.Sign() < 0 {
+			d.resetValueSatToZero(&balance.SentSat, spendDesc, "sent amount")
+		}
+		balance.BalanceSat.Add(&balance.BalanceSat, valueSat)
+		balance.DelegatedSat.Add(&balance.DelegatedSat, valueSat)
+		balance.addUtxoInDisconnect(&Utxo{
+			BtxID:    input.btxID,
+			Vout:     input.index,
+			Height:   inputHeight,
+			ValueSat: *valueSat,
+		})
+	} else if d.watchlist.contains(spendDesc) {
+		glog.Warningf("Balance for cold staking spend address %s not found", spendDesc)
+	}
+	exist = addressFoundInTx(stakingDesc, btxID)
+	staking, err := getAddressBalance(stakingDesc)
+	if err != nil {
+		return err
+	}
+	if staking != nil {
+		if !exist {
+			staking.Txs--
+		}
+		staking.StakingSat.Add(&staking.StakingSat, valueSat)
+		staking.StakingUtxos = append(staking.StakingUtxos, Utxo{
+			BtxID:    input.btxID,
+			Vout:     input.index,
+			Height:   inputHeight,
+			ValueSat: *valueSat,
+		})
+	} else if d.watchlist.contains(stakingDesc) {
+		glog.Warningf("Balance for cold staking address %s not found", stakingDesc)
+	}
+	return nil
+}
+
+// disconnectColdStakingOutput reverts connectColdStakingOutput
+func (d *RocksDB) disconnectColdStakingOutput(btxID []byte, vout int32, spendDesc, stakingDesc bchain.AddressDescriptor, valueSat *big.Int,
+	getAddressBalance func(addrDesc bchain.AddressDescriptor) (*AddrBalance, error),
+	addressFoundInTx func(addrDesc bchain.AddressDescriptor, btxID []byte) bool) error {
+	exist := addressFoundInTx(spendDesc, btxID)
+	balance, err := getAddressBalance(spendDesc)
+	if err != nil {
+		return err
+	}
+	if balance != nil {
+		if !exist {
+			balance.Txs--
+		}
+		balance.BalanceSat.Sub(&balance.BalanceSat, valueSat)
+		if balance.BalanceSat.Sign() < 0 {
+			d.resetValueSatToZero(&balance.BalanceSat, spendDesc, "balance")
+		}
+		balance.DelegatedSat.Sub(&balance.DelegatedSat, valueSat)
+		if balance.DelegatedSat.Sign() < 0 {
+			d.resetValueSatToZero(&balance.DelegatedSat, spendDesc, "delegated balance")
+		}
+		balance.markUtxoAsSpent(btxID, vout)
+	} else if d.watchlist.contains(spendDesc) {
+		glog.Warningf("Balance for cold staking spend address %s not found", spendDesc)
+	}
+	exist = addressFoundInTx(stakingDesc, btxID)
+	staking, err := getAddressBalance(stakingDesc)
+	if err != nil {
+		return err
+	}
+	if staking != nil {
+		if !exist {
+			staking.Txs--
+		}
+		staking.StakingSat.Sub(&staking.StakingSat, valueSat)
+		if staking.StakingSat.Sign() < 0 {
+			d.resetValueSatToZero(&staking.StakingSat, stakingDesc, "staking balance")
+		}
+		staking.removeStakingUtxo(btxID, vout)
+	} else if d.watchlist.contains(stakingDesc) {
+		glog.Warningf("Balance for cold staking address %s not found", stakingDesc)
+	}
+	return nil
+}
+
+// Particl staking rewards
+// The reward of coinstake transaction is the value of its outputs without the treasury fund payouts minus the value of its inputs.
+// It is stored per address and block, credited to the staker of the block (the staking address in case of cold staking),
+// see part.BlockStake.
+
+// coinstakeReward returns the reward of the coinstake transaction, the treasury fund payouts are not part of the reward
+func coinstakeReward(p *part.ParticlParser, ta *TxAddresses) *big.Int {
+	var reward big.Int
+	for i := range ta.Outputs {
+		if !p.IsTreasuryAddrDesc(ta.Outputs[i].AddrDesc) {
+			reward.Add(&reward, &ta.Outputs[i].ValueSat)
+		}
+	}
+	for i := range ta.Inputs {
+		reward.Sub(&reward, &ta.Inputs[i].ValueSat)
+	}
+	if reward.Sign() < 0 {
+		reward.SetInt64(0)
+	}
+	return &reward
+}
+
+// blockStakingRewards computes the reward of the coinstake transaction in the block, credited to the staker of the block
+func (d *RocksDB) blockStakingRewards(block *bchain.Block, txAddressesMap map[string]*TxAddresses) (map[string]*big.Int, error) {
+	p, ok := d.chainParser.(*part.ParticlParser)
+	if !ok {
+		return nil, nil
+	}
+	bs := p.BlockStakeFromBlock(block)
+	if bs == nil || len(bs.StakerAddrDesc) == 0 {
+		return nil, nil
+	}
+	btxID, err := d.chainParser.PackTxid(bs.CoinstakeTxid)
+	if err != nil {
+		return nil, err
+	}
+	ta := txAddressesMap[string(btxID)]
+	if ta == nil {
+		return nil, nil
+	}
+	reward := coinstakeReward(p, ta)
+	if reward.Sign() == 0 {
+		return nil, nil
+	}
+	return map[string]*big.Int{string(bs.StakerAddrDesc): reward}, nil
+}
+
+// storeStakingRewards stores the staking rewards of the block at given height
+func (d *RocksDB) storeStakingRewards(wb *grocksdb.WriteBatch, height uint32, rewards map[string]*big.Int) {
+	if len(rewards) == 0 {
+		return
+	}
+	buf := make([]byte, maxPackedBigintBytes)
+	for addrDesc, reward := range rewards {
+		l := packBigint(reward, buf)
+		wb.PutCF(d.cfh[cfStakingRewards], packAddressKey(bchain.AddressDescriptor(addrDesc), height), buf[:l])
+	}
+}
+
+// GetStakingRewards passes the staking rewards of the address in the height range to the callback function, from the newest block to the oldest
+func (d *RocksDB) GetStakingRewards(addrDesc bchain.AddressDescriptor, lower uint32, higher uint32, fn func(height uint32, rewardSat *big.Int) error) error {
+	startKey := packAddressKey(addrDesc, higher)
+	stopKey := packAddressKey(addrDesc, lower)
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfStakingRewards])
+	defer it.Close()
+	for it.Seek(startKey); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if bytes.Compare(key, stopKey) > 0 {
+			break
+		}
+		if len(key) != len(addrDesc)+packedHeightBytes {
+			continue
+		}
+		_, height, err := unpackAddressKey(key)
+		if err != nil {
+			return err
+		}
+		reward, _ := unpackBigint(it.Value().Data())
+		if err := fn(height, &reward); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Particl RingCT key images
+// Each anon input spends the outputs identified by the key images, a key image can be used only once.
+// The column keyImages maps the key image to the spending transaction, its height and the index of the input.
+// For the blocks which can be disconnected, the list of key images of the block is stored in the same column under the height key,
+// the lengths of the keys (4 bytes height, 33 bytes key image) do not overlap.
+
+// KeyImageSpend is the spend of RingCT key image
+type KeyImageSpend struct {
+	Txid   string
+	Height uint32
+	Vin    uint32
+}
+
+type blockKeyImage struct {
+	keyImage []byte
+	btxID    []byte
+	vin      uint32
+}
+
+// blockKeyImages returns the key images of the anon inputs of the block
+func (d *RocksDB) blockKeyImages(block *bchain.Block) ([]blockKeyImage, error) {
+	var keyImages []blockKeyImage
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		var btxID []byte
+		for v := range tx.Vin {
+			for _, s := range tx.Vin[v].KeyImages {
+				keyImage, err := hex.DecodeString(s)
+				if err != nil || len(keyImage) != part.KeyImageSize {
+					glog.Warning("rocksdb: tx ", tx.Txid, ", input ", v, ": invalid key image ", s)
+					continue
+				}
+				if btxID == nil {
+					if btxID, err = d.chainParser.PackTxid(tx.Txid); err != nil {
+						return nil, err
+					}
+				}
+				keyImages = append(keyImages, blockKeyImage{keyImage: keyImage, btxID: btxID, vin: uint32(v)})
+			}
+		}
+	}
+	return keyImages, nil
+}
+
+// storeKeyImages stores the spends of the key images of the block at given height
+func (d *RocksDB) storeKeyImages(wb *grocksdb.WriteBatch, height uint32, keyImages []blockKeyImage) {
+	varBuf := make([]byte, vlq.MaxLen64)
+	for i := range keyImages {
+		ki := &keyImages[i]
+		buf := make([]byte, 0, len(ki.btxID)+2*vlq.MaxLen64)
+		buf = append(buf, ki.btxID...)
+		l := packVaruint(uint(height), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		l = packVaruint(uint(ki.vin), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		wb.PutCF(d.cfh[cfKeyImages], ki.keyImage, buf)
+	}
+}
+
+// storeBlockKeyImages stores the list of the key images of the block, needed to disconnect the block
+func (d *RocksDB) storeBlockKeyImages(wb *grocksdb.WriteBatch, block *bchain.Block, keyImages []blockKeyImage) {
+	if len(keyImages) == 0 {
+		return
+	}
+	buf := make([]byte, 0, len(keyImages)*part.KeyImageSize)
+	for i := range keyImages {
+		buf = append(buf, keyImages[i].keyImage...)
+	}
+	wb.PutCF(d.cfh[cfKeyImages], packUint(block.Height), buf)
+}
+
+// disconnectKeyImages removes the key images of the block at given height
+func (d *RocksDB) disconnectKeyImages(wb *grocksdb.WriteBatch, height uint32) error {
+	key := packUint(height)
+	val, err := d.db.GetCF(d.ro, d.cfh[cfKeyImages], key)
+	if err != nil {
+		return err
+	}
+	defer val.Free()
+	buf := val.Data()
+	if len(buf)%part.KeyImageSize != 0 {
+		glog.Error("rocksdb: Inconsistent data in keyImages ", hex.EncodeToString(buf))
+		return errors.New("Inconsistent data in keyImages")
+	}
+	for i := 0; i < len(buf); i += part.KeyImageSize {
+		wb.DeleteCF(d.cfh[cfKeyImages], buf[i:i+part.KeyImageSize])
+	}
+	wb.DeleteCF(d.cfh[cfKeyImages], key)
+	return nil
+}
+
+// GetKeyImageSpend returns the spend of the RingCT key image or nil if the key image was not used
+func (d *RocksDB) GetKeyImageSpend(keyImage []byte) (*KeyImageSpend, error) {
+	if len(keyImage) != part.KeyImageSize {
+		return nil, nil
+	}
+	val, err := d.db.GetCF(d.ro, d.cfh[cfKeyImages], keyImage)
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	buf := val.Data()
+	pl := d.chainParser.PackedTxidLen()
+	if len(buf) < pl+2 {
+		return nil, nil
+	}
+	txid, err := d.chainParser.UnpackTxid(buf[:pl])
+	if err != nil {
+		return nil, err
+	}
+	height, l := unpackVaruint(buf[pl:])
+	vin, _ := unpackVaruint(buf[pl+l:])
+	return &KeyImageSpend{
+		Txid:   txid,
+		Height: uint32(height),
+		Vin:    uint32(vin),
+	}, nil
+}
+
+// Particl RingCT anon outputs
+// Every anon output gets a global index, assigned sequentially in the order of blocks, transactions and outputs, starting from 1.
+// The index is used when building the rings of anon inputs. The column anonOutputs maps the index (8 bytes big endian)
+// to the output, its public key and commitment. As the indexes grow with the height of the block,
+// the outputs of the disconnected block are the last ones in the column.
+
+// AnonOutput is RingCT anon output with its global index
+type AnonOutput struct {
+	Index      uint64
+	Txid       string
+	Vout       uint32
+	Height     uint32
+	PubKey     []byte
+	Commitment []byte
+}
+
+type blockAnonOutput struct {
+	index      uint64
+	btxID      []byte
+	vout       uint32
+	pubKey     []byte
+	commitment []byte
+}
+
+func packAnonIndex(index uint64) []byte {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, index)
+	return buf
+}
+
+// blockAnonOutputs assigns global indexes to the anon outputs of the block, continuing after lastIndex,
+// and returns the outputs together with the last assigned index. The index provided by the backend takes precedence,
+// it must however continue after lastIndex, otherwise the indexes in the database differ from the backend.
+func (d *RocksDB) blockAnonOutputs(block *bchain.Block, lastIndex uint64) ([]blockAnonOutput, uint64, error) {
+	var outputs []blockAnonOutput
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		var btxID []byte
+		for v := range tx.Vout {
+			vout := &tx.Vout[v]
+			if vout.OutputType != "anon" {
+				continue
+			}
+			if btxID == nil {
+				var err error
+				if btxID, err = d.chainParser.PackTxid(tx.Txid); err != nil {
+					return nil, lastIndex, err
+				}
+			}
+			index := lastIndex + 1
+			if vout.AnonIndex > 0 && uint64(vout.AnonIndex) != index {
+				return nil, lastIndex, errors.Errorf("Anon output %s:%d has the index %d in the backend, %d in the database, reindex required", tx.Txid, vout.N, vout.AnonIndex, index)
+			}
+			pubKey, err := hex.DecodeString(vout.PubKey)
+			if err != nil {
+				glog.Warning("rocksdb: tx ", tx.Txid, ", output ", v, ": invalid pubkey ", vout.PubKey)
+			}
+			commitment, err := hex.DecodeString(vout.ValueCommitment)
+			if err != nil {
+				glog.Warning("rocksdb: tx ", tx.Txid, ", output ", v, ": invalid commitment ", vout.ValueCommitment)
+			}
+			outputs = append(outputs, blockAnonOutput{
+				index:      index,
+				btxID:      btxID,
+				vout:       vout.N,
+				pubKey:     pubKey,
+				commitment: commitment,
+			})
+			lastIndex = index
+		}
+	}
+	return outputs, lastIndex, nil
+}
+
+// storeAnonOutputs stores the anon outputs of the block at given height
+func (d *RocksDB) storeAnonOutputs(wb *grocksdb.WriteBatch, height uint32, outputs []blockAnonOutput) {
+	varBuf := make([]byte, vlq.MaxLen64)
+	for i := range outputs {
+		o := &outputs[i]
+		buf := make([]byte, 0, len(o.btxID)+len(o.pubKey)+len(o.commitment)+4*vlq.MaxLen64)
+		buf = append(buf, o.btxID...)
+		l := packVaruint(uint(o.vout), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		l = packVaruint(uint(height), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		l = packVaruint(uint(len(o.pubKey)), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		buf = append(buf, o.pubKey...)
+		l = packVaruint(uint(len(o.commitment)), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		buf = append(buf, o.commitment...)
+		wb.PutCF(d.cfh[cfAnonOutputs], packAnonIndex(o.index), buf)
+	}
+}
+
+func (d *RocksDB) unpackAnonOutput(key []byte, buf []byte) (*AnonOutput, error) {
+	pl := d.chainParser.PackedTxidLen()
+	if len(key) != 8 || len(buf) < pl+2 {
+		return nil, errors.New("Inconsistent data in anonOutputs")
+	}
+	txid, err := d.chainParser.UnpackTxid(buf[:pl])
+	if err != nil {
+		return nil, err
+	}
+	o := AnonOutput{
+		Index: binary.BigEndian.Uint64(key),
+		Txid:  txid,
+	}
+	buf = buf[pl:]
+	vout, l := unpackVaruint(buf)
+	buf = buf[l:]
+	height, l := unpackVaruint(buf)
+	buf = buf[l:]
+	o.Vout = uint32(vout)
+	o.Height = uint32(height)
+	for _, field := range []*[]byte{&o.PubKey, &o.Commitment} {
+		fl, l := unpackVaruint(buf)
+		if len(buf) < l+int(fl) {
+			return nil, errors.New("Inconsistent data in anonOutputs")
+		}
+		*field = append([]byte(nil), buf[l:l+int(fl)]...)
+		buf = buf[l+int(fl):]
+	}
+	return &o, nil
+}
+
+// GetLastAnonIndex returns the highest stored global index of anon output, 0 if there is none
+func (d *RocksDB) GetLastAnonIndex() (uint64, error) {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfAnonOutputs])
+	defer it.Close()
+	it.SeekToLast()
+	if !it.Valid() {
+		return 0, it.Err()
+	}
+	key := it.Key().Data()
+	if len(key) != 8 {
+		return 0, errors.New("Inconsistent data in anonOutputs")
+	}
+	return binary.BigEndian.Uint64(key), nil
+}
+
+// VerifyAnonIndex checks that the last stored anon output has the same global index in the backend,
+// the database with different indexes would return wrong ring members and must be reindexed
+func (d *RocksDB) VerifyAnonIndex(chain bchain.BlockChain) error {
+	if !d.isParticl() {
+		return nil
+	}
+	last, err := d.GetLastAnonIndex()
+	if err != nil || last == 0 {
+		return err
+	}
+	outputs, err := d.GetAnonOutputs(last, 1)
+	if err != nil {
+		return err
+	}
+	if len(outputs) == 0 {
+		return errors.Errorf("Anon output %d not found", last)
+	}
+	o := &outputs[0]
+	bo, err := chain.GetAnonOutput(last)
+	if err != nil {
+		// the backend may not support the lookup, the indexes cannot be verified
+		glog.Warning("rocksdb: anon output ", last, " cannot be verified against the backend: ", err)
+		return nil
+	}
+	if bo.Txid != o.Txid || bo.Vout != o.Vout {
+		return errors.Errorf("Anon output %d is %s:%d in the database, %s:%d in the backend, reindex required", last, o.Txid, o.Vout, bo.Txid, bo.Vout)
+	}
+	glog.Info("rocksdb: anon output index verified against the backend, last index ", last)
+	return nil
+}
+
+// disconnectAnonOutputs removes the anon outputs of the block at given height, they are the last ones stored
+func (d *RocksDB) disconnectAnonOutputs(wb *grocksdb.WriteBatch, height uint32) error {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfAnonOutputs])
+	defer it.Close()
+	for it.SeekToLast(); it.Valid(); it.Prev() {
+		key := it.Key().Data()
+		o, err := d.unpackAnonOutput(key, it.Value().Data())
+		if err != nil {
+			return err
+		}
+		if o.Height < height {
+			break
+		}
+		wb.DeleteCF(d.cfh[cfAnonOutputs], append([]byte(nil), key...))
+	}
+	return nil
+}
+
+// GetAnonOutputs returns at most count anon outputs starting from the global index from
+func (d *RocksDB) GetAnonOutputs(from uint64, count int) ([]AnonOutput, error) {
+	outputs := make([]AnonOutput, 0)
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfAnonOutputs])
+	defer it.Close()
+	for it.Seek(packAnonIndex(from)); it.Valid() && len(outputs) < count; it.Next() {
+		o, err := d.unpackAnonOutput(it.Key().Data(), it.Value().Data())
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, *o)
+	}
+	return outputs, nil
+}
+
+// Particl confidential transactions
+// The value of blind (CT) output is hidden in the commitment, the output is indexed with zero value.
+// The blind outputs are therefore not part of the UTXO list of the address, they are counted separately
+// and the unspent ones are kept in the BlindUtxos list, stored in the column blindOutputs.
+// The output type is always stored in txAddresses for Particl, the spent blind output is recognized by it.
+
+// BlindUtxo is unspent blind (CT) output, its value is known only to the owner of the blinding key
+type BlindUtxo struct {
+	BtxID      []byte
+	Vout       int32
+	Height     uint32
+	Commitment []byte
+}
+
+// storesOutputType returns true if the output type is stored in txAddresses, always for Particl, for other coins with extended index
+func (d *RocksDB) storesOutputType() bool {
+	return d.extendedIndex || d.isParticl()
+}
+
+// isBlindTxOutput returns true if the output is blind (CT) output
+func (d *RocksDB) isBlindTxOutput(o *TxOutput) bool {
+	return o.OutputType == "blind"
+}
+
+// blindOwnerAddrDesc returns the address credited with the blind output, the owner (spend) address in case of cold staking
+func blindOwnerAddrDesc(addrDesc bchain.AddressDescriptor) bchain.AddressDescriptor {
+	if spendDesc, _ := part.ColdStakingAddrDescs(addrDesc); spendDesc != nil {
+		return spendDesc
+	}
+	return addrDesc
+}
+
+func (ab *AddrBalance) removeBlindUtxo(btxID []byte, vout int32) bool {
+	for i := range ab.BlindUtxos {
+		u := &ab.BlindUtxos[i]
+		if u.Vout == vout && bytes.Equal(u.BtxID, btxID) {
+			ab.BlindUtxos = append(ab.BlindUtxos[:i], ab.BlindUtxos[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// getBlindBalance loads the blind outputs part of the address balance
+func (d *RocksDB) getBlindBalance(addrDesc bchain.AddressDescriptor, ab *AddrBalance) error {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfBlindOutputs], addrDesc)
+	if err != nil {
+		return err
+	}
+	defer val.Free()
+	buf := val.Data()
+	if len(buf) < 3 {
+		return nil
+	}
+	received, l := unpackVaruint(buf)
+	buf = buf[l:]
+	spent, l := unpackVaruint(buf)
+	buf = buf[l:]
+	count, l := unpackVaruint(buf)
+	buf = buf[l:]
+	pl := d.chainParser.PackedTxidLen()
+	utxos := make([]BlindUtxo, 0, count)
+	for i := uint(0); i < count; i++ {
+		if len(buf) < pl {
+			return errors.New("Inconsistent data in blindOutputs")
+		}
+		u := BlindUtxo{BtxID: append([]byte(nil), buf[:pl]...)}
+		buf = buf[pl:]
+		vout, l := unpackVaruint(buf)
+		buf = buf[l:]
+		height, l := unpackVaruint(buf)
+		buf = buf[l:]
+		cl, l := unpackVaruint(buf)
+		if len(buf) < l+int(cl) {
+			return errors.New("Inconsistent data in blindOutputs")
+		}
+		u.Vout = int32(vout)
+		u.Height = uint32(height)
+		if cl > 0 {
+			u.Commitment = append([]byte(nil), buf[l:l+int(cl)]...)
+		}
+		buf = buf[l+int(cl):]
+		utxos = append(utxos, u)
+	}
+	ab.BlindReceived = uint32(received)
+	ab.BlindSpent = uint32(spent)
+	ab.BlindUtxos = utxos
+	ab.blindStored = true
+	return nil
+}
+
+// storeBlindBalance stores the blind outputs part of the address balance, empty entries are removed
+func (d *RocksDB) storeBlindBalance(wb *grocksdb.WriteBatch, addrDesc bchain.AddressDescriptor, ab *AddrBalance) {
+	if ab == nil || ab.Txs <= 0 || (ab.BlindReceived == 0 && ab.BlindSpent == 0 && len(ab.BlindUtxos) == 0) {
+		if ab != nil && ab.blindStored {
+			wb.DeleteCF(d.cfh[cfBlindOutputs], addrDesc)
+			ab.blindStored = false
+		}
+		return
+	}
+	varBuf := make([]byte, vlq.MaxLen64)
+	buf := make([]byte, 0, 3*vlq.MaxLen64+len(ab.BlindUtxos)*(d.chainParser.PackedTxidLen()+3*vlq.MaxLen64+33))
+	for _, v := range []uint{uint(ab.BlindReceived), uint(ab.BlindSpent), uint(len(ab.BlindUtxos))} {
+		l := packVaruint(v, varBuf)
+		buf = append(buf, varBuf[:l]...)
+	}
+	for i := range ab.BlindUtxos {
+		u := &ab.BlindUtxos[i]
+		buf = append(buf, u.BtxID...)
+		l := packVaruint(uint(u.Vout), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		l = packVaruint(uint(u.Height), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		l = packVaruint(uint(len(u.Commitment)), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		buf = append(buf, u.Commitment...)
+	}
+	wb.PutCF(d.cfh[cfBlindOutputs], addrDesc, buf)
+	ab.blindStored = true
+}
+
+// connectBlindOutput adds the blind output to the address
+func (d *RocksDB) connectBlindOutput(addresses addressesMap, balances map[string]*AddrBalance, addrDesc bchain.AddressDescriptor,
+	btxID []byte, vout int32, height uint32, commitment string) error {
+	balance, err := d.balanceForUpdate(balances, addrDesc)
+	if err != nil {
+		return err
+	}
+	c, err := hex.DecodeString(commitment)
+	if err != nil {
+		glog.Warning("rocksdb: blind output ", hex.EncodeToString(btxID), ":", vout, ": invalid commitment ", commitment)
+	}
+	balance.BlindReceived++
+	balance.BlindUtxos = append(balance.BlindUtxos, BlindUtxo{
+		BtxID:      btxID,
+		Vout:       vout,
+		Height:     height,
+		Commitment: c,
+	})
+	if counted := addToAddressesMap(addresses, string(addrDesc), btxID, vout); !counted {
+		balance.Txs++
+	}
+	return nil
+}
+
+// connectBlindInput removes the spent blind output from the address, the address of the watchlist wl is flagged
+// as incomplete if the output is not found
+func (d *RocksDB) connectBlindInput(addresses addressesMap, balances map[string]*AddrBalance, wl *watchlist, addrDesc bchain.AddressDescriptor,
+	spendingTxid []byte, index int32, btxID []byte, vout int32) error {
+	balance, err := d.balanceForUpdate(balances, addrDesc)
+	if err != nil {
+		return err
+	}
+	if counted := addToAddressesMap(addresses, string(addrDesc), spendingTxid, ^index); !counted {
+		balance.Txs++
+	}
+	balance.BlindSpent++
+	if !balance.removeBlindUtxo(btxID, vout) {
+		glog.Errorf("Blind utxo %s:%d not found", hex.EncodeToString(btxID), vout)
+		wl.markIncomplete(addrDesc)
+	}
+	return nil
+}
+
+// disconnectBlindInput reverts connectBlindInput
+func (d *RocksDB) disconnectBlindInput(btxID []byte, input *outpoint, inputHeight uint32, addrDesc bchain.AddressDescriptor,
+	getAddressBalance func(addrDesc bchain.AddressDescriptor) (*AddrBalance, error),
+	addressFoundInTx func(addrDesc bchain.AddressDescriptor, btxID []byte) bool) error {
+	exist := addressFoundInTx(addrDesc, btxID)
+	balance, err := getAddressBalance(addrDesc)
+	if err != nil {
+		return err
+	}
+	if balance == nil {
+		if d.watchlist.contains(addrDesc) {
+			glog.Warningf("Balance for blind output address %s not found", addrDesc)
+		}
+		return nil
+	}
+	if !exist {
+		balance.Txs--
+	}
+	if balance.BlindSpent > 0 {
+		balance.BlindSpent--
+	}
+	c, _, err := d.getTxOutputBlob(input.btxID, uint32(input.index))
+	if err != nil {
+		return err
+	}
+	balance.BlindUtxos = append(balance.BlindUtxos, BlindUtxo{
+		BtxID:      input.btxID,
+		Vout:       input.index,
+		Height:     inputHeight,
+		Commitment: c,
+	})
+	sort.SliceStable(balance.BlindUtxos, func(i, j int) bool {
+		return balance.BlindUtxos[i].Height < balance.BlindUtxos[j].Height
+	})
+	return nil
+}
+
+// disconnectBlindOutput reverts connectBlindOutput
+func (d *RocksDB) disconnectBlindOutput(btxID []byte, vout int32, addrDesc bchain.AddressDescriptor,
+	getAddressBalance func(addrDesc bchain.AddressDescriptor) (*AddrBalance, error),
+	addressFoundInTx func(addrDesc bchain.AddressDescriptor, btxID []byte) bool) error {
+	exist := addressFoundInTx(addrDesc, btxID)
+	balance, err := getAddressBalance(addrDesc)
+	if err != nil {
+		return err
+	}
+	if balance == nil {
+		if d.watchlist.contains(addrDesc) {
+			glog.Warningf("Balance for blind output address %s not found", addrDesc)
+		}
+		return nil
+	}
+	if !exist {
+		balance.Txs--
+	}
+	if balance.BlindReceived > 0 {
+		balance.BlindReceived--
+	}
+	if !balance.removeBlindUtxo(btxID, vout) {
+		glog.Errorf("Blind utxo %s:%d not found", hex.EncodeToString(btxID), vout)
+	}
+	return nil
+}
+
+// Particl stealth outputs
+// The one-time destinations of the payments to stealth addresses are derived from an ephemeral key stored in the transaction.
+// To find the payments of a stealth address by its scan key without reading the blocks, the outputs which can be paid
+// to a stealth address are stored in the column stealthOutputs under the block height, together with their ephemeral keys.
+// Blind and anon outputs carry their own ephemeral key, the standard P2PKH outputs are stored only if the transaction
+// contains a data output with the DOStealth prefix, with the ephemeral keys of all such data outputs of the transaction.
+
+// StealthOutput is an output which can be paid to a stealth address
+type StealthOutput struct {
+	Txid   string
+	Vout   uint32
+	Height uint32
+	Type   byte
+	// EphemPubKeys are the ephemeral public keys which can belong to the output
+	EphemPubKeys [][]byte
+	// Destination is the hash160 of the one-time public key for P2PKH outputs, the one-time public key for anon outputs
+	Destination []byte
+	Commitment  []byte
+	ValueSat    big.Int
+}
+
+type blockStealthOutput struct {
+	btxID        []byte
+	vout         uint32
+	outputType   byte
+	ephemPubKeys [][]byte
+	destination  []byte
+	commitment   []byte
+	valueSat     big.Int
+}
+
+var outputTypesByName = map[string]byte{
+	"standard": part.OutputStandard,
+	"blind":    part.OutputCT,
+	"anon":     part.OutputRingCT,
+	"data":     part.OutputData,
+}
+
+// p2pkhHash returns the hash160 from P2PKH output script or nil if the script is not P2PKH
+func p2pkhHash(script []byte) []byte {
+	if len(script) == 25 && script[0] == 0x76 && script[1] == 0xa9 && script[2] == 0x14 && script[23] == 0x88 && script[24] == 0xac {
+		return script[3:23]
+	}
+	return nil
+}
+
+// blockStealthOutputs returns the outputs of the block which can be paid to a stealth address
+func (d *RocksDB) blockStealthOutputs(block *bchain.Block) ([]blockStealthOutput, error) {
+	var outputs []blockStealthOutput
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		var txEphemPubKeys [][]byte
+		for v := range tx.Vout {
+			if tx.Vout[v].OutputType != "data" {
+				continue
+			}
+			data, err := hex.DecodeString(tx.Vout[v].Data)
+			if err != nil {
+				continue
+			}
+			if ephem := part.StealthEphemeralPubKey(part.OutputData, data); ephem != nil {
+				txEphemPubKeys = append(txEphemPubKeys, ephem)
+			}
+		}
+		var btxID []byte
+		for v := range tx.Vout {
+			vout := &tx.Vout[v]
+			o := blockStealthOutput{vout: vout.N, outputType: outputTypesByName[vout.OutputType]}
+			switch o.outputType {
+			case part.OutputStandard:
+				if len(txEphemPubKeys) == 0 {
+					continue
+				}
+				script, err := hex.DecodeString(vout.ScriptPubKey.Hex)
+				if err != nil {
+					continue
+				}
+				if o.destination = p2pkhHash(script); o.destination == nil {
+					continue
+				}
+				o.ephemPubKeys = txEphemPubKeys
+				o.valueSat = vout.ValueSat
+			case part.OutputCT, part.OutputRingCT:
+				data, err := hex.DecodeString(vout.Data)
+				if err != nil {
+					continue
+				}
+				ephem := part.StealthEphemeralPubKey(o.outputType, data)
+				if ephem == nil {
+					continue
+				}
+				o.ephemPubKeys = [][]byte{ephem}
+				if o.outputType == part.OutputCT {
+					script, err := hex.DecodeString(vout.ScriptPubKey.Hex)
+					if err != nil {
+						continue
+					}
+					if o.destination = p2pkhHash(script); o.destination == nil {
+						continue
+					}
+				} else if o.destination, err = hex.DecodeString(vout.PubKey); err != nil || len(o.destination) == 0 {
+					continue
+				}
+				if o.commitment, err = hex.DecodeString(vout.ValueCommitment); err != nil {
+					glog.Warning("rocksdb: tx ", tx.Txid, ", output ", v, ": invalid commitment ", vout.ValueCommitment)
+				}
+			default:
+				continue
+			}
+			if btxID == nil {
+				var err error
+				if btxID, err = d.chainParser.PackTxid(tx.Txid); err != nil {
+					return nil, err
+				}
+			}
+			o.btxID = btxID
+			outputs = append(outputs, o)
+		}
+	}
+	return outputs, nil
+}
+
+// storeStealthOutputs stores the outputs of the block at given height which can be paid to a stealth address
+func (d *RocksDB) storeStealthOutputs(wb *grocksdb.WriteBatch, height uint32, outputs []blockStealthOutput) {
+	if len(outputs) == 0 {
+		return
+	}
+	varBuf := make([]byte, maxPackedBigintBytes)
+	buf := make([]byte, 0, len(outputs)*128)
+	for i := range outputs {
+		o := &outputs[i]
+		buf = append(buf, o.btxID...)
+		l := packVaruint(uint(o.vout), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		buf = append(buf, o.outputType)
+		l = packVaruint(uint(len(o.ephemPubKeys)), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		for _, ephem := range o.ephemPubKeys {
+			buf = append(buf, ephem...)
+		}
+		for _, field := range [][]byte{o.destination, o.commitment} {
+			l = packVaruint(uint(len(field)), varBuf)
+			buf = append(buf, varBuf[:l]...)
+			buf = append(buf, field...)
+		}
+		l = packBigint(&o.valueSat, varBuf)
+		buf = append(buf, varBuf[:l]...)
+	}
+	wb.PutCF(d.cfh[cfStealthOutputs], packUint(height), buf)
+}
+
+func (d *RocksDB) unpackStealthOutputs(height uint32, buf []byte, fn func(o *StealthOutput) error) error {
+	pl := d.chainParser.PackedTxidLen()
+	for len(buf) > 0 {
+		if len(buf) < pl+3 {
+			return errors.New("Inconsistent data in stealthOutputs")
+		}
+		txid, err := d.chainParser.UnpackTxid(buf[:pl])
+		if err != nil {
+			return err
+		}
+		o := StealthOutput{Txid: txid, Height: height}
+		buf = buf[pl:]
+		vout, l := unpackVaruint(buf)
+		o.Vout = uint32(vout)
+		o.Type = buf[l]
+		buf = buf[l+1:]
+		n, l := unpackVaruint(buf)
+		buf = buf[l:]
+		if len(buf) < int(n)*33 {
+			return errors.New("Inconsistent data in stealthOutputs")
+		}
+		o.EphemPubKeys = make([][]byte, n)
+		for i := range o.EphemPubKeys {
+			o.EphemPubKeys[i] = append([]byte(nil), buf[:33]...)
+			buf = buf[33:]
+		}
+		for _, field := range []*[]byte{&o.Destination, &o.Commitment} {
+			fl, l := unpackVaruint(buf)
+			if len(buf) < l+int(fl) {
+				return errors.New("Inconsistent data in stealthOutputs")
+			}
+			*field = append([]byte(nil), buf[l:l+int(fl)]...)
+			buf = buf[l+int(fl):]
+		}
+		if len(buf) == 0 {
+			return errors.New("Inconsistent data in stealthOutputs")
+		}
+		o.ValueSat, l = unpackBigint(buf)
+		buf = buf[l:]
+		if err := fn(&o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetStealthOutputs calls fn for the outputs which can be paid to a stealth address in the blocks from lower to higher height
+func (d *RocksDB) GetStealthOutputs(lower uint32, higher uint32, fn func(o *StealthOutput) error) error {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfStealthOutputs])
+	defer it.Close()
+	for it.Seek(packUint(lower)); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if len(key) != 4 {
+			return errors.New("Inconsistent data in stealthOutputs")
+		}
+		height := unpackUint(key)
+		if height > higher {
+			break
+		}
+		if err := d.unpackStealthOutputs(height, it.Value().Data(), fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Particl value commitments and range proofs
+// The commitments and range proofs of the blind and anon outputs are not stored in txAddresses, which is read for every input
+// during the sync, but in the column txOutputBlobs under the outpoint key (packed txid, vout), in binary form.
+// They are loaded only when the transaction is returned by the API. The commitment is stored always, it is needed
+// to restore the blind utxo when a block is disconnected, the range proof only with the extended index.
+
+type blockTxOutputBlob struct {
+	btxID      []byte
+	vout       uint32
+	commitment []byte
+	rangeProof []byte
+}
+
+func packTxOutputBlobKey(btxID []byte, vout uint32) []byte {
+	key := make([]byte, len(btxID)+vlq.MaxLen32)
+	copy(key, btxID)
+	l := packVaruint(uint(vout), key[len(btxID):])
+	return key[:len(btxID)+l]
+}
+
+// mayHaveTxOutputBlob returns true for the outputs with hidden value, only they can have commitment and range proof
+func (d *RocksDB) mayHaveTxOutputBlob(o *TxOutput) bool {
+	return o.OutputType == "blind" || o.OutputType == "anon"
+}
+
+// txOutputBlob converts the hex commitment and range proof of the output to binary form, returns false if there is nothing to store
+func (d *RocksDB) txOutputBlob(txid string, vout uint32, commitment, rangeProof string) (*blockTxOutputBlob, bool) {
+	if !d.extendedIndex {
+		rangeProof = ""
+	}
+	if commitment == "" && rangeProof == "" {
+		return nil, false
+	}
+	var err error
+	b := &blockTxOutputBlob{vout: vout}
+	if b.commitment, err = hex.DecodeString(commitment); err != nil {
+		glog.Warning("rocksdb: tx ", txid, ", output ", vout, ": invalid commitment ", commitment)
+		b.commitment = nil
+	}
+	if b.rangeProof, err = hex.DecodeString(rangeProof); err != nil {
+		glog.Warning("rocksdb: tx ", txid, ", output ", vout, ": invalid range proof")
+		b.rangeProof = nil
+	}
+	return b, len(b.commitment) > 0 || len(b.rangeProof) > 0
+}
+
+// blockTxOutputBlobs returns the commitments and range proofs of the outputs of the block
+func (d *RocksDB) blockTxOutputBlobs(block *bchain.Block) ([]blockTxOutputBlob, error) {
+	var blobs []blockTxOutputBlob
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		var btxID []byte
+		for v := range tx.Vout {
+			vout := &tx.Vout[v]
+			b, ok := d.txOutputBlob(tx.Txid, uint32(v), vout.ValueCommitment, vout.RangeProof)
+			if !ok {
+				continue
+			}
+			if btxID == nil {
+				var err error
+				if btxID, err = d.chainParser.PackTxid(tx.Txid); err != nil {
+					return nil, err
+				}
+			}
+			b.btxID = btxID
+			blobs = append(blobs, *b)
+		}
+	}
+	return blobs, nil
+}
+
+// storeTxOutputBlobs stores the commitments and range proofs of the outputs
+func (d *RocksDB) storeTxOutputBlobs(wb *grocksdb.WriteBatch, blobs []blockTxOutputBlob) {
+	varBuf := make([]byte, vlq.MaxLen64)
+	for i := range blobs {
+		b := &blobs[i]
+		buf := make([]byte, 0, len(b.commitment)+len(b.rangeProof)+vlq.MaxLen64)
+		l := packVaruint(uint(len(b.commitment)), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		buf = append(buf, b.commitment...)
+		buf = append(buf, b.rangeProof...)
+		wb.PutCF(d.cfh[cfTxOutputBlobs], packTxOutputBlobKey(b.btxID, b.vout), buf)
+	}
+}
+
+// getTxOutputBlob returns the commitment and range proof of the output, nil if not stored
+func (d *RocksDB) getTxOutputBlob(btxID []byte, vout uint32) ([]byte, []byte, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfTxOutputBlobs], packTxOutputBlobKey(btxID, vout))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer val.Free()
+	buf := val.Data()
+	if len(buf) == 0 {
+		return nil, nil, nil
+	}
+	cl, l := unpackVaruint(buf)
+	if l+int(cl) > len(buf) {
+		return nil, nil, errors.New("Inconsistent data in txOutputBlobs")
+	}
+	commitment := append([]byte(nil), buf[l:l+int(cl)]...)
+	var rangeProof []byte
+	if l+int(cl) < len(buf) {
+		rangeProof = append([]byte(nil), buf[l+int(cl):]...)
+	}
+	return commitment, rangeProof, nil
+}
+
+// GetTxOutputBlob returns the commitment and range proof of the output, nil if not stored,
+// the range proof is stored only with the extended index
+func (d *RocksDB) GetTxOutputBlob(txid string, vout uint32) ([]byte, []byte, error) {
+	btxID, err := d.chainParser.PackTxid(txid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d.getTxOutputBlob(btxID, vout)
+}
+
+// LoadTxOutputBlobs fills the value commitments and range proofs of the outputs of the transaction
+func (d *RocksDB) LoadTxOutputBlobs(txid string, ta *TxAddresses) error {
+	if !d.isParticl() {
+		return nil
+	}
+	btxID, err := d.chainParser.PackTxid(txid)
+	if err != nil {
+		return err
+	}
+	for i := range ta.Outputs {
+		o := &ta.Outputs[i]
+		if !d.mayHaveTxOutputBlob(o) {
+			continue
+		}
+		commitment, rangeProof, err := d.getTxOutputBlob(btxID, uint32(i))
+		if err != nil {
+			return err
+		}
+		o.ValueCommitment = hex.EncodeToString(commitment)
+		o.RangeProof = hex.EncodeToString(rangeProof)
+	}
+	return nil
+}
+
+// disconnectTxOutputBlobs removes the commitments and range proofs of the outputs of the transaction
+func (d *RocksDB) disconnectTxOutputBlobs(wb *grocksdb.WriteBatch, btxID []byte, ta *TxAddresses) {
+	for i := range ta.Outputs {
+		if d.mayHaveTxOutputBlob(&ta.Outputs[i]) {
+			wb.DeleteCF(d.cfh[cfTxOutputBlobs], packTxOutputBlobKey(btxID, uint32(i)))
+		}
+	}
+}
+
+// Particl governance votes
+// Stakers vote for the proposals in the data output of the coinstake transaction, see part.ParseCoinstakeData.
+// The column votes maps the proposal and the height of the block to the option and the stake weight of the vote,
+// the stake weight is the value of the inputs of the coinstake transaction. To be able to disconnect the block,
+// the proposal is stored also under the height key, the lengths of the keys (2+4 bytes, 4 bytes) do not overlap.
+
+// Vote is the vote of the staker of a block for a proposal
+type Vote struct {
+	Height   uint32
+	Option   uint16
+	StakeSat big.Int
+}
+
+type blockVote struct {
+	proposal uint16
+	option   uint16
+	stakeSat big.Int
+}
+
+func packVoteKey(proposal uint16, height uint32) []byte {
+	key := make([]byte, 2+packedHeightBytes)
+	binary.BigEndian.PutUint16(key, proposal)
+	binary.BigEndian.PutUint32(key[2:], height)
+	return key
+}
+
+// blockVote returns the vote of the coinstake transaction of the block, nil if the block does not vote
+func (d *RocksDB) blockVote(block *bchain.Block, txAddressesMap map[string]*TxAddresses) (*blockVote, error) {
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		cd := part.CoinstakeDataFromTx(tx)
+		if cd == nil || !cd.HasVote {
+			continue
+		}
+		btxID, err := d.chainParser.PackTxid(tx.Txid)
+		if err != nil {
+			return nil, err
+		}
+		v := &blockVote{
+			proposal: cd.Proposal,
+			option:   cd.Option,
+		}
+		if ta := txAddressesMap[string(btxID)]; ta != nil {
+			for j := range ta.Inputs {
+				v.stakeSat.Add(&v.stakeSat, &ta.Inputs[j].ValueSat)
+			}
+		}
+		return v, nil
+	}
+	return nil, nil
+}
+
+// storeVote stores the vote of the block at given height
+func (d *RocksDB) storeVote(wb *grocksdb.WriteBatch, height uint32, v *blockVote) {
+	if v == nil {
+		return
+	}
+	buf := make([]byte, vlq.MaxLen64+maxPackedBigintBytes)
+	l := packVaruint(uint(v.option), buf)
+	l += packBigint(&v.stakeSat, buf[l:])
+	wb.PutCF(d.cfh[cfVotes], packVoteKey(v.proposal, height), buf[:l])
+	var p [2]byte
+	binary.BigEndian.PutUint16(p[:], v.proposal)
+	wb.PutCF(d.cfh[cfVotes], packUint(height), p[:])
+}
+
+// GetVotes passes the votes for the proposal in the blocks from lower to higher height to the callback function, in the order of heights
+func (d *RocksDB) GetVotes(proposal uint16, lower uint32, higher uint32, fn func(v *Vote) error) error {
+	startKey := packVoteKey(proposal, lower)
+	stopKey := packVoteKey(proposal, higher)
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfVotes])
+	defer it.Close()
+	for it.Seek(startKey); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if bytes.Compare(key, stopKey) > 0 {
+			break
+		}
+		if len(key) != 2+packedHeightBytes {
+			continue
+		}
+		buf := it.Value().Data()
+		option, l := unpackVaruint(buf)
+		stake, _ := unpackBigint(buf[l:])
+		if err := fn(&Vote{
+			Height:   binary.BigEndian.Uint32(key[2:]),
+			Option:   uint16(option),
+			StakeSat: stake,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// disconnectVote removes the vote of the block at given height
+func (d *RocksDB) disconnectVote(wb *grocksdb.WriteBatch, height uint32) error {
+	key := packUint(height)
+	val, err := d.db.GetCF(d.ro, d.cfh[cfVotes], key)
+	if err != nil {
+		return err
+	}
+	defer val.Free()
+	if p := val.Data(); len(p) == 2 {
+		wb.DeleteCF(d.cfh[cfVotes], packVoteKey(binary.BigEndian.Uint16(p), height))
+		wb.DeleteCF(d.cfh[cfVotes], key)
+	}
+	return nil
+}
+
+// Particl treasury fund
+// The column treasury maps the height of the block to the amount paid by the coinstake transaction of the block
+// to the treasury fund addresses and to the amount carried forward to the next payout, see part.ParseCoinstakeData.
+
+// TreasuryBlock is the treasury fund payout and the carried forward amount of the coinstake transaction of a block
+type TreasuryBlock struct {
+	Height            uint32
+	Txid              string
+	PaidSat           big.Int
+	CarriedForwardSat big.Int
+}
+
+type blockTreasury struct {
+	btxID   []byte
+	paidSat big.Int
+	cfwdSat big.Int
+}
+
+// blockTreasury returns the treasury fund data of the coinstake transaction of the block, nil if there are none
+func (d *RocksDB) blockTreasury(block *bchain.Block) (*blockTreasury, error) {
+	p, ok := d.chainParser.(*part.ParticlParser)
+	if !ok {
+		return nil, nil
+	}
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		if !part.IsCoinStakeTx(tx) {
+			continue
+		}
+		t := &blockTreasury{}
+		for j := range tx.Vout {
+			addrDesc, err := p.GetAddrDescFromVout(&tx.Vout[j])
+			if err != nil || !p.IsTreasuryAddrDesc(addrDesc) {
+				continue
+			}
+			t.paidSat.Add(&t.paidSat, &tx.Vout[j].ValueSat)
+		}
+		if cd := part.CoinstakeDataFromTx(tx); cd != nil {
+			t.cfwdSat.SetInt64(cd.DevFundCfwd)
+		}
+		if t.paidSat.Sign() == 0 && t.cfwdSat.Sign() == 0 {
+			return nil, nil
+		}
+		btxID, err := d.chainParser.PackTxid(tx.Txid)
+		if err != nil {
+			return nil, err
+		}
+		t.btxID = btxID
+		return t, nil
+	}
+	return nil, nil
+}
+
+// storeTreasury stores the treasury fund data of the block at given height
+func (d *RocksDB) storeTreasury(wb *grocksdb.WriteBatch, height uint32, t *blockTreasury) {
+	if t == nil {
+		return
+	}
+	buf := make([]byte, 2*maxPackedBigintBytes+len(t.btxID))
+	l := packBigint(&t.paidSat, buf)
+	l += packBigint(&t.cfwdSat, buf[l:])
+	l += copy(buf[l:], t.btxID)
+	wb.PutCF(d.cfh[cfTreasury], packUint(height), buf[:l])
+}
+
+// GetTreasury passes the treasury fund data of the blocks from lower to higher height to the callback function, in the order of heights
+func (d *RocksDB) GetTreasury(lower uint32, higher uint32, fn func(t *TreasuryBlock) error) error {
+	stopKey := packUint(higher)
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfTreasury])
+	defer it.Close()
+	for it.Seek(packUint(lower)); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if bytes.Compare(key, stopKey) > 0 {
+			break
+		}
+		buf := it.Value().Data()
+		t := TreasuryBlock{Height: unpackUint(key)}
+		var l, ll int
+		t.PaidSat, l = unpackBigint(buf)
+		t.CarriedForwardSat, ll = unpackBigint(buf[l:])
+		txid, err := d.chainParser.UnpackTxid(buf[l+ll:])
+		if err != nil {
+			return err
+		}
+		t.Txid = txid
+		if err := fn(&t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Particl money supply
+// The column supply maps the height of the block to the money supply at the block and to the value moved between
+// the plain (standard) and the hidden (blind and anon) outputs in the block. The values of blind and anon outputs are hidden,
+// only their total can be computed from the plain flow and the explicit fee of the transactions with hidden inputs or outputs.
+// The value of a blind or anon output alone cannot be known, the supply is therefore not split between blind and anon.
+// The supply is a running total from the genesis block, the index must be built by a full synchronization.
+// If the spent output of a block is not found, the supply is marked as inexact from that block on.
+
+// Supply is the Particl money supply at the block, with the value moved between plain and hidden outputs in the block
+type Supply struct {
+	Height           uint32
+	PlainSat         big.Int
+	HiddenSat        big.Int
+	PlainToHiddenSat big.Int
+	HiddenToPlainSat big.Int
+	Inexact          bool
+}
+
+// blockSupply returns the supply at the block, computed from the supply at the previous block
+func (d *RocksDB) blockSupply(block *bchain.Block, txAddressesMap map[string]*TxAddresses, prev *Supply) (*Supply, error) {
+	if !d.isParticl() {
+		return nil, nil
+	}
+	s := &Supply{Height: block.Height}
+	if prev != nil {
+		s.PlainSat.Set(&prev.PlainSat)
+		s.HiddenSat.Set(&prev.HiddenSat)
+		s.Inexact = prev.Inexact
+	}
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		var plainIn, plainOut big.Int
+		hidden := false
+		for j := range tx.Vin {
+			input := &tx.Vin[j]
+			if input.InputType == "anon" {
+				hidden = true
+				continue
+			}
+			btxID, err := d.chainParser.PackTxid(input.Txid)
+			if err != nil {
+				if err == bchain.ErrTxidMissing {
+					continue
+				}
+				return nil, err
+			}
+			// the supply is not exact without the value of the spent output
+			ita := txAddressesMap[string(btxID)]
+			if ita == nil || len(ita.Outputs) <= int(input.Vout) {
+				if !s.Inexact {
+					glog.Warningf("rocksdb: supply at height %d, tx %v, input tx %v vout %v not found, the supply is inexact", block.Height, tx.Txid, input.Txid, input.Vout)
+					s.Inexact = true
+				}
+				continue
+			}
+			spentOutput := &ita.Outputs[input.Vout]
+			if d.isBlindTxOutput(spentOutput) {
+				hidden = true
+				continue
+			}
+			plainIn.Add(&plainIn, &spentOutput.ValueSat)
+		}
+		for j := range tx.Vout {
+			switch tx.Vout[j].OutputType {
+			case "blind", "anon":
+				hidden = true
+			default:
+				plainOut.Add(&plainOut, &tx.Vout[j].ValueSat)
+			}
+		}
+		s.PlainSat.Add(&s.PlainSat, &plainOut)
+		s.PlainSat.Sub(&s.PlainSat, &plainIn)
+		if !hidden {
+			continue
+		}
+		// the fee of the transaction with hidden inputs or outputs is explicit, the rest of the plain value moves to the hidden outputs
+		flow := new(big.Int).Sub(&plainIn, &plainOut)
+		flow.Sub(flow, big.NewInt(part.TxCTFeeSat(tx)))
+		s.HiddenSat.Add(&s.HiddenSat, flow)
+		switch flow.Sign() {
+		case 1:
+			s.PlainToHiddenSat.Add(&s.PlainToHiddenSat, flow)
+		case -1:
+			s.HiddenToPlainSat.Sub(&s.HiddenToPlainSat, flow)
+		}
+	}
+	return s, nil
+}
+
+// packSignedBigint packs the sign of the big int (1 byte) and its absolute value,
+// the hidden supply becomes negative only if more value is taken from the hidden outputs than was moved to them
+func packSignedBigint(bi *big.Int, buf []byte) int {
+	buf[0] = 0
+	if bi.Sign() < 0 {
+		buf[0] = 1
+	}
+	return 1 + packBigint(new(big.Int).Abs(bi), buf[1:])
+}
+
+func unpackSignedBigint(buf []byte) (big.Int, int) {
+	bi, l := unpackBigint(buf[1:])
+	if buf[0] == 1 {
+		bi.Neg(&bi)
+	}
+	return bi, l + 1
+}
+
+func packSupply(s *Supply) []byte {
+	buf := make([]byte, 2+4*maxPackedBigintBytes)
+	l := packBigint(&s.PlainSat, buf)
+	l += packSignedBigint(&s.HiddenSat, buf[l:])
+	l += packBigint(&s.PlainToHiddenSat, buf[l:])
+	l += packBigint(&s.HiddenToPlainSat, buf[l:])
+	// the flag is stored only for the inexact supply
+	if s.Inexact {
+		buf[l] = 1
+		l++
+	}
+	return buf[:l]
+}
+
+func unpackSupply(height uint32, buf []byte) *Supply {
+	s := &Supply{Height: height}
+	var l, ll int
+	s.PlainSat, l = unpackBigint(buf)
+	s.HiddenSat, ll = unpackSignedBigint(buf[l:])
+	l += ll
+	s.PlainToHiddenSat, ll = unpackBigint(buf[l:])
+	l += ll
+	s.HiddenToPlainSat, ll = unpackBigint(buf[l:])
+	l += ll
+	s.Inexact = len(buf) > l && buf[l] == 1
+	return s
+}
+
+// storeSupply stores the supply at the block
+func (d *RocksDB) storeSupply(wb *grocksdb.WriteBatch, s *Supply) {
+	if s == nil {
+		return
+	}
+	wb.PutCF(d.cfh[cfSupply], packUint(s.Height), packSupply(s))
+}
+
+// GetSupply returns the supply at the block at given height, nil if the supply of the block is not stored
+func (d *RocksDB) GetSupply(height uint32) (*Supply, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfSupply], packUint(height))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	buf := val.Data()
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	return unpackSupply(height, buf), nil
+}
+
+// GetLastSupply returns the supply at the highest block with stored supply, nil if there is none
+func (d *RocksDB) GetLastSupply() (*Supply, error) {
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfSupply])
+	defer it.Close()
+	it.SeekToLast()
+	if !it.Valid() {
+		return nil, nil
+	}
+	return unpackSupply(unpackUint(it.Key().Data()), it.Value().Data()), nil
+}
+
+// Particl coinstakes
+// The column coinstakes maps the height of proof-of-stake block to its coinstake transaction: the kernel,
+// the staker, the stake (value of the inputs) and the reward (value of the outputs without the treasury fund payouts minus the stake).
+// The staker and the reward are the same as for the staking rewards, the staker is the staking address in case of cold staking.
+
+// Coinstake is the coinstake transaction of Particl proof-of-stake block
+type Coinstake struct {
+	Height         uint32
+	Txid           string
+	KernelTxid     string
+	KernelVout     uint32
+	StakerAddrDesc bchain.AddressDescriptor
+	ColdStaked     bool
+	StakeSat       big.Int
+	RewardSat      big.Int
+}
+
+// blockCoinstake returns the coinstake of the block, nil for the blocks without coinstake
+func (d *RocksDB) blockCoinstake(block *bchain.Block, txAddressesMap map[string]*TxAddresses) (*Coinstake, error) {
+	p, ok := d.chainParser.(*part.ParticlParser)
+	if !ok {
+		return nil, nil
+	}
+	bs := p.BlockStakeFromBlock(block)
+	if bs == nil {
+		return nil, nil
+	}
+	cs := &Coinstake{
+		Height:         block.Height,
+		Txid:           bs.CoinstakeTxid,
+		KernelTxid:     bs.KernelTxid,
+		KernelVout:     bs.KernelVout,
+		StakerAddrDesc: bs.StakerAddrDesc,
+		ColdStaked:     bs.ColdStaked,
+	}
+	btxID, err := d.chainParser.PackTxid(bs.CoinstakeTxid)
+	if err != nil {
+		return nil, err
+	}
+	if ta := txAddressesMap[string(btxID)]; ta != nil {
+		for i := range ta.Inputs {
+			cs.StakeSat.Add(&cs.StakeSat, &ta.Inputs[i].ValueSat)
+		}
+		cs.RewardSat.Set(coinstakeReward(p, ta))
+	}
+	return cs, nil
+}
+
+func (d *RocksDB) packCoinstake(cs *Coinstake) ([]byte, error) {
+	btxID, err := d.chainParser.PackTxid(cs.Txid)
+	if err != nil {
+		return nil, err
+	}
+	kernelTxID, err := d.chainParser.PackTxid(cs.KernelTxid)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 0, len(btxID)+len(kernelTxID)+vlq.MaxLen64+1+2*maxPackedBigintBytes+len(cs.StakerAddrDesc))
+	buf = append(buf, btxID...)
+	buf = append(buf, kernelTxID...)
+	varBuf := make([]byte, maxPackedBigintBytes)
+	l := packVaruint(uint(cs.KernelVout), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	if cs.ColdStaked {
+		buf = append(buf, 1)
+	} else {
+		buf = append(buf, 0)
+	}
+	l = packBigint(&cs.StakeSat, varBuf)
+	buf = append(buf, varBuf[:l]...)
+	l = packBigint(&cs.RewardSat, varBuf)
+	buf = append(buf, varBuf[:l]...)
+	return append(buf, cs.StakerAddrDesc...), nil
+}
+
+func (d *RocksDB) unpackCoinstake(height uint32, buf []byte) (*Coinstake, error) {
+	txidLen := d.chainParser.PackedTxidLen()
+	if len(buf) < 2*txidLen+2 {
+		return nil, errors.New("Invalid coinstake value")
+	}
+	txid, err := d.chainParser.UnpackTxid(buf[:txidLen])
+	if err != nil {
+		return nil, err
+	}
+	kernelTxid, err := d.chainParser.UnpackTxid(buf[txidLen : 2*txidLen])
+	if err != nil {
+		return nil, err
+	}
+	cs := &Coinstake{
+		Height:     height,
+		Txid:       txid,
+		KernelTxid: kernelTxid,
+	}
+	o := 2 * txidLen
+	vout, l := unpackVaruint(buf[o:])
+	cs.KernelVout = uint32(vout)
+	o += l
+	cs.ColdStaked = buf[o] == 1
+	o++
+	cs.StakeSat, l = unpackBigint(buf[o:])
+	o += l
+	cs.RewardSat, l = unpackBigint(buf[o:])
+	o += l
+	cs.StakerAddrDesc = append(bchain.AddressDescriptor(nil), buf[o:]...)
+	return cs, nil
+}
+
+// storeCoinstake stores the coinstake of the block
+func (d *RocksDB) storeCoinstake(wb *grocksdb.WriteBatch, cs *Coinstake) error {
+	if cs == nil {
+		return nil
+	}
+	buf, err := d.packCoinstake(cs)
+	if err != nil {
+		return err
+	}
+	wb.PutCF(d.cfh[cfCoinstakes], packUint(cs.Height), buf)
+	return nil
+}
+
+// GetCoinstake returns the coinstake of the block at given height, nil if the block has no stored coinstake
+func (d *RocksDB) GetCoinstake(height uint32) (*Coinstake, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfCoinstakes], packUint(height))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	buf := val.Data()
+	if len(buf) == 0 {
+		return nil, nil
+	}
+	return d.unpackCoinstake(height, buf)
+}
+
+// GetCoinstakes passes the coinstakes of the blocks from lower to higher height to the callback function, in the order of heights
+func (d *RocksDB) GetCoinstakes(lower uint32, higher uint32, fn func(cs *Coinstake) error) error {
+	stopKey := packUint(higher)
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfCoinstakes])
+	defer it.Close()
+	for it.Seek(packUint(lower)); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if bytes.Compare(key, stopKey) > 0 {
+			break
+		}
+		cs, err := d.unpackCoinstake(unpackUint(key), it.Value().Data())
+		if err != nil {
+			return err
+		}
+		if err := fn(cs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBlockTxAddresses passes the TxAddresses of the transactions of the block at given height to the callback function,
+// in the order of the block, the first one is the coinstake or coinbase.
+// Only the last KeepBlockAddresses blocks have the list of transactions stored, nothing is passed for older blocks.
+func (d *RocksDB) GetBlockTxAddresses(height uint32, fn func(ta *TxAddresses) error) error {
+	bt, err := d.getBlockTxs(height)
+	if err != nil {
+		return err
+	}
+	for i := range bt {
+		ta, err := d.getTxAddresses(bt[i].btxID)
+		if err != nil {
+			return err
+		}
+		if ta == nil {
+			continue
+		}
+		if err := fn(ta); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Particl privacy statistics
+// The column privacyStats maps the height of the block to the numbers of the transactions converting value between
+// the plain (standard), blind and anon outputs, the ring sizes of the anon inputs and the CT fees of the block.
+// A transaction is counted in the conversion from each type of its inputs to each other type of its outputs,
+// the data outputs are ignored. Only the blocks with a blind or anon input or output are stored.
+
+// PrivacyStats contains the statistics of the Particl privacy transactions of the block
+type PrivacyStats struct {
+	Height          uint32
+	PrivacyTxs      uint32 // transactions with blind or anon input or output
+	PlainToBlindTxs uint32
+	PlainToAnonTxs  uint32
+	BlindToAnonTxs  uint32
+	BlindToPlainTxs uint32
+	AnonToPlainTxs  uint32
+	AnonToBlindTxs  uint32
+	AnonInputs      uint32 // number of the real anon inputs, one ring input can spend several of them
+	RingSizeSum     uint32 // sum of the ring sizes of the real anon inputs
+	CTFeeSat        big.Int
+}
+
+const (
+	privacyPlain = 1 << iota
+	privacyBlind
+	privacyAnon
+)
+
+// blockPrivacyStats returns the privacy statistics of the block, nil if the block has no privacy transaction.
+// The CT fee, stored also as CTFeeSat of TxAddresses in the extended index, is taken from the transaction,
+// so that the statistics do not depend on the extended index.
+func (d *RocksDB) blockPrivacyStats(block *bchain.Block, txAddressesMap map[string]*TxAddresses) (*PrivacyStats, error) {
+	if !d.isParticl() {
+		return nil, nil
+	}
+	ps := &PrivacyStats{Height: block.Height}
+	for i := range block.Txs {
+		tx := &block.Txs[i]
+		var in, out int
+		for j := range tx.Vin {
+			input := &tx.Vin[j]
+			if input.InputType == "anon" {
+				in |= privacyAnon
+				n := input.AnonInputs
+				if n == 0 {
+					n = 1
+				}
+				ps.AnonInputs += n
+				ps.RingSizeSum += n * input.RingSize
+				continue
+			}
+			btxID, err := d.chainParser.PackTxid(input.Txid)
+			if err != nil {
+				if err == bchain.ErrTxidMissing {
+					continue
+				}
+				return nil, err
+			}
+			ita := txAddressesMap[string(btxID)]
+			if ita == nil || len(ita.Outputs) <= int(input.Vout) {
+				continue
+			}
+			if d.isBlindTxOutput(&ita.Outputs[input.Vout]) {
+				in |= privacyBlind
+			} else {
+				in |= privacyPlain
+			}
+		}
+		for j := range tx.Vout {
+			switch tx.Vout[j].OutputType {
+			case "blind":
+				out |= privacyBlind
+			case "anon":
+				out |= privacyAnon
+			case "data":
+			default:
+				out |= privacyPlain
+			}
+		}
+		if (in|out)&(privacyBlind|privacyAnon) == 0 {
+			continue
+		}
+		ps.PrivacyTxs++
+		ps.CTFeeSat.Add(&ps.CTFeeSat, big.NewInt(part.TxCTFeeSat(tx)))
+		conversions := []struct {
+			from, to int
+			txs      *uint32
+		}{
+			{privacyPlain, privacyBlind, &ps.PlainToBlindTxs},
+			{privacyPlain, privacyAnon, &ps.PlainToAnonTxs},
+			{privacyBlind, privacyAnon, &ps.BlindToAnonTxs},
+			{privacyBlind, privacyPlain, &ps.BlindToPlainTxs},
+			{privacyAnon, privacyPlain, &ps.AnonToPlainTxs},
+			{privacyAnon, privacyBlind, &ps.AnonToBlindTxs},
+		}
+		for _, c := range conversions {
+			if in&c.from != 0 && out&c.to != 0 {
+				*c.txs++
+			}
+		}
+	}
+	if ps.PrivacyTxs == 0 {
+		return nil, nil
+	}
+	return ps, nil
+}
+
+func packPrivacyStats(ps *PrivacyStats) []byte {
+	buf := make([]byte, 9*vlq.MaxLen32+maxPackedBigintBytes)
+	l := 0
+	for _, v := range []uint32{ps.PrivacyTxs, ps.PlainToBlindTxs, ps.PlainToAnonTxs, ps.BlindToAnonTxs, ps.BlindToPlainTxs,
+		ps.AnonToPlainTxs, ps.AnonToBlindTxs, ps.AnonInputs, ps.RingSizeSum} {
+		l += packVaruint(uint(v), buf[l:])
+	}
+	l += packBigint(&ps.CTFeeSat, buf[l:])
+	return buf[:l]
+}
+
+func unpackPrivacyStats(height uint32, buf []byte) *PrivacyStats {
+	ps := &PrivacyStats{Height: height}
+	l := 0
+	for _, v := range []*uint32{&ps.PrivacyTxs, &ps.PlainToBlindTxs, &ps.PlainToAnonTxs, &ps.BlindToAnonTxs, &ps.BlindToPlainTxs,
+		&ps.AnonToPlainTxs, &ps.AnonToBlindTxs, &ps.AnonInputs, &ps.RingSizeSum} {
+		u, ll := unpackVaruint(buf[l:])
+		*v = uint32(u)
+		l += ll
+	}
+	ps.CTFeeSat, _ = unpackBigint(buf[l:])
+	return ps
+}
+
+// storePrivacyStats stores the privacy statistics of the block
+func (d *RocksDB) storePrivacyStats(wb *grocksdb.WriteBatch, ps *PrivacyStats) {
+	if ps == nil {
+		return
+	}
+	wb.PutCF(d.cfh[cfPrivacyStats], packUint(ps.Height), packPrivacyStats(ps))
+}
+
+// GetPrivacyStats passes the privacy statistics of the blocks from lower to higher height to the callback function,
+// in the order of heights, the blocks without privacy transactions are skipped
+func (d *RocksDB) GetPrivacyStats(lower uint32, higher uint32, fn func(ps *PrivacyStats) error) error {
+	stopKey := packUint(higher)
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfPrivacyStats])
+	defer it.Close()
+	for it.Seek(packUint(lower)); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if bytes.Compare(key, stopKey) > 0 {
+			break
+		}
+		if err := fn(unpackPrivacyStats(unpackUint(key), it.Value().Data())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,790 @@
+//go:build unittest
+
+package db
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/bchain/coins/btc"
+	"github.com/trezor/blockbook/bchain/coins/part"
+	"github.com/trezor/blockbook/common"
+	"github.com/trezor/blockbook/tests/dbtestdata"
+)
+
+func particlTestParser() *part.ParticlParser {
+	return part.NewParticlParser(part.GetChainParams("main"), &btc.Configuration{
+		BlockAddressesToKeep: 10,
+	})
+}
+
+func TestRocksDB_ParticlColumns(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		parser bchain.BlockChainParser
+		want   int
+	}{
+		{name: "bitcoin", parser: bitcoinTestnetParser(), want: len(cfBaseNames) + len(cfNamesBitcoinType)},
+		{name: "particl", parser: particlTestParser(), want: len(cfBaseNames) + len(cfNamesBitcoinType) + len(cfNamesParticl)},
+	} {
+		d := setupRocksDB(t, tt.parser)
+		if len(d.cfh) != tt.want || len(cfNames) != tt.want {
+			t.Errorf("%s: %d columns, want %d", tt.name, len(d.cfh), tt.want)
+		}
+		if got := cfNames[cfWatchlist]; got != "watchlist" {
+			t.Errorf("%s: column %d is %s, want watchlist", tt.name, cfWatchlist, got)
+		}
+		closeAndDestroyRocksDB(t, d)
+	}
+	if got := cfNames[cfPrivacyStats]; got != "privacyStats" {
+		t.Errorf("column %d is %s, want privacyStats", cfPrivacyStats, got)
+	}
+}
+
+// particlTypeBalance is the balance of an address of the Particl fixture chain
+type particlTypeBalance struct {
+	txs                         uint32
+	balance, delegated, staking int64
+	utxos                       int
+	stakingUtxos                []string
+	blindReceived, blindSpent   uint32
+	blindUtxos                  []BlindUtxo
+}
+
+// particlTypeBlob is an output of the Particl fixture chain with the commitment and the range proof stored in txOutputBlobs
+type particlTypeBlob struct {
+	txid       string
+	vout       int
+	outputType string
+	commitment string
+	rangeProof string
+}
+
+// particlTypeBlockIndex is the Particl specific index added by a block of the Particl fixture chain,
+// balances contain the addresses changed by the block, nil if the address has no balance
+type particlTypeBlockIndex struct {
+	balances       map[string]*particlTypeBalance
+	stakingRewards map[string]int64
+	keyImages      map[string]*KeyImageSpend
+	anonOutputs    []AnonOutput
+	blobs          []particlTypeBlob
+	stealthOutputs []StealthOutput
+	votes          []Vote
+	treasury       *TreasuryBlock
+	supply         *Supply
+	coinstake      *Coinstake
+	privacyStats   *PrivacyStats
+}
+
+func newTestSupply(height uint32, plain, hidden, plainToHidden, hiddenToPlain int64) *Supply {
+	return &Supply{
+		Height:           height,
+		PlainSat:         *big.NewInt(plain),
+		HiddenSat:        *big.NewInt(hidden),
+		PlainToHiddenSat: *big.NewInt(plainToHidden),
+		HiddenToPlainSat: *big.NewInt(hiddenToPlain),
+	}
+}
+
+// particlTypeBlockIndexes returns the index added by the blocks of the Particl fixture chain by the block hash,
+// the privacy transactions pay the CT fee 215200
+func particlTypeBlockIndexes(d *RocksDB) map[string]*particlTypeBlockIndex {
+	owner := dbtestdata.AddressToPubKeyHex(dbtestdata.AddrPartOwner, d.chainParser)
+	staker := dbtestdata.AddressToPubKeyHex(dbtestdata.AddrPartStaker, d.chainParser)
+	a := dbtestdata.AddressToPubKeyHex(dbtestdata.AddrPartA, d.chainParser)
+	b := dbtestdata.AddressToPubKeyHex(dbtestdata.AddrPartB, d.chainParser)
+	c := dbtestdata.AddressToPubKeyHex(dbtestdata.AddrPartC, d.chainParser)
+	fund := dbtestdata.AddressToPubKeyHex(dbtestdata.TreasuryAddrPart, d.chainParser)
+	return map[string]*particlTypeBlockIndex{
+		"0000000000000000000000000000000000000000000000000000000000000500": {
+			balances: map[string]*particlTypeBalance{
+				owner:                            {txs: 1, balance: 10000000000, delegated: 10000000000, utxos: 1},
+				staker:                           {txs: 1, staking: 10000000000, stakingUtxos: []string{dbtestdata.TxidPartB500T1 + ":0"}},
+				dbtestdata.ColdStakingPartScript: nil,
+				a:                                {txs: 1, balance: 5000000000, utxos: 1},
+				b:                                {txs: 1, balance: 2000000000, utxos: 1},
+			},
+			supply: newTestSupply(500, 17000000000, 0, 0, 0),
+		},
+		"0000000000000000000000000000000000000000000000000000000000000501": {
+			balances: map[string]*particlTypeBalance{
+				owner:  {txs: 2, balance: 10050000000, delegated: 10050000000, utxos: 1},
+				staker: {txs: 2, staking: 10050000000, stakingUtxos: []string{dbtestdata.TxidPartB501T1 + ":1"}},
+				a:      {txs: 2, balance: 3000000000, utxos: 1},
+				b: {txs: 2, balance: 2000000000, utxos: 1, blindReceived: 1, blindUtxos: []BlindUtxo{
+					{BtxID: hexToBytes(dbtestdata.TxidPartB501T2), Vout: 1, Height: 501, Commitment: hexToBytes(dbtestdata.BlindPartCommitment)},
+				}},
+				fund: {txs: 1, balance: 10000000, utxos: 1},
+			},
+			stakingRewards: map[string]int64{staker: 50000000},
+			blobs: []particlTypeBlob{
+				{txid: dbtestdata.TxidPartB501T2, vout: 1, outputType: "blind", commitment: dbtestdata.BlindPartCommitment, rangeProof: dbtestdata.RangeProofPart},
+			},
+			stealthOutputs: []StealthOutput{{
+				Txid: dbtestdata.TxidPartB501T2, Vout: 1, Height: 501, Type: part.OutputCT,
+				EphemPubKeys: [][]byte{hexToBytes(dbtestdata.StealthPartBlindEphem)},
+				Destination:  hexToBytes(b[6:46]),
+				Commitment:   hexToBytes(dbtestdata.BlindPartCommitment),
+			}},
+			votes:    []Vote{{Height: 501, Option: 1, StakeSat: *big.NewInt(10000000000)}},
+			treasury: &TreasuryBlock{Height: 501, Txid: dbtestdata.TxidPartB501T1, PaidSat: *big.NewInt(10000000)},
+			supply:   newTestSupply(501, 15060000000, 1999784800, 1999784800, 0),
+			coinstake: &Coinstake{
+				Height:         501,
+				Txid:           dbtestdata.TxidPartB501T1,
+				KernelTxid:     dbtestdata.TxidPartB500T1,
+				KernelVout:     0,
+				StakerAddrDesc: hexToBytes(staker),
+				ColdStaked:     true,
+				StakeSat:       *big.NewInt(10000000000),
+				RewardSat:      *big.NewInt(50000000),
+			},
+			privacyStats: &PrivacyStats{Height: 501, PrivacyTxs: 1, PlainToBlindTxs: 1, CTFeeSat: *big.NewInt(215200)},
+		},
+		"0000000000000000000000000000000000000000000000000000000000000502": {
+			balances: map[string]*particlTypeBalance{
+				b: {txs: 4, balance: 2030000000, utxos: 1, blindReceived: 1, blindSpent: 1},
+			},
+			stakingRewards: map[string]int64{b: 30000000},
+			anonOutputs: []AnonOutput{
+				{Index: 1, Txid: dbtestdata.TxidPartB502T2, Vout: 1, Height: 502, PubKey: hexToBytes(dbtestdata.AnonPartPubKey), Commitment: hexToBytes(dbtestdata.AnonPartCommitment)},
+			},
+			blobs: []particlTypeBlob{
+				{txid: dbtestdata.TxidPartB502T2, vout: 1, outputType: "anon", commitment: dbtestdata.AnonPartCommitment},
+			},
+			stealthOutputs: []StealthOutput{{
+				Txid: dbtestdata.TxidPartB502T2, Vout: 1, Height: 502, Type: part.OutputRingCT,
+				EphemPubKeys: [][]byte{hexToBytes(dbtestdata.StealthPartAnonEphem)},
+				Destination:  hexToBytes(dbtestdata.AnonPartPubKey),
+				Commitment:   hexToBytes(dbtestdata.AnonPartCommitment),
+			}},
+			votes:    []Vote{{Height: 502, Option: 2, StakeSat: *big.NewInt(2000000000)}},
+			treasury: &TreasuryBlock{Height: 502, Txid: dbtestdata.TxidPartB502T1, CarriedForwardSat: *big.NewInt(dbtestdata.TreasuryCfwdPart)},
+			supply:   newTestSupply(502, 15090000000, 1999569600, 0, 215200),
+			coinstake: &Coinstake{
+				Height:         502,
+				Txid:           dbtestdata.TxidPartB502T1,
+				KernelTxid:     dbtestdata.TxidPartB500T1,
+				KernelVout:     2,
+				StakerAddrDesc: hexToBytes(b),
+				StakeSat:       *big.NewInt(2000000000),
+				RewardSat:      *big.NewInt(30000000),
+			},
+			privacyStats: &PrivacyStats{Height: 502, PrivacyTxs: 1, BlindToAnonTxs: 1, CTFeeSat: *big.NewInt(215200)},
+		},
+		"0000000000000000000000000000000000000000000000000000000000000503": {
+			balances: map[string]*particlTypeBalance{
+				owner:  {txs: 3, balance: 10090000000, delegated: 10090000000, utxos: 1},
+				staker: {txs: 3, staking: 10090000000, stakingUtxos: []string{dbtestdata.TxidPartB503T1 + ":1"}},
+				c:      {txs: 1, balance: 500000000, utxos: 1},
+			},
+			stakingRewards: map[string]int64{staker: 40000000},
+			keyImages:      map[string]*KeyImageSpend{dbtestdata.KeyImagePart: {Txid: dbtestdata.TxidPartB503T2, Height: 503, Vin: 0}},
+			anonOutputs: []AnonOutput{
+				{Index: 2, Txid: dbtestdata.TxidPartB503T2, Vout: 2, Height: 503, PubKey: hexToBytes(dbtestdata.AnonPartChangePubKey), Commitment: hexToBytes(dbtestdata.AnonPartChangeCommitment)},
+			},
+			blobs: []particlTypeBlob{
+				{txid: dbtestdata.TxidPartB503T2, vout: 2, outputType: "anon", commitment: dbtestdata.AnonPartChangeCommitment},
+			},
+			stealthOutputs: []StealthOutput{{
+				Txid: dbtestdata.TxidPartB503T2, Vout: 1, Height: 503, Type: part.OutputStandard,
+				EphemPubKeys: [][]byte{hexToBytes(dbtestdata.StealthPartEphem)},
+				Destination:  hexToBytes(c[6:46]),
+				ValueSat:     *big.NewInt(500000000),
+			}},
+			supply: newTestSupply(503, 15630000000, 1499354400, 0, 500215200),
+			coinstake: &Coinstake{
+				Height:         503,
+				Txid:           dbtestdata.TxidPartB503T1,
+				KernelTxid:     dbtestdata.TxidPartB501T1,
+				KernelVout:     1,
+				StakerAddrDesc: hexToBytes(staker),
+				ColdStaked:     true,
+				StakeSat:       *big.NewInt(10050000000),
+				RewardSat:      *big.NewInt(40000000),
+			},
+			privacyStats: &PrivacyStats{Height: 503, PrivacyTxs: 1, AnonToPlainTxs: 1, AnonInputs: 1, RingSizeSum: 5, CTFeeSat: *big.NewInt(215200)},
+		},
+		"1000000000000000000000000000000000000000000000000000000000000503": {
+			balances: map[string]*particlTypeBalance{
+				b: {txs: 5, balance: 2050000000, utxos: 1, blindReceived: 1, blindSpent: 1},
+			},
+			stakingRewards: map[string]int64{b: 20000000},
+			supply:         newTestSupply(503, 15110000000, 1999569600, 0, 0),
+			coinstake: &Coinstake{
+				Height:         503,
+				Txid:           dbtestdata.TxidPartB503R1,
+				KernelTxid:     dbtestdata.TxidPartB502T1,
+				KernelVout:     1,
+				StakerAddrDesc: hexToBytes(b),
+				StakeSat:       *big.NewInt(2030000000),
+				RewardSat:      *big.NewInt(20000000),
+			},
+		},
+	}
+}
+
+// verifyAfterParticlTypeBlocks checks the Particl specific index after the blocks of the Particl fixture chain were connected
+func verifyAfterParticlTypeBlocks(t *testing.T, d *RocksDB, name string, blocks []*bchain.Block) {
+	indexes := particlTypeBlockIndexes(d)
+	// the addresses and key images of the other blocks must not be in the index
+	balances := make(map[string]*particlTypeBalance)
+	rewards := make(map[string]map[uint32]int64)
+	keyImages := make(map[string]*KeyImageSpend)
+	for _, bi := range indexes {
+		for addrDesc := range bi.balances {
+			balances[addrDesc] = nil
+			rewards[addrDesc] = make(map[uint32]int64)
+		}
+		for ki := range bi.keyImages {
+			keyImages[ki] = nil
+		}
+	}
+	var want particlTypeBlockIndex
+	var treasury []TreasuryBlock
+	var supply []*Supply
+	var coinstakes []Coinstake
+	var privacyStats []PrivacyStats
+	for _, block := range blocks {
+		bi := indexes[block.Hash]
+		for addrDesc, ab := range bi.balances {
+			balances[addrDesc] = ab
+		}
+		for addrDesc, r := range bi.stakingRewards {
+			rewards[addrDesc][block.Height] = r
+		}
+		for ki, s := range bi.keyImages {
+			keyImages[ki] = s
+		}
+		want.anonOutputs = append(want.anonOutputs, bi.anonOutputs...)
+		want.blobs = append(want.blobs, bi.blobs...)
+		want.stealthOutputs = append(want.stealthOutputs, bi.stealthOutputs...)
+		want.votes = append(want.votes, bi.votes...)
+		if bi.treasury != nil {
+			treasury = append(treasury, *bi.treasury)
+		}
+		supply = append(supply, bi.supply)
+		if bi.coinstake != nil {
+			coinstakes = append(coinstakes, *bi.coinstake)
+		} else if cs, err := d.GetCoinstake(block.Height); err != nil || cs != nil {
+			t.Errorf("%s: GetCoinstake(%d) = %+v, %v, want nil for a block without coinstake", name, block.Height, cs, err)
+		}
+		if bi.privacyStats != nil {
+			privacyStats = append(privacyStats, *bi.privacyStats)
+		}
+	}
+
+	for addrDesc, w := range balances {
+		checkParticlTypeBalance(t, d, name, addrDesc, w)
+		checkStakingRewards(t, d, name, addrDesc, rewards[addrDesc])
+	}
+	checkKeyImages(t, d, name, keyImages)
+	checkAnonOutputs(t, d, name, want.anonOutputs)
+	for i := range want.blobs {
+		checkTxOutputBlob(t, d, name, &want.blobs[i])
+	}
+	checkStealthOutputs(t, d, name, 0, 1000, want.stealthOutputs)
+	checkVotes(t, d, name, dbtestdata.VoteProposalPart, 0, 1000, want.votes)
+	checkVotes(t, d, name, dbtestdata.VoteProposalPart+1, 0, 1000, nil)
+	checkTreasury(t, d, name, 0, 1000, treasury)
+	checkCoinstakes(t, d, name, coinstakes)
+	checkPrivacyStats(t, d, name, privacyStats)
+	if len(blocks) > 0 {
+		checkSupply(t, d, name, supply)
+		// the range queries return only the last block
+		last := indexes[blocks[len(blocks)-1].Hash]
+		checkStealthOutputs(t, d, name+" last block", blocks[len(blocks)-1].Height, blocks[len(blocks)-1].Height, last.stealthOutputs)
+		return
+	}
+	for cf := cfColdStakingBalance; cf <= cfPrivacyStats; cf++ {
+		it := d.db.NewIteratorCF(d.ro, d.cfh[cf])
+		for it.SeekToFirst(); it.Valid(); it.Next() {
+			t.Errorf("%s: %s not empty, key %x", name, cfNames[cf], it.Key().Data())
+		}
+		it.Close()
+	}
+}
+
+func checkParticlTypeBalance(t *testing.T, d *RocksDB, name string, addrDesc string, want *particlTypeBalance) {
+	ab, err := d.GetAddrDescBalance(hexToBytes(addrDesc), AddressBalanceDetailUTXO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want == nil {
+		if ab != nil {
+			t.Errorf("%s: balance of %s = %+v, want nil", name, addrDesc, ab)
+		}
+		return
+	}
+	if ab == nil {
+		t.Fatalf("%s: balance of %s not found", name, addrDesc)
+	}
+	if ab.Txs != want.txs || ab.BalanceSat.Int64() != want.balance || ab.DelegatedSat.Int64() != want.delegated ||
+		ab.StakingSat.Int64() != want.staking || len(ab.Utxos) != want.utxos || ab.BlindReceived != want.blindReceived || ab.BlindSpent != want.blindSpent {
+		t.Errorf("%s: balance of %s = txs %d, balance %v, delegated %v, staking %v, utxos %d, blind received %d, spent %d, want %+v", name, addrDesc,
+			ab.Txs, ab.BalanceSat.String(), ab.DelegatedSat.String(), ab.StakingSat.String(), len(ab.Utxos), ab.BlindReceived, ab.BlindSpent, *want)
+	}
+	stakingUtxos := []string{}
+	for _, u := range ab.StakingUtxos {
+		stakingUtxos = append(stakingUtxos, fmt.Sprintf("%x:%d", u.BtxID, u.Vout))
+	}
+	if len(stakingUtxos) != 0 || len(want.stakingUtxos) != 0 {
+		if !reflect.DeepEqual(stakingUtxos, want.stakingUtxos) {
+			t.Errorf("%s: StakingUtxos of %s = %v, want %v", name, addrDesc, stakingUtxos, want.stakingUtxos)
+		}
+	}
+	if len(ab.BlindUtxos) != 0 || len(want.blindUtxos) != 0 {
+		if !reflect.DeepEqual(ab.BlindUtxos, want.blindUtxos) {
+			t.Errorf("%s: BlindUtxos of %s = %+v, want %+v", name, addrDesc, ab.BlindUtxos, want.blindUtxos)
+		}
+	}
+}
+
+func checkStakingRewards(t *testing.T, d *RocksDB, name string, addrDesc string, want map[uint32]int64) {
+	got := make(map[uint32]int64)
+	if err := d.GetStakingRewards(hexToBytes(addrDesc), 0, ^uint32(0), func(height uint32, rewardSat *big.Int) error {
+		got[height] = rewardSat.Int64()
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: GetStakingRewards(%s) = %v, want %v", name, addrDesc, got, want)
+	}
+}
+
+func checkKeyImages(t *testing.T, d *RocksDB, name string, want map[string]*KeyImageSpend) {
+	for ki, w := range want {
+		got, err := d.GetKeyImageSpend(hexToBytes(ki))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("%s: GetKeyImageSpend(%s) = %+v, want %+v", name, ki, got, w)
+		}
+	}
+}
+
+func checkAnonOutputs(t *testing.T, d *RocksDB, name string, want []AnonOutput) {
+	last, err := d.GetLastAnonIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last != uint64(len(want)) {
+		t.Errorf("%s: GetLastAnonIndex() = %d, want %d", name, last, len(want))
+	}
+	got, err := d.GetAnonOutputs(0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want == nil {
+		want = []AnonOutput{}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: GetAnonOutputs() = %+v, want %+v", name, got, want)
+	}
+	if len(want) > 1 {
+		got, err := d.GetAnonOutputs(2, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want[1:2]) {
+			t.Errorf("%s: GetAnonOutputs(2, 1) = %+v, want %+v", name, got, want[1:2])
+		}
+	}
+}
+
+// checkTxOutputBlob checks that the blobs of the output are not in txAddresses and that they are loaded from txOutputBlobs,
+// the range proof is stored only with the extended index
+func checkTxOutputBlob(t *testing.T, d *RocksDB, name string, want *particlTypeBlob) {
+	ta, err := d.GetTxAddresses(want.txid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ta == nil {
+		t.Fatalf("%s: TxAddresses of %s not found", name, want.txid)
+	}
+	o := &ta.Outputs[want.vout]
+	// the output type is stored also without extended index, the spent blind output is recognized by it
+	if o.OutputType != want.outputType {
+		t.Errorf("%s: TxAddresses output %s:%d type %v, want %v", name, want.txid, want.vout, o.OutputType, want.outputType)
+	}
+	if o.ValueCommitment != "" || o.RangeProof != "" {
+		t.Errorf("%s: TxAddresses output %s:%d with commitment %v, range proof %v", name, want.txid, want.vout, o.ValueCommitment, o.RangeProof)
+	}
+	if err := d.LoadTxOutputBlobs(want.txid, ta); err != nil {
+		t.Fatal(err)
+	}
+	wantRangeProof := ""
+	if d.extendedIndex {
+		wantRangeProof = want.rangeProof
+	}
+	if o.ValueCommitment != want.commitment || o.RangeProof != wantRangeProof {
+		t.Errorf("%s: LoadTxOutputBlobs(%s) output %d = %v, %v, want %v, %v", name, want.txid, want.vout, o.ValueCommitment, o.RangeProof, want.commitment, wantRangeProof)
+	}
+}
+
+func checkStealthOutputs(t *testing.T, d *RocksDB, name string, lower, higher uint32, want []StealthOutput) {
+	got := make([]StealthOutput, 0)
+	if err := d.GetStealthOutputs(lower, higher, func(o *StealthOutput) error {
+		got = append(got, *o)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("%s: GetStealthOutputs(%d, %d) = %+v, want %+v", name, lower, higher, got, want)
+	}
+	for i := range got {
+		g, w := got[i], want[i]
+		if g.ValueSat.Cmp(&w.ValueSat) != 0 {
+			t.Errorf("%s: GetStealthOutputs()[%d].ValueSat = %v, want %v", name, i, g.ValueSat.String(), w.ValueSat.String())
+		}
+		g.ValueSat, w.ValueSat = big.Int{}, big.Int{}
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("%s: GetStealthOutputs()[%d] = %+v, want %+v", name, i, g, w)
+		}
+	}
+}
+
+func checkVotes(t *testing.T, d *RocksDB, name string, proposal uint16, lower, higher uint32, want []Vote) {
+	var got []Vote
+	if err := d.GetVotes(proposal, lower, higher, func(v *Vote) error {
+		got = append(got, *v)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: GetVotes(%d, %d, %d) = %+v, want %+v", name, proposal, lower, higher, got, want)
+	}
+}
+
+func checkTreasury(t *testing.T, d *RocksDB, name string, lower, higher uint32, want []TreasuryBlock) {
+	var got []TreasuryBlock
+	if err := d.GetTreasury(lower, higher, func(tb *TreasuryBlock) error {
+		got = append(got, *tb)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: GetTreasury(%d, %d) = %+v, want %+v", name, lower, higher, got, want)
+	}
+}
+
+func checkSupply(t *testing.T, d *RocksDB, name string, want []*Supply) {
+	for _, w := range want {
+		got, err := d.GetSupply(w.Height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, w) {
+			t.Errorf("%s: GetSupply(%d) = %+v, want %+v", name, w.Height, got, w)
+		}
+	}
+	got, err := d.GetSupply(want[len(want)-1].Height + 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Errorf("%s: GetSupply(%d) = %+v, want nil", name, want[len(want)-1].Height+1, got)
+	}
+}
+
+func checkCoinstakes(t *testing.T, d *RocksDB, name string, want []Coinstake) {
+	var got []Coinstake
+	if err := d.GetCoinstakes(0, 1000, func(cs *Coinstake) error {
+		got = append(got, *cs)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: GetCoinstakes() = %+v, want %+v", name, got, want)
+	}
+	for i := range want {
+		cs, err := d.GetCoinstake(want[i].Height)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(cs, &want[i]) {
+			t.Errorf("%s: GetCoinstake(%d) = %+v, want %+v", name, want[i].Height, cs, want[i])
+		}
+	}
+}
+
+func checkPrivacyStats(t *testing.T, d *RocksDB, name string, want []PrivacyStats) {
+	var got []PrivacyStats
+	if err := d.GetPrivacyStats(0, 1000, func(ps *PrivacyStats) error {
+		got = append(got, *ps)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s: GetPrivacyStats() = %+v, want %+v", name, got, want)
+	}
+}
+
+func TestRocksDB_Index_ParticlType(t *testing.T) {
+	for _, extendedIndex := range []bool{false, true} {
+		t.Run(fmt.Sprintf("extendedIndex=%v", extendedIndex), func(t *testing.T) {
+			d := setupRocksDB(t, particlTestParser())
+			defer closeAndDestroyRocksDB(t, d)
+			d.extendedIndex = extendedIndex
+
+			blocks := dbtestdata.GetTestParticlTypeBlocks(d.chainParser)
+			verifyAfterParticlTypeBlocks(t, d, "empty", nil)
+			for i, block := range blocks {
+				if err := d.ConnectBlock(block); err != nil {
+					t.Fatal(err)
+				}
+				verifyAfterParticlTypeBlocks(t, d, fmt.Sprintf("block%d", block.Height), blocks[:i+1])
+			}
+
+			// the block 503 is replaced by the block 503R, then all the blocks are disconnected
+			if err := d.DisconnectBlockRangeBitcoinType(503, 503); err != nil {
+				t.Fatal(err)
+			}
+			verifyAfterParticlTypeBlocks(t, d, "disconnect block503", blocks[:3])
+			blocks = append(blocks[:3], dbtestdata.GetTestParticlTypeBlock503R(d.chainParser))
+			if err := d.ConnectBlock(blocks[3]); err != nil {
+				t.Fatal(err)
+			}
+			verifyAfterParticlTypeBlocks(t, d, "block503R", blocks)
+			for i := len(blocks) - 1; i >= 0; i-- {
+				if err := d.DisconnectBlockRangeBitcoinType(blocks[i].Height, blocks[i].Height); err != nil {
+					t.Fatal(err)
+				}
+				verifyAfterParticlTypeBlocks(t, d, fmt.Sprintf("disconnect block%d", blocks[i].Height), blocks[:i])
+			}
+		})
+	}
+}
+
+func TestBulkConnect_ParticlType(t *testing.T) {
+	for _, extendedIndex := range []bool{false, true} {
+		t.Run(fmt.Sprintf("extendedIndex=%v", extendedIndex), func(t *testing.T) {
+			d := setupRocksDB(t, particlTestParser())
+			defer closeAndDestroyRocksDB(t, d)
+			d.extendedIndex = extendedIndex
+
+			blocks := dbtestdata.GetTestParticlTypeBlocks(d.chainParser)
+			bc, err := d.InitBulkConnect()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, block := range blocks[:3] {
+				if err := bc.ConnectBlock(block, i == 2); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := bc.Close(); err != nil {
+				t.Fatal(err)
+			}
+			verifyAfterParticlTypeBlocks(t, d, "bulk", blocks[:3])
+
+			// the indexes continue after the bulk import
+			if err := d.ConnectBlock(blocks[3]); err != nil {
+				t.Fatal(err)
+			}
+			verifyAfterParticlTypeBlocks(t, d, "block503", blocks)
+		})
+	}
+}
+
+func TestRocksDB_VerifyAnonIndex(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+
+	blocks := dbtestdata.GetTestParticlTypeBlocks(d.chainParser)
+	for _, block := range blocks[:3] {
+		if err := d.ConnectBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the index provided by the backend must continue the indexes of the database
+	blocks[3].Txs[1].Vout[2].AnonIndex = 3
+	if err := d.ConnectBlock(blocks[3]); err == nil || !strings.Contains(err.Error(), "reindex required") {
+		t.Errorf("ConnectBlock() with backend anon index 3 error = %v, want reindex required", err)
+	}
+	blocks[3].Txs[1].Vout[2].AnonIndex = 2
+	if err := d.ConnectBlock(blocks[3]); err != nil {
+		t.Fatal(err)
+	}
+	verifyAfterParticlTypeBlocks(t, d, "block503", blocks)
+
+	chain, err := dbtestdata.NewFakeBlockChainParticlType(d.chainParser, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.VerifyAnonIndex(chain); err != nil {
+		t.Errorf("VerifyAnonIndex() error = %v", err)
+	}
+	// the backend has one more anon output before the last one stored in the database
+	blocks = dbtestdata.GetTestParticlTypeBlocks(d.chainParser)
+	blocks[1].Txs[1].Vout[1].OutputType = "anon"
+	chain, err = dbtestdata.NewFakeBlockChainParticlType(d.chainParser, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.VerifyAnonIndex(chain); err == nil || !strings.Contains(err.Error(), "reindex required") {
+		t.Errorf("VerifyAnonIndex() error = %v, want reindex required", err)
+	}
+}
+
+func TestRocksDB_CheckColumnsReindexRequired(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+	// the index of cold staking outputs of the older databases cannot be migrated
+	if _, err := d.checkColumns(&common.InternalState{DbColumns: []common.InternalStateColumn{{Name: "txAddresses", Version: 7, Rows: 1}}}); err == nil ||
+		!strings.Contains(err.Error(), "reindex required") {
+		t.Errorf("checkColumns() of DB version 7 error = %v, want reindex required", err)
+	}
+	// the supply is computed from the genesis block and cannot be added to an existing database
+	if _, err := d.checkColumns(&common.InternalState{DbColumns: []common.InternalStateColumn{{Name: "txAddresses", Version: dbVersion, Rows: 1}}}); err == nil ||
+		!strings.Contains(err.Error(), "reindex required") {
+		t.Errorf("checkColumns() of DB without supply error = %v, want reindex required", err)
+	}
+	nc, err := d.checkColumns(&common.InternalState{DbColumns: []common.InternalStateColumn{{Name: "txAddresses", Version: dbVersion, Rows: 1}, {Name: "supply", Version: dbVersion}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range nc {
+		if nc[i].Version != dbVersion {
+			t.Errorf("checkColumns() column %s version %d, want %d", nc[i].Name, nc[i].Version, dbVersion)
+		}
+	}
+}
+
+func TestRocksDB_MigrateTxAddressesToV8(t *testing.T) {
+	d := setupRocksDB(t, bitcoinTestnetParser())
+	defer closeAndDestroyRocksDB(t, d)
+	d.extendedIndex = true
+
+	// txAddresses in the layout of DB version 7, the output type, commitment and range proof are in every output
+	varBuf := make([]byte, maxPackedBigintBytes)
+	var buf []byte
+	for _, v := range []uint{225493, 200, 0, 2} {
+		l := packVaruint(v, varBuf)
+		buf = append(buf, varBuf[:l]...)
+	}
+	for _, o := range []struct {
+		addrDesc bchain.AddressDescriptor
+		spent    bool
+	}{
+		{addrDesc: addressToAddrDesc(dbtestdata.Addr1, d.chainParser)},
+		{addrDesc: addressToAddrDesc(dbtestdata.Addr2, d.chainParser), spent: true},
+	} {
+		la := len(o.addrDesc)
+		if o.spent {
+			la = ^la
+		}
+		l := packVarint(la, varBuf)
+		buf = append(buf, varBuf[:l]...)
+		buf = append(buf, o.addrDesc...)
+		l = packBigint(big.NewInt(1000), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		if o.spent {
+			buf = append(buf, hexToBytes(dbtestdata.TxidB2T1)...)
+			buf = append(buf, 1, 2)
+		}
+		// empty output type, commitment and range proof
+		buf = append(buf, 0, 0, 0)
+	}
+	if err := d.db.PutCF(d.wo, d.cfh[cfTxAddresses], hexToBytes(dbtestdata.TxidB1T1), buf); err != nil {
+		t.Fatal(err)
+	}
+
+	nc, err := d.checkColumns(&common.InternalState{DbColumns: []common.InternalStateColumn{{Name: "txAddresses", Version: 7, Rows: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range nc {
+		if nc[i].Version != dbVersion {
+			t.Errorf("checkColumns() column %s version %d, want %d", nc[i].Name, nc[i].Version, dbVersion)
+		}
+	}
+	ta, err := d.GetTxAddresses(dbtestdata.TxidB1T1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &TxAddresses{
+		Height: 225493,
+		VSize:  200,
+		Inputs: []TxInput{},
+		Outputs: []TxOutput{
+			{AddrDesc: addressToAddrDesc(dbtestdata.Addr1, d.chainParser), ValueSat: *big.NewInt(1000)},
+			{AddrDesc: addressToAddrDesc(dbtestdata.Addr2, d.chainParser), ValueSat: *big.NewInt(1000), Spent: true, SpentTxid: dbtestdata.TxidB2T1, SpentIndex: 1, SpentHeight: 2},
+		},
+	}
+	if !reflect.DeepEqual(ta, want) {
+		t.Errorf("GetTxAddresses() = %+v, want %+v", ta, want)
+	}
+}
+
+func TestRocksDB_SupplyInexact(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+
+	// the input spends an output which is not indexed, the block is connected with the inexact supply
+	block := &bchain.Block{
+		BlockHeader: bchain.BlockHeader{Height: 100, Hash: "0000000000000000000000000000000000000000000000000000000000000100"},
+		Txs: []bchain.Tx{{
+			Txid: "0100000000000000000000000000000000000000000000000000000000000001",
+			Vin:  []bchain.Vin{{Txid: "0100000000000000000000000000000000000000000000000000000000000002", Vout: 1}},
+			Vout: []bchain.Vout{{N: 0, OutputType: "standard", ValueSat: *big.NewInt(1000)}},
+		}},
+	}
+	if err := d.ConnectBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	want := newTestSupply(100, 1000, 0, 0, 0)
+	want.Inexact = true
+	checkSupply(t, d, "inexact", []*Supply{want})
+}
+
+func TestRocksDB_GetBlockTxAddresses(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+
+	blocks := dbtestdata.GetTestParticlTypeBlocks(d.chainParser)[:2]
+	for _, block := range blocks {
+		if err := d.ConnectBlock(block); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, block := range blocks {
+		var got []*TxAddresses
+		if err := d.GetBlockTxAddresses(block.Height, func(ta *TxAddresses) error {
+			got = append(got, ta)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(block.Txs) {
+			t.Fatalf("GetBlockTxAddresses(%d) returned %d txs, want %d", block.Height, len(got), len(block.Txs))
+		}
+		for i, ta := range got {
+			want, err := d.GetTxAddresses(block.Txs[i].Txid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ta, want) {
+				t.Errorf("GetBlockTxAddresses(%d)[%d] = %+v, want %+v", block.Height, i, ta, want)
+			}
+		}
+	}
+	n := 0
+	if err := d.GetBlockTxAddresses(502, func(ta *TxAddresses) error {
+		n++
+		return nil
+	}); err != nil || n != 0 {
+		t.Errorf("GetBlockTxAddresses(502) = %d txs, %v, want none", n, err)
+	}
+}
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"sync"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/common"
+)
+
+// Secondary instance
+// The database of a running blockbook (the primary instance) can be opened by other processes as a RocksDB secondary
+// instance. The secondary instance does not write to the database, it periodically catches up with the changes
+// written by the primary instance and updates its internal state (best block and block times) from the database.
+// The column statistics and the other state maintained by the primary instance are taken from the internal state
+// stored by the primary instance.
+// The forks of the chain are detected from the reorgs stored by the primary instance.
+
+// secondaryState is the state of the database opened as a secondary instance
+type secondaryState struct {
+	mux         sync.Mutex
+	path        string
+	bestHeight  uint32
+	bestHash    string
+	lastReorgID uint32
+	initialized bool
+}
+
+// NewRocksDBSecondary opens the database of the primary instance in path as a secondary instance,
+// secondaryPath is the directory for the info logs of the secondary instance
+func NewRocksDBSecondary(path, secondaryPath string, cacheSize int, parser bchain.BlockChainParser, metrics *common.Metrics, extendedIndex bool) (*RocksDB, error) {
+	glog.Infof("rocksdb: opening %s as secondary instance in %s, required data version %v, cache size %v", path, secondaryPath, dbVersion, cacheSize)
+	// the secondary instance must keep all files open, the primary instance may delete them at any time
+	return newRocksDB(path, secondaryPath, cacheSize, -1, parser, metrics, extendedIndex)
+}
+
+// IsSecondary returns true if the database is opened as a secondary instance
+func (d *RocksDB) IsSecondary() bool {
+	return d.secondary != nil
+}
+
+// refreshInternalState updates the internal state from the state stored by the primary instance
+func (d *RocksDB) refreshInternalState() error {
+	stored, err := d.storedInternalState()
+	if err != nil {
+		return err
+	}
+	if stored == nil {
+		return nil
+	}
+	d.is.UpdateFromStored(stored)
+	if d.metrics != nil {
+		for c := 0; c < len(cfNames); c++ {
+			rows, keyBytes, valueBytes := d.is.GetDBColumnStatValues(c)
+			d.metrics.DbColumnRows.With(common.Labels{"column": cfNames[c]}).Set(float64(rows))
+			d.metrics.DbColumnSize.With(common.Labels{"column": cfNames[c]}).Set(float64(keyBytes + valueBytes))
+		}
+	}
+	return nil
+}
+
+// CatchUpWithPrimary reads the changes written by the primary instance since the last call and updates the internal state.
+// The reorgs stored by the primary instance are passed to onReorg (without the affected addresses), then onNewBlock
+// is called for each newly connected block. The first call only initializes the state, without callbacks.
+func (d *RocksDB) CatchUpWithPrimary(onNewBlock bchain.OnNewBlockFunc, onReorg OnReorgFunc) error {
+	s := d.secondary
+	if s == nil {
+		return errors.New("Database is not opened as secondary instance")
+	}
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if err := d.db.TryCatchUpWithPrimary(); err != nil {
+		return errors.Annotate(err, "TryCatchUpWithPrimary")
+	}
+	if err := d.refreshInternalState(); err != nil {
+		return err
+	}
+	height, hash, err := d.GetBestBlock()
+	if err != nil {
+		return err
+	}
+	isBitcoinType := d.chainParser.GetChainType() == bchain.ChainBitcoinType
+	if !s.initialized {
+		if isBitcoinType {
+			s.lastReorgID = d.GetLastReorgID()
+		}
+		s.bestHeight, s.bestHash, s.initialized = height, hash, true
+		d.is.FinishedSync(height)
+		glog.Infof("rocksdb: secondary instance at block %d %s", height, hash)
+		return nil
+	}
+	if height == s.bestHeight && hash == s.bestHash {
+		d.is.FinishedSyncNoChange()
+		return nil
+	}
+	// the blocks above the fork height were connected by the primary instance since the last call
+	fork := s.bestHeight
+	var reorgs []*Reorg
+	if isBitcoinType {
+		for id := s.lastReorgID + 1; ; id++ {
+			r, err := d.GetReorg(id)
+			if err != nil {
+				return err
+			}
+			if r == nil {
+				break
+			}
+			reorgs = append(reorgs, r)
+			s.lastReorgID = id
+			if r.ForkHeight < fork {
+				fork = r.ForkHeight
+			}
+		}
+	}
+	if fork > height {
+		fork = height
+	}
+	if len(reorgs) == 0 && fork == s.bestHeight {
+		// a fork without the stored reorg (not Bitcoin type coin) is detected only as the change of the last known block
+		if h, err := d.GetBlockHash(s.bestHeight); err != nil {
+			return err
+		} else if h != s.bestHash {
+			glog.Warning("rocksdb: secondary instance, block ", s.bestHeight, " ", s.bestHash, " replaced by ", h, ", reloading block times")
+			if fork > 0 {
+				fork--
+			}
+			d.setBlockTimes()
+		}
+	}
+	if s.bestHeight > fork {
+		d.is.RemoveLastBlockTimes(int(s.bestHeight - fork))
+	}
+	hashes := make([]string, 0, height-fork)
+	for h := fork + 1; h <= height; h++ {
+		bi, err := d.GetBlockInfo(h)
+		if err != nil {
+			return err
+		}
+		if bi == nil {
+			return errors.Errorf("Missing block %d in the database of the primary instance", h)
+		}
+		avg := d.is.SetBlockTime(h, uint32(bi.Time))
+		if d.metrics != nil {
+			d.metrics.AvgBlockPeriod.Set(float64(avg))
+		}
+		hashes = append(hashes, bi.Hash)
+	}
+	d.is.FinishedSync(height)
+	glog.Infof("rocksdb: secondary instance caught up from block %d %s to %d %s", s.bestHeight, s.bestHash, height, hash)
+	s.bestHeight, s.bestHash = height, hash
+	if onReorg != nil {
+		for _, r := range reorgs {
+			onReorg(r, nil)
+		}
+	}
+	if onNewBlock != nil {
+		for i, h := range hashes {
+			onNewBlock(h, fork+1+uint32(i))
+		}
+	}
+	return nil
+}
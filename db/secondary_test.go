@@ -0,0 +1,161 @@
+//go:build unittest
+
+package db
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/trezor/blockbook/common"
+	"github.com/trezor/blockbook/tests/dbtestdata"
+)
+
+type secondaryNewBlock struct {
+	hash   string
+	height uint32
+}
+
+func TestRocksDB_CatchUpWithPrimary(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+	blocks := dbtestdata.GetTestParticlTypeBlocks(d.chainParser)
+	for i := uint32(0); i < blocks[0].Height; i++ {
+		d.is.BlockTimes = append(d.is.BlockTimes, 0)
+	}
+	for _, b := range blocks[:2] {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tmp, err := os.MkdirTemp("", "testsecondary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	s, err := NewRocksDBSecondary(d.path, tmp, 100000, d.chainParser, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if !s.IsSecondary() || d.IsSecondary() {
+		t.Fatal("IsSecondary() mismatch")
+	}
+	is, err := s.LoadInternalState(&common.Config{CoinName: "coin-unittest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetInternalState(is)
+
+	var newBlocks []secondaryNewBlock
+	var reorgs []*Reorg
+	onNewBlock := func(hash string, height uint32) {
+		newBlocks = append(newBlocks, secondaryNewBlock{hash, height})
+	}
+	onReorg := func(reorg *Reorg, addrTxids map[string][]string) {
+		if addrTxids != nil {
+			t.Errorf("onReorg addrTxids = %v, want nil", addrTxids)
+		}
+		reorgs = append(reorgs, reorg)
+	}
+	checkState := func(height uint32, hash string) {
+		t.Helper()
+		synced, bestHeight, _, _ := is.GetSyncState()
+		if !synced || bestHeight != height {
+			t.Errorf("GetSyncState() = %v %d, want true %d", synced, bestHeight, height)
+		}
+		if got := s.secondary.bestHash; got != hash {
+			t.Errorf("bestHash = %s, want %s", got, hash)
+		}
+		if len(is.BlockTimes) != int(height)+1 {
+			t.Errorf("len(BlockTimes) = %d, want %d", len(is.BlockTimes), height+1)
+		}
+	}
+
+	// the first call only initializes the state
+	if err = s.CatchUpWithPrimary(onNewBlock, onReorg); err != nil {
+		t.Fatal(err)
+	}
+	if len(newBlocks) != 0 || len(reorgs) != 0 {
+		t.Errorf("first CatchUpWithPrimary() notified %v %v", newBlocks, reorgs)
+	}
+	checkState(501, blocks[1].Hash)
+
+	for _, b := range blocks[2:] {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = s.CatchUpWithPrimary(onNewBlock, onReorg); err != nil {
+		t.Fatal(err)
+	}
+	want := []secondaryNewBlock{{blocks[2].Hash, 502}, {blocks[3].Hash, 503}}
+	if !reflect.DeepEqual(newBlocks, want) || len(reorgs) != 0 {
+		t.Errorf("CatchUpWithPrimary() notified %v %v, want %v", newBlocks, reorgs, want)
+	}
+	checkState(503, blocks[3].Hash)
+	if got := is.GetBlockTime(503); got != uint32(blocks[3].Time) {
+		t.Errorf("GetBlockTime(503) = %d, want %d", got, blocks[3].Time)
+	}
+
+	// no change of the chain, the state stored by the primary instance is taken over
+	newBlocks = nil
+	d.is.SetDBColumnStats(cfTransactions, 7, 70, 700)
+	d.is.UtxoChecked = true
+	if err = d.StoreInternalState(d.is); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.CatchUpWithPrimary(onNewBlock, onReorg); err != nil {
+		t.Fatal(err)
+	}
+	if len(newBlocks) != 0 {
+		t.Errorf("CatchUpWithPrimary() without change notified %v", newBlocks)
+	}
+	if rows, keyBytes, valueBytes := is.GetDBColumnStatValues(cfTransactions); rows != 7 || keyBytes != 70 || valueBytes != 700 {
+		t.Errorf("GetDBColumnStatValues() = %d %d %d, want 7 70 700", rows, keyBytes, valueBytes)
+	}
+	if !is.UtxoChecked {
+		t.Error("UtxoChecked not taken from the primary instance")
+	}
+
+	// reorg of the last block handled by the primary instance
+	chain, err := dbtestdata.NewFakeBlockChainParticlType(d.chainParser, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, err := common.GetMetrics("ParticlSecondary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewSyncWorker(d, chain, 1, 0, 0, false, nil, metrics, d.is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := blocks[3]
+	blocks[3] = dbtestdata.GetTestParticlTypeBlock503R(d.chainParser)
+	if err := w.handleFork(old.Height, old.Hash, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.CatchUpWithPrimary(onNewBlock, onReorg); err != nil {
+		t.Fatal(err)
+	}
+	want = []secondaryNewBlock{{blocks[3].Hash, 503}}
+	if !reflect.DeepEqual(newBlocks, want) {
+		t.Errorf("CatchUpWithPrimary() after reorg notified %v, want %v", newBlocks, want)
+	}
+	if len(reorgs) != 1 || reorgs[0].ID != 1 || reorgs[0].ForkHeight != 502 || reorgs[0].OldHash != old.Hash || reorgs[0].NewHash != blocks[3].Hash {
+		t.Errorf("CatchUpWithPrimary() after reorg notified reorgs %+v", reorgs)
+	}
+	checkState(503, blocks[3].Hash)
+	if got := is.GetBlockTime(503); got != uint32(blocks[3].Time) {
+		t.Errorf("GetBlockTime(503) after reorg = %d, want %d", got, blocks[3].Time)
+	}
+
+	if _, err = s.CreateSnapshot(tmp + "/snapshot"); err == nil {
+		t.Error("CreateSnapshot() of secondary instance succeeded")
+	}
+	if err = d.CatchUpWithPrimary(nil, nil); err == nil {
+		t.Error("CatchUpWithPrimary() of primary instance succeeded")
+	}
+}
@@ -0,0 +1,392 @@
+package db
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/common"
+)
+
+// Database snapshots
+// The snapshot is a gzip compressed tar archive with the description of the snapshot in the file snapshot.json,
+// which is the first entry of the archive, and with the RocksDB checkpoint of the database in the directory db.
+// The checkpoint is consistent, it is created while the connecting and disconnecting of blocks is blocked, together with the internal
+// state of the database, which is stored in the checkpoint in the closed state. The checkpoint is created next to
+// the archive and removed after it is archived, it shares the files with the database only on the same filesystem.
+
+// snapshotVersion is the version of the format of the snapshot
+const snapshotVersion = 2
+
+const (
+	snapshotInfoFile = "snapshot.json"
+	snapshotDbDir    = "db"
+)
+
+// SnapshotInfo describes the snapshot of the database
+type SnapshotInfo struct {
+	Version          int       `json:"version"`
+	Coin             string    `json:"coin"`
+	DbVersion        int       `json:"dbVersion"`
+	ExtendedIndex    bool      `json:"extendedIndex"`
+	BestHeight       uint32    `json:"bestHeight"`
+	BestHash         string    `json:"bestHash"`
+	Time             time.Time `json:"time"`
+	BlockbookVersion string    `json:"blockbookVersion"`
+	GitCommit        string    `json:"gitCommit"`
+	Path             string    `json:"path,omitempty"`
+}
+
+// CreateSnapshot creates the snapshot archive of the database in the file path, which must not exist
+func (d *RocksDB) CreateSnapshot(path string) (*SnapshotInfo, error) {
+	if d.is == nil {
+		return nil, errors.New("Internal state not created")
+	}
+	if d.secondary != nil {
+		return nil, errors.New("Snapshot cannot be created by secondary instance")
+	}
+	if d.is.DbState == common.DbStateInconsistent {
+		return nil, errors.New("Database is in inconsistent state, snapshot cannot be created")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return nil, errors.Errorf("Snapshot %s already exists", path)
+	}
+	start := time.Now()
+	dir := path + ".checkpoint"
+	if err := os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	packed, err := d.createCheckpoint(filepath.Join(dir, snapshotDbDir))
+	if err != nil {
+		return nil, err
+	}
+	si, err := d.closeCheckpoint(filepath.Join(dir, snapshotDbDir), packed)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := json.MarshalIndent(si, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err = os.WriteFile(filepath.Join(dir, snapshotInfoFile), buf, 0o644); err != nil {
+		return nil, err
+	}
+	// the archive gets its name only when it is complete
+	tmp := path + ".tmp"
+	if err = writeSnapshotArchive(tmp, dir); err != nil {
+		os.Remove(tmp)
+		return nil, err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+	si.Path = path
+	glog.Infof("rocksdb: snapshot of block %d %s created in %s, %v", si.BestHeight, si.BestHash, path, time.Since(start))
+	return si, nil
+}
+
+// createCheckpoint creates the checkpoint of the database in dir and returns the internal state at the time of the checkpoint,
+// the blocks are not connected or disconnected meanwhile so that the internal state matches the checkpoint
+func (d *RocksDB) createCheckpoint(dir string) ([]byte, error) {
+	d.connectBlockMux.Lock()
+	defer d.connectBlockMux.Unlock()
+	packed, err := d.is.Pack()
+	if err != nil {
+		return nil, err
+	}
+	cp, err := d.db.NewCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	defer cp.Destroy()
+	if err = os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return nil, err
+	}
+	if err = cp.CreateCheckpoint(dir, 0); err != nil {
+		return nil, errors.Annotatef(err, "CreateCheckpoint %s", dir)
+	}
+	return packed, nil
+}
+
+// closeCheckpoint stores the internal state in the checkpoint in dir in the closed state and returns the description of the snapshot
+func (d *RocksDB) closeCheckpoint(dir string, packed []byte) (*SnapshotInfo, error) {
+	sd, err := NewRocksDB(dir, 0, d.maxOpenFiles, d.chainParser, nil, d.extendedIndex)
+	if err != nil {
+		return nil, err
+	}
+	defer sd.Close()
+	is, err := common.UnpackInternalState(packed)
+	if err != nil {
+		return nil, err
+	}
+	is.DbState = common.DbStateClosed
+	if err = sd.storeState(is); err != nil {
+		return nil, err
+	}
+	height, hash, err := sd.GetBestBlock()
+	if err != nil {
+		return nil, err
+	}
+	vi := common.GetVersionInfo()
+	return &SnapshotInfo{
+		Version:          snapshotVersion,
+		Coin:             is.Coin,
+		DbVersion:        dbVersion,
+		ExtendedIndex:    d.extendedIndex,
+		BestHeight:       height,
+		BestHash:         hash,
+		Time:             time.Now().UTC(),
+		BlockbookVersion: vi.Version,
+		GitCommit:        vi.GitCommit,
+	}, nil
+}
+
+// writeSnapshotArchive writes the description and the checkpoint in dir to the archive in path
+func writeSnapshotArchive(path string, dir string) (err error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	add := func(file string, fi os.FileInfo) error {
+		name, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		h, err := tar.FileInfoHeader(fi, "")
+		if err != nil {
+			return err
+		}
+		h.Name = filepath.ToSlash(name)
+		if fi.IsDir() {
+			h.Name += "/"
+		}
+		if err = tw.WriteHeader(h); err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			return nil
+		}
+		r, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		_, err = io.Copy(tw, r)
+		return err
+	}
+	// the description is the first entry, it can be read without unpacking the database
+	fi, err := os.Stat(filepath.Join(dir, snapshotInfoFile))
+	if err != nil {
+		return err
+	}
+	if err = add(filepath.Join(dir, snapshotInfoFile), fi); err != nil {
+		return err
+	}
+	err = filepath.Walk(filepath.Join(dir, snapshotDbDir), func(file string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		return add(file, fi)
+	})
+	if err != nil {
+		return err
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// storedInternalState returns the internal state stored in the database without initializing it, nil if there is none
+func (d *RocksDB) storedInternalState() (*common.InternalState, error) {
+	val, err := d.db.GetCF(d.ro, d.cfh[cfDefault], []byte(internalStateKey))
+	if err != nil {
+		return nil, err
+	}
+	defer val.Free()
+	if len(val.Data()) == 0 {
+		return nil, nil
+	}
+	return common.UnpackInternalState(val.Data())
+}
+
+// openSnapshotArchive opens the archive in path and reads its description, the reader is positioned after the description
+func openSnapshotArchive(path string) (*os.File, *tar.Reader, *SnapshotInfo, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, nil, errors.Annotatef(err, "Invalid snapshot %s", path)
+	}
+	tr := tar.NewReader(zr)
+	h, err := tr.Next()
+	if err != nil || h.Name != snapshotInfoFile {
+		f.Close()
+		return nil, nil, nil, errors.Errorf("Invalid snapshot %s, missing %s", path, snapshotInfoFile)
+	}
+	var si SnapshotInfo
+	if err = json.NewDecoder(tr).Decode(&si); err != nil {
+		f.Close()
+		return nil, nil, nil, errors.Annotatef(err, "Invalid snapshot %s", path)
+	}
+	si.Path = path
+	return f, tr, &si, nil
+}
+
+// ReadSnapshotInfo reads the description of the snapshot archive in path
+func ReadSnapshotInfo(path string) (*SnapshotInfo, error) {
+	f, _, si, err := openSnapshotArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return si, nil
+}
+
+// extractSnapshotDb extracts the database of the archive to dir
+func extractSnapshotDb(tr *tar.Reader, dir string) error {
+	prefix := snapshotDbDir + "/"
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(h.Name, prefix) {
+			continue
+		}
+		name := filepath.Clean(filepath.FromSlash(strings.TrimPrefix(h.Name, prefix)))
+		if name == "." {
+			continue
+		}
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return errors.Errorf("Invalid path %s in snapshot", h.Name)
+		}
+		file := filepath.Join(dir, name)
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err = os.MkdirAll(file, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err = os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+				return err
+			}
+			w, err := os.OpenFile(file, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(w, tr)
+			if cerr := w.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+		default:
+			return errors.Errorf("Unsupported entry %s in snapshot", h.Name)
+		}
+	}
+}
+
+// RestoreSnapshot checks the snapshot archive in archive and creates the database in path from it.
+// The snapshot must match the coin, the data version and the index type of the database, and its best block
+// must be in the chain of the backend. The path must not exist. The sync resumes from the best block of the snapshot.
+// The archive is only read, the database is extracted next to path and moved to path after it is checked.
+func RestoreSnapshot(archive string, path string, maxOpenFiles int, chain bchain.BlockChain, config *common.Config, extendedIndex bool) (*SnapshotInfo, error) {
+	f, tr, si, err := openSnapshotArchive(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if si.Version != snapshotVersion {
+		return nil, errors.Errorf("Snapshot version %d is not supported, required version %d", si.Version, snapshotVersion)
+	}
+	if si.Coin != config.CoinName {
+		return nil, errors.Errorf("Coins do not match. Snapshot coin %v, RPC coin %v", si.Coin, config.CoinName)
+	}
+	if si.DbVersion != dbVersion {
+		return nil, errors.Errorf("Snapshot data version %d does not match the required version %d", si.DbVersion, dbVersion)
+	}
+	if si.ExtendedIndex != extendedIndex {
+		return nil, errors.Errorf("ExtendedIndex setting does not match. Snapshot extendedIndex %v, extendedIndex in options %v", si.ExtendedIndex, extendedIndex)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return nil, errors.Errorf("Database directory %s already exists, snapshot can be restored only to a new directory", path)
+	}
+	hash, err := chain.GetBlockHash(si.BestHeight)
+	if err != nil {
+		return nil, errors.Annotatef(err, "GetBlockHash %d", si.BestHeight)
+	}
+	if hash != si.BestHash {
+		return nil, errors.Errorf("Snapshot block %d %s is not in the backend chain, backend hash %s", si.BestHeight, si.BestHash, hash)
+	}
+	dir := path + ".restore"
+	if err = os.RemoveAll(dir); err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err = extractSnapshotDb(tr, dir); err != nil {
+		os.RemoveAll(dir)
+		return nil, errors.Annotatef(err, "Extract snapshot %s", archive)
+	}
+	if err = checkSnapshotDb(dir, si, maxOpenFiles, chain.GetChainParser(), config, extendedIndex); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if err = os.Rename(dir, path); err != nil {
+		return nil, err
+	}
+	glog.Infof("rocksdb: snapshot %s of block %d %s restored to %s", archive, si.BestHeight, si.BestHash, path)
+	return si, nil
+}
+
+// checkSnapshotDb checks that the extracted database matches the description of the snapshot
+func checkSnapshotDb(dir string, si *SnapshotInfo, maxOpenFiles int, parser bchain.BlockChainParser, config *common.Config, extendedIndex bool) error {
+	sd, err := NewRocksDB(dir, 0, maxOpenFiles, parser, nil, extendedIndex)
+	if err != nil {
+		return err
+	}
+	defer sd.Close()
+	is, err := sd.storedInternalState()
+	if err != nil {
+		return err
+	}
+	if is == nil || is.Coin != config.CoinName {
+		return errors.New("Snapshot database does not contain the internal state of the coin")
+	}
+	if is.DbState != common.DbStateClosed {
+		return errors.New("Snapshot database is not in closed state")
+	}
+	height, hash, err := sd.GetBestBlock()
+	if err != nil {
+		return err
+	}
+	if height != si.BestHeight || hash != si.BestHash {
+		return errors.Errorf("Snapshot database best block %d %s does not match the snapshot description", height, hash)
+	}
+	return nil
+}
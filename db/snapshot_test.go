@@ -0,0 +1,158 @@
+//go:build unittest
+
+package db
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trezor/blockbook/common"
+	"github.com/trezor/blockbook/tests/dbtestdata"
+)
+
+func TestRocksDB_Snapshot(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+	blocks := dbtestdata.GetTestParticlTypeBlocks(d.chainParser)
+	for i := uint32(0); i < blocks[0].Height; i++ {
+		d.is.BlockTimes = append(d.is.BlockTimes, 0)
+	}
+	for _, b := range blocks {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	best := blocks[len(blocks)-1]
+	tmp, err := os.MkdirTemp("", "testsnapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	dir := filepath.Join(tmp, "snapshot.tar.gz")
+	si, err := d.CreateSnapshot(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(dir); err != nil || !fi.Mode().IsRegular() {
+		t.Fatalf("CreateSnapshot() archive %v, %v", fi, err)
+	}
+	if _, err := os.Stat(dir + ".checkpoint"); !os.IsNotExist(err) {
+		t.Errorf("CreateSnapshot() checkpoint not removed, %v", err)
+	}
+	archive, err := os.ReadFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if si.Version != snapshotVersion || si.Coin != "coin-unittest" || si.DbVersion != dbVersion || si.ExtendedIndex ||
+		si.BestHeight != best.Height || si.BestHash != best.Hash || si.Path != dir {
+		t.Errorf("CreateSnapshot() = %+v", si)
+	}
+	if _, err = d.CreateSnapshot(dir); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("CreateSnapshot() to existing directory error = %v", err)
+	}
+	rsi, err := ReadSnapshotInfo(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *rsi != *si {
+		t.Errorf("ReadSnapshotInfo() = %+v, want %+v", rsi, si)
+	}
+
+	chain, err := dbtestdata.NewFakeBlockChainParticlType(d.chainParser, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &common.Config{CoinName: "coin-unittest"}
+	if _, err = RestoreSnapshot(dir, filepath.Join(tmp, "other"), -1, chain, &common.Config{CoinName: "other"}, false); err == nil || !strings.Contains(err.Error(), "Coins do not match") {
+		t.Errorf("RestoreSnapshot() of other coin error = %v", err)
+	}
+	if _, err = RestoreSnapshot(dir, filepath.Join(tmp, "extended"), -1, chain, config, true); err == nil || !strings.Contains(err.Error(), "ExtendedIndex") {
+		t.Errorf("RestoreSnapshot() with extendedIndex error = %v", err)
+	}
+	if _, err = RestoreSnapshot(dir, tmp, -1, chain, config, false); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("RestoreSnapshot() to existing directory error = %v", err)
+	}
+	forked := append(append(blocks[:0:0], blocks[:3]...), dbtestdata.GetTestParticlTypeBlock503R(d.chainParser))
+	forkedChain, err := dbtestdata.NewFakeBlockChainParticlType(d.chainParser, forked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = RestoreSnapshot(dir, filepath.Join(tmp, "forked"), -1, forkedChain, config, false); err == nil || !strings.Contains(err.Error(), "not in the backend chain") {
+		t.Errorf("RestoreSnapshot() with forked backend error = %v", err)
+	}
+
+	path := filepath.Join(tmp, "restored")
+	if _, err = RestoreSnapshot(dir, path, -1, chain, config, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + ".restore"); !os.IsNotExist(err) {
+		t.Errorf("RestoreSnapshot() temporary directory not removed, %v", err)
+	}
+	if got, err := os.ReadFile(dir); err != nil || !bytes.Equal(got, archive) {
+		t.Errorf("RestoreSnapshot() modified the archive, %v", err)
+	}
+	if _, err = ReadSnapshotInfo(path); err == nil {
+		t.Error("ReadSnapshotInfo() of a directory succeeded")
+	}
+	r, err := NewRocksDB(path, 100000, -1, d.chainParser, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	is, err := r.storedInternalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if is == nil || is.Coin != "coin-unittest" || is.DbState != common.DbStateClosed {
+		t.Errorf("restored internal state = %+v", is)
+	}
+	height, hash, err := r.GetBestBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if height != best.Height || hash != best.Hash {
+		t.Errorf("restored GetBestBlock() = %d %s, want %d %s", height, hash, best.Height, best.Hash)
+	}
+	ta, err := r.GetTxAddresses(dbtestdata.TxidPartB503T1)
+	if err != nil || ta == nil {
+		t.Errorf("restored GetTxAddresses() = %v, %v", ta, err)
+	}
+}
+
+func TestRocksDB_DisconnectBlockedBySnapshot(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+	blocks := dbtestdata.GetTestParticlTypeBlocks(d.chainParser)
+	for i := uint32(0); i < blocks[0].Height; i++ {
+		d.is.BlockTimes = append(d.is.BlockTimes, 0)
+	}
+	for _, b := range blocks {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	best := blocks[len(blocks)-1]
+	// the lock is held by the creation of the checkpoint
+	d.connectBlockMux.Lock()
+	done := make(chan error)
+	go func() {
+		done <- d.DisconnectBlockRangeBitcoinType(best.Height, best.Height)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("DisconnectBlockRangeBitcoinType() finished while the checkpoint was created, error %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	d.connectBlockMux.Unlock()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if height, _, err := d.GetBestBlock(); err != nil || height != best.Height-1 {
+		t.Errorf("GetBestBlock() = %d, %v, want %d", height, err, best.Height-1)
+	}
+}
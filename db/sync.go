@@ -23,8 +23,20 @@ type SyncWorker struct {
 	chanOsSignal           chan os.Signal
 	metrics                *common.Metrics
 	is                     *common.InternalState
+	onReorg                OnReorgFunc
+	// pendingReorg is the reorg with disconnected blocks, which is stored after the new chain is synchronized
+	pendingReorg *pendingReorg
 }
 
+type pendingReorg struct {
+	reorg     *Reorg
+	addrTxids map[string][]string
+}
+
+// OnReorgFunc is used to send notification about a reorg of the chain handled by the sync,
+// addrTxids maps the address descriptors of the disconnected transactions to their txids
+type OnReorgFunc func(reorg *Reorg, addrTxids map[string][]string)
+
 // NewSyncWorker creates new SyncWorker and returns its handle
 func NewSyncWorker(db *RocksDB, chain bchain.BlockChain, syncWorkers, syncChunk int, minStartHeight int, dryRun bool, chanOsSignal chan os.Signal, metrics *common.Metrics, is *common.InternalState) (*SyncWorker, error) {
 	if minStartHeight < 0 {
@@ -43,13 +55,19 @@ func NewSyncWorker(db *RocksDB, chain bchain.BlockChain, syncWorkers, syncChunk
 	}, nil
 }
 
+// SetOnReorg sets the callback called after a reorg of the chain is handled and recorded
+func (w *SyncWorker) SetOnReorg(onReorg OnReorgFunc) {
+	w.onReorg = onReorg
+}
+
 var errSynced = errors.New("synced")
 var errFork = errors.New("fork")
 
 // ErrOperationInterrupted is returned when operation is interrupted by OS signal
 var ErrOperationInterrupted = errors.New("ErrOperationInterrupted")
 
-func (w *SyncWorker) updateBackendInfo() {
+// UpdateBackendInfo gets the info about the backend and stores it in the internal state
+func (w *SyncWorker) UpdateBackendInfo() {
 	ci, err := w.chain.GetChainInfo()
 	var backendError string
 	if err != nil {
@@ -84,7 +102,12 @@ func (w *SyncWorker) ResyncIndex(onNewBlock bchain.OnNewBlockFunc, initialSync b
 	err := w.resyncIndex(onNewBlock, initialSync)
 
 	// update backend info after each resync
-	w.updateBackendInfo()
+	w.UpdateBackendInfo()
+
+	if err == nil || err == errSynced {
+		// the reorg whose resync failed is completed by the next successful resync
+		w.completePendingReorg()
+	}
 
 	switch err {
 	case nil:
@@ -116,6 +139,15 @@ func (w *SyncWorker) ResyncIndex(onNewBlock bchain.OnNewBlockFunc, initialSync b
 }
 
 func (w *SyncWorker) resyncIndex(onNewBlock bchain.OnNewBlockFunc, initialSync bool) error {
+	if !initialSync {
+		synced, err := w.connectNotifiedBlocks(onNewBlock)
+		if err != nil {
+			return err
+		}
+		if synced {
+			return nil
+		}
+	}
 	remoteBestHash, err := w.chain.GetBestBlockHash()
 	if err != nil {
 		return err
@@ -196,6 +228,37 @@ func (w *SyncWorker) resyncIndex(onNewBlock bchain.OnNewBlockFunc, initialSync b
 	return err
 }
 
+// connectNotifiedBlocks connects the blocks received in the notifications of the backend without requests to the backend.
+// It returns false if there are no such blocks or if they do not follow the local best block and the full resync is necessary.
+func (w *SyncWorker) connectNotifiedBlocks(onNewBlock bchain.OnNewBlockFunc) (bool, error) {
+	blocks := w.chain.GetNotifiedBlocks()
+	if len(blocks) == 0 {
+		return false, nil
+	}
+	height, hash, err := w.db.GetBestBlock()
+	if err != nil || hash == "" {
+		return false, err
+	}
+	for _, block := range blocks {
+		// the blocks already connected by the previous resync and the blocks not following the local chain are skipped
+		if block.Prev != hash {
+			continue
+		}
+		height++
+		block.Height = height
+		if err = w.db.ConnectBlock(block); err != nil {
+			return false, err
+		}
+		hash = block.Hash
+		if onNewBlock != nil {
+			onNewBlock(block.Hash, block.Height)
+		}
+		w.metrics.BlockbookBestHeight.Set(float64(block.Height))
+		glog.Info("resync: connected notified block ", block.Height, " ", block.Hash)
+	}
+	return hash == blocks[len(blocks)-1].Hash, nil
+}
+
 func (w *SyncWorker) handleFork(localBestHeight uint32, localBestHash string, onNewBlock bchain.OnNewBlockFunc, initialSync bool) error {
 	// find forked blocks, disconnect them and then synchronize again
 	var height uint32
@@ -218,10 +281,101 @@ func (w *SyncWorker) handleFork(localBestHeight uint32, localBestHash string, on
 		}
 		hashes = append(hashes, local)
 	}
+	var reorg *Reorg
+	var addrTxids map[string][]string
+	if w.chain.GetChainParser().GetChainType() == bchain.ChainBitcoinType {
+		txids, at, err := w.db.getReorgTxs(height+1, localBestHeight)
+		if err != nil {
+			return err
+		}
+		reorg = &Reorg{
+			ForkHeight: height,
+			OldHeight:  localBestHeight,
+			OldHash:    localBestHash,
+			Txids:      txids,
+		}
+		addrTxids = at
+	}
 	if err := w.DisconnectBlocks(height+1, localBestHeight, hashes); err != nil {
 		return err
 	}
-	return w.resyncIndex(onNewBlock, initialSync)
+	if reorg != nil {
+		w.addPendingReorg(reorg, addrTxids)
+	}
+	if err := w.resyncIndex(onNewBlock, initialSync); err != nil && err != errSynced {
+		// the new chain is not known, the reorg is kept pending until the next successful resync
+		return err
+	}
+	w.completePendingReorg()
+	return nil
+}
+
+// addPendingReorg sets the reorg as pending, a still pending reorg of a previous fork is merged with it,
+// the merged reorg spans from the old tip of the chain before the first fork
+func (w *SyncWorker) addPendingReorg(reorg *Reorg, addrTxids map[string][]string) {
+	p := w.pendingReorg
+	if p == nil {
+		w.pendingReorg = &pendingReorg{reorg: reorg, addrTxids: addrTxids}
+		return
+	}
+	if reorg.ForkHeight < p.reorg.ForkHeight {
+		p.reorg.ForkHeight = reorg.ForkHeight
+	}
+	p.reorg.Txids = appendMissing(p.reorg.Txids, reorg.Txids)
+	if p.addrTxids == nil {
+		p.addrTxids = make(map[string][]string)
+	}
+	for addrDesc, txids := range addrTxids {
+		p.addrTxids[addrDesc] = appendMissing(p.addrTxids[addrDesc], txids)
+	}
+}
+
+// appendMissing appends the values of add which are not in s
+func appendMissing(s []string, add []string) []string {
+	present := make(map[string]struct{}, len(s))
+	for _, v := range s {
+		present[v] = struct{}{}
+	}
+	for _, v := range add {
+		if _, found := present[v]; !found {
+			present[v] = struct{}{}
+			s = append(s, v)
+		}
+	}
+	return s
+}
+
+// completePendingReorg stores and notifies the pending reorg, it must be called after the new chain is synchronized
+func (w *SyncWorker) completePendingReorg() {
+	if p := w.pendingReorg; p != nil {
+		w.pendingReorg = nil
+		w.storeReorg(p.reorg, p.addrTxids)
+	}
+}
+
+// storeReorg completes the reorg record by the new tip of the chain, stores it and notifies about it
+func (w *SyncWorker) storeReorg(reorg *Reorg, addrTxids map[string][]string) {
+	var err error
+	reorg.Time = time.Now().Unix()
+	reorg.NewHeight, reorg.NewHash, err = w.db.GetBestBlock()
+	if err != nil {
+		glog.Error("sync: GetBestBlock error ", err)
+		return
+	}
+	// the transactions included also in the new chain were not reorged
+	if err = w.db.removeReconfirmedTxs(reorg, addrTxids); err != nil {
+		glog.Error("sync: removeReconfirmedTxs error ", err)
+		return
+	}
+	if err = w.db.StoreReorg(reorg); err != nil {
+		glog.Error("sync: StoreReorg error ", err)
+		return
+	}
+	glog.Infof("sync: reorg %d of depth %d, old tip %d %s, new tip %d %s, %d txs disconnected", reorg.ID, reorg.Depth(),
+		reorg.OldHeight, reorg.OldHash, reorg.NewHeight, reorg.NewHash, len(reorg.Txids))
+	if w.onReorg != nil {
+		w.onReorg(reorg, addrTxids)
+	}
 }
 
 func (w *SyncWorker) connectBlocks(onNewBlock bchain.OnNewBlockFunc, initialSync bool) error {
@@ -0,0 +1,93 @@
+//go:build unittest
+
+package db
+
+import (
+	"testing"
+
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/common"
+	"github.com/trezor/blockbook/tests/dbtestdata"
+)
+
+type notifyingBlockChain struct {
+	bchain.BlockChain
+	notified []*bchain.Block
+}
+
+func (c *notifyingBlockChain) GetNotifiedBlocks() []*bchain.Block {
+	blocks := c.notified
+	c.notified = nil
+	return blocks
+}
+
+func TestSyncWorker_connectNotifiedBlocks(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+	blocks := dbtestdata.GetTestParticlTypeBlocks(d.chainParser)
+	for i := uint32(0); i < blocks[0].Height; i++ {
+		d.is.BlockTimes = append(d.is.BlockTimes, 0)
+	}
+	for _, b := range blocks[:3] {
+		if err := d.ConnectBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fake, err := dbtestdata.NewFakeBlockChainParticlType(d.chainParser, blocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chain := &notifyingBlockChain{BlockChain: fake}
+	metrics, err := common.GetMetrics("ParticlNotifiedBlocks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := NewSyncWorker(d, chain, 1, 0, 0, false, nil, metrics, d.is)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var connected []uint32
+	onNewBlock := func(hash string, height uint32) {
+		connected = append(connected, height)
+	}
+	// the notified block knows its previous block but not its height
+	b503 := *blocks[3]
+	b503.Height = 0
+	b503.Prev = blocks[2].Hash
+
+	tests := []struct {
+		name       string
+		notified   []*bchain.Block
+		wantSynced bool
+		wantHeight uint32
+		connected  []uint32
+	}{
+		{name: "no notified blocks", wantSynced: false, wantHeight: 502},
+		{name: "not following the best block", notified: []*bchain.Block{blocks[1]}, wantSynced: false, wantHeight: 502},
+		{name: "following the best block", notified: []*bchain.Block{blocks[2], &b503}, wantSynced: true, wantHeight: 503, connected: []uint32{503}},
+		{name: "already connected", notified: []*bchain.Block{&b503}, wantSynced: true, wantHeight: 503},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			connected = nil
+			chain.notified = tt.notified
+			synced, err := w.connectNotifiedBlocks(onNewBlock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if synced != tt.wantSynced {
+				t.Errorf("connectNotifiedBlocks() = %v, want %v", synced, tt.wantSynced)
+			}
+			height, _, err := d.GetBestBlock()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if height != tt.wantHeight {
+				t.Errorf("GetBestBlock() height = %d, want %d", height, tt.wantHeight)
+			}
+			if len(connected) != len(tt.connected) || (len(connected) > 0 && connected[0] != tt.connected[0]) {
+				t.Errorf("onNewBlock heights = %v, want %v", connected, tt.connected)
+			}
+		})
+	}
+}
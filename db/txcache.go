@@ -88,7 +88,8 @@ func (c *TxCache) GetTransaction(txid string) (*bchain.Tx, int, error) {
 		} else {
 			return nil, 0, errors.New("Unknown chain type")
 		}
-		if c.enabled {
+		// the secondary instance only reads the transactions cached by the primary instance
+		if c.enabled && !c.db.IsSecondary() {
 			err = c.db.PutTx(tx, h, tx.Blocktime)
 			// do not return caching error, only log it
 			if err != nil {
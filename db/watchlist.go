@@ -0,0 +1,758 @@
+package db
+
+import (
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	vlq "github.com/bsm/go-vlq"
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/linxGnu/grocksdb"
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/common"
+)
+
+// Watch-only mode
+// In the watch-only mode the columns addresses, addressBalance and stakingRewards are written only for the addresses
+// in the watchlist, the other columns are written for all transactions as they are needed to connect and disconnect
+// the blocks. The watchlist is stored in the column watchlist and contains single addresses and xpubs. The addresses
+// of an xpub are derived up to the gap limit above the last used address and the derivation is extended as the addresses
+// are used in the connected blocks. The entries added to the watchlist of an already synchronized database are indexed
+// by the rescan of the blocks from the requested height. The rescan stores its entries and progress separately and is resumed
+// at the start if it was interrupted. A watched address spending an output which is not in its balance
+// received the output before it was indexed, such address is flagged as incomplete.
+// The watch-only mode is supported only for Bitcoin type coins.
+
+const (
+	watchlistKeyAddress    = byte(0)
+	watchlistKeyXpub       = byte(1)
+	watchlistKeyIncomplete = byte(2)
+	// the entries of the unfinished rescan and its progress
+	watchlistKeyRescanAddress = byte(3)
+	watchlistKeyRescanXpub    = byte(4)
+	watchlistKeyRescanHeight  = byte(5)
+
+	maxWatchlistRescanBatch = 100000
+	// watchlistProgressBlocks is the number of rescanned blocks between the progress reports
+	watchlistProgressBlocks = 1000
+	// watchlistLockedRescanBlocks is the number of the last blocks rescanned under the lock of the block connection,
+	// the older blocks are rescanned without the lock as they are not expected to be disconnected during the rescan
+	watchlistLockedRescanBlocks = 100
+
+	// DefaultWatchlistGap is the number of addresses derived above the last used address of a watched xpub
+	DefaultWatchlistGap = 20
+)
+
+// WatchlistRequest contains the entries to be added to the watchlist, the entries are indexed from the height FromHeight,
+// which is mandatory if there are new entries
+type WatchlistRequest struct {
+	Addresses  []string `json:"addresses,omitempty"`
+	Xpubs      []string `json:"xpubs,omitempty"`
+	Gap        uint32   `json:"gap,omitempty"`
+	FromHeight *uint32  `json:"fromHeight,omitempty"`
+}
+
+// WatchlistXpub describes the xpub in the watchlist
+type WatchlistXpub struct {
+	Xpub    string            `json:"xpub"`
+	Gap     uint32            `json:"gap"`
+	Derived map[uint32]uint32 `json:"derived"`
+}
+
+// Watchlist is the content of the watchlist, Incomplete are the watched addresses with history before they were indexed
+type Watchlist struct {
+	Addresses  []string        `json:"addresses"`
+	Xpubs      []WatchlistXpub `json:"xpubs"`
+	Incomplete []string        `json:"incomplete"`
+}
+
+// WatchlistResult is the result or the progress of the addition of the entries to the watchlist,
+// Incomplete are the added addresses found to have history before FromHeight
+type WatchlistResult struct {
+	AddedAddresses int      `json:"addedAddresses"`
+	AddedXpubs     int      `json:"addedXpubs"`
+	FromHeight     uint32   `json:"fromHeight"`
+	ToHeight       uint32   `json:"toHeight"`
+	Blocks         int      `json:"blocks"`
+	Incomplete     []string `json:"incomplete,omitempty"`
+	Duration       string   `json:"duration,omitempty"`
+}
+
+type watchedXpub struct {
+	xpub       string
+	descriptor *bchain.XpubDescriptor
+	gap        uint32
+	// derived is the number of derived addresses per change index
+	derived map[uint32]uint32
+	changed bool
+}
+
+type watchedAddress struct {
+	xpub   *watchedXpub
+	change uint32
+	index  uint32
+}
+
+// watchlist is the set of watched address descriptors, a nil watchlist watches all addresses
+type watchlist struct {
+	mux       sync.RWMutex
+	parser    bchain.BlockChainParser
+	addresses map[string]watchedAddress
+	xpubs     map[string]*watchedXpub
+	// unstored are the single addresses not yet stored in the database
+	unstored []bchain.AddressDescriptor
+	// incomplete are the addresses which spent an output received before they were indexed
+	incomplete         map[string]struct{}
+	unstoredIncomplete []bchain.AddressDescriptor
+	// rescan is set for the watchlist processing already connected blocks from the height rescanFrom,
+	// rescanHeight is the next block to be rescanned
+	rescan       bool
+	rescanFrom   uint32
+	rescanHeight uint32
+}
+
+func newWatchlist(parser bchain.BlockChainParser, rescan bool) *watchlist {
+	return &watchlist{
+		parser:     parser,
+		addresses:  make(map[string]watchedAddress),
+		xpubs:      make(map[string]*watchedXpub),
+		incomplete: make(map[string]struct{}),
+		rescan:     rescan,
+	}
+}
+
+// contains returns true if the address descriptor is watched
+func (wl *watchlist) contains(addrDesc bchain.AddressDescriptor) bool {
+	if wl == nil {
+		return true
+	}
+	wl.mux.RLock()
+	defer wl.mux.RUnlock()
+	_, found := wl.addresses[string(addrDesc)]
+	return found
+}
+
+func (wl *watchlist) isRescan() bool {
+	return wl != nil && wl.rescan
+}
+
+// markIncomplete flags the watched address which spent an output not found in its balance,
+// the output was received before the address was indexed
+func (wl *watchlist) markIncomplete(addrDesc bchain.AddressDescriptor) {
+	if wl == nil {
+		return
+	}
+	wl.mux.Lock()
+	defer wl.mux.Unlock()
+	if _, found := wl.incomplete[string(addrDesc)]; found {
+		return
+	}
+	wl.incomplete[string(addrDesc)] = struct{}{}
+	wl.unstoredIncomplete = append(wl.unstoredIncomplete, addrDesc)
+	ad, _, _ := wl.parser.GetAddressesFromAddrDesc(addrDesc)
+	glog.Warningf("rocksdb: watched address %v (%s) has history before it was indexed", ad, addrDesc)
+}
+
+// addressesOf converts the address descriptors of the map to sorted addresses
+func (wl *watchlist) addressesOf(descs map[string]struct{}) []string {
+	addresses := make([]string, 0, len(descs))
+	for a := range descs {
+		addrs, _, err := wl.parser.GetAddressesFromAddrDesc(bchain.AddressDescriptor(a))
+		if err != nil || len(addrs) == 0 {
+			continue
+		}
+		addresses = append(addresses, addrs[0])
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// addAddress adds single address descriptor, returns false if it is already watched
+func (wl *watchlist) addAddress(addrDesc bchain.AddressDescriptor) bool {
+	if _, found := wl.addresses[string(addrDesc)]; found {
+		return false
+	}
+	wl.addresses[string(addrDesc)] = watchedAddress{}
+	wl.unstored = append(wl.unstored, addrDesc)
+	return true
+}
+
+// addXpub adds the xpub and derives its addresses up to the gap, derived contains the already derived counts
+// of the stored xpub
+func (wl *watchlist) addXpub(xpub string, gap uint32, derived map[uint32]uint32) (*watchedXpub, error) {
+	descriptor, err := wl.parser.ParseXpub(xpub)
+	if err != nil {
+		return nil, err
+	}
+	if gap == 0 {
+		gap = DefaultWatchlistGap
+	}
+	x := &watchedXpub{
+		xpub:       xpub,
+		descriptor: descriptor,
+		gap:        gap,
+		derived:    make(map[uint32]uint32),
+	}
+	for _, change := range descriptor.ChangeIndexes {
+		to := gap
+		if derived[change] > to {
+			to = derived[change]
+		}
+		if err = wl.derive(x, change, to); err != nil {
+			return nil, err
+		}
+	}
+	wl.xpubs[xpub] = x
+	return x, nil
+}
+
+// derive derives the addresses of the xpub for the change index up to the index to (excluding)
+func (wl *watchlist) derive(x *watchedXpub, change uint32, to uint32) error {
+	from := x.derived[change]
+	if to <= from {
+		return nil
+	}
+	descs, err := wl.parser.DeriveAddressDescriptorsFromTo(x.descriptor, change, from, to)
+	if err != nil {
+		return err
+	}
+	for i, ad := range descs {
+		if _, found := wl.addresses[string(ad)]; !found {
+			wl.addresses[string(ad)] = watchedAddress{xpub: x, change: change, index: from + uint32(i)}
+		}
+	}
+	x.derived[change] = to
+	x.changed = true
+	return nil
+}
+
+// use extends the derivation of the xpub of the used address to keep the gap above it, returns true if extended
+func (wl *watchlist) use(wa watchedAddress) (bool, error) {
+	x := wa.xpub
+	if x == nil || wa.index+x.gap < x.derived[wa.change] {
+		return false, nil
+	}
+	return true, wl.derive(x, wa.change, wa.index+1+x.gap)
+}
+
+// watchOutputs extends the derivation of the xpubs by the watched addresses of the outputs of the block,
+// it is repeated until the extended derivation does not make watched other addresses of the block
+func (wl *watchlist) watchOutputs(block *bchain.Block) error {
+	if wl == nil {
+		return nil
+	}
+	var descs []bchain.AddressDescriptor
+	for txi := range block.Txs {
+		tx := &block.Txs[txi]
+		for i := range tx.Vout {
+			addrDesc, err := wl.parser.GetAddrDescFromVout(&tx.Vout[i])
+			if err != nil || len(addrDesc) == 0 {
+				continue
+			}
+			descs = append(descs, wl.parser.GetIndexedAddrDescs(addrDesc)...)
+		}
+	}
+	wl.mux.Lock()
+	defer wl.mux.Unlock()
+	for extended := true; extended; {
+		extended = false
+		for _, ad := range descs {
+			if wa, found := wl.addresses[string(ad)]; found {
+				e, err := wl.use(wa)
+				if err != nil {
+					return err
+				}
+				extended = extended || e
+			}
+		}
+	}
+	return nil
+}
+
+// filterStakingRewards removes the rewards of the addresses which are not watched
+func (wl *watchlist) filterStakingRewards(rewards map[string]*big.Int) {
+	if wl == nil {
+		return
+	}
+	wl.mux.RLock()
+	defer wl.mux.RUnlock()
+	for a := range rewards {
+		if _, found := wl.addresses[a]; !found {
+			delete(rewards, a)
+		}
+	}
+}
+
+// merge adds the entries of the other watchlist
+func (wl *watchlist) merge(other *watchlist) {
+	wl.mux.Lock()
+	defer wl.mux.Unlock()
+	for a, wa := range other.addresses {
+		if _, found := wl.addresses[a]; !found {
+			wl.addresses[a] = wa
+		}
+	}
+	for xpub, x := range other.xpubs {
+		wl.xpubs[xpub] = x
+	}
+	for a := range other.incomplete {
+		wl.incomplete[a] = struct{}{}
+	}
+}
+
+func packWatchlistXpub(x *watchedXpub) []byte {
+	buf := make([]byte, 0, 8+len(x.derived)*8)
+	varBuf := make([]byte, vlq.MaxLen64)
+	l := packVaruint(uint(x.gap), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	l = packVaruint(uint(len(x.derived)), varBuf)
+	buf = append(buf, varBuf[:l]...)
+	changes := make([]uint32, 0, len(x.derived))
+	for change := range x.derived {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i] < changes[j] })
+	for _, change := range changes {
+		l = packVaruint(uint(change), varBuf)
+		buf = append(buf, varBuf[:l]...)
+		l = packVaruint(uint(x.derived[change]), varBuf)
+		buf = append(buf, varBuf[:l]...)
+	}
+	return buf
+}
+
+func unpackWatchlistXpub(buf []byte) (uint32, map[uint32]uint32, error) {
+	gap, l := unpackVaruint(buf)
+	n, ll := unpackVaruint(buf[l:])
+	l += ll
+	derived := make(map[uint32]uint32, n)
+	for i := uint(0); i < n; i++ {
+		if l >= len(buf) {
+			return 0, nil, errors.New("Invalid watchlist xpub data")
+		}
+		change, ll := unpackVaruint(buf[l:])
+		l += ll
+		if l >= len(buf) {
+			return 0, nil, errors.New("Invalid watchlist xpub data")
+		}
+		count, ll := unpackVaruint(buf[l:])
+		l += ll
+		derived[uint32(change)] = uint32(count)
+	}
+	return uint32(gap), derived, nil
+}
+
+// storeWatchlist stores the new single addresses and the changed xpubs of the watchlist,
+// the entries of the rescan are stored with its progress under the rescan keys
+func (d *RocksDB) storeWatchlist(wb *grocksdb.WriteBatch, wl *watchlist) {
+	if wl == nil {
+		return
+	}
+	wl.mux.Lock()
+	defer wl.mux.Unlock()
+	keyAddress, keyXpub := watchlistKeyAddress, watchlistKeyXpub
+	if wl.rescan {
+		keyAddress, keyXpub = watchlistKeyRescanAddress, watchlistKeyRescanXpub
+		wb.PutCF(d.cfh[cfWatchlist], []byte{watchlistKeyRescanHeight}, packWatchlistRescanHeight(wl.rescanFrom, wl.rescanHeight))
+	}
+	for _, ad := range wl.unstored {
+		wb.PutCF(d.cfh[cfWatchlist], append([]byte{keyAddress}, ad...), []byte{})
+	}
+	wl.unstored = nil
+	for _, ad := range wl.unstoredIncomplete {
+		wb.PutCF(d.cfh[cfWatchlist], append([]byte{watchlistKeyIncomplete}, ad...), []byte{})
+	}
+	wl.unstoredIncomplete = nil
+	for xpub, x := range wl.xpubs {
+		if x.changed {
+			wb.PutCF(d.cfh[cfWatchlist], append([]byte{keyXpub}, xpub...), packWatchlistXpub(x))
+			x.changed = false
+		}
+	}
+}
+
+// storeFinishedWatchlistRescan moves the entries of the finished rescan to the watchlist
+func (d *RocksDB) storeFinishedWatchlistRescan(wb *grocksdb.WriteBatch, wl *watchlist) {
+	wl.mux.Lock()
+	defer wl.mux.Unlock()
+	for a, wa := range wl.addresses {
+		if wa.xpub == nil {
+			wb.DeleteCF(d.cfh[cfWatchlist], append([]byte{watchlistKeyRescanAddress}, a...))
+			wb.PutCF(d.cfh[cfWatchlist], append([]byte{watchlistKeyAddress}, a...), []byte{})
+		}
+	}
+	for xpub, x := range wl.xpubs {
+		wb.DeleteCF(d.cfh[cfWatchlist], append([]byte{watchlistKeyRescanXpub}, xpub...))
+		wb.PutCF(d.cfh[cfWatchlist], append([]byte{watchlistKeyXpub}, xpub...), packWatchlistXpub(x))
+		x.changed = false
+	}
+	wb.DeleteCF(d.cfh[cfWatchlist], []byte{watchlistKeyRescanHeight})
+	wl.rescan = false
+}
+
+func packWatchlistRescanHeight(from, next uint32) []byte {
+	buf := make([]byte, 2*vlq.MaxLen32)
+	l := packVaruint(uint(from), buf)
+	l += packVaruint(uint(next), buf[l:])
+	return buf[:l]
+}
+
+func unpackWatchlistRescanHeight(buf []byte) (uint32, uint32) {
+	from, l := unpackVaruint(buf)
+	next, _ := unpackVaruint(buf[l:])
+	return uint32(from), uint32(next)
+}
+
+// InitWatchlist switches the database to the watch-only mode and loads the stored watchlist,
+// it must be called before LoadInternalState
+func (d *RocksDB) InitWatchlist() error {
+	if d.chainParser.GetChainType() != bchain.ChainBitcoinType {
+		return errors.New("Watch-only mode is supported only for Bitcoin type coins")
+	}
+	wl := newWatchlist(d.chainParser, false)
+	rescan := newWatchlist(d.chainParser, true)
+	interrupted := false
+	it := d.db.NewIteratorCF(d.ro, d.cfh[cfWatchlist])
+	defer it.Close()
+	for it.SeekToFirst(); it.Valid(); it.Next() {
+		key := it.Key().Data()
+		if len(key) == 1 && key[0] == watchlistKeyRescanHeight {
+			rescan.rescanFrom, rescan.rescanHeight = unpackWatchlistRescanHeight(it.Value().Data())
+			interrupted = true
+			continue
+		}
+		if len(key) < 2 {
+			continue
+		}
+		switch key[0] {
+		case watchlistKeyAddress:
+			wl.addresses[string(key[1:])] = watchedAddress{}
+		case watchlistKeyXpub:
+			gap, derived, err := unpackWatchlistXpub(it.Value().Data())
+			if err != nil {
+				return err
+			}
+			if _, err = wl.addXpub(string(key[1:]), gap, derived); err != nil {
+				return errors.Annotatef(err, "Watchlist xpub %s", key[1:])
+			}
+		case watchlistKeyIncomplete:
+			wl.incomplete[string(key[1:])] = struct{}{}
+		case watchlistKeyRescanAddress:
+			rescan.addresses[string(key[1:])] = watchedAddress{}
+		case watchlistKeyRescanXpub:
+			gap, derived, err := unpackWatchlistXpub(it.Value().Data())
+			if err != nil {
+				return err
+			}
+			if _, err = rescan.addXpub(string(key[1:]), gap, derived); err != nil {
+				return errors.Annotatef(err, "Watchlist rescan xpub %s", key[1:])
+			}
+		}
+	}
+	d.watchlist = wl
+	d.watchlistRescan = nil
+	glog.Infof("rocksdb: watch-only mode, watching %d addresses of %d xpubs and single addresses", len(wl.addresses), len(wl.xpubs))
+	if interrupted {
+		d.watchlistRescan = rescan
+		glog.Warningf("rocksdb: watchlist rescan from the block %d was interrupted at the block %d, it must be resumed",
+			rescan.rescanFrom, rescan.rescanHeight)
+	}
+	return nil
+}
+
+// IsWatchOnly returns true if the database indexes only the addresses in the watchlist
+func (d *RocksDB) IsWatchOnly() bool {
+	return d.watchlist != nil
+}
+
+// GetWatchlist returns the content of the watchlist
+func (d *RocksDB) GetWatchlist() (*Watchlist, error) {
+	wl := d.watchlist
+	if wl == nil {
+		return nil, errors.New("Database is not in watch-only mode")
+	}
+	wl.mux.RLock()
+	defer wl.mux.RUnlock()
+	single := make(map[string]struct{})
+	for a, wa := range wl.addresses {
+		if wa.xpub == nil {
+			single[a] = struct{}{}
+		}
+	}
+	r := &Watchlist{
+		Addresses:  wl.addressesOf(single),
+		Xpubs:      []WatchlistXpub{},
+		Incomplete: wl.addressesOf(wl.incomplete),
+	}
+	for xpub, x := range wl.xpubs {
+		derived := make(map[uint32]uint32, len(x.derived))
+		for change, count := range x.derived {
+			derived[change] = count
+		}
+		r.Xpubs = append(r.Xpubs, WatchlistXpub{Xpub: xpub, Gap: x.gap, Derived: derived})
+	}
+	sort.Slice(r.Xpubs, func(i, j int) bool { return r.Xpubs[i].Xpub < r.Xpubs[j].Xpub })
+	return r, nil
+}
+
+// CheckWatchlistRequest checks that the entries of the request can be added to the watchlist
+func (d *RocksDB) CheckWatchlistRequest(req *WatchlistRequest) error {
+	d.connectBlockMux.Lock()
+	defer d.connectBlockMux.Unlock()
+	_, err := d.newWatchlistEntries(req)
+	return err
+}
+
+// newWatchlistEntries returns the watchlist of the entries of the request which are not watched yet
+func (d *RocksDB) newWatchlistEntries(req *WatchlistRequest) (*watchlist, error) {
+	if d.watchlist == nil {
+		return nil, errors.New("Database is not in watch-only mode")
+	}
+	if d.secondary != nil {
+		return nil, errors.New("Watchlist cannot be modified by secondary instance")
+	}
+	if d.watchlistRescan != nil {
+		return nil, errors.Errorf("Watchlist rescan from the block %d is running or was interrupted, it is resumed at the start", d.watchlistRescan.rescanFrom)
+	}
+	wl := newWatchlist(d.chainParser, true)
+	for _, a := range req.Addresses {
+		addrDesc, err := d.chainParser.GetAddrDescFromAddress(a)
+		if err != nil {
+			return nil, errors.Annotatef(err, "Address %s", a)
+		}
+		if !d.watchlist.contains(addrDesc) {
+			wl.addAddress(addrDesc)
+		}
+	}
+	for _, xpub := range req.Xpubs {
+		d.watchlist.mux.RLock()
+		_, found := d.watchlist.xpubs[xpub]
+		d.watchlist.mux.RUnlock()
+		if found {
+			continue
+		}
+		if _, err := wl.addXpub(xpub, req.Gap, nil); err != nil {
+			return nil, errors.Annotatef(err, "Xpub %s", xpub)
+		}
+	}
+	// the addresses of the new xpubs may be already watched
+	for a := range wl.addresses {
+		if d.watchlist.contains(bchain.AddressDescriptor(a)) {
+			delete(wl.addresses, a)
+		}
+	}
+	// the rescan of the whole chain is too expensive to be the default
+	if (len(wl.addresses) > 0 || len(wl.xpubs) > 0) && req.FromHeight == nil {
+		return nil, errors.New("Missing fromHeight, the first block in which the new entries may appear")
+	}
+	return wl, nil
+}
+
+// AddToWatchlist adds the entries to the watchlist and indexes them by the rescan of the connected blocks
+// from the height FromHeight, the blocks are read from the chain. The entries already in the watchlist are skipped.
+// Only the last blocks are rescanned under the lock of the block connection, progress is called with the state
+// of the rescan if it is not nil.
+func (d *RocksDB) AddToWatchlist(chain bchain.BlockChain, req *WatchlistRequest, progress func(r *WatchlistResult)) (*WatchlistResult, error) {
+	start := time.Now()
+	wl, r, err := d.startWatchlistRescan(req)
+	if err != nil {
+		return nil, err
+	}
+	if wl == nil {
+		r.Duration = time.Since(start).String()
+		return r, nil
+	}
+	return r, d.rescanWatchlist(chain, wl, r, start, progress)
+}
+
+// ResumeWatchlistRescan finishes the rescan of the watchlist which was interrupted, returns nil if there is none
+func (d *RocksDB) ResumeWatchlistRescan(chain bchain.BlockChain) (*WatchlistResult, error) {
+	wl := d.watchlistRescan
+	if wl == nil {
+		return nil, nil
+	}
+	start := time.Now()
+	r := &WatchlistResult{
+		AddedXpubs: len(wl.xpubs),
+		FromHeight: wl.rescanFrom,
+	}
+	for _, wa := range wl.addresses {
+		if wa.xpub == nil {
+			r.AddedAddresses++
+		}
+	}
+	glog.Infof("rocksdb: resuming watchlist rescan from the block %d at the block %d", wl.rescanFrom, wl.rescanHeight)
+	return r, d.rescanWatchlist(chain, wl, r, start, nil)
+}
+
+// startWatchlistRescan stores the new entries of the request as the entries of the rescan,
+// it returns nil watchlist if there are no new entries
+func (d *RocksDB) startWatchlistRescan(req *WatchlistRequest) (*watchlist, *WatchlistResult, error) {
+	d.connectBlockMux.Lock()
+	defer d.connectBlockMux.Unlock()
+	if d.is != nil && d.is.DbState == common.DbStateInconsistent {
+		return nil, nil, errors.New("Database is in inconsistent state, watchlist cannot be modified")
+	}
+	wl, err := d.newWatchlistEntries(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := &WatchlistResult{
+		AddedAddresses: len(wl.unstored),
+		AddedXpubs:     len(wl.xpubs),
+	}
+	if len(wl.addresses) == 0 && len(wl.xpubs) == 0 {
+		return nil, r, nil
+	}
+	r.FromHeight = *req.FromHeight
+	wl.rescanFrom = *req.FromHeight
+	wl.rescanHeight = *req.FromHeight
+	wb := grocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	d.storeWatchlist(wb, wl)
+	if err = d.WriteBatch(wb); err != nil {
+		return nil, nil, err
+	}
+	d.watchlistRescan = wl
+	return wl, r, nil
+}
+
+// rescanWatchlist rescans the connected blocks for the entries of the watchlist wl from the height wl.rescanHeight,
+// the blocks up to watchlistLockedRescanBlocks below the best block are rescanned without the lock of the block connection,
+// the rest under it. The entries are moved to the watchlist at the end.
+func (d *RocksDB) rescanWatchlist(chain bchain.BlockChain, wl *watchlist, r *WatchlistResult, start time.Time, progress func(r *WatchlistResult)) error {
+	reportProgress := func() {
+		if progress != nil {
+			p := *r
+			progress(&p)
+		}
+	}
+	// the blocks connected during the rescan are rescanned in the next round
+	var lastHash string
+	for {
+		bestHeight, _, err := d.GetBestBlock()
+		if err != nil {
+			return err
+		}
+		if bestHeight < wl.rescanHeight+watchlistLockedRescanBlocks {
+			break
+		}
+		r.ToHeight = bestHeight - watchlistLockedRescanBlocks
+		reportProgress()
+		if lastHash, err = d.rescanWatchlistBlocks(chain, wl, r, reportProgress); err != nil {
+			return err
+		}
+	}
+	d.connectBlockMux.Lock()
+	defer d.connectBlockMux.Unlock()
+	if d.is != nil && d.is.DbState == common.DbStateInconsistent {
+		return errors.New("Database is in inconsistent state, watchlist rescan cannot be finished")
+	}
+	if lastHash != "" {
+		hash, err := d.GetBlockHash(wl.rescanHeight - 1)
+		if err != nil {
+			return err
+		}
+		if hash != lastHash {
+			return errors.Errorf("Block %d was disconnected during the watchlist rescan", wl.rescanHeight-1)
+		}
+	}
+	bestHeight, _, err := d.GetBestBlock()
+	if err != nil {
+		return err
+	}
+	r.ToHeight = bestHeight
+	reportProgress()
+	if _, err = d.rescanWatchlistBlocks(chain, wl, r, reportProgress); err != nil {
+		return err
+	}
+	wb := grocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	d.storeFinishedWatchlistRescan(wb, wl)
+	if err = d.WriteBatch(wb); err != nil {
+		return err
+	}
+	d.watchlist.merge(wl)
+	d.watchlistRescan = nil
+	if len(wl.incomplete) > 0 {
+		r.Incomplete = wl.addressesOf(wl.incomplete)
+	}
+	r.Duration = time.Since(start).String()
+	glog.Infof("rocksdb: watchlist, added %d addresses and %d xpubs, rescanned blocks %d-%d in %v, %d addresses with history before the block %d",
+		r.AddedAddresses, r.AddedXpubs, r.FromHeight, r.ToHeight, r.Duration, len(r.Incomplete), r.FromHeight)
+	return nil
+}
+
+// rescanWatchlistBlocks rescans the blocks from wl.rescanHeight to r.ToHeight, the index, the balances, the watchlist
+// and the progress are written in batches, it returns the hash of the last rescanned block
+func (d *RocksDB) rescanWatchlistBlocks(chain bchain.BlockChain, wl *watchlist, r *WatchlistResult, reportProgress func()) (string, error) {
+	wb := grocksdb.NewWriteBatch()
+	defer wb.Destroy()
+	balances := make(map[string]*AddrBalance)
+	storeBatch := func() error {
+		if err := d.storeBalances(wb, balances); err != nil {
+			return err
+		}
+		d.storeWatchlist(wb, wl)
+		if err := d.WriteBatch(wb); err != nil {
+			return err
+		}
+		wb.Clear()
+		balances = make(map[string]*AddrBalance)
+		return nil
+	}
+	var lastHash string
+	for height := wl.rescanHeight; height <= r.ToHeight; height++ {
+		if common.IsInShutdown() {
+			return "", errors.New("Watchlist rescan interrupted by shutdown")
+		}
+		hash, err := d.GetBlockHash(height)
+		if err != nil {
+			return "", err
+		}
+		if hash == "" {
+			continue
+		}
+		block, err := chain.GetBlock(hash, height)
+		if err != nil {
+			return "", errors.Annotatef(err, "GetBlock %d %s", height, hash)
+		}
+		if block.Hash != hash {
+			return "", errors.Errorf("Block %d hash %s does not match the index %s", height, block.Hash, hash)
+		}
+		addresses := make(addressesMap)
+		txAddressesMap := make(map[string]*TxAddresses)
+		if err = d.processAddressesBitcoinTypeWatched(block, addresses, txAddressesMap, balances, nil, wl); err != nil {
+			return "", err
+		}
+		if d.isParticl() {
+			stakingRewards, err := d.blockStakingRewards(block, txAddressesMap)
+			if err != nil {
+				return "", err
+			}
+			wl.filterStakingRewards(stakingRewards)
+			d.storeStakingRewards(wb, height, stakingRewards)
+		}
+		if err = d.storeAddresses(wb, height, addresses); err != nil {
+			return "", err
+		}
+		wl.rescanHeight = height + 1
+		lastHash = hash
+		r.Blocks++
+		if r.Blocks%watchlistProgressBlocks == 0 {
+			reportProgress()
+		}
+		if wb.Count() > maxWatchlistRescanBatch {
+			if err = storeBatch(); err != nil {
+				return "", err
+			}
+			glog.Info("rocksdb: watchlist rescan at block ", height)
+		}
+	}
+	if err := storeBatch(); err != nil {
+		return "", err
+	}
+	return lastHash, nil
+}
@@ -0,0 +1,336 @@
+//go:build unittest
+
+package db
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/trezor/blockbook/bchain/coins/btc"
+	"github.com/trezor/blockbook/common"
+	"github.com/trezor/blockbook/tests/dbtestdata"
+)
+
+func watchlistTestParser() *btc.BitcoinParser {
+	return btc.NewBitcoinParser(btc.GetChainParams("test"), &btc.Configuration{
+		BlockAddressesToKeep:  1,
+		XPubMagic:             70617039,
+		XPubMagicSegwitP2sh:   71979618,
+		XPubMagicSegwitNative: 73342198,
+	})
+}
+
+func setupWatchOnlyRocksDB(t *testing.T) *RocksDB {
+	tmp, err := os.MkdirTemp("", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := NewRocksDB(tmp, 100000, -1, watchlistTestParser(), nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = d.InitWatchlist(); err != nil {
+		t.Fatal(err)
+	}
+	is, err := d.LoadInternalState(&common.Config{CoinName: "coin-unittest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetInternalState(is)
+	return d
+}
+
+func watchlistAddressData(t *testing.T, d *RocksDB, address string) (*AddrBalance, []string) {
+	addrDesc, err := d.chainParser.GetAddrDescFromAddress(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ab, err := d.GetAddrDescBalance(addrDesc, AddressBalanceDetailUTXO)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var txs []string
+	if err = d.GetAddrDescTransactions(addrDesc, 0, ^uint32(0), func(txid string, height uint32, indexes []int32) error {
+		txs = append(txs, fmt.Sprint(txid, height, indexes))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return ab, txs
+}
+
+func TestRocksDB_Watchlist_BitcoinType(t *testing.T) {
+	full := setupRocksDB(t, bitcoinTestnetParser())
+	defer closeAndDestroyRocksDB(t, full)
+	d := setupWatchOnlyRocksDB(t)
+	defer closeAndDestroyRocksDB(t, d)
+	chain, err := dbtestdata.NewFakeBlockChain(d.chainParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs := []string{
+		dbtestdata.Addr1, dbtestdata.Addr2, dbtestdata.Addr3, dbtestdata.Addr4, dbtestdata.Addr5,
+		dbtestdata.Addr6, dbtestdata.Addr7, dbtestdata.Addr8, dbtestdata.Addr9, dbtestdata.AddrA,
+	}
+	// the addresses with history before they were indexed have different data than in the full database
+	incomplete := map[string]bool{}
+	verify := func(step string, watched map[string]bool) {
+		t.Helper()
+		for _, a := range addrs {
+			if incomplete[a] {
+				continue
+			}
+			ab, txs := watchlistAddressData(t, d, a)
+			if !watched[a] {
+				if ab != nil || len(txs) != 0 {
+					t.Errorf("%s: address %s is not watched, got balance %+v, txs %v", step, a, ab, txs)
+				}
+				continue
+			}
+			wab, wtxs := watchlistAddressData(t, full, a)
+			if !reflect.DeepEqual(ab, wab) {
+				t.Errorf("%s: balance of %s = %+v, want %+v", step, a, ab, wab)
+			}
+			if !reflect.DeepEqual(txs, wtxs) {
+				t.Errorf("%s: transactions of %s = %v, want %v", step, a, txs, wtxs)
+			}
+		}
+	}
+
+	// fromHeight is mandatory for the new entries
+	req := &WatchlistRequest{Addresses: []string{dbtestdata.Addr6}, Xpubs: []string{dbtestdata.Xpub}, Gap: 4}
+	if err = d.CheckWatchlistRequest(req); err == nil {
+		t.Error("CheckWatchlistRequest() without fromHeight succeeded")
+	}
+	if _, err = d.AddToWatchlist(chain, req, nil); err == nil {
+		t.Error("AddToWatchlist() without fromHeight succeeded")
+	}
+
+	// the entries added to the empty database
+	fromHeight := uint32(0)
+	req.FromHeight = &fromHeight
+	if err = d.CheckWatchlistRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	r, err := d.AddToWatchlist(chain, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AddedAddresses != 1 || r.AddedXpubs != 1 || r.Blocks != 0 {
+		t.Errorf("AddToWatchlist() = %+v", r)
+	}
+	block1 := dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)
+	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
+	for _, db := range []*RocksDB{full, d} {
+		if err := db.ConnectBlock(block1); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.ConnectBlock(block2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Addr4 is xpub address 0/0, Addr8 is xpub address 1/3 and extends the derivation of the change addresses
+	watched := map[string]bool{dbtestdata.Addr4: true, dbtestdata.Addr6: true, dbtestdata.Addr8: true}
+	verify("connect", watched)
+	wantDerived := map[uint32]uint32{0: 5, 1: 8}
+	if got := d.watchlist.xpubs[dbtestdata.Xpub].derived; !reflect.DeepEqual(got, wantDerived) {
+		t.Errorf("derived = %v, want %v", got, wantDerived)
+	}
+
+	// rescan of the connected blocks for the new address, the already watched address is skipped
+	fromHeight = block1.Height
+	var progress []WatchlistResult
+	r, err = d.AddToWatchlist(chain, &WatchlistRequest{Addresses: []string{dbtestdata.Addr2, dbtestdata.Addr6}, FromHeight: &fromHeight}, func(r *WatchlistResult) {
+		progress = append(progress, *r)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AddedAddresses != 1 || r.AddedXpubs != 0 || r.Blocks != 2 || r.FromHeight != block1.Height || r.ToHeight != block2.Height || len(r.Incomplete) != 0 {
+		t.Errorf("AddToWatchlist() = %+v", r)
+	}
+	wantProgress := []WatchlistResult{{AddedAddresses: 1, FromHeight: block1.Height, ToHeight: block2.Height}}
+	if !reflect.DeepEqual(progress, wantProgress) {
+		t.Errorf("AddToWatchlist() progress = %+v, want %+v", progress, wantProgress)
+	}
+	watched[dbtestdata.Addr2] = true
+	verify("rescan", watched)
+
+	// Addr3 spends in the block 2 the output received in the block 1, it is flagged as incomplete
+	fromHeight = block2.Height
+	r, err = d.AddToWatchlist(chain, &WatchlistRequest{Addresses: []string{dbtestdata.Addr3}, FromHeight: &fromHeight}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AddedAddresses != 1 || r.Blocks != 1 || !reflect.DeepEqual(r.Incomplete, []string{dbtestdata.Addr3}) {
+		t.Errorf("AddToWatchlist() = %+v", r)
+	}
+	incomplete[dbtestdata.Addr3] = true
+	verify("incomplete", watched)
+
+	// the watchlist is restored from the database
+	if err = d.InitWatchlist(); err != nil {
+		t.Fatal(err)
+	}
+	wl, err := d.GetWatchlist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Watchlist{
+		Addresses:  []string{dbtestdata.Addr2, dbtestdata.Addr3, dbtestdata.Addr6},
+		Xpubs:      []WatchlistXpub{{Xpub: dbtestdata.Xpub, Gap: 4, Derived: wantDerived}},
+		Incomplete: []string{dbtestdata.Addr3},
+	}
+	if !reflect.DeepEqual(wl, want) {
+		t.Errorf("GetWatchlist() = %+v, want %+v", wl, want)
+	}
+
+	for _, db := range []*RocksDB{full, d} {
+		if err := db.DisconnectBlockRangeBitcoinType(block2.Height, block2.Height); err != nil {
+			t.Fatal(err)
+		}
+	}
+	verify("disconnect", watched)
+
+	// the mode of the database cannot be changed
+	if err = d.StoreInternalState(d.is); err != nil {
+		t.Fatal(err)
+	}
+	d.watchlist = nil
+	if _, err = d.LoadInternalState(&common.Config{CoinName: "coin-unittest"}); err == nil {
+		t.Error("LoadInternalState() of watch-only database without watchlist succeeded")
+	}
+	if _, err = full.AddToWatchlist(chain, &WatchlistRequest{Addresses: []string{dbtestdata.Addr2}, FromHeight: &fromHeight}, nil); err == nil {
+		t.Error("AddToWatchlist() of full database succeeded")
+	}
+}
+
+func TestRocksDB_WatchlistRescanResume(t *testing.T) {
+	full := setupRocksDB(t, bitcoinTestnetParser())
+	defer closeAndDestroyRocksDB(t, full)
+	d := setupWatchOnlyRocksDB(t)
+	defer closeAndDestroyRocksDB(t, d)
+	chain, err := dbtestdata.NewFakeBlockChain(d.chainParser)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block1 := dbtestdata.GetTestBitcoinTypeBlock1(d.chainParser)
+	block2 := dbtestdata.GetTestBitcoinTypeBlock2(d.chainParser)
+	for _, db := range []*RocksDB{full, d} {
+		if err := db.ConnectBlock(block1); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.ConnectBlock(block2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the rescan is interrupted after the first block
+	fromHeight := block1.Height
+	req := &WatchlistRequest{Addresses: []string{dbtestdata.Addr2}, Xpubs: []string{dbtestdata.Xpub}, Gap: 4, FromHeight: &fromHeight}
+	wl, r, err := d.startWatchlistRescan(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.ToHeight = block1.Height
+	if _, err = d.rescanWatchlistBlocks(chain, wl, r, func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the entries of the interrupted rescan are not watched yet and the watchlist cannot be modified
+	if err = d.InitWatchlist(); err != nil {
+		t.Fatal(err)
+	}
+	if d.watchlistRescan == nil || d.watchlistRescan.rescanFrom != block1.Height || d.watchlistRescan.rescanHeight != block2.Height {
+		t.Fatalf("watchlistRescan = %+v", d.watchlistRescan)
+	}
+	if got, err := d.GetWatchlist(); err != nil || len(got.Addresses) != 0 || len(got.Xpubs) != 0 {
+		t.Errorf("GetWatchlist() = %+v, %v", got, err)
+	}
+	if err = d.CheckWatchlistRequest(&WatchlistRequest{Addresses: []string{dbtestdata.Addr6}, FromHeight: &fromHeight}); err == nil {
+		t.Error("CheckWatchlistRequest() during unfinished rescan succeeded")
+	}
+
+	r, err = d.ResumeWatchlistRescan(chain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.AddedAddresses != 1 || r.AddedXpubs != 1 || r.Blocks != 1 || r.FromHeight != block1.Height || r.ToHeight != block2.Height {
+		t.Errorf("ResumeWatchlistRescan() = %+v", r)
+	}
+	// Addr4 and Addr8 are the xpub addresses
+	for _, a := range []string{dbtestdata.Addr2, dbtestdata.Addr4, dbtestdata.Addr8} {
+		ab, txs := watchlistAddressData(t, d, a)
+		wab, wtxs := watchlistAddressData(t, full, a)
+		if !reflect.DeepEqual(ab, wab) {
+			t.Errorf("balance of %s = %+v, want %+v", a, ab, wab)
+		}
+		if !reflect.DeepEqual(txs, wtxs) {
+			t.Errorf("transactions of %s = %v, want %v", a, txs, wtxs)
+		}
+	}
+
+	// the finished rescan is moved to the watchlist
+	if err = d.InitWatchlist(); err != nil {
+		t.Fatal(err)
+	}
+	if r, err = d.ResumeWatchlistRescan(chain); r != nil || err != nil {
+		t.Errorf("ResumeWatchlistRescan() of finished rescan = %+v, %v", r, err)
+	}
+	got, err := d.GetWatchlist()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Watchlist{
+		Addresses:  []string{dbtestdata.Addr2},
+		Xpubs:      []WatchlistXpub{{Xpub: dbtestdata.Xpub, Gap: 4, Derived: map[uint32]uint32{0: 5, 1: 8}}},
+		Incomplete: []string{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("GetWatchlist() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRocksDB_processAddressesBitcoinTypeWatched_Particl(t *testing.T) {
+	d := setupRocksDB(t, particlTestParser())
+	defer closeAndDestroyRocksDB(t, d)
+	// the staking part of the cold staking output and the receiver of the blind output are watched,
+	// the spend part of the cold staking output and the other addresses are not
+	wl := newWatchlist(d.chainParser, false)
+	watched := map[string]bool{}
+	for _, a := range []string{dbtestdata.AddrPartStaker, dbtestdata.AddrPartB} {
+		addrDesc, err := d.chainParser.GetAddrDescFromAddress(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wl.addAddress(addrDesc)
+		watched[string(addrDesc)] = true
+	}
+	balances := make(map[string]*AddrBalance)
+	txAddressesMap := make(map[string]*TxAddresses)
+	for _, block := range dbtestdata.GetTestParticlTypeBlocks(d.chainParser)[:3] {
+		addresses := make(addressesMap)
+		if err := d.processAddressesBitcoinTypeWatched(block, addresses, txAddressesMap, balances, nil, wl); err != nil {
+			t.Fatal(err)
+		}
+		for a := range addresses {
+			if !watched[a] {
+				t.Errorf("block %d: addresses contain not watched address %x", block.Height, a)
+			}
+		}
+	}
+	if len(balances) != len(watched) {
+		t.Errorf("balances of %d addresses, want %d", len(balances), len(watched))
+	}
+	for a := range balances {
+		if !watched[a] {
+			t.Errorf("balances contain not watched address %x", a)
+		}
+	}
+	// the balances of the not watched addresses are not loaded from the database
+	if d.cbs.balancesMiss != len(watched) {
+		t.Errorf("balancesMiss = %d, want %d", d.cbs.balancesMiss, len(watched))
+	}
+}
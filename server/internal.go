@@ -11,6 +11,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/juju/errors"
@@ -25,15 +26,17 @@ import (
 // InternalServer is handle to internal http server
 type InternalServer struct {
 	htmlTemplates[InternalTemplateData]
-	https       *http.Server
-	certFiles   string
-	db          *db.RocksDB
-	txCache     *db.TxCache
-	chain       bchain.BlockChain
-	chainParser bchain.BlockChainParser
-	mempool     bchain.Mempool
-	is          *common.InternalState
-	api         *api.Worker
+	https        *http.Server
+	certFiles    string
+	db           *db.RocksDB
+	txCache      *db.TxCache
+	chain        bchain.BlockChain
+	chainParser  bchain.BlockChainParser
+	mempool      bchain.Mempool
+	is           *common.InternalState
+	api          *api.Worker
+	snapshotJob  adminJob
+	watchlistJob adminJob
 }
 
 // NewInternalServer creates new internal http interface to blockbook and returns its handle
@@ -53,15 +56,17 @@ func NewInternalServer(binding, certFiles string, db *db.RocksDB, chain bchain.B
 		htmlTemplates: htmlTemplates[InternalTemplateData]{
 			debug: true,
 		},
-		https:       https,
-		certFiles:   certFiles,
-		db:          db,
-		txCache:     txCache,
-		chain:       chain,
-		chainParser: chain.GetChainParser(),
-		mempool:     mempool,
-		is:          is,
-		api:         api,
+		https:        https,
+		certFiles:    certFiles,
+		db:           db,
+		txCache:      txCache,
+		chain:        chain,
+		chainParser:  chain.GetChainParser(),
+		mempool:      mempool,
+		is:           is,
+		api:          api,
+		snapshotJob:  adminJob{name: "snapshot"},
+		watchlistJob: adminJob{name: "watchlist"},
 	}
 	s.htmlTemplates.newTemplateData = s.newTemplateData
 	s.htmlTemplates.newTemplateDataWithError = s.newTemplateDataWithError
@@ -79,6 +84,12 @@ func NewInternalServer(binding, certFiles string, db *db.RocksDB, chain bchain.B
 		serveMux.HandleFunc(path+"admin/contract-info", s.htmlTemplateHandler(s.contractInfoPage))
 		serveMux.HandleFunc(path+"admin/contract-info/", s.jsonHandler(s.apiContractInfo, 0))
 	}
+	if s.is.SnapshotDir != "" {
+		serveMux.HandleFunc(path+"admin/snapshot", s.jsonHandler(s.apiSnapshot, 0))
+	}
+	if s.db.IsWatchOnly() {
+		serveMux.HandleFunc(path+"admin/watchlist", s.jsonHandler(s.apiWatchlist, 0))
+	}
 	return s, nil
 }
 
@@ -272,3 +283,55 @@ func (s *InternalServer) updateContracts(r *http.Request) (interface{}, error) {
 	}
 	return "{\"success\":\"Updated " + strconv.Itoa(len(contractInfos)) + " contracts\"}", nil
 }
+
+// apiSnapshot starts the creation of the snapshot archive of the database in the snapshot directory in the background,
+// GET returns the status of the running or of the last snapshot
+func (s *InternalServer) apiSnapshot(r *http.Request, apiVersion int) (interface{}, error) {
+	if r.Method != http.MethodPost {
+		return s.snapshotJob.get(), nil
+	}
+	path := filepath.Join(s.is.SnapshotDir, fmt.Sprintf("%s-%d.tar.gz", s.is.CoinShortcut, time.Now().Unix()))
+	status, err := s.snapshotJob.start(func(progress func(interface{})) (interface{}, error) {
+		progress(path)
+		return s.db.CreateSnapshot(path)
+	})
+	if err != nil {
+		return nil, api.NewAPIError("Snapshot not started: "+err.Error(), true)
+	}
+	return status, nil
+}
+
+// apiWatchlist returns the watchlist of the watch-only database and the status of the running or of the last rescan,
+// POST adds the entries in the body and starts the rescan of the blocks from the requested height in the background
+func (s *InternalServer) apiWatchlist(r *http.Request, apiVersion int) (interface{}, error) {
+	if r.Method != http.MethodPost {
+		wl, err := s.db.GetWatchlist()
+		if err != nil {
+			return nil, api.NewAPIError(err.Error(), true)
+		}
+		return struct {
+			*db.Watchlist
+			Rescan adminJobStatus `json:"rescan"`
+		}{wl, s.watchlistJob.get()}, nil
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, api.NewAPIError("Cannot get request body", true)
+	}
+	var req db.WatchlistRequest
+	if err = json.Unmarshal(data, &req); err != nil {
+		return nil, api.NewAPIError("Cannot unmarshal body to watchlist request: "+err.Error(), true)
+	}
+	if err = s.db.CheckWatchlistRequest(&req); err != nil {
+		return nil, api.NewAPIError("Invalid watchlist request: "+err.Error(), true)
+	}
+	status, err := s.watchlistJob.start(func(progress func(interface{})) (interface{}, error) {
+		return s.db.AddToWatchlist(s.chain, &req, func(r *db.WatchlistResult) {
+			progress(r)
+		})
+	})
+	if err != nil {
+		return nil, api.NewAPIError("Watchlist rescan not started: "+err.Error(), true)
+	}
+	return status, nil
+}
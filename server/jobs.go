@@ -0,0 +1,67 @@
+package server
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// errJobRunning is returned when a job is started while the previous one is still running
+var errJobRunning = errors.New("the previous job is still running")
+
+// adminJobStatus is the state of the last job of the admin interface
+type adminJobStatus struct {
+	Running  bool        `json:"running"`
+	Started  *time.Time  `json:"started,omitempty"`
+	Finished *time.Time  `json:"finished,omitempty"`
+	Progress interface{} `json:"progress,omitempty"`
+	Result   interface{} `json:"result,omitempty"`
+	Error    string      `json:"error,omitempty"`
+}
+
+// adminJob runs a long operation of the admin interface in the background, only one at a time,
+// the requests return immediately and the status of the job is polled
+type adminJob struct {
+	name   string
+	mux    sync.Mutex
+	status adminJobStatus
+}
+
+// start runs the job in a new goroutine, run may report its progress by the passed function
+func (j *adminJob) start(run func(progress func(interface{})) (interface{}, error)) (adminJobStatus, error) {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	if j.status.Running {
+		return j.status, errJobRunning
+	}
+	started := time.Now().UTC()
+	j.status = adminJobStatus{Running: true, Started: &started}
+	go func() {
+		progress := func(p interface{}) {
+			j.mux.Lock()
+			j.status.Progress = p
+			j.mux.Unlock()
+		}
+		result, err := run(progress)
+		j.mux.Lock()
+		defer j.mux.Unlock()
+		finished := time.Now().UTC()
+		j.status.Running = false
+		j.status.Finished = &finished
+		j.status.Result = result
+		if err != nil {
+			glog.Error(j.name, ": ", err)
+			j.status.Error = err.Error()
+		}
+	}()
+	return j.status, nil
+}
+
+// get returns the status of the running or of the last finished job
+func (j *adminJob) get() adminJobStatus {
+	j.mux.Lock()
+	defer j.mux.Unlock()
+	return j.status
+}
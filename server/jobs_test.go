@@ -0,0 +1,49 @@
+//go:build unittest
+
+package server
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAdminJob(t *testing.T) {
+	j := adminJob{name: "test"}
+	if status := j.get(); status.Running || status.Started != nil {
+		t.Errorf("get() before start = %+v", status)
+	}
+	release := make(chan struct{})
+	progressed := make(chan struct{})
+	status, err := j.start(func(progress func(interface{})) (interface{}, error) {
+		progress(1)
+		close(progressed)
+		<-release
+		return "done", nil
+	})
+	if err != nil || !status.Running || status.Started == nil {
+		t.Fatalf("start() = %+v, %v", status, err)
+	}
+	<-progressed
+	if status = j.get(); !status.Running || status.Progress != 1 {
+		t.Errorf("get() while running = %+v", status)
+	}
+	if _, err = j.start(func(func(interface{})) (interface{}, error) { return nil, nil }); err != errJobRunning {
+		t.Errorf("start() while running error = %v, want %v", err, errJobRunning)
+	}
+	close(release)
+	for status = j.get(); status.Running; status = j.get() {
+	}
+	if status.Finished == nil || status.Result != "done" || status.Error != "" {
+		t.Errorf("get() after finish = %+v", status)
+	}
+
+	status, err = j.start(func(func(interface{})) (interface{}, error) { return nil, errors.New("failed") })
+	if err != nil || status.Progress != nil || status.Finished != nil {
+		t.Fatalf("start() after finish = %+v, %v", status, err)
+	}
+	for status = j.get(); status.Running; status = j.get() {
+	}
+	if status.Error != "failed" || status.Result != nil {
+		t.Errorf("get() after failure = %+v", status)
+	}
+}
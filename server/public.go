@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"html"
 	"html/template"
+	"io"
 	"math/big"
 	"net/http"
 	"net/url"
@@ -22,6 +23,7 @@ import (
 	"github.com/golang/glog"
 	"github.com/trezor/blockbook/api"
 	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/bchain/coins/part"
 	"github.com/trezor/blockbook/common"
 	"github.com/trezor/blockbook/db"
 	"github.com/trezor/blockbook/fiat"
@@ -30,6 +32,8 @@ import (
 const txsOnPage = 25
 const blocksOnPage = 50
 const mempoolTxsOnPage = 50
+const reorgsInAPI = 100
+const treasuryPayoutsInAPI = 1000
 const txsInAPI = 1000
 
 const secondaryCoinCookieName = "secondary_coin"
@@ -60,6 +64,7 @@ type PublicServer struct {
 	fiatRates           *fiat.FiatRates
 	useSatsAmountFormat bool
 	isFullInterface     bool
+	sendTxLimiter       *sendTxLimiter
 }
 
 // NewPublicServer creates new public server http interface to blockbook and returns its handle
@@ -81,6 +86,11 @@ func NewPublicServer(binding string, certFiles string, db *db.RocksDB, chain bch
 		return nil, err
 	}
 
+	// the limit of sent transactions is shared by all public interfaces
+	sendTxLimiter := newSendTxLimiter(is.SendTxLimit)
+	socketio.sendTxLimiter = sendTxLimiter
+	websocket.sendTxLimiter = sendTxLimiter
+
 	addr, path := splitBinding(binding)
 	serveMux := http.NewServeMux()
 	https := &http.Server{
@@ -109,6 +119,7 @@ func NewPublicServer(binding string, certFiles string, db *db.RocksDB, chain bch
 		is:                  is,
 		fiatRates:           fiatRates,
 		useSatsAmountFormat: chain.GetChainParser().GetChainType() == bchain.ChainBitcoinType && chain.GetChainParser().AmountDecimals() == 8,
+		sendTxLimiter:       sendTxLimiter,
 	}
 	s.htmlTemplates.newTemplateData = s.newTemplateData
 	s.htmlTemplates.newTemplateDataWithError = s.newTemplateDataWithError
@@ -155,6 +166,9 @@ func (s *PublicServer) ConnectFullPublicInterface() {
 		serveMux.HandleFunc(path+"spending/", s.htmlTemplateHandler(s.explorerSpendingTx))
 		serveMux.HandleFunc(path+"sendtx", s.htmlTemplateHandler(s.explorerSendTx))
 		serveMux.HandleFunc(path+"mempool", s.htmlTemplateHandler(s.explorerMempool))
+		serveMux.HandleFunc(path+"coldstaking/", s.htmlTemplateHandler(s.explorerColdStaking))
+		serveMux.HandleFunc(path+"votes/", s.htmlTemplateHandler(s.explorerVotes))
+		serveMux.HandleFunc(path+"privacy", s.htmlTemplateHandler(s.explorerPrivacyStats))
 		if s.chainParser.GetChainType() == bchain.ChainEthereumType {
 			serveMux.HandleFunc(path+"nft/", s.htmlTemplateHandler(s.explorerNftDetail))
 		}
@@ -210,6 +224,18 @@ func (s *PublicServer) ConnectFullPublicInterface() {
 	serveMux.HandleFunc(path+"api/v2/estimatefee/", s.jsonHandler(s.apiEstimateFee, apiV2))
 	serveMux.HandleFunc(path+"api/v2/feestats/", s.jsonHandler(s.apiFeeStats, apiV2))
 	serveMux.HandleFunc(path+"api/v2/balancehistory/", s.jsonHandler(s.apiBalanceHistory, apiDefault))
+	serveMux.HandleFunc(path+"api/v2/coldstaking/", s.jsonHandler(s.apiColdStaking, apiV2))
+	serveMux.HandleFunc(path+"api/v2/stakingrewards/", s.jsonHandler(s.apiStakingRewards, apiV2))
+	serveMux.HandleFunc(path+"api/v2/treasury", s.jsonHandler(s.apiTreasury, apiV2))
+	serveMux.HandleFunc(path+"api/v2/supply", s.jsonHandler(s.apiSupply, apiV2))
+	serveMux.HandleFunc(path+"api/v2/stakinginfo", s.jsonHandler(s.apiStakingInfo, apiV2))
+	serveMux.HandleFunc(path+"api/v2/estimatetxfee/", s.jsonHandler(s.apiEstimateTxFee, apiV2))
+	serveMux.HandleFunc(path+"api/v2/privacystats", s.jsonHandler(s.apiPrivacyStats, apiV2))
+	serveMux.HandleFunc(path+"api/v2/reorgs", s.jsonHandler(s.apiReorgs, apiV2))
+	serveMux.HandleFunc(path+"api/v2/keyimage/", s.jsonHandler(s.apiKeyImage, apiV2))
+	serveMux.HandleFunc(path+"api/v2/anonoutputs", s.jsonHandler(s.apiAnonOutputs, apiV2))
+	serveMux.HandleFunc(path+"api/v2/stealthscan", s.jsonHandler(s.apiStealthScan, apiV2))
+	serveMux.HandleFunc(path+"api/v2/votes/", s.jsonHandler(s.apiVotes, apiV2))
 	serveMux.HandleFunc(path+"api/v2/tickers/", s.jsonHandler(s.apiTickers, apiV2))
 	serveMux.HandleFunc(path+"api/v2/multi-tickers/", s.jsonHandler(s.apiMultiTickers, apiV2))
 	serveMux.HandleFunc(path+"api/v2/tickers-list/", s.jsonHandler(s.apiAvailableVsCurrencies, apiV2))
@@ -238,6 +264,11 @@ func (s *PublicServer) OnNewBlock(hash string, height uint32) {
 	s.websocket.OnNewBlock(hash, height)
 }
 
+// OnReorg notifies users subscribed to reorgs or to the addresses affected by the reorg
+func (s *PublicServer) OnReorg(reorg *db.Reorg, addrTxids map[string][]string) {
+	s.websocket.OnReorg(reorg, addrTxids)
+}
+
 // OnNewFiatRatesTicker notifies users subscribed to bitcoind/fiatrates about new ticker
 func (s *PublicServer) OnNewFiatRatesTicker(ticker *common.CurrencyRatesTicker) {
 	s.websocket.OnNewFiatRatesTicker(ticker)
@@ -369,6 +400,9 @@ const (
 	sendTransactionTpl
 	mempoolTpl
 	nftDetailTpl
+	coldStakingTpl
+	votesTpl
+	privacyStatsTpl
 
 	publicTplCount
 )
@@ -391,6 +425,11 @@ type TemplateData struct {
 	Block                    *api.Block
 	Info                     *api.SystemInfo
 	MempoolTxids             *api.MempoolTxids
+	ColdStaking              *api.ColdStaking
+	Votes                    *api.Votes
+	Supply                   *api.Supply
+	PrivacyStats             *api.PrivacyStats
+	PrivacyStatsMaxTxs       int
 	Page                     int
 	PrevPage                 int
 	NextPage                 int
@@ -399,6 +438,7 @@ type TemplateData struct {
 	Minified                 string
 	TOSLink                  string
 	SendTxHex                string
+	SendTxDisabled           bool
 	Status                   string
 	NonZeroBalanceTokens     bool
 	TokenId                  string
@@ -440,6 +480,7 @@ func (s *PublicServer) parseTemplates() []*template.Template {
 		"tokenCount":               tokenCount,
 		"hasPrefix":                strings.HasPrefix,
 		"jsStr":                    jsStr,
+		"percent":                  percent,
 	}
 	var createTemplate func(filenames ...string) *template.Template
 	if s.debug {
@@ -497,6 +538,9 @@ func (s *PublicServer) parseTemplates() []*template.Template {
 	}
 	t[xpubTpl] = createTemplate("./static/templates/xpub.html", "./static/templates/txdetail.html", "./static/templates/paging.html", "./static/templates/base.html")
 	t[mempoolTpl] = createTemplate("./static/templates/mempool.html", "./static/templates/paging.html", "./static/templates/base.html")
+	t[coldStakingTpl] = createTemplate("./static/templates/coldstaking.html", "./static/templates/paging.html", "./static/templates/base.html")
+	t[votesTpl] = createTemplate("./static/templates/votes.html", "./static/templates/base.html")
+	t[privacyStatsTpl] = createTemplate("./static/templates/privacy.html", "./static/templates/base.html")
 	return t
 }
 
@@ -769,6 +813,14 @@ func jsStr(s string) template.JSStr {
 	return template.JSStr(s)
 }
 
+// percent returns the value as a percentage of the total, used for the height of the chart bars
+func percent(value, total int) int {
+	if total <= 0 {
+		return 0
+	}
+	return value * 100 / total
+}
+
 func (s *PublicServer) explorerTx(w http.ResponseWriter, r *http.Request) (tpl, *TemplateData, error) {
 	var tx *api.Tx
 	var err error
@@ -905,6 +957,32 @@ func (s *PublicServer) explorerAddress(w http.ResponseWriter, r *http.Request) (
 	return addressTpl, data, nil
 }
 
+func (s *PublicServer) explorerColdStaking(w http.ResponseWriter, r *http.Request) (tpl, *TemplateData, error) {
+	var addressParam string
+	i := strings.LastIndexByte(r.URL.Path, '/')
+	if i > 0 {
+		addressParam = r.URL.Path[i+1:]
+	}
+	if len(addressParam) == 0 {
+		return errorTpl, nil, api.NewAPIError("Missing address", true)
+	}
+	s.metrics.ExplorerViews.With(common.Labels{"action": "coldstaking"}).Inc()
+	page, ec := strconv.Atoi(r.URL.Query().Get("page"))
+	if ec != nil {
+		page = 0
+	}
+	cs, err := s.api.GetColdStaking(addressParam, page, txsOnPage)
+	if err != nil {
+		return errorTpl, nil, err
+	}
+	data := s.newTemplateData(r)
+	data.AddrStr = cs.AddrStr
+	data.ColdStaking = cs
+	data.Page = cs.Page
+	data.PagingRange, data.PrevPage, data.NextPage = getPagingRange(cs.Page, cs.TotalPages)
+	return coldStakingTpl, data, nil
+}
+
 func (s *PublicServer) explorerNftDetail(w http.ResponseWriter, r *http.Request) (tpl, *TemplateData, error) {
 	parts := strings.Split(r.URL.Path, "/")
 	if len(parts) < 3 {
@@ -1014,6 +1092,13 @@ func (s *PublicServer) explorerIndex(w http.ResponseWriter, r *http.Request) (tp
 	}
 	data := s.newTemplateData(r)
 	data.Info = si
+	if _, ok := s.chainParser.(*part.ParticlParser); ok {
+		// only the plain and the total hidden supply are known, the split of the hidden value between blind and anon outputs is not,
+		// the page is shown without the supply if it cannot be read
+		if data.Supply, err = s.api.GetSupply(""); err != nil {
+			glog.V(1).Info("GetSupply error ", err)
+		}
+	}
 	return indexTpl, data, nil
 }
 
@@ -1052,14 +1137,15 @@ func (s *PublicServer) explorerSearch(w http.ResponseWriter, r *http.Request) (t
 func (s *PublicServer) explorerSendTx(w http.ResponseWriter, r *http.Request) (tpl, *TemplateData, error) {
 	s.metrics.ExplorerViews.With(common.Labels{"action": "sendtx"}).Inc()
 	data := s.newTemplateData(r)
-	if r.Method == http.MethodPost {
+	data.SendTxDisabled = s.is.DisableSendTx
+	if r.Method == http.MethodPost && !data.SendTxDisabled {
 		err := r.ParseForm()
 		if err != nil {
 			return sendTransactionTpl, data, err
 		}
 		hex := r.FormValue("hex")
 		if len(hex) > 0 {
-			res, err := s.chain.SendRawTransaction(hex, false)
+			res, err := sendTransaction(s.chain, s.is, s.sendTxLimiter, getIP(r, s.is.TrustProxyHeaders), hex, false)
 			if err != nil {
 				data.SendTxHex = hex
 				data.Error = &api.APIError{Text: err.Error(), Public: true}
@@ -1071,6 +1157,87 @@ func (s *PublicServer) explorerSendTx(w http.ResponseWriter, r *http.Request) (t
 	return sendTransactionTpl, data, nil
 }
 
+// getVotes parses the proposal from the path and the height range from the query of the votes request
+func (s *PublicServer) getVotes(r *http.Request) (*api.Votes, error) {
+	var proposalParam string
+	i := strings.LastIndexByte(r.URL.Path, '/')
+	if i > 0 {
+		proposalParam = r.URL.Path[i+1:]
+	}
+	if len(proposalParam) == 0 {
+		return nil, api.NewAPIError("Missing proposal", true)
+	}
+	from, to := 0, -1
+	var err error
+	if f := r.URL.Query().Get("from"); f != "" {
+		from, err = strconv.Atoi(f)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'from' is not a valid height", true)
+		}
+	}
+	if t := r.URL.Query().Get("to"); t != "" {
+		to, err = strconv.Atoi(t)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'to' is not a valid height", true)
+		}
+	}
+	return s.api.GetVotes(proposalParam, from, to)
+}
+
+func (s *PublicServer) explorerVotes(w http.ResponseWriter, r *http.Request) (tpl, *TemplateData, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "votes"}).Inc()
+	votes, err := s.getVotes(r)
+	if err != nil {
+		return errorTpl, nil, err
+	}
+	data := s.newTemplateData(r)
+	data.Votes = votes
+	return votesTpl, data, nil
+}
+
+// privacyStatsExplorerDays is the default time range of the privacy statistics page
+const privacyStatsExplorerDays = 30
+
+// getPrivacyStats parses the time range and the grouping from the query of the privacy statistics request,
+// the range starts at defaultFrom if it is not specified
+func (s *PublicServer) getPrivacyStats(r *http.Request, defaultFrom int64) (*api.PrivacyStats, error) {
+	fromTimestamp, toTimestamp := defaultFrom, int64(0)
+	var err error
+	if from := r.URL.Query().Get("from"); from != "" {
+		fromTimestamp, err = strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'from' is not a valid timestamp", true)
+		}
+	}
+	if to := r.URL.Query().Get("to"); to != "" {
+		toTimestamp, err = strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'to' is not a valid timestamp", true)
+		}
+	}
+	groupBy, err := strconv.ParseUint(r.URL.Query().Get("groupBy"), 10, 32)
+	if err != nil || groupBy == 0 {
+		groupBy = 86400
+	}
+	return s.api.GetPrivacyStats(fromTimestamp, toTimestamp, uint32(groupBy))
+}
+
+func (s *PublicServer) explorerPrivacyStats(w http.ResponseWriter, r *http.Request) (tpl, *TemplateData, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "privacy"}).Inc()
+	ps, err := s.getPrivacyStats(r, time.Now().Add(-privacyStatsExplorerDays*24*time.Hour).Unix())
+	if err != nil {
+		return errorTpl, nil, err
+	}
+	data := s.newTemplateData(r)
+	data.PrivacyStats = ps
+	for i := range ps.Stats {
+		if ps.Stats[i].PrivacyTxs > data.PrivacyStatsMaxTxs {
+			data.PrivacyStatsMaxTxs = ps.Stats[i].PrivacyTxs
+		}
+	}
+	return privacyStatsTpl, data, nil
+}
+
 func (s *PublicServer) explorerMempool(w http.ResponseWriter, r *http.Request) (tpl, *TemplateData, error) {
 	var mempoolTxids *api.MempoolTxids
 	var err error
@@ -1451,6 +1618,214 @@ func (s *PublicServer) apiBalanceHistory(r *http.Request, apiVersion int) (inter
 	return history, err
 }
 
+func (s *PublicServer) apiColdStaking(r *http.Request, apiVersion int) (interface{}, error) {
+	var addressParam string
+	i := strings.LastIndexByte(r.URL.Path, '/')
+	if i > 0 {
+		addressParam = r.URL.Path[i+1:]
+	}
+	if len(addressParam) == 0 {
+		return nil, api.NewAPIError("Missing address", true)
+	}
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-coldstaking"}).Inc()
+	page, ec := strconv.Atoi(r.URL.Query().Get("page"))
+	if ec != nil {
+		page = 0
+	}
+	pageSize, ec := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if ec != nil || pageSize <= 0 || pageSize > txsInAPI {
+		pageSize = txsInAPI
+	}
+	return s.api.GetColdStaking(addressParam, page, pageSize)
+}
+
+func (s *PublicServer) apiStakingRewards(r *http.Request, apiVersion int) (interface{}, error) {
+	var addressParam string
+	i := strings.LastIndexByte(r.URL.Path, '/')
+	if i > 0 {
+		addressParam = r.URL.Path[i+1:]
+	}
+	if len(addressParam) == 0 {
+		return nil, api.NewAPIError("Missing address", true)
+	}
+	var fromTimestamp, toTimestamp int64
+	var err error
+	from := r.URL.Query().Get("from")
+	if from != "" {
+		fromTimestamp, err = strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'from' is not a valid timestamp", true)
+		}
+	}
+	to := r.URL.Query().Get("to")
+	if to != "" {
+		toTimestamp, err = strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'to' is not a valid timestamp", true)
+		}
+	}
+	groupBy, err := strconv.ParseUint(r.URL.Query().Get("groupBy"), 10, 32)
+	if err != nil || groupBy == 0 {
+		groupBy = 86400
+	}
+	fiat := r.URL.Query().Get("fiatcurrency")
+	var fiatArray []string
+	if fiat != "" {
+		fiatArray = []string{fiat}
+	}
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-stakingrewards"}).Inc()
+	return s.api.GetStakingRewards(addressParam, fromTimestamp, toTimestamp, fiatArray, uint32(groupBy))
+}
+
+func (s *PublicServer) apiTreasury(r *http.Request, apiVersion int) (interface{}, error) {
+	var fromTimestamp, toTimestamp int64
+	var err error
+	from := r.URL.Query().Get("from")
+	if from != "" {
+		fromTimestamp, err = strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'from' is not a valid timestamp", true)
+		}
+	}
+	to := r.URL.Query().Get("to")
+	if to != "" {
+		toTimestamp, err = strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'to' is not a valid timestamp", true)
+		}
+	}
+	page, ec := strconv.Atoi(r.URL.Query().Get("page"))
+	if ec != nil {
+		page = 0
+	}
+	pageSize, ec := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if ec != nil || pageSize <= 0 || pageSize > treasuryPayoutsInAPI {
+		pageSize = treasuryPayoutsInAPI
+	}
+	fiat := r.URL.Query().Get("fiatcurrency")
+	var fiatArray []string
+	if fiat != "" {
+		fiatArray = []string{fiat}
+	}
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-treasury"}).Inc()
+	return s.api.GetTreasury(fromTimestamp, toTimestamp, page, pageSize, fiatArray)
+}
+
+func (s *PublicServer) apiSupply(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-supply"}).Inc()
+	return s.api.GetSupply(r.URL.Query().Get("block"))
+}
+
+func (s *PublicServer) apiStakingInfo(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-stakinginfo"}).Inc()
+	return s.api.GetStakingInfo(r.URL.Query().Get("weight"))
+}
+
+func (s *PublicServer) apiPrivacyStats(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-privacystats"}).Inc()
+	return s.getPrivacyStats(r, 0)
+}
+
+func (s *PublicServer) apiReorgs(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-reorgs"}).Inc()
+	page, ec := strconv.Atoi(r.URL.Query().Get("page"))
+	if ec != nil {
+		page = 0
+	}
+	pageSize, ec := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if ec != nil || pageSize <= 0 || pageSize > reorgsInAPI {
+		pageSize = reorgsInAPI
+	}
+	return s.api.GetReorgs(page, pageSize)
+}
+
+func (s *PublicServer) apiEstimateTxFee(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-estimatetxfee"}).Inc()
+	var b string
+	if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+		b = r.URL.Path[i+1:]
+	}
+	if len(b) == 0 {
+		return nil, api.NewAPIError("Missing parameter 'number of blocks'", true)
+	}
+	blocks, err := strconv.Atoi(b)
+	if err != nil {
+		return nil, api.NewAPIError("Parameter 'number of blocks' is not a number", true)
+	}
+	conservative := true
+	if c := r.URL.Query().Get("conservative"); len(c) > 0 {
+		conservative, err = strconv.ParseBool(c)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'conservative' cannot be converted to boolean", true)
+		}
+	}
+	var params [3]int
+	for i, name := range []string{"inputs", "outputs", "ringsize"} {
+		if v := r.URL.Query().Get(name); len(v) > 0 {
+			params[i], err = strconv.Atoi(v)
+			if err != nil {
+				return nil, api.NewAPIError(fmt.Sprintf("Parameter '%s' is not a number", name), true)
+			}
+		}
+	}
+	return s.api.EstimateTxFees(blocks, conservative, params[0], params[1], params[2])
+}
+
+func (s *PublicServer) apiKeyImage(r *http.Request, apiVersion int) (interface{}, error) {
+	var keyImageParam string
+	i := strings.LastIndexByte(r.URL.Path, '/')
+	if i > 0 {
+		keyImageParam = r.URL.Path[i+1:]
+	}
+	if len(keyImageParam) == 0 {
+		return nil, api.NewAPIError("Missing key image", true)
+	}
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-keyimage"}).Inc()
+	return s.api.GetKeyImage(keyImageParam)
+}
+
+func (s *PublicServer) apiAnonOutputs(r *http.Request, apiVersion int) (interface{}, error) {
+	from, err := strconv.ParseUint(r.URL.Query().Get("from"), 10, 64)
+	if err != nil {
+		return nil, api.NewAPIError("Parameter 'from' is not a valid index", true)
+	}
+	count := 100
+	if c := r.URL.Query().Get("count"); c != "" {
+		count, err = strconv.Atoi(c)
+		if err != nil {
+			return nil, api.NewAPIError("Parameter 'count' is not a valid number", true)
+		}
+	}
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-anonoutputs"}).Inc()
+	return s.api.GetAnonOutputs(from, count)
+}
+
+func (s *PublicServer) apiVotes(r *http.Request, apiVersion int) (interface{}, error) {
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-votes"}).Inc()
+	return s.getVotes(r)
+}
+
+// apiStealthScan accepts only POST, so that the scan secret does not appear in the URL and access logs
+func (s *PublicServer) apiStealthScan(r *http.Request, apiVersion int) (interface{}, error) {
+	if r.Method != http.MethodPost {
+		return nil, api.NewAPIError("Stealth scan requires POST request", true)
+	}
+	var req struct {
+		Address    string `json:"address"`
+		ScanSecret string `json:"scanSecret"`
+		From       int    `json:"from"`
+		To         int    `json:"to"`
+	}
+	if err := json.NewDecoder(io.LimitReader(r.Body, 4096)).Decode(&req); err != nil {
+		return nil, api.NewAPIError("Invalid request body", true)
+	}
+	if req.To <= 0 {
+		req.To = -1
+	}
+	s.metrics.ExplorerViews.With(common.Labels{"action": "api-stealthscan"}).Inc()
+	return s.api.GetStealthScan(req.Address, req.ScanSecret, req.From, req.To)
+}
+
 func (s *PublicServer) apiBlock(r *http.Request, apiVersion int) (interface{}, error) {
 	var block *api.Block
 	var err error
@@ -1493,10 +1868,29 @@ type resultSendTransaction struct {
 }
 
 func (s *PublicServer) apiSendTx(r *http.Request, apiVersion int) (interface{}, error) {
+	var err error
+	var res resultSendTransaction
+	var hex string
 	s.metrics.ExplorerViews.With(common.Labels{"action": "api-sendtx"}).Inc()
-
-	// Transaction broadcasting temporarily disabled
-	return nil, api.NewAPIError("Transaction broadcasting is temporarily disabled. Please use particl-cli or Particl Core wallet to send transactions.", true)
+	if r.Method == http.MethodPost {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, api.NewAPIError("Missing tx blob", true)
+		}
+		hex = string(data)
+	} else {
+		if i := strings.LastIndexByte(r.URL.Path, '/'); i > 0 {
+			hex = r.URL.Path[i+1:]
+		}
+	}
+	if len(hex) > 0 {
+		res.Result, err = sendTransaction(s.chain, s.is, s.sendTxLimiter, getIP(r, s.is.TrustProxyHeaders), hex, false)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+	return nil, api.NewAPIError("Missing tx blob", true)
 }
 
 // apiAvailableVsCurrencies returns a list of available versus currencies
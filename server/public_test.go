@@ -859,6 +859,204 @@ func httpTestsBitcoinType(t *testing.T, ts *httptest.Server) {
 				`[{"time":1521594000,"txs":1,"received":"118641975500","sent":"1","sentToSelf":"118641975500","rates":{"eur":1302,"usd":2002}}]`,
 			},
 		},
+		{
+			name:        "apiColdStaking Addr2 not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/coldstaking/mtGXQvBowMkBpnhLckhxhbwYK44Gs9eEtz"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiStakingRewards Addr2 fiatcurrency=usd not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/stakingrewards/mtGXQvBowMkBpnhLckhxhbwYK44Gs9eEtz?fiatcurrency=usd"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiStakingRewards invalid from",
+			r:           newGetRequest(ts.URL + "/api/v2/stakingrewards/mtGXQvBowMkBpnhLckhxhbwYK44Gs9eEtz?from=abc"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Parameter 'from' is not a valid timestamp"}`,
+			},
+		},
+		{
+			name:        "apiKeyImage unspent not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/keyimage/02abababababababababababababababababababababababababababababababab"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiKeyImage invalid not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/keyimage/1234"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiStealthScan GET",
+			r:           newGetRequest(ts.URL + "/api/v2/stealthscan"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Stealth scan requires POST request"}`,
+			},
+		},
+		{
+			name:        "apiStealthScan not enabled",
+			r:           newPostRequest(ts.URL+"/api/v2/stealthscan", `{"address":"SPGwb6oqzdPSXUZvknW3UcN6MupFjKR1SVaBDdaCAConLdJNR7Hq5wZdpXBHAp5ayJUhERb4tFQ7Ksu3X7og5td8FiaA7GTj2f8cES","scanSecret":"0000000000000000000000000000000000000000000000000000000000000001","from":1}`),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Stealth scan not enabled, use -enablestealthscan flag to enable"}`,
+			},
+		},
+		{
+			name:        "apiAnonOutputs not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/anonoutputs?from=1&count=10"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiVotes not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/votes/5?from=225493"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiVotes invalid proposal not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/votes/70000"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiTreasury not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/treasury"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiTreasury invalid to",
+			r:           newGetRequest(ts.URL + "/api/v2/treasury?to=abc"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Parameter 'to' is not a valid timestamp"}`,
+			},
+		},
+		{
+			name:        "apiSupply not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/supply?block=225493"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiStakingInfo not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/stakinginfo"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiPrivacyStats not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/privacystats?groupBy=3600"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiPrivacyStats invalid from",
+			r:           newGetRequest(ts.URL + "/api/v2/privacystats?from=x"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Parameter 'from' is not a valid timestamp"}`,
+			},
+		},
+		{
+			name:        "apiReorgs",
+			r:           newGetRequest(ts.URL + "/api/v2/reorgs"),
+			status:      http.StatusOK,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"page":1,"totalPages":1,"itemsOnPage":100,"reorgs":[]}`,
+			},
+		},
+		{
+			name:        "apiEstimateTxFee not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/estimatetxfee/2?inputs=2&outputs=2&ringsize=5"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiEstimateTxFee inputs not a number",
+			r:           newGetRequest(ts.URL + "/api/v2/estimatetxfee/2?inputs=x"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Parameter 'inputs' is not a number"}`,
+			},
+		},
+		{
+			name:        "apiAnonOutputs count too big not Particl",
+			r:           newGetRequest(ts.URL + "/api/v2/anonoutputs?from=1&count=1001"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Not supported"}`,
+			},
+		},
+		{
+			name:        "apiAnonOutputs missing from",
+			r:           newGetRequest(ts.URL + "/api/v2/anonoutputs"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Parameter 'from' is not a valid index"}`,
+			},
+		},
+		{
+			name:        "apiColdStaking missing address",
+			r:           newGetRequest(ts.URL + "/api/v2/coldstaking/"),
+			status:      http.StatusBadRequest,
+			contentType: "application/json; charset=utf-8",
+			body: []string{
+				`{"error":"Missing address"}`,
+			},
+		},
 		{
 			name:        "apiSendTx",
 			r:           newGetRequest(ts.URL + "/api/v2/sendtx/1234567890"),
@@ -1495,6 +1693,88 @@ var websocketTestsBitcoinType = []websocketTest{
 		},
 		want: `{"id":"44","data":{"error":{"message":"not supported"}}}`,
 	},
+	{
+		name: "websocket getColdStaking Addr2",
+		req: websocketReq{
+			Method: "getColdStaking",
+			Params: map[string]interface{}{
+				"descriptor": "mtGXQvBowMkBpnhLckhxhbwYK44Gs9eEtz",
+				"pageSize":   10,
+			},
+		},
+		want: `{"id":"45","data":{"page":1,"totalPages":1,"itemsOnPage":10,"address":"mtGXQvBowMkBpnhLckhxhbwYK44Gs9eEtz","totalDelegated":"0","owners":[],"delegations":[],"history":[]}}`,
+	},
+	{
+		name: "websocket getKeyImages",
+		req: websocketReq{
+			Method: "getKeyImages",
+			Params: map[string]interface{}{
+				"keyImages": []string{"02abababababababababababababababababababababababababababababababab", "03cdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd"},
+			},
+		},
+		want: `{"id":"46","data":[{"keyImage":"02abababababababababababababababababababababababababababababababab","spent":false},{"keyImage":"03cdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcdcd","spent":false}]}`,
+	},
+	{
+		name: "websocket getKeyImages empty",
+		req: websocketReq{
+			Method: "getKeyImages",
+			Params: map[string]interface{}{
+				"keyImages": []string{},
+			},
+		},
+		want: `{"id":"47","data":{"error":{"message":"Missing key image"}}}`,
+	},
+	{
+		name: "websocket scanStealthOutputs not enabled",
+		req: websocketReq{
+			Method: "scanStealthOutputs",
+			Params: map[string]interface{}{
+				"address":    "SPGwb6oqzdPSXUZvknW3UcN6MupFjKR1SVaBDdaCAConLdJNR7Hq5wZdpXBHAp5ayJUhERb4tFQ7Ksu3X7og5td8FiaA7GTj2f8cES",
+				"scanSecret": "0000000000000000000000000000000000000000000000000000000000000001",
+				"from":       1,
+			},
+		},
+		want: `{"id":"48","data":{"error":{"message":"Stealth scan not enabled, use -enablestealthscan flag to enable"}}}`,
+	},
+	{
+		name: "websocket subscribeStealthOutputs no keys",
+		req: websocketReq{
+			Method: "subscribeStealthOutputs",
+			Params: map[string]interface{}{
+				"keys": []interface{}{},
+			},
+		},
+		want: `{"id":"49","data":{"error":{"message":"Number of stealth addresses must be between 1 and 10"}}}`,
+	},
+	{
+		name: "websocket estimateFee txtype not Particl",
+		req: websocketReq{
+			Method: "estimateFee",
+			Params: map[string]interface{}{
+				"blocks": []int{2},
+				"specific": map[string]interface{}{
+					"txtype":   "anon",
+					"inputs":   2,
+					"ringsize": 5,
+				},
+			},
+		},
+		want: `{"id":"50","data":{"error":{"message":"Not supported"}}}`,
+	},
+	{
+		name: "websocket subscribeReorgs",
+		req: websocketReq{
+			Method: "subscribeReorgs",
+		},
+		want: `{"id":"51","data":{"subscribed":true}}`,
+	},
+	{
+		name: "websocket unsubscribeReorgs",
+		req: websocketReq{
+			Method: "unsubscribeReorgs",
+		},
+		want: `{"id":"52","data":{"subscribed":false}}`,
+	},
 }
 
 func runWebsocketTests(t *testing.T, ts *httptest.Server, tests []websocketTest) {
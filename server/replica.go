@@ -0,0 +1,298 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/juju/errors"
+	"github.com/trezor/blockbook/bchain"
+)
+
+// Replica notifications
+// The primary instance publishes the notifications about new blocks and new mempool transactions on a unix socket
+// to the secondary instances, which serve the API from the database of the primary instance opened as RocksDB
+// secondary instance. The notifications are JSON objects separated by newlines. The secondary instance uses them
+// only as triggers of the catch up with the primary database and of the mempool resync.
+
+const (
+	replicaEventNewBlock = "newBlock"
+	replicaEventNewTx    = "newTx"
+
+	replicaClientQueueSize = 1024
+	replicaWriteTimeout    = 5 * time.Second
+	replicaReconnectDelay  = 5 * time.Second
+	// the socket is readable and writable only by the user and the group
+	replicaSocketMode = 0o660
+)
+
+type replicaNotification struct {
+	Event  string `json:"event"`
+	Height uint32 `json:"height,omitempty"`
+	Hash   string `json:"hash,omitempty"`
+	Txid   string `json:"txid,omitempty"`
+}
+
+type replicaClient struct {
+	conn  net.Conn
+	queue chan []byte
+}
+
+// ReplicaServer publishes the notifications of the primary instance to the connected secondary instances
+type ReplicaServer struct {
+	path     string
+	listener net.Listener
+	mux      sync.Mutex
+	clients  map[*replicaClient]struct{}
+	closed   bool
+}
+
+// NewReplicaServer creates the unix socket in path for the secondary instances. An existing socket is removed only if
+// nobody listens on it (a stale socket left by a crashed instance), the socket of a running instance is never taken over.
+// The socket is accessible only by the user and the group of the primary instance.
+func NewReplicaServer(path string) (*ReplicaServer, error) {
+	if fi, err := os.Stat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, errors.Errorf("Replica socket %s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, replicaWriteTimeout); err == nil {
+			conn.Close()
+			return nil, errors.Errorf("Replica socket %s is used by another running instance", path)
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	listener, err := listenReplicaSocket(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplicaServer{
+		path:     path,
+		listener: listener,
+		clients:  make(map[*replicaClient]struct{}),
+	}, nil
+}
+
+// listenReplicaSocket creates the socket in a new directory accessible only by the user, sets the permissions
+// of the socket and moves it to path, so that the socket is never accessible with wider permissions
+func listenReplicaSocket(path string) (net.Listener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".replica")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	tmp := filepath.Join(dir, filepath.Base(path))
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmp, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// the listener would unlink the temporary path, the socket at path is removed by Close
+	listener.SetUnlinkOnClose(false)
+	if err = os.Chmod(tmp, replicaSocketMode); err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// Run accepts the connections of the secondary instances until the server is closed
+func (s *ReplicaServer) Run() error {
+	glog.Info("replica server starting on ", s.path)
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			s.mux.Lock()
+			closed := s.closed
+			s.mux.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+		c := &replicaClient{conn: conn, queue: make(chan []byte, replicaClientQueueSize)}
+		s.mux.Lock()
+		s.clients[c] = struct{}{}
+		s.mux.Unlock()
+		glog.Info("replica server: secondary instance connected, ", s.clientCount(), " connected")
+		go s.writeLoop(c)
+	}
+}
+
+// Close closes the socket and disconnects the secondary instances
+func (s *ReplicaServer) Close() error {
+	s.mux.Lock()
+	s.closed = true
+	s.mux.Unlock()
+	err := s.listener.Close()
+	if rerr := os.Remove(s.path); rerr != nil && !os.IsNotExist(rerr) && err == nil {
+		err = rerr
+	}
+	s.mux.Lock()
+	for c := range s.clients {
+		s.removeClient(c)
+	}
+	s.mux.Unlock()
+	return err
+}
+
+func (s *ReplicaServer) clientCount() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.clients)
+}
+
+// removeClient must be called with the lock held
+func (s *ReplicaServer) removeClient(c *replicaClient) {
+	if _, ok := s.clients[c]; ok {
+		delete(s.clients, c)
+		close(c.queue)
+		c.conn.Close()
+	}
+}
+
+func (s *ReplicaServer) writeLoop(c *replicaClient) {
+	for msg := range c.queue {
+		c.conn.SetWriteDeadline(time.Now().Add(replicaWriteTimeout))
+		if _, err := c.conn.Write(msg); err != nil {
+			glog.Warning("replica server: secondary instance disconnected, ", err)
+			s.mux.Lock()
+			s.removeClient(c)
+			s.mux.Unlock()
+			// drain the queue until it is closed
+			for range c.queue {
+			}
+			return
+		}
+	}
+}
+
+func (s *ReplicaServer) publish(n *replicaNotification) {
+	msg, err := json.Marshal(n)
+	if err != nil {
+		glog.Error("replica server: ", err)
+		return
+	}
+	msg = append(msg, '\n')
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	for c := range s.clients {
+		select {
+		case c.queue <- msg:
+		default:
+			// the secondary instance does not read the notifications, it catches up after reconnect
+			glog.Warning("replica server: secondary instance is not reading notifications, disconnecting")
+			s.removeClient(c)
+		}
+	}
+}
+
+// OnNewBlock publishes the new block notification
+func (s *ReplicaServer) OnNewBlock(hash string, height uint32) {
+	s.publish(&replicaNotification{Event: replicaEventNewBlock, Height: height, Hash: hash})
+}
+
+// OnNewTx publishes the new mempool transaction notification
+func (s *ReplicaServer) OnNewTx(tx *bchain.MempoolTx) {
+	s.publish(&replicaNotification{Event: replicaEventNewTx, Txid: tx.Txid})
+}
+
+// ReplicaSubscriber receives the notifications of the primary instance in the secondary instance
+type ReplicaSubscriber struct {
+	path        string
+	pushHandler func(bchain.NotificationType)
+	mux         sync.Mutex
+	conn        net.Conn
+	closed      bool
+}
+
+// NewReplicaSubscriber connects to the replica socket of the primary instance in path and calls pushHandler for each
+// notification. The connection is reestablished when lost, after each connect pushHandler is called with
+// NotificationNewBlock and NotificationNewTx, the notifications sent while disconnected are lost.
+func NewReplicaSubscriber(path string, pushHandler func(bchain.NotificationType)) *ReplicaSubscriber {
+	s := &ReplicaSubscriber{
+		path:        path,
+		pushHandler: pushHandler,
+	}
+	go s.run()
+	return s
+}
+
+func (s *ReplicaSubscriber) run() {
+	for {
+		conn, err := net.Dial("unix", s.path)
+		s.mux.Lock()
+		if s.closed {
+			s.mux.Unlock()
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+		s.conn = conn
+		s.mux.Unlock()
+		if err != nil {
+			glog.Warning("replica subscriber: ", err, ", retrying in ", replicaReconnectDelay)
+		} else {
+			glog.Info("replica subscriber: connected to ", s.path)
+			s.readLoop(conn)
+			conn.Close()
+		}
+		s.mux.Lock()
+		closed := s.closed
+		s.mux.Unlock()
+		if closed {
+			return
+		}
+		time.Sleep(replicaReconnectDelay)
+	}
+}
+
+func (s *ReplicaSubscriber) readLoop(conn net.Conn) {
+	// catch up with the changes made while disconnected
+	s.pushHandler(bchain.NotificationNewBlock)
+	s.pushHandler(bchain.NotificationNewTx)
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var n replicaNotification
+		if err := json.Unmarshal(scanner.Bytes(), &n); err != nil {
+			glog.Error("replica subscriber: ", err)
+			continue
+		}
+		switch n.Event {
+		case replicaEventNewBlock:
+			glog.V(1).Info("replica subscriber: new block ", n.Height, " ", n.Hash)
+			// resync also the mempool to remove the confirmed transactions
+			s.pushHandler(bchain.NotificationNewBlock)
+			s.pushHandler(bchain.NotificationNewTx)
+		case replicaEventNewTx:
+			glog.V(2).Info("replica subscriber: new tx ", n.Txid)
+			s.pushHandler(bchain.NotificationNewTx)
+		default:
+			glog.Warning("replica subscriber: unknown event ", n.Event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		glog.Warning("replica subscriber: ", err)
+	} else {
+		glog.Warning("replica subscriber: connection closed by the primary instance")
+	}
+}
+
+// Close disconnects from the primary instance and stops the reconnecting
+func (s *ReplicaSubscriber) Close() {
+	s.mux.Lock()
+	s.closed = true
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.mux.Unlock()
+}
@@ -0,0 +1,96 @@
+//go:build unittest
+
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/trezor/blockbook/bchain"
+)
+
+func TestReplicaServer(t *testing.T) {
+	tmp, err := os.MkdirTemp("", "testreplica")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	path := filepath.Join(tmp, "replica.sock")
+	// stale socket file is replaced
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+	s, err := NewReplicaServer(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go s.Run()
+	defer s.Close()
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != replicaSocketMode {
+		t.Errorf("replica socket permissions %v, %v", fi.Mode(), err)
+	}
+	// the socket of the running instance is not taken over
+	if _, err := NewReplicaServer(path); err == nil || !strings.Contains(err.Error(), "used by another running instance") {
+		t.Errorf("NewReplicaServer() over running socket error = %v", err)
+	}
+
+	notifications := make(chan bchain.NotificationType, 16)
+	sub := NewReplicaSubscriber(path, func(nt bchain.NotificationType) {
+		notifications <- nt
+	})
+	defer sub.Close()
+	next := func() bchain.NotificationType {
+		t.Helper()
+		select {
+		case nt := <-notifications:
+			return nt
+		case <-time.After(5 * time.Second):
+			t.Fatal("timeout waiting for notification")
+		}
+		return 0
+	}
+	expect := func(want ...bchain.NotificationType) {
+		t.Helper()
+		for _, w := range want {
+			if got := next(); got != w {
+				t.Fatalf("notification %v, want %v", got, w)
+			}
+		}
+	}
+	// catch up after connect
+	expect(bchain.NotificationNewBlock, bchain.NotificationNewTx)
+	for i := 0; s.clientCount() == 0; i++ {
+		if i == 500 {
+			t.Fatal("secondary instance not connected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s.OnNewTx(&bchain.MempoolTx{Txid: "a503000000000000000000000000000000000000000000000000000000000001"})
+	expect(bchain.NotificationNewTx)
+	s.OnNewBlock("0000000000000000000000000000000000000000000000000000000000000503", 503)
+	expect(bchain.NotificationNewBlock, bchain.NotificationNewTx)
+
+	if err := os.WriteFile(filepath.Join(tmp, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewReplicaServer(filepath.Join(tmp, "file")); err == nil {
+		t.Error("NewReplicaServer() over regular file succeeded")
+	}
+	// the socket is removed on close, no temporary directory is left
+	s.Close()
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "file" {
+		t.Errorf("files after Close() %v, want [file]", entries)
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/golang/glog"
+	gosocketio "github.com/martinboehm/golang-socketio"
+	"github.com/trezor/blockbook/api"
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/common"
+)
+
+const sendTxLimitWindow = time.Minute
+
+// sendTxLimiter limits the number of transactions broadcast from one IP address,
+// the counters of all addresses are reset at the start of every window
+type sendTxLimiter struct {
+	limit       int
+	mux         sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+func newSendTxLimiter(limit int) *sendTxLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &sendTxLimiter{
+		limit:  limit,
+		counts: make(map[string]int),
+	}
+}
+
+// allow counts the transaction sent from the ip and returns false if the limit is exceeded
+func (l *sendTxLimiter) allow(ip string) bool {
+	return l.allowAt(ip, time.Now())
+}
+
+func (l *sendTxLimiter) allowAt(ip string, now time.Time) bool {
+	if l == nil {
+		return true
+	}
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	if now.Sub(l.windowStart) >= sendTxLimitWindow {
+		l.windowStart = now
+		l.counts = make(map[string]int)
+	}
+	if l.counts[ip] >= l.limit {
+		return false
+	}
+	l.counts[ip]++
+	return true
+}
+
+// sendTransaction broadcasts the transaction, it is shared by all public interfaces so that they behave the same way
+func sendTransaction(chain bchain.BlockChain, is *common.InternalState, limiter *sendTxLimiter, ip string, tx string, disableAlternativeRPC bool) (string, error) {
+	if is.DisableSendTx {
+		return "", api.NewAPIError("Transaction broadcasting is disabled", true)
+	}
+	if !limiter.allow(ip) {
+		glog.Info("sendTransaction limit exceeded, ", ip)
+		return "", api.NewAPIError("Too many transactions sent, try again later", true)
+	}
+	txid, err := chain.SendRawTransaction(tx, disableAlternativeRPC)
+	if err != nil {
+		return "", api.NewAPIError(err.Error(), true)
+	}
+	return txid, nil
+}
+
+// getSocketIoIP returns the ip of the socket.io client without the port,
+// the same proxy headers as for websocket are honored only if trustProxy is set
+func getSocketIoIP(c *gosocketio.Channel, trustProxy bool) string {
+	if h := c.RequestHeader(); trustProxy && h != nil {
+		if ip := getProxyIP(h); ip != "" {
+			return ip
+		}
+	}
+	return stripPort(c.Ip())
+}
@@ -0,0 +1,74 @@
+//go:build unittest
+
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/trezor/blockbook/common"
+)
+
+func TestSendTxLimiter(t *testing.T) {
+	if l := newSendTxLimiter(0); l != nil {
+		t.Fatal("newSendTxLimiter(0) should not limit")
+	}
+	var nilLimiter *sendTxLimiter
+	if !nilLimiter.allow("1.2.3.4") {
+		t.Fatal("nil limiter should allow all")
+	}
+	l := newSendTxLimiter(2)
+	now := time.Unix(1700000000, 0)
+	for i, want := range []bool{true, true, false} {
+		if got := l.allowAt("1.2.3.4", now.Add(time.Duration(i)*time.Second)); got != want {
+			t.Errorf("allowAt #%d = %v, want %v", i, got, want)
+		}
+	}
+	if !l.allowAt("5.6.7.8", now.Add(3*time.Second)) {
+		t.Error("other ip should not be limited")
+	}
+	if !l.allowAt("1.2.3.4", now.Add(sendTxLimitWindow)) {
+		t.Error("limit should be reset in the next window")
+	}
+}
+
+func TestSendTransaction(t *testing.T) {
+	_, chain := setupChain(t)
+	is := &common.InternalState{}
+	limiter := newSendTxLimiter(1)
+
+	txid, err := sendTransaction(chain, is, limiter, "1.2.3.4", "123456", false)
+	if err != nil || txid != "9876" {
+		t.Fatalf("sendTransaction = %v, %v, want 9876", txid, err)
+	}
+	if _, err = sendTransaction(chain, is, limiter, "1.2.3.4", "123456", false); err == nil || err.Error() != "Too many transactions sent, try again later" {
+		t.Errorf("sendTransaction over limit error = %v", err)
+	}
+	if _, err = sendTransaction(chain, is, limiter, "5.6.7.8", "1234", false); err == nil || err.Error() != "Invalid data" {
+		t.Errorf("sendTransaction invalid data error = %v", err)
+	}
+	is.DisableSendTx = true
+	if _, err = sendTransaction(chain, is, nil, "5.6.7.8", "123456", false); err == nil || err.Error() != "Transaction broadcasting is disabled" {
+		t.Errorf("sendTransaction disabled error = %v", err)
+	}
+}
+
+func TestGetIP(t *testing.T) {
+	r := &http.Request{RemoteAddr: "1.2.3.4:5678", Header: http.Header{}}
+	r.Header.Set("X-Real-Ip", "5.6.7.8")
+	if got := getIP(r, false); got != "1.2.3.4" {
+		t.Errorf("getIP without proxy = %v, want 1.2.3.4", got)
+	}
+	if got := getIP(r, true); got != "5.6.7.8" {
+		t.Errorf("getIP behind proxy = %v, want 5.6.7.8", got)
+	}
+	r.Header.Set("cf-connecting-ip", "9.9.9.9")
+	if got := getIP(r, true); got != "9.9.9.9" {
+		t.Errorf("getIP behind cloudflare = %v, want 9.9.9.9", got)
+	}
+	r = &http.Request{RemoteAddr: "[2001:db8::1]:5678", Header: http.Header{}}
+	if got := getIP(r, true); got != "2001:db8::1" {
+		t.Errorf("getIP ipv6 = %v, want 2001:db8::1", got)
+	}
+}
@@ -22,15 +22,16 @@ import (
 
 // SocketIoServer is handle to SocketIoServer
 type SocketIoServer struct {
-	server      *gosocketio.Server
-	db          *db.RocksDB
-	txCache     *db.TxCache
-	chain       bchain.BlockChain
-	chainParser bchain.BlockChainParser
-	mempool     bchain.Mempool
-	metrics     *common.Metrics
-	is          *common.InternalState
-	api         *api.Worker
+	server        *gosocketio.Server
+	db            *db.RocksDB
+	txCache       *db.TxCache
+	chain         bchain.BlockChain
+	chainParser   bchain.BlockChainParser
+	mempool       bchain.Mempool
+	metrics       *common.Metrics
+	is            *common.InternalState
+	api           *api.Worker
+	sendTxLimiter *sendTxLimiter
 }
 
 // NewSocketIoServer creates new SocketIo interface to blockbook and returns its handle
@@ -80,7 +81,14 @@ func NewSocketIoServer(db *db.RocksDB, chain bchain.BlockChain, mempool bchain.M
 
 // GetHandler returns socket.io http handler
 func (s *SocketIoServer) GetHandler() http.Handler {
-	return s.server
+	if s.is.TrustProxyHeaders {
+		return s.server
+	}
+	// socket.io library takes the client ip from the X-Forwarded-For header, which can be spoofed without a proxy
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(gosocketio.HeaderForward)
+		s.server.ServeHTTP(w, r)
+	})
 }
 
 type addrOpts struct {
@@ -91,60 +99,60 @@ type addrOpts struct {
 	To               int  `json:"to"`
 }
 
-var onMessageHandlers = map[string]func(*SocketIoServer, json.RawMessage) (interface{}, error){
-	"getAddressTxids": func(s *SocketIoServer, params json.RawMessage) (rv interface{}, err error) {
+var onMessageHandlers = map[string]func(*SocketIoServer, *gosocketio.Channel, json.RawMessage) (interface{}, error){
+	"getAddressTxids": func(s *SocketIoServer, c *gosocketio.Channel, params json.RawMessage) (rv interface{}, err error) {
 		addr, opts, err := unmarshalGetAddressRequest(params)
 		if err == nil {
 			rv, err = s.getAddressTxids(addr, &opts)
 		}
 		return
 	},
-	"getAddressHistory": func(s *SocketIoServer, params json.RawMessage) (rv interface{}, err error) {
+	"getAddressHistory": func(s *SocketIoServer, c *gosocketio.Channel, params json.RawMessage) (rv interface{}, err error) {
 		addr, opts, err := unmarshalGetAddressRequest(params)
 		if err == nil {
 			rv, err = s.getAddressHistory(addr, &opts)
 		}
 		return
 	},
-	"getBlockHeader": func(s *SocketIoServer, params json.RawMessage) (rv interface{}, err error) {
+	"getBlockHeader": func(s *SocketIoServer, c *gosocketio.Channel, params json.RawMessage) (rv interface{}, err error) {
 		height, hash, err := unmarshalGetBlockHeader(params)
 		if err == nil {
 			rv, err = s.getBlockHeader(height, hash)
 		}
 		return
 	},
-	"estimateSmartFee": func(s *SocketIoServer, params json.RawMessage) (rv interface{}, err error) {
+	"estimateSmartFee": func(s *SocketIoServer, c *gosocketio.Channel, params json.RawMessage) (rv interface{}, err error) {
 		blocks, conservative, err := unmarshalEstimateSmartFee(params)
 		if err == nil {
 			rv, err = s.estimateSmartFee(blocks, conservative)
 		}
 		return
 	},
-	"estimateFee": func(s *SocketIoServer, params json.RawMessage) (rv interface{}, err error) {
+	"estimateFee": func(s *SocketIoServer, c *gosocketio.Channel, params json.RawMessage) (rv interface{}, err error) {
 		blocks, err := unmarshalEstimateFee(params)
 		if err == nil {
 			rv, err = s.estimateFee(blocks)
 		}
 		return
 	},
-	"getInfo": func(s *SocketIoServer, params json.RawMessage) (rv interface{}, err error) {
+	"getInfo": func(s *SocketIoServer, c *gosocketio.Channel, params json.RawMessage) (rv interface{}, err error) {
 		return s.getInfo()
 	},
-	"getDetailedTransaction": func(s *SocketIoServer, params json.RawMessage) (rv interface{}, err error) {
+	"getDetailedTransaction": func(s *SocketIoServer, c *gosocketio.Channel, params json.RawMessage) (rv interface{}, err error) {
 		txid, err := unmarshalGetDetailedTransaction(params)
 		if err == nil {
 			rv, err = s.getDetailedTransaction(txid)
 		}
 		return
 	},
-	"sendTransaction": func(s *SocketIoServer, params json.RawMessage) (rv interface{}, err error) {
+	"sendTransaction": func(s *SocketIoServer, c *gosocketio.Channel, params json.RawMessage) (rv interface{}, err error) {
 		tx, err := unmarshalStringParameter(params)
 		if err == nil {
-			rv, err = s.sendTransaction(tx)
+			rv, err = s.sendTransaction(getSocketIoIP(c, s.is.TrustProxyHeaders), tx)
 		}
 		return
 	},
-	"getMempoolEntry": func(s *SocketIoServer, params json.RawMessage) (rv interface{}, err error) {
+	"getMempoolEntry": func(s *SocketIoServer, c *gosocketio.Channel, params json.RawMessage) (rv interface{}, err error) {
 		txid, err := unmarshalStringParameter(params)
 		if err == nil {
 			rv, err = s.getMempoolEntry(txid)
@@ -180,7 +188,7 @@ func (s *SocketIoServer) onMessage(c *gosocketio.Channel, req map[string]json.Ra
 	}()
 	f, ok := onMessageHandlers[method]
 	if ok {
-		rv, err = f(s, params)
+		rv, err = f(s, c, params)
 	} else {
 		err = errors.New("unknown method")
 	}
@@ -640,8 +648,8 @@ func (s *SocketIoServer) getDetailedTransaction(txid string) (res resultGetDetai
 	return
 }
 
-func (s *SocketIoServer) sendTransaction(tx string) (res resultSendTransaction, err error) {
-	txid, err := s.chain.SendRawTransaction(tx, false)
+func (s *SocketIoServer) sendTransaction(ip string, tx string) (res resultSendTransaction, err error) {
+	txid, err := sendTransaction(s.chain, s.is, s.sendTxLimiter, ip, tx, false)
 	if err != nil {
 		return res, err
 	}
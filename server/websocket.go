@@ -2,7 +2,9 @@ package server
 
 import (
 	"encoding/json"
+	"fmt"
 	"math/big"
+	"net"
 	"net/http"
 	"os"
 	"runtime/debug"
@@ -63,6 +65,8 @@ type WebsocketServer struct {
 	block0hash                      string
 	newBlockSubscriptions           map[*websocketChannel]string
 	newBlockSubscriptionsLock       sync.Mutex
+	reorgSubscriptions              map[*websocketChannel]string
+	reorgSubscriptionsLock          sync.Mutex
 	newTransactionEnabled           bool
 	newTransactionSubscriptions     map[*websocketChannel]string
 	newTransactionSubscriptionsLock sync.Mutex
@@ -71,7 +75,25 @@ type WebsocketServer struct {
 	fiatRatesSubscriptions          map[string]map[*websocketChannel]string
 	fiatRatesTokenSubscriptions     map[*websocketChannel][]string
 	fiatRatesSubscriptionsLock      sync.Mutex
+	stealthSubscriptions            map[*websocketChannel]*stealthSubscription
+	stealthSubscriptionsLock        sync.Mutex
 	allowedRpcCallTo                map[string]struct{}
+	sendTxLimiter                   *sendTxLimiter
+}
+
+// stealthSubscription contains the scan keys of the stealth addresses subscribed by a channel
+type stealthSubscription struct {
+	id   string
+	keys []*api.StealthScanKey
+}
+
+// maxStealthSubscriptionKeys is the maximum number of stealth addresses subscribed by one channel
+const maxStealthSubscriptionKeys = 10
+
+// hiddenParamsMethods contain secrets in the parameters, which must not be logged
+var hiddenParamsMethods = map[string]struct{}{
+	"scanStealthOutputs":      {},
+	"subscribeStealthOutputs": {},
 }
 
 // NewWebsocketServer creates new websocket interface to blockbook and returns its handle
@@ -101,11 +123,13 @@ func NewWebsocketServer(db *db.RocksDB, chain bchain.BlockChain, mempool bchain.
 		api:                         api,
 		block0hash:                  b0,
 		newBlockSubscriptions:       make(map[*websocketChannel]string),
+		reorgSubscriptions:          make(map[*websocketChannel]string),
 		newTransactionEnabled:       is.EnableSubNewTx,
 		newTransactionSubscriptions: make(map[*websocketChannel]string),
 		addressSubscriptions:        make(map[string]map[*websocketChannel]string),
 		fiatRatesSubscriptions:      make(map[string]map[*websocketChannel]string),
 		fiatRatesTokenSubscriptions: make(map[*websocketChannel][]string),
+		stealthSubscriptions:        make(map[*websocketChannel]*stealthSubscription),
 	}
 	envRpcCall := os.Getenv(strings.ToUpper(is.GetNetwork()) + "_ALLOWED_RPC_CALL_TO")
 	if envRpcCall != "" {
@@ -123,16 +147,31 @@ func checkOrigin(r *http.Request) bool {
 	return true
 }
 
-func getIP(r *http.Request) string {
-	ip := r.Header.Get("cf-connecting-ip")
-	if ip != "" {
-		return ip
+// getIP returns the ip address of the client without the port,
+// the headers set by a reverse proxy are honored only if trustProxy is set
+func getIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if ip := getProxyIP(r.Header); ip != "" {
+			return ip
+		}
 	}
-	ip = r.Header.Get("X-Real-Ip")
+	return stripPort(r.RemoteAddr)
+}
+
+func getProxyIP(h http.Header) string {
+	ip := h.Get("cf-connecting-ip")
 	if ip != "" {
 		return ip
 	}
-	return r.RemoteAddr
+	return h.Get("X-Real-Ip")
+}
+
+func stripPort(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
 }
 
 // ServeHTTP sets up handler of websocket channel
@@ -150,7 +189,7 @@ func (s *WebsocketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		id:            atomic.AddUint64(&connectionCounter, 1),
 		conn:          conn,
 		out:           make(chan *WsRes, outChannelSize),
-		ip:            getIP(r),
+		ip:            getIP(r, s.is.TrustProxyHeaders),
 		requestHeader: r.Header,
 		alive:         true,
 	}
@@ -269,9 +308,11 @@ func (s *WebsocketServer) onConnect(c *websocketChannel) {
 
 func (s *WebsocketServer) onDisconnect(c *websocketChannel) {
 	s.unsubscribeNewBlock(c)
+	s.unsubscribeReorgs(c)
 	s.unsubscribeNewTransaction(c)
 	s.unsubscribeAddresses(c)
 	s.unsubscribeFiatRates(c)
+	s.unsubscribeStealthOutputs(c)
 	glog.Info("Client disconnected ", c.id, ", ", c.ip)
 	s.metrics.WebsocketClients.Dec()
 }
@@ -350,6 +391,36 @@ var requestHandlers = map[string]func(*WebsocketServer, *websocketChannel, *WsRe
 		}
 		return
 	},
+	"getColdStaking": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
+		r := WsColdStakingReq{}
+		err = json.Unmarshal(req.Params, &r)
+		if err == nil {
+			if r.PageSize <= 0 || r.PageSize > txsInAPI {
+				r.PageSize = txsInAPI
+			}
+			rv, err = s.api.GetColdStaking(r.Descriptor, r.Page, r.PageSize)
+		}
+		return
+	},
+	"getKeyImages": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
+		r := WsKeyImagesReq{}
+		err = json.Unmarshal(req.Params, &r)
+		if err == nil {
+			rv, err = s.api.GetKeyImages(r.KeyImages)
+		}
+		return
+	},
+	"scanStealthOutputs": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
+		r := WsStealthScanReq{}
+		err = json.Unmarshal(req.Params, &r)
+		if err == nil {
+			if r.ToHeight <= 0 {
+				r.ToHeight = -1
+			}
+			rv, err = s.api.GetStealthScan(r.Address, r.ScanSecret, r.FromHeight, r.ToHeight)
+		}
+		return
+	},
 	"getTransaction": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
 		r := WsTransactionReq{}
 		err = json.Unmarshal(req.Params, &r)
@@ -376,7 +447,7 @@ var requestHandlers = map[string]func(*WebsocketServer, *websocketChannel, *WsRe
 		r := WsSendTransactionReq{}
 		err = json.Unmarshal(req.Params, &r)
 		if err == nil {
-			rv, err = s.sendTransaction(r.Hex, r.DisableAlternativeRPC)
+			rv, err = s.sendTransaction(c, r.Hex, r.DisableAlternativeRPC)
 		}
 		return
 	},
@@ -419,6 +490,12 @@ var requestHandlers = map[string]func(*WebsocketServer, *websocketChannel, *WsRe
 	"unsubscribeNewBlock": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
 		return s.unsubscribeNewBlock(c)
 	},
+	"subscribeReorgs": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
+		return s.subscribeReorgs(c, req)
+	},
+	"unsubscribeReorgs": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
+		return s.unsubscribeReorgs(c)
+	},
 	"subscribeNewTransaction": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
 		return s.subscribeNewTransaction(c, req)
 	},
@@ -450,6 +527,17 @@ var requestHandlers = map[string]func(*WebsocketServer, *websocketChannel, *WsRe
 	"unsubscribeFiatRates": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
 		return s.unsubscribeFiatRates(c)
 	},
+	"subscribeStealthOutputs": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
+		var r WsSubscribeStealthOutputsReq
+		err = json.Unmarshal(req.Params, &r)
+		if err != nil {
+			return nil, err
+		}
+		return s.subscribeStealthOutputs(c, &r, req)
+	},
+	"unsubscribeStealthOutputs": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
+		return s.unsubscribeStealthOutputs(c)
+	},
 	"ping": func(s *WebsocketServer, c *websocketChannel, req *WsReq) (rv interface{}, err error) {
 		r := struct{}{}
 		return r, nil
@@ -513,7 +601,11 @@ func (s *WebsocketServer) onRequest(c *websocketChannel, req *WsReq) {
 			s.metrics.WebsocketRequests.With(common.Labels{"method": req.Method, "status": "success"}).Inc()
 		} else {
 			if apiErr, ok := err.(*api.APIError); !ok || !apiErr.Public {
-				glog.Error("Client ", c.id, " onMessage ", req.Method, ": ", errors.ErrorStack(err), ", data ", string(req.Params))
+				params := string(req.Params)
+				if _, hidden := hiddenParamsMethods[req.Method]; hidden {
+					params = "<hidden>"
+				}
+				glog.Error("Client ", c.id, " onMessage ", req.Method, ": ", errors.ErrorStack(err), ", data ", params)
 			}
 			s.metrics.WebsocketRequests.With(common.Labels{"method": req.Method, "status": "failure"}).Inc()
 			e := resultError{}
@@ -724,7 +816,43 @@ func (s *WebsocketServer) estimateFee(params []byte) (interface{}, error) {
 				txSize = int(f)
 			}
 		}
+		// Particl estimation of the fee of plain, blind or anon transaction by the number of inputs, outputs and the ring size
+		txType := ""
+		v, ok = r.Specific["txtype"]
+		if ok {
+			txType, _ = v.(string)
+		}
+		var txParts [3]int
+		for j, name := range []string{"inputs", "outputs", "ringsize"} {
+			v, ok = r.Specific[name]
+			if ok {
+				f, ok := v.(float64)
+				if ok {
+					txParts[j] = int(f)
+					if txType == "" {
+						txType = api.TxTypePlain
+					}
+				}
+			}
+		}
 		for i, b := range r.Blocks {
+			if txType != "" {
+				est, err := s.api.EstimateTxFees(b, conservative, txParts[0], txParts[1], txParts[2])
+				if err != nil {
+					return nil, err
+				}
+				res[i].FeePerUnit = est.FeePerUnit.String()
+				res[i].TxFees = est.Fees
+				for j := range est.Fees {
+					if est.Fees[j].Type == txType {
+						res[i].FeePerTx = est.Fees[j].FeeSat.String()
+					}
+				}
+				if res[i].FeePerTx == "" {
+					return nil, api.NewAPIError("Invalid txtype "+txType, true)
+				}
+				continue
+			}
 			fee, err := s.api.EstimateFee(b, conservative)
 			if err != nil {
 				return nil, err
@@ -752,8 +880,8 @@ func (s *WebsocketServer) longTermFeeRate() (res interface{}, err error) {
 	}, nil
 }
 
-func (s *WebsocketServer) sendTransaction(tx string, disableAlternativeRPC bool) (res resultSendTransaction, err error) {
-	txid, err := s.chain.SendRawTransaction(tx, disableAlternativeRPC)
+func (s *WebsocketServer) sendTransaction(c *websocketChannel, tx string, disableAlternativeRPC bool) (res resultSendTransaction, err error) {
+	txid, err := sendTransaction(s.chain, s.is, s.sendTxLimiter, c.ip, tx, disableAlternativeRPC)
 	if err != nil {
 		return res, err
 	}
@@ -862,6 +990,22 @@ func (s *WebsocketServer) unsubscribeNewBlock(c *websocketChannel) (res interfac
 	return &subscriptionResponse{false}, nil
 }
 
+func (s *WebsocketServer) subscribeReorgs(c *websocketChannel, req *WsReq) (res interface{}, err error) {
+	s.reorgSubscriptionsLock.Lock()
+	defer s.reorgSubscriptionsLock.Unlock()
+	s.reorgSubscriptions[c] = req.ID
+	s.metrics.WebsocketSubscribes.With((common.Labels{"method": "subscribeReorgs"})).Set(float64(len(s.reorgSubscriptions)))
+	return &subscriptionResponse{true}, nil
+}
+
+func (s *WebsocketServer) unsubscribeReorgs(c *websocketChannel) (res interface{}, err error) {
+	s.reorgSubscriptionsLock.Lock()
+	defer s.reorgSubscriptionsLock.Unlock()
+	delete(s.reorgSubscriptions, c)
+	s.metrics.WebsocketSubscribes.With((common.Labels{"method": "subscribeReorgs"})).Set(float64(len(s.reorgSubscriptions)))
+	return &subscriptionResponse{false}, nil
+}
+
 func (s *WebsocketServer) subscribeNewTransaction(c *websocketChannel, req *WsReq) (res interface{}, err error) {
 	s.newTransactionSubscriptionsLock.Lock()
 	defer s.newTransactionSubscriptionsLock.Unlock()
@@ -995,6 +1139,71 @@ func (s *WebsocketServer) unsubscribeFiatRates(c *websocketChannel) (res interfa
 	return &subscriptionResponse{false}, nil
 }
 
+func (s *WebsocketServer) subscribeStealthOutputs(c *websocketChannel, r *WsSubscribeStealthOutputsReq, req *WsReq) (res interface{}, err error) {
+	if len(r.Keys) == 0 || len(r.Keys) > maxStealthSubscriptionKeys {
+		return nil, api.NewAPIError(fmt.Sprintf("Number of stealth addresses must be between 1 and %d", maxStealthSubscriptionKeys), true)
+	}
+	keys := make([]*api.StealthScanKey, len(r.Keys))
+	for i := range r.Keys {
+		if keys[i], err = s.api.NewStealthScanKey(r.Keys[i].Address, r.Keys[i].ScanSecret); err != nil {
+			return nil, err
+		}
+	}
+	s.stealthSubscriptionsLock.Lock()
+	defer s.stealthSubscriptionsLock.Unlock()
+	// replaces the previous subscription of the channel
+	s.stealthSubscriptions[c] = &stealthSubscription{id: req.ID, keys: keys}
+	s.metrics.WebsocketSubscribes.With((common.Labels{"method": "subscribeStealthOutputs"})).Set(float64(len(s.stealthSubscriptions)))
+	return &subscriptionResponse{true}, nil
+}
+
+func (s *WebsocketServer) unsubscribeStealthOutputs(c *websocketChannel) (res interface{}, err error) {
+	s.stealthSubscriptionsLock.Lock()
+	defer s.stealthSubscriptionsLock.Unlock()
+	delete(s.stealthSubscriptions, c)
+	s.metrics.WebsocketSubscribes.With((common.Labels{"method": "subscribeStealthOutputs"})).Set(float64(len(s.stealthSubscriptions)))
+	return &subscriptionResponse{false}, nil
+}
+
+// sendOnNewBlockStealthOutputs scans the new block for the subscribed stealth addresses and sends the found outputs
+func (s *WebsocketServer) sendOnNewBlockStealthOutputs(height uint32) {
+	s.stealthSubscriptionsLock.Lock()
+	defer s.stealthSubscriptionsLock.Unlock()
+	if len(s.stealthSubscriptions) == 0 {
+		return
+	}
+	// all subscribed keys are matched in one pass over the outputs of the block
+	channels := make([]*websocketChannel, 0, len(s.stealthSubscriptions))
+	var keys []*api.StealthScanKey
+	for c, sub := range s.stealthSubscriptions {
+		channels = append(channels, c)
+		keys = append(keys, sub.keys...)
+	}
+	outputs, err := s.api.ScanStealthOutputs(keys, height, height)
+	if err != nil {
+		glog.Error("ScanStealthOutputs block ", height, ": ", err)
+		return
+	}
+	i := 0
+	for _, c := range channels {
+		sub := s.stealthSubscriptions[c]
+		for _, k := range sub.keys {
+			if len(outputs[i]) > 0 {
+				c.DataOut(&WsRes{
+					ID: sub.id,
+					Data: &api.StealthScan{
+						Address:    k.Address,
+						FromHeight: int(height),
+						ToHeight:   int(height),
+						Outputs:    outputs[i],
+					},
+				})
+			}
+			i++
+		}
+	}
+}
+
 func (s *WebsocketServer) onNewBlockAsync(hash string, height uint32) {
 	s.newBlockSubscriptionsLock.Lock()
 	defer s.newBlockSubscriptionsLock.Unlock()
@@ -1017,6 +1226,71 @@ func (s *WebsocketServer) onNewBlockAsync(hash string, height uint32) {
 // OnNewBlock is a callback that broadcasts info about new block to subscribed clients
 func (s *WebsocketServer) OnNewBlock(hash string, height uint32) {
 	go s.onNewBlockAsync(hash, height)
+	go s.sendOnNewBlockStealthOutputs(height)
+}
+
+func (s *WebsocketServer) onReorgAsync(reorg *api.Reorg, addrTxids map[string][]string) {
+	// the disconnected transactions of the addresses the channel is subscribed to
+	channelAddresses := make(map[*websocketChannel]map[string][]string)
+	channelAddressesID := make(map[*websocketChannel]string)
+	s.addressSubscriptionsLock.Lock()
+	for stringAddressDescriptor, txids := range addrTxids {
+		as, ok := s.addressSubscriptions[stringAddressDescriptor]
+		if !ok || len(as) == 0 {
+			continue
+		}
+		addr, _, err := s.chainParser.GetAddressesFromAddrDesc(bchain.AddressDescriptor(stringAddressDescriptor))
+		if err != nil || len(addr) != 1 {
+			continue
+		}
+		for c, id := range as {
+			if channelAddresses[c] == nil {
+				channelAddresses[c] = make(map[string][]string)
+			}
+			channelAddresses[c][addr[0]] = txids
+			channelAddressesID[c] = id
+		}
+	}
+	s.addressSubscriptionsLock.Unlock()
+	// the subscribers of the affected addresses get the reorg under the ID of the address subscription
+	for c, id := range channelAddressesID {
+		data := struct {
+			Reorg     *api.Reorg          `json:"reorg"`
+			Addresses map[string][]string `json:"addresses"`
+		}{
+			Reorg:     reorg,
+			Addresses: channelAddresses[c],
+		}
+		c.DataOut(&WsRes{
+			ID:   id,
+			Data: &data,
+		})
+	}
+	if len(channelAddressesID) > 0 {
+		glog.Info("broadcasting reorg ", reorg.ID, " to ", len(channelAddressesID), " address channels")
+	}
+	s.reorgSubscriptionsLock.Lock()
+	defer s.reorgSubscriptionsLock.Unlock()
+	for c, id := range s.reorgSubscriptions {
+		data := struct {
+			*api.Reorg
+			Addresses map[string][]string `json:"addresses,omitempty"`
+		}{
+			Reorg:     reorg,
+			Addresses: channelAddresses[c],
+		}
+		c.DataOut(&WsRes{
+			ID:   id,
+			Data: &data,
+		})
+	}
+	glog.Info("broadcasting reorg ", reorg.ID, " to ", len(s.reorgSubscriptions), " channels")
+}
+
+// OnReorg is a callback that broadcasts info about a reorg of the chain to the clients subscribed to reorgs
+// and to the clients subscribed to the addresses with disconnected transactions
+func (s *WebsocketServer) OnReorg(reorg *db.Reorg, addrTxids map[string][]string) {
+	go s.onReorgAsync(api.NewReorg(reorg), addrTxids)
 }
 
 func (s *WebsocketServer) sendOnNewTx(tx *api.Tx) {
@@ -0,0 +1,69 @@
+//go:build unittest
+
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/trezor/blockbook/api"
+	"github.com/trezor/blockbook/bchain/coins/btc"
+)
+
+func TestWebsocketServer_onReorgAsync(t *testing.T) {
+	parser := btc.NewBitcoinParser(btc.GetChainParams("main"), &btc.Configuration{})
+	addrDesc, err := parser.GetAddrDescFromAddress("1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	newChannel := func() *websocketChannel {
+		return &websocketChannel{out: make(chan *WsRes, outChannelSize), alive: true}
+	}
+	reorgAndAddress, reorgOnly, addressOnly := newChannel(), newChannel(), newChannel()
+	s := &WebsocketServer{
+		chainParser: parser,
+		reorgSubscriptions: map[*websocketChannel]string{
+			reorgAndAddress: "reorgs1",
+			reorgOnly:       "reorgs2",
+		},
+		addressSubscriptions: map[string]map[*websocketChannel]string{
+			string(addrDesc): {
+				reorgAndAddress: "addresses1",
+				addressOnly:     "addresses3",
+			},
+		},
+	}
+	s.onReorgAsync(&api.Reorg{ID: 1, Txids: []string{"tx1", "tx2"}}, map[string][]string{string(addrDesc): {"tx1"}})
+
+	reorg := `{"id":1,"time":0,"depth":0,"forkHeight":0,"oldHeight":0,"oldHash":"","newHeight":0,"newHash":"","txids":["tx1","tx2"]`
+	tests := []struct {
+		name string
+		c    *websocketChannel
+		want []string
+	}{
+		{name: "reorg and address", c: reorgAndAddress, want: []string{
+			`{"id":"addresses1","data":{"reorg":` + reorg + `},"addresses":{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2":["tx1"]}}}`,
+			`{"id":"reorgs1","data":` + reorg + `,"addresses":{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2":["tx1"]}}}`,
+		}},
+		{name: "reorg only", c: reorgOnly, want: []string{`{"id":"reorgs2","data":` + reorg + `}}`}},
+		{name: "address only", c: addressOnly, want: []string{
+			`{"id":"addresses3","data":{"reorg":` + reorg + `},"addresses":{"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2":["tx1"]}}}`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for len(tt.c.out) > 0 {
+				b, err := json.Marshal(<-tt.c.out)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, string(b))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("onReorgAsync() messages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,7 +9,7 @@ import (
 // WsReq represents a generic WebSocket request with an ID, method, and raw parameters.
 type WsReq struct {
 	ID     string          `json:"id" ts_doc:"Unique request identifier."`
-	Method string          `json:"method" ts_type:"'getAccountInfo' | 'getInfo' | 'getBlockHash'| 'getBlock' | 'getAccountUtxo' | 'getBalanceHistory' | 'getTransaction' | 'getTransactionSpecific' | 'estimateFee' | 'sendTransaction' | 'subscribeNewBlock' | 'unsubscribeNewBlock' | 'subscribeNewTransaction' | 'unsubscribeNewTransaction' | 'subscribeAddresses' | 'unsubscribeAddresses' | 'subscribeFiatRates' | 'unsubscribeFiatRates' | 'ping' | 'getCurrentFiatRates' | 'getFiatRatesForTimestamps' | 'getFiatRatesTickersList' | 'getMempoolFilters'" ts_doc:"Requested method name."`
+	Method string          `json:"method" ts_type:"'getAccountInfo' | 'getInfo' | 'getBlockHash'| 'getBlock' | 'getAccountUtxo' | 'getBalanceHistory' | 'getColdStaking' | 'getKeyImages' | 'scanStealthOutputs' | 'getTransaction' | 'getTransactionSpecific' | 'estimateFee' | 'sendTransaction' | 'subscribeNewBlock' | 'unsubscribeNewBlock' | 'subscribeNewTransaction' | 'unsubscribeNewTransaction' | 'subscribeAddresses' | 'unsubscribeAddresses' | 'subscribeFiatRates' | 'unsubscribeFiatRates' | 'subscribeStealthOutputs' | 'unsubscribeStealthOutputs' | 'ping' | 'getCurrentFiatRates' | 'getFiatRatesForTimestamps' | 'getFiatRatesTickersList' | 'getMempoolFilters'" ts_doc:"Requested method name."`
 	Params json.RawMessage `json:"params" ts_type:"any" ts_doc:"Parameters for the requested method in raw JSON format."`
 }
 
@@ -87,6 +87,26 @@ type WsBalanceHistoryReq struct {
 	GroupBy    uint32   `json:"groupBy,omitempty" ts_doc:"Size of each aggregated time window in seconds."`
 }
 
+// WsColdStakingReq requests the outputs delegated to a Particl cold staking address.
+type WsColdStakingReq struct {
+	Descriptor string `json:"descriptor" ts_doc:"Cold staking address to query."`
+	Page       int    `json:"page,omitempty" ts_doc:"Requested page of the delegation history."`
+	PageSize   int    `json:"pageSize,omitempty" ts_doc:"Number of delegation history items per page."`
+}
+
+// WsKeyImagesReq requests the spent state of Particl RingCT key images.
+type WsKeyImagesReq struct {
+	KeyImages []string `json:"keyImages" ts_doc:"List of key images (hex) to check."`
+}
+
+// WsStealthScanReq requests the scan for outputs paid to a Particl stealth address in a range of blocks.
+type WsStealthScanReq struct {
+	Address    string `json:"address" ts_doc:"Stealth address to scan for."`
+	ScanSecret string `json:"scanSecret" ts_doc:"Scan secret key of the stealth address (hex)."`
+	FromHeight int    `json:"from" ts_doc:"First block height of the scan."`
+	ToHeight   int    `json:"to,omitempty" ts_doc:"Last block height of the scan, the best block if not specified."`
+}
+
 // WsTransactionReq requests details for a specific transaction by its txid.
 type WsTransactionReq struct {
 	Txid string `json:"txid" ts_doc:"Transaction ID to retrieve details for."`
@@ -122,7 +142,7 @@ type WsTransactionSpecificReq struct {
 // WsEstimateFeeReq requests an estimation of transaction fees for a set of blocks or with specific parameters.
 type WsEstimateFeeReq struct {
 	Blocks   []int                  `json:"blocks,omitempty" ts_doc:"Block confirmations targets for which fees should be estimated."`
-	Specific map[string]interface{} `json:"specific,omitempty" ts_type:"{conservative?: boolean; txsize?: number; from?: string; to?: string; data?: string; value?: string;}" ts_doc:"Additional chain-specific parameters (e.g. for Ethereum)."`
+	Specific map[string]interface{} `json:"specific,omitempty" ts_type:"{conservative?: boolean; txsize?: number; txtype?: 'plain' | 'blind' | 'anon'; inputs?: number; outputs?: number; ringsize?: number; from?: string; to?: string; data?: string; value?: string;}" ts_doc:"Additional chain-specific parameters (e.g. for Ethereum)."`
 }
 
 // WsEstimateFeeRes is returned in response to a fee estimation request.
@@ -131,6 +151,7 @@ type WsEstimateFeeRes struct {
 	FeePerUnit string           `json:"feePerUnit,omitempty" ts_doc:"Estimated fee per unit (sat/byte, Wei/gas, etc.)."`
 	FeeLimit   string           `json:"feeLimit,omitempty" ts_doc:"Max fee limit for blockchains like Ethereum."`
 	Eip1559    *api.Eip1559Fees `json:"eip1559,omitempty"`
+	TxFees     []api.TxTypeFee  `json:"txFees,omitempty" ts_doc:"Estimated fees of Particl plain, blind and anon transactions, if txtype, inputs, outputs or ringsize is specified."`
 }
 
 // WsLongTermFeeRateRes is returned in response to a long term fee rate request.
@@ -150,6 +171,17 @@ type WsSubscribeAddressesReq struct {
 	Addresses []string `json:"addresses" ts_doc:"List of addresses to subscribe for updates (e.g., new transactions)."`
 }
 
+// WsStealthKey is a stealth address with its scan secret key.
+type WsStealthKey struct {
+	Address    string `json:"address" ts_doc:"Stealth address to scan for."`
+	ScanSecret string `json:"scanSecret" ts_doc:"Scan secret key of the stealth address (hex)."`
+}
+
+// WsSubscribeStealthOutputsReq subscribes to outputs paid to Particl stealth addresses in new blocks.
+type WsSubscribeStealthOutputsReq struct {
+	Keys []WsStealthKey `json:"keys" ts_doc:"Stealth addresses with their scan secret keys."`
+}
+
 // WsSubscribeFiatRatesReq subscribes to updates of fiat rates for a specific currency or set of tokens.
 type WsSubscribeFiatRatesReq struct {
 	Currency string   `json:"currency,omitempty" ts_doc:"Fiat currency code (e.g. 'USD')."`
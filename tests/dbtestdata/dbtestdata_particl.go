@@ -0,0 +1,196 @@
+package dbtestdata
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"strings"
+
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/bchain/coins/part"
+)
+
+// Particl fixture chain of blocks 500-503 and the block 503R replacing the block 503 in a reorg.
+// Block 500 funds a cold staking output and two standard addresses, the following blocks contain a coinstake
+// and a privacy transaction: plain to blind (501), blind to anon (502) and anon to plain with anon change (503).
+// The privacy transactions pay the CT fee 215200 stored in the data output, their blind (501), anon (502)
+// and standard (503) outputs are paid to stealth addresses. The coinstakes of the blocks 501 and 502 vote
+// for the proposal 7, the coinstake 501 pays the treasury fund and the coinstake 502 carries forward its amount.
+const (
+	TxidPartB500T1 = "a500000000000000000000000000000000000000000000000000000000000001"
+	TxidPartB501T1 = "a501000000000000000000000000000000000000000000000000000000000001"
+	TxidPartB501T2 = "a501000000000000000000000000000000000000000000000000000000000002"
+	TxidPartB502T1 = "a502000000000000000000000000000000000000000000000000000000000001"
+	TxidPartB502T2 = "a502000000000000000000000000000000000000000000000000000000000002"
+	TxidPartB503T1 = "a503000000000000000000000000000000000000000000000000000000000001"
+	TxidPartB503T2 = "a503000000000000000000000000000000000000000000000000000000000002"
+	TxidPartB503R1 = "b503000000000000000000000000000000000000000000000000000000000001"
+
+	AddrPartOwner         = "PmARRjwsRMZUwRdt6vaqNrWR9ZpxhoGB6P"                  // 76a914912e2b234f941f30b18afbb4fa46171214bf66c888ac, spend part of the cold staking script
+	AddrPartStaker        = "2vjzfpCeiohkBtg3gcQJFLFaV6Yjqehw6Q4SwiKqRwKWfut2zPD" // 76a8207be3f09c8d809bc6fa2ced97e35c65d813f29129645bfb45fa3362d28d46123188ac, staking part of the cold staking script
+	AddrPartA             = "Po3VBGWztKbFnU9rFGKNx2Rtg1zWoS4zTR"                  // 76a914a5cea39a684776fa5d6782faf02baf04251b53bc88ac
+	AddrPartB             = "PqkTVMj7edjcS94MZ5peu4USmcForGJoLa"                  // 76a914c37e8931bcdcf42c89b3a790e2ba0aaf399938cd88ac
+	AddrPartC             = "PoE8J4PYQ6vR6ZKA8imo4HFcKuqUUHuacv"                  // 76a914a7d1c8503c9e465e575fb4e050adec36c8ee010588ac
+	TreasuryAddrPart      = "RJAPhgckEgRGVPZa9WoGSWW24spskSfLTQ"                  // the first treasury fund address of the main chain
+	ColdStakingPartScript = "b86376a914912e2b234f941f30b18afbb4fa46171214bf66c888ac6776a8207be3f09c8d809bc6fa2ced97e35c65d813f29129645bfb45fa3362d28d46123188ac68"
+
+	// CTFeePartData is the data output with the CT fee 215200
+	CTFeePartData       = "06a0910d"
+	BlindPartCommitment = "08eeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeeee"
+	AnonPartCommitment  = "09dddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddddd"
+	AnonPartPubKey      = "02aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	// the anon change output of the block 503 keeps the rest of the spent anon value hidden
+	AnonPartChangeCommitment = "08cccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccccc"
+	AnonPartChangePubKey     = "02bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	KeyImagePart             = "021111111111111111111111111111111111111111111111111111111111111111"
+	// the ephemeral public keys of the outputs paid to stealth addresses in the blocks 501, 502 and 503
+	StealthPartBlindEphem = "022f8bde4d1a07209355b4a7250a5c5128e88b84bddc619ab7cba8d569b240efe4"
+	StealthPartAnonEphem  = "025cbdf0646e5db4eaa398f365f2ea7a0e3d419b7e0330e39ce92bddedcac4f9bc"
+	StealthPartEphem      = "02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9"
+
+	// VoteProposalPart is the proposal voted for by the coinstakes 501 (option 1) and 502 (option 2)
+	VoteProposalPart = 7
+	// TreasuryCfwdPart is the treasury fund amount carried forward by the coinstake 502
+	TreasuryCfwdPart = 100
+
+	// version of Particl coinstake transaction, type 2 in the second byte
+	coinStakePartVersion = 0x2a0
+	blockPartTime        = 1650000000
+)
+
+// RangeProofPart is the range proof of the blind output, with the header of proof of 32 bit mantissa, exponent 2
+// and zero min value and the length of the Borromean proof of 16 rings of 4 members
+var RangeProofPart = "621f" + strings.Repeat("00", 8+2562)
+
+// Amounts in satoshis
+var (
+	SatPartCTFee       = big.NewInt(215200)
+	SatPartB500Cold    = big.NewInt(10000000000)
+	SatPartB500A       = big.NewInt(5000000000)
+	SatPartB500B       = big.NewInt(2000000000)
+	SatPartB501Cold    = big.NewInt(10050000000)
+	SatPartB501Fund    = big.NewInt(10000000)
+	SatPartB501AChange = big.NewInt(3000000000)
+	SatPartB502B       = big.NewInt(2030000000)
+	SatPartB503Cold    = big.NewInt(10090000000)
+	SatPartB503C       = big.NewInt(500000000)
+	SatPartB503RB      = big.NewInt(2050000000)
+)
+
+func particlBlock(height uint32, hash string, txs []bchain.Tx) *bchain.Block {
+	t := int64(blockPartTime + (height-500)*120)
+	for i := range txs {
+		txs[i].Blocktime = t
+		txs[i].Time = t
+		txs[i].BlockHeight = height
+	}
+	return &bchain.Block{
+		BlockHeader: bchain.BlockHeader{
+			Height: height,
+			Hash:   hash,
+			Time:   t,
+		},
+		Txs: txs,
+	}
+}
+
+// particlCoinstake returns coinstake transaction spending the kernel output and paying value back to the script,
+// data is the hex of the data output and the other outputs are appended
+func particlCoinstake(txid string, kernelTxid string, kernelVout uint32, value *big.Int, script string, data string, other ...bchain.Vout) bchain.Tx {
+	tx := bchain.Tx{
+		Txid:    txid,
+		Version: coinStakePartVersion,
+		Vin:     []bchain.Vin{{Txid: kernelTxid, Vout: kernelVout}},
+		Vout: []bchain.Vout{
+			{N: 0, OutputType: "data", Data: data},
+			{N: 1, OutputType: "standard", ValueSat: *value, ScriptPubKey: bchain.ScriptPubKey{Hex: script}},
+		},
+	}
+	for i := range other {
+		other[i].N = uint32(len(tx.Vout))
+		tx.Vout = append(tx.Vout, other[i])
+	}
+	return tx
+}
+
+// particlCoinstakeData returns the hex of the coinstake data output of the block with the vote for the option
+// of the proposal VoteProposalPart and the carried forward treasury fund amount, which are omitted if zero
+func particlCoinstakeData(height uint32, option uint16, cfwd byte) string {
+	data := make([]byte, 4, 11)
+	binary.LittleEndian.PutUint32(data, height)
+	if option != 0 {
+		data = append(data, part.DOVote, 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(data[5:], uint32(option)<<16|VoteProposalPart)
+	}
+	if cfwd != 0 {
+		data = append(data, part.DODevFundCfwd, cfwd)
+	}
+	return hex.EncodeToString(data)
+}
+
+// GetTestParticlTypeBlocks returns the blocks 500-503 of the Particl fixture chain
+func GetTestParticlTypeBlocks(parser bchain.BlockChainParser) []*bchain.Block {
+	return []*bchain.Block{
+		particlBlock(500, "0000000000000000000000000000000000000000000000000000000000000500", []bchain.Tx{
+			{
+				Txid: TxidPartB500T1,
+				Vin:  []bchain.Vin{{Coinbase: "01f4"}},
+				Vout: []bchain.Vout{
+					{N: 0, OutputType: "standard", ValueSat: *SatPartB500Cold, ScriptPubKey: bchain.ScriptPubKey{Hex: ColdStakingPartScript}},
+					{N: 1, OutputType: "standard", ValueSat: *SatPartB500A, ScriptPubKey: bchain.ScriptPubKey{Hex: AddressToPubKeyHex(AddrPartA, parser)}},
+					{N: 2, OutputType: "standard", ValueSat: *SatPartB500B, ScriptPubKey: bchain.ScriptPubKey{Hex: AddressToPubKeyHex(AddrPartB, parser)}},
+				},
+			},
+		}),
+		particlBlock(501, "0000000000000000000000000000000000000000000000000000000000000501", []bchain.Tx{
+			particlCoinstake(TxidPartB501T1, TxidPartB500T1, 0, SatPartB501Cold, ColdStakingPartScript, particlCoinstakeData(501, 1, 0),
+				bchain.Vout{OutputType: "standard", ValueSat: *SatPartB501Fund, ScriptPubKey: bchain.ScriptPubKey{Hex: AddressToPubKeyHex(TreasuryAddrPart, parser)}}),
+			{
+				Txid: TxidPartB501T2,
+				Vin:  []bchain.Vin{{Txid: TxidPartB500T1, Vout: 1}},
+				Vout: []bchain.Vout{
+					{N: 0, OutputType: "data", Data: CTFeePartData},
+					{N: 1, OutputType: "blind", Data: StealthPartBlindEphem, ValueCommitment: BlindPartCommitment, RangeProof: RangeProofPart,
+						ScriptPubKey: bchain.ScriptPubKey{Hex: AddressToPubKeyHex(AddrPartB, parser)}},
+					{N: 2, OutputType: "standard", ValueSat: *SatPartB501AChange, ScriptPubKey: bchain.ScriptPubKey{Hex: AddressToPubKeyHex(AddrPartA, parser)}},
+				},
+			},
+		}),
+		particlBlock(502, "0000000000000000000000000000000000000000000000000000000000000502", []bchain.Tx{
+			particlCoinstake(TxidPartB502T1, TxidPartB500T1, 2, SatPartB502B, AddressToPubKeyHex(AddrPartB, parser), particlCoinstakeData(502, 2, TreasuryCfwdPart)),
+			{
+				Txid: TxidPartB502T2,
+				Vin:  []bchain.Vin{{Txid: TxidPartB501T2, Vout: 1}},
+				Vout: []bchain.Vout{
+					{N: 0, OutputType: "data", Data: CTFeePartData},
+					{N: 1, OutputType: "anon", Data: StealthPartAnonEphem + "0401020304", PubKey: AnonPartPubKey, ValueCommitment: AnonPartCommitment},
+				},
+			},
+		}),
+		GetTestParticlTypeBlock503(parser),
+	}
+}
+
+// GetTestParticlTypeBlock503 returns the block 503 of the Particl fixture chain, spending the anon output to the address C
+func GetTestParticlTypeBlock503(parser bchain.BlockChainParser) *bchain.Block {
+	return particlBlock(503, "0000000000000000000000000000000000000000000000000000000000000503", []bchain.Tx{
+		particlCoinstake(TxidPartB503T1, TxidPartB501T1, 1, SatPartB503Cold, ColdStakingPartScript, ""),
+		{
+			Txid: TxidPartB503T2,
+			Vin:  []bchain.Vin{{InputType: "anon", AnonInputs: 1, RingSize: 5, KeyImages: []string{KeyImagePart}}},
+			Vout: []bchain.Vout{
+				{N: 0, OutputType: "data", Data: CTFeePartData},
+				{N: 1, OutputType: "standard", ValueSat: *SatPartB503C, ScriptPubKey: bchain.ScriptPubKey{Hex: AddressToPubKeyHex(AddrPartC, parser)}},
+				{N: 2, OutputType: "anon", PubKey: AnonPartChangePubKey, ValueCommitment: AnonPartChangeCommitment},
+				{N: 3, OutputType: "data", Data: "03" + StealthPartEphem + "0401020304"},
+			},
+		},
+	})
+}
+
+// GetTestParticlTypeBlock503R returns the block replacing the block 503 in a reorg, it contains only the coinstake of the address B
+func GetTestParticlTypeBlock503R(parser bchain.BlockChainParser) *bchain.Block {
+	return particlBlock(503, "1000000000000000000000000000000000000000000000000000000000000503", []bchain.Tx{
+		particlCoinstake(TxidPartB503R1, TxidPartB502T1, 1, SatPartB503RB, AddressToPubKeyHex(AddrPartB, parser), ""),
+	})
+}
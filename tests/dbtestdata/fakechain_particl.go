@@ -0,0 +1,142 @@
+package dbtestdata
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/trezor/blockbook/bchain"
+	"github.com/trezor/blockbook/common"
+)
+
+type fakeBlockChainParticlType struct {
+	*fakeBlockChain
+	blocks []*bchain.Block
+}
+
+// NewFakeBlockChainParticlType returns mocked Particl blockchain RPC interface used for tests.
+// The chain serves the given blocks, the last one is the best block. A reorg is simulated by replacing items of the slice.
+func NewFakeBlockChainParticlType(parser bchain.BlockChainParser, blocks []*bchain.Block) (bchain.BlockChain, error) {
+	return &fakeBlockChainParticlType{&fakeBlockChain{&bchain.BaseChain{Parser: parser}}, blocks}, nil
+}
+
+func (c *fakeBlockChainParticlType) bestBlock() *bchain.Block {
+	return c.blocks[len(c.blocks)-1]
+}
+
+func (c *fakeBlockChainParticlType) GetNetworkName() string {
+	return "main"
+}
+
+func (c *fakeBlockChainParticlType) GetCoinName() string {
+	return "Particl"
+}
+
+func (c *fakeBlockChainParticlType) GetSubversion() string {
+	return "/Satoshi:23.2.7/"
+}
+
+func (c *fakeBlockChainParticlType) GetChainInfo() (v *bchain.ChainInfo, err error) {
+	b := c.bestBlock()
+	return &bchain.ChainInfo{
+		Chain:         c.GetNetworkName(),
+		Blocks:        int(b.Height),
+		Headers:       int(b.Height),
+		Bestblockhash: b.Hash,
+		Version:       "23020700",
+		Subversion:    c.GetSubversion(),
+	}, nil
+}
+
+func (c *fakeBlockChainParticlType) GetBestBlockHash() (v string, err error) {
+	return c.bestBlock().Hash, nil
+}
+
+func (c *fakeBlockChainParticlType) GetBestBlockHeight() (v uint32, err error) {
+	return c.bestBlock().Height, nil
+}
+
+func (c *fakeBlockChainParticlType) GetBlockHash(height uint32) (v string, err error) {
+	for _, b := range c.blocks {
+		if b.Height == height {
+			return b.Hash, nil
+		}
+	}
+	return "", bchain.ErrBlockNotFound
+}
+
+func (c *fakeBlockChainParticlType) GetBlockHeader(hash string) (v *bchain.BlockHeader, err error) {
+	for _, b := range c.blocks {
+		if b.Hash == hash {
+			return &b.BlockHeader, nil
+		}
+	}
+	return nil, bchain.ErrBlockNotFound
+}
+
+func (c *fakeBlockChainParticlType) GetBlock(hash string, height uint32) (v *bchain.Block, err error) {
+	for _, b := range c.blocks {
+		if hash == b.Hash || (hash == "" && height == b.Height) {
+			return b, nil
+		}
+	}
+	return nil, bchain.ErrBlockNotFound
+}
+
+func (c *fakeBlockChainParticlType) GetBlockInfo(hash string) (v *bchain.BlockInfo, err error) {
+	for _, b := range c.blocks {
+		if b.Hash == hash {
+			return getBlockInfo(b), nil
+		}
+	}
+	return nil, bchain.ErrBlockNotFound
+}
+
+func (c *fakeBlockChainParticlType) GetTransaction(txid string) (v *bchain.Tx, err error) {
+	best := c.bestBlock().Height
+	for _, b := range c.blocks {
+		if v = getTxInBlock(b, txid); v != nil {
+			v.Confirmations = best - b.Height + 1
+			return v, nil
+		}
+	}
+	return nil, bchain.ErrTxNotFound
+}
+
+func (c *fakeBlockChainParticlType) GetTransactionSpecific(tx *bchain.Tx) (v json.RawMessage, err error) {
+	tx, err = c.GetTransaction(tx.Txid)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tx)
+}
+
+func (c *fakeBlockChainParticlType) GetStakingInfo() (*bchain.StakingInfo, error) {
+	return &bchain.StakingInfo{
+		Enabled:           true,
+		PercentYearReward: common.JSONNumber("8"),
+		MoneySupply:       common.JSONNumber("170.00000000"),
+		Difficulty:        common.JSONNumber("12345.6789"),
+		NetStakeWeight:    common.JSONNumber("60000000000"),
+	}, nil
+}
+
+func (c *fakeBlockChainParticlType) GetAnonOutput(index uint64) (*bchain.AnonOutput, error) {
+	var last uint64
+	for _, b := range c.blocks {
+		for i := range b.Txs {
+			for _, vout := range b.Txs[i].Vout {
+				if vout.OutputType != "anon" {
+					continue
+				}
+				last++
+				if vout.AnonIndex > 0 {
+					last = uint64(vout.AnonIndex)
+				}
+				if last == index {
+					return &bchain.AnonOutput{Index: index, PubKey: vout.PubKey, Txid: b.Txs[i].Txid, Vout: vout.N, BlockHeight: b.Height}, nil
+				}
+			}
+		}
+	}
+	return nil, errors.New("anonoutput: output not found")
+}